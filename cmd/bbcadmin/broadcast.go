@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/arseniisemenow/bbc-common/pkg/telegram"
+	"github.com/arseniisemenow/bbc-common/pkg/ydb"
+)
+
+// progressEvery is how many chats pass between progress logs of a broadcast
+const progressEvery = 100
+
+func broadcastSend(ctx context.Context, args []string) error {
+	fs := newFlagSet("broadcast send", "-text t [-dry-run] [-resume id]")
+	text := fs.String("text", "", "the message; \"-\" reads it from stdin")
+	dryRun := fs.Bool("dry-run", false, "only count the recipients")
+	resume := fs.Int64("resume", 0, "continue an interrupted broadcast after this chat ID, its checkpoint")
+	silent := fs.Bool("silent", false, "send without a notification sound")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *text == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read message: %w", err)
+		}
+		*text = string(data)
+	}
+	if strings.TrimSpace(*text) == "" {
+		fs.Usage()
+		return errUsage
+	}
+
+	users, err := ydb.GetActiveUsers(ctx)
+	if err != nil {
+		return err
+	}
+	chatIDs := make([]int64, 0, len(users))
+	for _, u := range users {
+		chatIDs = append(chatIDs, u.TelegramChatID)
+	}
+	if *dryRun {
+		fmt.Printf("Would send to %d active users\n", len(chatIDs))
+		return nil
+	}
+
+	bot, err := telegram.NewBotClientFromEnv()
+	if err != nil {
+		return err
+	}
+	b := telegram.NewBroadcaster(bot, telegram.NewDefaultLimiter())
+	b.OnProgress = func(done, total int, last telegram.BroadcastResult) {
+		if done%progressEvery == 0 || done == total {
+			slog.Info("Broadcast progress", "done", done, "total", total, "checkpoint", last.ChatID)
+		}
+	}
+	msg := telegram.BroadcastMessage{Text: *text}
+	if *silent {
+		msg.Options = append(msg.Options, telegram.Silent())
+	}
+
+	var report *telegram.BroadcastReport
+	if *resume != 0 {
+		report, err = b.Resume(ctx, chatIDs, msg, *resume)
+	} else {
+		report, err = b.Broadcast(ctx, chatIDs, msg)
+	}
+	if report != nil {
+		fmt.Printf("Sent %d, blocked %d, failed %d, checkpoint %d\n", report.Sent, report.Blocked, report.Failed, report.Checkpoint)
+		for _, r := range report.Results {
+			if r.Status == telegram.BroadcastFailed {
+				fmt.Fprintf(os.Stderr, "chat %d: %v\n", r.ChatID, r.Err)
+			}
+		}
+	}
+	if errors.Is(err, context.Canceled) && report != nil {
+		return fmt.Errorf("interrupted, resume with -resume %d", report.Checkpoint)
+	}
+	return err
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+	"github.com/arseniisemenow/bbc-common/pkg/ydb"
+)
+
+// defaultRetention is how long notifications are kept by notifications purge
+const defaultRetention = 90 * 24 * time.Hour
+
+func notificationsPurge(ctx context.Context, args []string) error {
+	fs := newFlagSet("notifications purge", "[-older-than d]")
+	olderThan := fs.Duration("older-than", defaultRetention, "delete notifications created longer ago than this")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *olderThan <= 0 {
+		return fmt.Errorf("-older-than must be positive")
+	}
+
+	before := time.Now().Add(-*olderThan)
+	n, err := ydb.DeleteNotificationsBefore(ctx, before)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Deleted %d notifications created before %s\n", n, before.Format(time.RFC3339))
+	return nil
+}
+
+func schemaMigrate(ctx context.Context, args []string) error {
+	fs := newFlagSet("schema migrate", "[-dry-run]")
+	dryRun := fs.Bool("dry-run", false, "only print the statements")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	stmts, err := ydb.Migrate(ctx, *dryRun)
+	for _, stmt := range stmts {
+		fmt.Println(stmt + ";")
+	}
+	if err != nil {
+		return err
+	}
+	if len(stmts) == 0 {
+		fmt.Println("Schema is up to date")
+	}
+	return nil
+}
+
+func schemaCheck(ctx context.Context, args []string) error {
+	fs := newFlagSet("schema check", "")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	if err := ydb.CheckSchemaCompatibility(ctx, ydb.CurrentSchema); err != nil {
+		return err
+	}
+	fmt.Printf("Schema is compatible with version %d\n", ydb.CurrentSchema.Version)
+	return nil
+}
+
+func exportUser(ctx context.Context, args []string) error {
+	fs := newFlagSet("export", "<chat_id> [-o file]")
+	out := fs.String("o", "-", "the file to write, \"-\" for stdout")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	chatID, err := parseChatID(pos[0])
+	if err != nil {
+		return err
+	}
+
+	export, err := ydb.ExportUserData(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode export: %w", err)
+	}
+	data = append(data, '\n')
+
+	if *out == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	// the export holds personal data, keep it private to the operator
+	return os.WriteFile(*out, data, 0o600)
+}
+
+func importUser(ctx context.Context, args []string) error {
+	fs := newFlagSet("import", "<file>")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	if pos[0] == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(pos[0])
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read export: %w", err)
+	}
+	var export models.UserDataExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return fmt.Errorf("failed to decode export: %w", err)
+	}
+
+	n, err := ydb.ImportUserData(ctx, export)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d records of user %d\n", n, export.User.TelegramChatID)
+	return nil
+}
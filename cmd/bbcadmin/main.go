@@ -0,0 +1,153 @@
+// Command bbcadmin runs operator tasks against the bot's database and Telegram bot, so
+// they need no direct YQL access:
+//
+//	bbcadmin users list [-status active]
+//	bbcadmin users deactivate <chat_id>
+//	bbcadmin subscriptions list [-chat <chat_id>] [-active]
+//	bbcadmin subscriptions expire [-dry-run] [-tz Europe/Berlin]
+//	bbcadmin notifications purge [-older-than 2160h]
+//	bbcadmin broadcast send -text <text> [-dry-run] [-resume <chat_id>]
+//	bbcadmin schema migrate [-dry-run]
+//	bbcadmin schema check
+//	bbcadmin export <chat_id> [-o file]
+//	bbcadmin import <file>
+//
+// It connects like the functions do: YDB_ENDPOINT and YDB_DATABASE with metadata
+// credentials, and TELEGRAM_BOT_TOKEN for broadcasts. Results go to stdout, logs to
+// stderr.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/arseniisemenow/bbc-common/pkg/logging"
+	"github.com/arseniisemenow/bbc-common/pkg/ydb"
+)
+
+// errUsage reports invalid arguments; the usage has already been printed
+var errUsage = errors.New("invalid usage")
+
+// command is a subcommand, e.g. "users list"
+type command struct {
+	name  string
+	args  string
+	short string
+	run   func(ctx context.Context, args []string) error
+}
+
+// commands lists the subcommands in the order of the usage
+var commands = []command{
+	{"users list", "[-status s]", "list users", usersList},
+	{"users deactivate", "<chat_id>", "stop serving a user", usersDeactivate},
+	{"subscriptions list", "[-chat id] [-active]", "list subscriptions", subscriptionsList},
+	{"subscriptions expire", "[-dry-run] [-tz zone]", "deactivate subscriptions whose date has passed", subscriptionsExpire},
+	{"notifications purge", "[-older-than d]", "delete old notifications", notificationsPurge},
+	{"broadcast send", "-text t [-dry-run] [-resume id]", "message all active users", broadcastSend},
+	{"schema migrate", "[-dry-run]", "create missing tables, columns and indexes", schemaMigrate},
+	{"schema check", "", "check that the schema is compatible with this build", schemaCheck},
+	{"export", "<chat_id> [-o file]", "export a user's data as JSON", exportUser},
+	{"import", "<file>", "import a user's data exported before", importUser},
+}
+
+func main() {
+	slog.SetDefault(slog.New(logging.NewHandler(os.Stderr, logging.LevelFromEnv())))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := run(ctx, os.Args[1:])
+	stop()
+
+	if closeErr := ydb.Close(context.Background()); closeErr != nil {
+		slog.Warn("Failed to close YDB connection", "error", closeErr)
+	}
+	if errors.Is(err, errUsage) {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bbcadmin:", err)
+		os.Exit(1)
+	}
+}
+
+// run finds the command named by the leading args and runs it with the rest
+func run(ctx context.Context, args []string) error {
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) >= len(words) && slices.Equal(args[:len(words)], words) {
+			return cmd.run(ctx, args[len(words):])
+		}
+	}
+	usage(os.Stderr)
+	return errUsage
+}
+
+// usage prints the commands to w
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: bbcadmin <command> [flags]")
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(tw, "  %s %s\t%s\n", cmd.name, cmd.args, cmd.short)
+	}
+	tw.Flush()
+}
+
+// newFlagSet returns a flag set for the command named name; errors print its usage
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: bbcadmin %s %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses args into fs and checks that there are nargs positional arguments, which
+// may come before or between the flags; "-" and negative numbers, e.g. group chat IDs,
+// are positional too
+func parse(fs *flag.FlagSet, args []string, nargs int) ([]string, error) {
+	var positional []string
+	for len(args) > 0 {
+		if _, err := strconv.ParseInt(args[0], 10, 64); err == nil || args[0] == "-" || !strings.HasPrefix(args[0], "-") {
+			positional = append(positional, args[0])
+			args = args[1:]
+			continue
+		}
+		if err := fs.Parse(args); err != nil {
+			return nil, errUsage
+		}
+		args = fs.Args()
+	}
+	if len(positional) != nargs {
+		fs.Usage()
+		return nil, errUsage
+	}
+	return positional, nil
+}
+
+// parseChatID parses a chat ID argument
+func parseChatID(s string) (int64, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid chat ID %q", s)
+	}
+	return id, nil
+}
+
+// table returns a writer aligning tab-separated columns on stdout; flush it when done
+func table(header ...string) *tabwriter.Writer {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	return tw
+}
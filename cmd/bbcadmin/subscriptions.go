@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+	"github.com/arseniisemenow/bbc-common/pkg/ydb"
+)
+
+func subscriptionsList(ctx context.Context, args []string) error {
+	fs := newFlagSet("subscriptions list", "[-chat id] [-active]")
+	chatID := fs.Int64("chat", 0, "only list the subscriptions of this chat")
+	activeOnly := fs.Bool("active", false, "only list active subscriptions")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	tw := table("ID", "CHAT_ID", "FROM", "TO", "DATE", "SEATS", "ACTIVE", "LAST_CHECKED_AT")
+	defer tw.Flush()
+	write := func(subs []models.SearchSubscription) {
+		for _, s := range subs {
+			if *activeOnly && !s.IsActive {
+				continue
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%d\t%t\t%s\n", s.ID, s.TelegramChatID, s.FromPlaceName, s.ToPlaceName,
+				s.DepartureDate, s.RequestedSeats, s.IsActive, formatTime(s.LastCheckedAt))
+		}
+	}
+
+	if *chatID != 0 {
+		subs, err := ydb.GetSearchSubscriptionsByUser(ctx, *chatID)
+		if err != nil {
+			return err
+		}
+		write(subs)
+		return nil
+	}
+
+	after := ""
+	for {
+		subs, err := ydb.ListSubscriptions(ctx, after, pageSize)
+		if err != nil {
+			return err
+		}
+		write(subs)
+		if len(subs) < pageSize {
+			return nil
+		}
+		after = subs[len(subs)-1].ID
+	}
+}
+
+func subscriptionsExpire(ctx context.Context, args []string) error {
+	fs := newFlagSet("subscriptions expire", "[-dry-run] [-tz zone]")
+	dryRun := fs.Bool("dry-run", false, "only list the subscriptions to deactivate")
+	tz := fs.String("tz", "UTC", "time zone deciding when a departure date has passed")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	loc, err := time.LoadLocation(*tz)
+	if err != nil {
+		return fmt.Errorf("invalid time zone %q: %w", *tz, err)
+	}
+
+	subs, err := ydb.GetActiveSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	expired := 0
+	for _, s := range subs {
+		if !s.DepartureDate.IsPast(now, loc) {
+			continue
+		}
+		if !*dryRun {
+			if err := ydb.SetSubscriptionActive(ctx, s.ID, false); err != nil {
+				return fmt.Errorf("failed to deactivate subscription %s: %w", s.ID, err)
+			}
+		}
+		slog.Info("Expired subscription", "subscription_id", s.ID, "chat_id", s.TelegramChatID,
+			"departure_date", s.DepartureDate, "dry_run", *dryRun)
+		expired++
+	}
+
+	fmt.Printf("Expired %d of %d active subscriptions\n", expired, len(subs))
+	return nil
+}
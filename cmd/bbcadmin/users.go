@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+	"github.com/arseniisemenow/bbc-common/pkg/ydb"
+)
+
+// pageSize is the number of rows read at once when listing
+const pageSize = 200
+
+func usersList(ctx context.Context, args []string) error {
+	fs := newFlagSet("users list", "[-status s]")
+	status := fs.String("status", "", "only list users with this status, e.g. active")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *status != "" && !models.UserStatus(*status).Valid() {
+		return fmt.Errorf("invalid status %q, want one of %v", *status, models.UserStatuses)
+	}
+
+	tw := table("CHAT_ID", "STATUS", "CREATED_AT", "LAST_AUTH_SUCCESS_AT")
+	defer tw.Flush()
+
+	var after *int64
+	for {
+		users, err := ydb.ListUsers(ctx, after, pageSize)
+		if err != nil {
+			return err
+		}
+		for _, u := range users {
+			if *status != "" && u.Status != models.UserStatus(*status) {
+				continue
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", u.TelegramChatID, u.Status, u.CreatedAt.Format(time.RFC3339), formatTime(u.LastAuthSuccessAt))
+		}
+		if len(users) < pageSize {
+			return nil
+		}
+		after = &users[len(users)-1].TelegramChatID
+	}
+}
+
+func usersDeactivate(ctx context.Context, args []string) error {
+	fs := newFlagSet("users deactivate", "<chat_id>")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	chatID, err := parseChatID(pos[0])
+	if err != nil {
+		return err
+	}
+
+	user, err := ydb.GetUserByTelegramChatID(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if err := ydb.UpdateUserStatus(ctx, chatID, models.UserStatusInactive); err != nil {
+		return err
+	}
+	fmt.Printf("Deactivated user %d, was %s\n", chatID, user.Status)
+	return nil
+}
+
+// formatTime formats an optional time as RFC 3339, "-" if unset
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,249 @@
+// Package analytics records usage events, such as invoked commands, created subscriptions
+// and clicked notifications. An Emitter buffers events and flushes them in batches to a
+// Sink: the YDB events table (ydb.EventStore) or a queue drained into it later.
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/clock"
+	"github.com/arseniisemenow/bbc-common/pkg/idgen"
+	"github.com/arseniisemenow/bbc-common/pkg/logging"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+	"github.com/arseniisemenow/bbc-common/pkg/queue"
+	"github.com/arseniisemenow/bbc-common/pkg/recovery"
+)
+
+const (
+	// DefaultBatchSize is the number of events written to the sink at once
+	DefaultBatchSize = 100
+	// DefaultFlushInterval is how often Run flushes the buffer
+	DefaultFlushInterval = 10 * time.Second
+	// DefaultMaxBuffered bounds the buffer while the sink is failing; the oldest events
+	// are dropped first
+	DefaultMaxBuffered = 10000
+)
+
+// Sink stores batches of events; ydb.EventStore and QueueSink satisfy it
+type Sink interface {
+	Write(ctx context.Context, events []models.Event) error
+}
+
+// SinkFunc adapts a function to Sink
+type SinkFunc func(ctx context.Context, events []models.Event) error
+
+// Write calls f
+func (f SinkFunc) Write(ctx context.Context, events []models.Event) error {
+	return f(ctx, events)
+}
+
+// Emitter buffers events and writes them to a sink in batches. Analytics must never
+// break the bot, so Emit does not block on the sink and failures are only logged.
+// A nil *Emitter discards events, so it can be left unset.
+type Emitter struct {
+	sink        Sink
+	clock       clock.Clock
+	batchSize   int
+	maxBuffered int
+	interval    time.Duration
+
+	mu      sync.Mutex
+	buf     []models.Event
+	dropped int
+	// flushMu serializes flushes, so events reach the sink in order
+	flushMu sync.Mutex
+	// flushing is set while a background flush started by Emit runs, so a slow sink gets
+	// one such flush at a time instead of one per event
+	flushing atomic.Bool
+}
+
+// Option configures an Emitter
+type Option func(*Emitter)
+
+// WithBatchSize sets the number of events per write; a full batch is flushed right away
+func WithBatchSize(n int) Option {
+	return func(e *Emitter) { e.batchSize = n }
+}
+
+// WithFlushInterval sets how often Run flushes
+func WithFlushInterval(d time.Duration) Option {
+	return func(e *Emitter) { e.interval = d }
+}
+
+// WithMaxBuffered bounds the buffer
+func WithMaxBuffered(n int) Option {
+	return func(e *Emitter) { e.maxBuffered = n }
+}
+
+// WithClock stamps events and times Run with c
+func WithClock(c clock.Clock) Option {
+	return func(e *Emitter) { e.clock = c }
+}
+
+// NewEmitter creates an emitter writing to sink
+func NewEmitter(sink Sink, opts ...Option) *Emitter {
+	e := &Emitter{
+		sink:        sink,
+		clock:       clock.Real,
+		batchSize:   DefaultBatchSize,
+		maxBuffered: DefaultMaxBuffered,
+		interval:    DefaultFlushInterval,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	e.batchSize = max(e.batchSize, 1)
+	e.maxBuffered = max(e.maxBuffered, e.batchSize)
+	return e
+}
+
+// Emit buffers ev, assigning an ID and the current time when unset; the properties are
+// copied. A full batch is flushed in the background unless a background flush is still
+// running; events buffered meanwhile wait for the next flush.
+func (e *Emitter) Emit(ctx context.Context, ev models.Event) {
+	if e == nil {
+		return
+	}
+	if ev.ID == "" {
+		ev.ID = idgen.New()
+	}
+	if ev.At.IsZero() {
+		ev.At = e.clock.Now()
+	}
+	ev.Properties = maps.Clone(ev.Properties)
+
+	e.mu.Lock()
+	e.buf = append(e.buf, ev)
+	if over := len(e.buf) - e.maxBuffered; over > 0 {
+		e.buf = e.buf[over:]
+		e.dropped += over
+	}
+	full := len(e.buf) >= e.batchSize
+	e.mu.Unlock()
+
+	if full && e.flushing.CompareAndSwap(false, true) {
+		go func() {
+			defer e.flushing.Store(false)
+			ctx := context.WithoutCancel(ctx)
+			err := recovery.Do(ctx, func() error { return e.Flush(ctx) })
+			if err != nil {
+				logger(ctx).Warn("Failed to flush events", "error", err)
+			}
+		}()
+	}
+}
+
+// Track emits an event of type t for chatID
+func (e *Emitter) Track(ctx context.Context, t models.EventType, chatID int64, props map[string]any) {
+	e.Emit(ctx, models.Event{Type: t, ChatID: chatID, Properties: props})
+}
+
+// Flush writes all buffered events in batches. Events of a failed batch go back to the
+// buffer to be retried by the next flush; call Flush before a function invocation returns.
+func (e *Emitter) Flush(ctx context.Context) error {
+	if e == nil {
+		return nil
+	}
+	e.flushMu.Lock()
+	defer e.flushMu.Unlock()
+
+	e.mu.Lock()
+	pending := e.buf
+	e.buf = nil
+	dropped := e.dropped
+	e.dropped = 0
+	e.mu.Unlock()
+
+	if dropped > 0 {
+		logger(ctx).Warn("Dropped events, buffer full", "count", dropped)
+	}
+	for len(pending) > 0 {
+		batch := pending[:min(e.batchSize, len(pending))]
+		if err := e.sink.Write(ctx, batch); err != nil {
+			e.requeue(pending)
+			return fmt.Errorf("failed to write %d events: %w", len(batch), err)
+		}
+		pending = pending[len(batch):]
+	}
+	return nil
+}
+
+// requeue puts unwritten events back in front of those emitted during the flush
+func (e *Emitter) requeue(events []models.Event) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.buf = append(events[:len(events):len(events)], e.buf...)
+	if over := len(e.buf) - e.maxBuffered; over > 0 {
+		e.buf = e.buf[over:]
+		e.dropped += over
+	}
+}
+
+// Run flushes every flush interval until ctx is cancelled and then once more, e.g. as a
+// lifecycle.Component; failed flushes are logged. On a nil *Emitter it waits for ctx.
+func (e *Emitter) Run(ctx context.Context) error {
+	if e == nil {
+		<-ctx.Done()
+		return nil
+	}
+	for {
+		select {
+		case <-e.clock.After(e.interval):
+			if err := e.Flush(ctx); err != nil {
+				logger(ctx).Warn("Failed to flush events", "error", err)
+			}
+		case <-ctx.Done():
+			return e.Flush(context.WithoutCancel(ctx))
+		}
+	}
+}
+
+// EventMessageType is the queue message type of an event published by QueueSink
+const EventMessageType = "analytics.event"
+
+// QueueSink publishes events to a queue, one message each, to be stored by a consumer
+// running Handler; it keeps writes to YDB off the bot's request path
+type QueueSink struct {
+	producer *queue.Producer
+}
+
+// NewQueueSink creates a sink publishing with producer
+func NewQueueSink(producer *queue.Producer) QueueSink {
+	return QueueSink{producer: producer}
+}
+
+// Write implements Sink
+func (s QueueSink) Write(ctx context.Context, events []models.Event) error {
+	envs := make([]queue.Envelope, 0, len(events))
+	for _, ev := range events {
+		env, err := queue.NewEnvelope(EventMessageType, ev)
+		if err != nil {
+			return err
+		}
+		envs = append(envs, env)
+	}
+	return s.producer.PublishEnvelopes(ctx, envs)
+}
+
+// Handler returns a queue handler writing the event of an EventMessageType message to
+// sink; register it with Consumer.Handle(EventMessageType, ...)
+func Handler(sink Sink) queue.HandlerFunc {
+	return func(ctx context.Context, msg queue.Message) error {
+		var ev models.Event
+		if err := msg.Decode(&ev); err != nil {
+			return err
+		}
+		return sink.Write(ctx, []models.Event{ev})
+	}
+}
+
+// logger returns the package's logger for ctx
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Component(ctx, "analytics")
+}
@@ -0,0 +1,128 @@
+package blablacar
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// ChallengeRequiredError is returned by Login when BlaBlaCar asks for a second factor;
+// pass the code the user received to VerifyLogin
+type ChallengeRequiredError struct {
+	ChallengeID string
+	Method      string // e.g. "sms" or "email"
+	Destination string // masked phone number or address the code was sent to
+}
+
+func (e *ChallengeRequiredError) Error() string {
+	return fmt.Sprintf("blablacar: %s verification required (sent to %s)", e.Method, e.Destination)
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	UserID       string `json:"user_id"`
+}
+
+type loginResponse struct {
+	tokenResponse
+	Challenge *struct {
+		ID          string `json:"id"`
+		Method      string `json:"method"`
+		Destination string `json:"destination"`
+	} `json:"challenge,omitempty"`
+}
+
+// bootstrapAppToken obtains the anonymous app token required before a user logs in
+func (c *Client) bootstrapAppToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	token := c.appToken
+	c.mu.Unlock()
+	if token != "" {
+		return token, nil
+	}
+
+	var resp tokenResponse
+	body := map[string]string{"grant_type": "client_credentials"}
+	if err := c.do(ctx, http.MethodPost, "/auth/v1/app-token", nil, body, &resp, nil); err != nil {
+		return "", fmt.Errorf("failed to obtain app token: %w", err)
+	}
+
+	c.mu.Lock()
+	c.appToken = resp.AccessToken
+	c.mu.Unlock()
+	return resp.AccessToken, nil
+}
+
+// Login authenticates with email and password. On success a client without a TokenSource
+// uses the returned tokens for subsequent calls; the caller sets TelegramChatID before storing them.
+// A *ChallengeRequiredError means the login must be completed with VerifyLogin.
+func (c *Client) Login(ctx context.Context, email, password string) (*models.UserTokens, error) {
+	appToken, err := c.bootstrapAppToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp loginResponse
+	body := map[string]string{"login": email, "password": password}
+	if err := c.doWithAppToken(ctx, "/auth/v1/login", appToken, body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to log in: %w", err)
+	}
+	if resp.Challenge != nil {
+		return nil, &ChallengeRequiredError{
+			ChallengeID: resp.Challenge.ID,
+			Method:      resp.Challenge.Method,
+			Destination: resp.Challenge.Destination,
+		}
+	}
+	return c.acceptTokens(resp.tokenResponse, appToken), nil
+}
+
+// VerifyLogin completes a login that required a second factor, e.g. an SMS code
+func (c *Client) VerifyLogin(ctx context.Context, challengeID, code string) (*models.UserTokens, error) {
+	appToken, err := c.bootstrapAppToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp tokenResponse
+	body := map[string]string{"challenge_id": challengeID, "code": code}
+	if err := c.doWithAppToken(ctx, "/auth/v1/login/verify", appToken, body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to verify login: %w", err)
+	}
+	return c.acceptTokens(resp, appToken), nil
+}
+
+// doWithAppToken posts body authenticated with the app token instead of user tokens
+func (c *Client) doWithAppToken(ctx context.Context, path, appToken string, body, out interface{}) error {
+	tokens := &models.UserTokens{AccessToken: appToken, Datadome: c.currentDatadome()}
+	return c.do(ctx, http.MethodPost, path, nil, body, out, tokens)
+}
+
+// acceptTokens converts a token response into UserTokens and makes the client use them
+func (c *Client) acceptTokens(resp tokenResponse, appToken string) *models.UserTokens {
+	now := time.Now()
+	tokens := &models.UserTokens{
+		AccessToken:   resp.AccessToken,
+		RefreshToken:  resp.RefreshToken,
+		UserID:        resp.UserID,
+		Datadome:      c.currentDatadome(),
+		AppToken:      appToken,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		HeaderProfile: c.profile,
+	}
+	if c.tokens == nil {
+		c.tokens = NewStoredTokens(tokens, nil)
+	}
+	return tokens
+}
+
+func (c *Client) currentDatadome() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.datadome
+}
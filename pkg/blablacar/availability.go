@@ -0,0 +1,54 @@
+package blablacar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Availability tells whether a trip can still be booked
+type Availability struct {
+	// Available is true when at least the requested seats are left
+	Available bool
+	SeatsLeft int
+	// Removed is set when the trip no longer exists, e.g. the driver cancelled it
+	Removed bool
+	Price   Price
+}
+
+type availabilityResponse struct {
+	SeatsLeft int   `json:"seats_left"`
+	Bookable  bool  `json:"bookable"`
+	Price     Price `json:"price"`
+}
+
+// CheckAvailability is a cheaper alternative to GetTripDetails for confirming right before
+// a notification that seats are still left. Removed and sold-out trips are reported as
+// unavailable without an error.
+func (c *Client) CheckAvailability(ctx context.Context, tripID string, seats int) (Availability, error) {
+	if seats <= 0 {
+		seats = 1
+	}
+	query := url.Values{"requested_seats": {strconv.Itoa(seats)}}
+
+	var resp availabilityResponse
+	path := "/trip/v3/" + url.PathEscape(tripID) + "/availability"
+	err := c.doAuthenticated(ctx, http.MethodGet, path, query, nil, &resp)
+	switch {
+	case errors.Is(err, ErrTripNotFound):
+		return Availability{Removed: true}, nil
+	case errors.Is(err, ErrSoldOut):
+		return Availability{}, nil
+	case err != nil:
+		return Availability{}, fmt.Errorf("failed to check availability of trip %s: %w", tripID, err)
+	}
+
+	return Availability{
+		Available: resp.Bookable && resp.SeatsLeft >= seats,
+		SeatsLeft: resp.SeatsLeft,
+		Price:     resp.Price,
+	}, nil
+}
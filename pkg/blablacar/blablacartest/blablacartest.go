@@ -0,0 +1,241 @@
+// Package blablacartest provides a BlaBlaCar client backed by canned responses,
+// for testing code that searches, inspects or books trips without network access
+package blablacartest
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+
+	"github.com/arseniisemenow/bbc-common/pkg/blablacar"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+//go:embed fixtures/*.json
+var fixtures embed.FS
+
+// Bundled fixtures usable with Fixture
+const (
+	FixtureSearch       = "search.json"
+	FixtureSearchEmpty  = "search_empty.json"
+	FixtureTripDetails  = "trip_details.json"
+	FixtureDatadome     = "datadome.json"
+	FixtureUnauthorized = "unauthorized.json"
+	FixtureRateLimited  = "rate_limited.json"
+)
+
+// TestTokens authenticate the client created by NewClient
+var TestTokens = models.UserTokens{
+	TelegramChatID: 1,
+	AccessToken:    "test-access-token",
+	RefreshToken:   "test-refresh-token",
+	UserID:         "test-user",
+}
+
+// Response is a canned HTTP response
+type Response struct {
+	Status int
+	Header http.Header
+	Body   string
+}
+
+// JSON answers 200 with body
+func JSON(body string) Response {
+	return Response{Status: http.StatusOK, Body: body}
+}
+
+// Fixture answers 200 with a bundled fixture, e.g. FixtureSearch; it panics for unknown names
+func Fixture(name string) Response {
+	data, err := fixtures.ReadFile("fixtures/" + name)
+	if err != nil {
+		panic(fmt.Sprintf("blablacartest: unknown fixture %q", name))
+	}
+	return JSON(string(data))
+}
+
+// FromFile answers 200 with the contents of a recorded response file
+func FromFile(path string) (Response, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to read fixture %s: %w", path, err)
+	}
+	return JSON(string(data)), nil
+}
+
+// Error answers status with body
+func Error(status int, body string) Response {
+	return Response{Status: status, Body: body}
+}
+
+// Unauthorized answers like an expired access token
+func Unauthorized() Response {
+	r := Fixture(FixtureUnauthorized)
+	r.Status = http.StatusUnauthorized
+	return r
+}
+
+// RateLimited answers 429 asking to retry after the given seconds
+func RateLimited(retryAfterSeconds int) Response {
+	r := Fixture(FixtureRateLimited)
+	r.Status = http.StatusTooManyRequests
+	r.Header = http.Header{"Retry-After": {fmt.Sprint(retryAfterSeconds)}}
+	return r
+}
+
+// DatadomeChallenge answers like the anti-bot protection asking for a captcha
+func DatadomeChallenge() Response {
+	r := Fixture(FixtureDatadome)
+	r.Status = http.StatusForbidden
+	r.Header = http.Header{"Set-Cookie": {"datadome=challenge-cookie; Path=/; Secure"}}
+	return r
+}
+
+// Request is a request received by the fake
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   string
+}
+
+// Client is a blablacar.Client talking to an in-process fake server. Responses are
+// registered per method and path; consecutive requests take them in order and the
+// last one repeats. Unregistered endpoints answer 404.
+type Client struct {
+	*blablacar.Client
+
+	server *httptest.Server
+
+	mu        sync.Mutex
+	responses map[string][]Response
+	requests  []Request
+}
+
+// NewClient starts the fake and creates a client authenticated with TestTokens. Retries,
+// circuit breaking and throttling are disabled; opts can re-enable them.
+func NewClient(opts ...blablacar.Option) *Client {
+	c := &Client{responses: make(map[string][]Response)}
+	c.server = httptest.NewServer(http.HandlerFunc(c.serve))
+
+	tokens := TestTokens
+	base := []blablacar.Option{
+		blablacar.WithBaseURL(c.server.URL),
+		blablacar.WithHTTPClient(c.server.Client()),
+		blablacar.WithTokens(&tokens),
+		blablacar.WithRetryPolicy(blablacar.RetryPolicy{}),
+		blablacar.WithBreakers(nil),
+		blablacar.WithUserLimiter(nil),
+	}
+	c.Client = blablacar.NewClient(append(base, opts...)...)
+	return c
+}
+
+// URL returns the base URL of the fake, e.g. for a blablacar.PublicClient
+func (c *Client) URL() string {
+	return c.server.URL
+}
+
+// Close stops the fake server
+func (c *Client) Close() {
+	c.server.Close()
+}
+
+// On registers responses for method and path, e.g. On("GET", "/trip/search/v7", Fixture(FixtureSearch))
+func (c *Client) On(method, path string, responses ...Response) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.responses[method+" "+path] = append(c.responses[method+" "+path], responses...)
+	return c
+}
+
+// OnSearch registers responses for edge trip searches
+func (c *Client) OnSearch(responses ...Response) *Client {
+	return c.On(http.MethodGet, "/trip/search/v7", responses...)
+}
+
+// OnTrip registers responses for GetTripDetails of tripID
+func (c *Client) OnTrip(tripID string, responses ...Response) *Client {
+	return c.On(http.MethodGet, "/trip/v3/"+tripID, responses...)
+}
+
+// Requests returns the requests received so far
+func (c *Client) Requests() []Request {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Request(nil), c.requests...)
+}
+
+// LastRequest returns the latest request to method and path
+func (c *Client) LastRequest(method, path string) (Request, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := len(c.requests) - 1; i >= 0; i-- {
+		if c.requests[i].Method == method && c.requests[i].Path == path {
+			return c.requests[i], true
+		}
+	}
+	return Request{}, false
+}
+
+// TB is the part of testing.TB used for assertions
+type TB interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// ExpectQuery fails t unless the latest request to method and path carried the given query parameters
+func (c *Client) ExpectQuery(t TB, method, path string, want map[string]string) {
+	t.Helper()
+	req, ok := c.LastRequest(method, path)
+	if !ok {
+		t.Errorf("blablacartest: no request to %s %s", method, path)
+		return
+	}
+	for key, value := range want {
+		if got := req.Query.Get(key); got != value {
+			t.Errorf("blablacartest: %s %s: query %s = %q, want %q", method, path, key, got, value)
+		}
+	}
+}
+
+func (c *Client) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	key := r.Method + " " + r.URL.Path
+
+	c.mu.Lock()
+	c.requests = append(c.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Header: r.Header.Clone(),
+		Body:   string(body),
+	})
+	queue := c.responses[key]
+	resp := Response{Status: http.StatusNotFound, Body: fmt.Sprintf(`{"error": {"code": "no_fixture", "message": "no response registered for %s"}}`, key)}
+	if len(queue) > 0 {
+		resp = queue[0]
+		if len(queue) > 1 {
+			c.responses[key] = queue[1:]
+		}
+	}
+	c.mu.Unlock()
+
+	for name, values := range resp.Header {
+		for _, v := range values {
+			w.Header().Add(name, v)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	status := resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	io.WriteString(w, resp.Body)
+}
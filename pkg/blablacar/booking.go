@@ -0,0 +1,121 @@
+package blablacar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// BookingOutcome is the result of a booking attempt
+type BookingOutcome string
+
+const (
+	BookingBooked          BookingOutcome = "booked"
+	BookingPendingApproval BookingOutcome = "pending_approval"
+	BookingPaymentRequired BookingOutcome = "payment_required"
+	BookingSoldOut         BookingOutcome = "sold_out"
+	BookingFailed          BookingOutcome = "failed"
+)
+
+// upstreamBookingStatuses maps booking API statuses to outcomes
+var upstreamBookingStatuses = map[string]BookingOutcome{
+	"BOOKED":                  BookingBooked,
+	"PENDING_DRIVER_APPROVAL": BookingPendingApproval,
+	"PAYMENT_REQUIRED":        BookingPaymentRequired,
+	"SOLD_OUT":                BookingSoldOut,
+}
+
+// PaymentOptions selects how a booking is paid
+type PaymentOptions struct {
+	// Method is e.g. "card" or "cash" where the trip allows it
+	Method string `json:"method,omitempty"`
+	// SavedCardID pays with a card stored in the BlaBlaCar account
+	SavedCardID string `json:"saved_card_id,omitempty"`
+}
+
+// BookingResult describes a booking attempt
+type BookingResult struct {
+	Outcome   BookingOutcome
+	BookingID string
+	// PaymentURL is where the user completes payment when Outcome is BookingPaymentRequired
+	PaymentURL string
+}
+
+// BookingRecorder stores booking attempts; ydb.BookingAttemptStore satisfies it
+type BookingRecorder interface {
+	RecordBookingAttempt(ctx context.Context, attempt *models.BookingAttempt) error
+}
+
+type bookingResponse struct {
+	Status     string `json:"status"`
+	BookingID  string `json:"booking_id"`
+	PaymentURL string `json:"payment_url,omitempty"`
+}
+
+// WithBookingRecorder records every BookSeat attempt
+func WithBookingRecorder(recorder BookingRecorder) Option {
+	return func(c *Client) { c.bookings = recorder }
+}
+
+// BookSeat books seats on a trip. Sold-out trips yield BookingSoldOut without an error;
+// an error is returned together with BookingFailed.
+func (c *Client) BookSeat(ctx context.Context, tripID string, seats int, payment PaymentOptions) (BookingResult, error) {
+	body := struct {
+		Seats   int            `json:"seats"`
+		Payment PaymentOptions `json:"payment"`
+	}{Seats: seats, Payment: payment}
+
+	var resp bookingResponse
+	path := "/trip/v3/" + url.PathEscape(tripID) + "/booking"
+	err := c.doAuthenticated(ctx, http.MethodPost, path, nil, body, &resp)
+
+	result := BookingResult{Outcome: BookingFailed}
+	switch {
+	case err == nil:
+		outcome, ok := upstreamBookingStatuses[resp.Status]
+		if !ok {
+			err = fmt.Errorf("unknown booking status %q", resp.Status)
+			break
+		}
+		result = BookingResult{Outcome: outcome, BookingID: resp.BookingID, PaymentURL: resp.PaymentURL}
+	case errors.Is(err, ErrSoldOut):
+		result.Outcome = BookingSoldOut
+		err = nil
+	}
+
+	c.recordBooking(ctx, tripID, seats, result, err)
+	if err != nil {
+		return result, fmt.Errorf("failed to book trip %s: %w", tripID, err)
+	}
+	return result, nil
+}
+
+func (c *Client) recordBooking(ctx context.Context, tripID string, seats int, result BookingResult, bookErr error) {
+	if c.bookings == nil {
+		return
+	}
+	attempt := &models.BookingAttempt{
+		ID:        uuid.NewString(),
+		TripID:    tripID,
+		Seats:     seats,
+		Outcome:   string(result.Outcome),
+		BookingID: result.BookingID,
+		CreatedAt: time.Now(),
+	}
+	if tokens, err := c.userTokens(ctx); err == nil {
+		attempt.TelegramChatID = tokens.TelegramChatID
+	}
+	if bookErr != nil {
+		attempt.Error = bookErr.Error()
+	}
+	if err := c.bookings.RecordBookingAttempt(ctx, attempt); err != nil {
+		logger(ctx).Warn("Failed to record booking attempt", "trip_id", tripID, "error", err)
+	}
+}
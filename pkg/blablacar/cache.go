@@ -0,0 +1,160 @@
+package blablacar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTL keeps search results for roughly one polling cycle
+const DefaultCacheTTL = 2 * time.Minute
+
+var ErrCacheMiss = errors.New("blablacar: cache miss")
+
+// CacheStore holds encoded search results; ydb.KVStore satisfies it. Any Get error is treated as a miss.
+type CacheStore interface {
+	Get(ctx context.Context, key string) (string, error)
+	Put(ctx context.Context, key, value string, ttl time.Duration) error
+}
+
+type cacheEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+// MemoryCache is an in-process CacheStore
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// NewMemoryCache creates an empty cache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]cacheEntry)}
+}
+
+// Get returns the value stored under key, or ErrCacheMiss if it is missing or expired
+func (m *MemoryCache) Get(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return "", ErrCacheMiss
+	}
+	return entry.value, nil
+}
+
+// Put stores value under key for ttl, dropping expired entries
+func (m *MemoryCache) Put(ctx context.Context, key, value string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for k, entry := range m.entries {
+		if now.After(entry.expiresAt) {
+			delete(m.entries, k)
+		}
+	}
+	m.entries[key] = cacheEntry{value: value, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+type inflightSearch struct {
+	done  chan struct{}
+	trips []Trip
+	err   error
+}
+
+// CachedSearcher shares search results between identical requests, so subscriptions to
+// the same route and date cost one upstream search per TTL
+type CachedSearcher struct {
+	searcher Searcher
+	store    CacheStore
+	ttl      time.Duration
+
+	mu       sync.Mutex
+	inflight map[string]*inflightSearch
+}
+
+var _ Searcher = (*CachedSearcher)(nil)
+
+// NewCachedSearcher caches the results of searcher in store for ttl
+func NewCachedSearcher(searcher Searcher, store CacheStore, ttl time.Duration) *CachedSearcher {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &CachedSearcher{searcher: searcher, store: store, ttl: ttl, inflight: make(map[string]*inflightSearch)}
+}
+
+// SearchTrips returns cached results for req, searching upstream on a miss;
+// concurrent identical searches wait for the first one
+func (s *CachedSearcher) SearchTrips(ctx context.Context, req SearchRequest) ([]Trip, error) {
+	key := searchCacheKey(req) + localeCacheKey(ctx)
+	if trips, ok := s.cached(ctx, key); ok {
+		return trips, nil
+	}
+
+	s.mu.Lock()
+	if call, ok := s.inflight[key]; ok {
+		s.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.trips, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	call := &inflightSearch{done: make(chan struct{})}
+	s.inflight[key] = call
+	s.mu.Unlock()
+
+	call.trips, call.err = s.searcher.SearchTrips(ctx, req)
+	if call.err == nil {
+		s.put(ctx, key, call.trips)
+	}
+
+	s.mu.Lock()
+	delete(s.inflight, key)
+	s.mu.Unlock()
+	close(call.done)
+	return call.trips, call.err
+}
+
+func (s *CachedSearcher) cached(ctx context.Context, key string) ([]Trip, bool) {
+	raw, err := s.store.Get(ctx, key)
+	if err != nil {
+		return nil, false
+	}
+	var trips []Trip
+	if err := json.Unmarshal([]byte(raw), &trips); err != nil {
+		logger(ctx).Warn("Ignoring corrupt cache entry", "key", key, "error", err)
+		return nil, false
+	}
+	return trips, true
+}
+
+func (s *CachedSearcher) put(ctx context.Context, key string, trips []Trip) {
+	raw, err := json.Marshal(trips)
+	if err == nil {
+		err = s.store.Put(ctx, key, string(raw), s.ttl)
+	}
+	if err != nil {
+		logger(ctx).Warn("Failed to cache search", "key", key, "error", err)
+	}
+}
+
+// searchCacheKey identifies a search by route, date, seats and filters
+func searchCacheKey(req SearchRequest) string {
+	seats := max(req.Seats, 1)
+	from, to := req.FromPlaceID, req.ToPlaceID
+	if from == "" {
+		from = formatCoordinates(req.From)
+	}
+	if to == "" {
+		to = formatCoordinates(req.To)
+	}
+	return strings.Join([]string{"search", from, to, req.Date, fmt.Sprint(seats), filtersCacheKey(req)}, "|")
+}
@@ -0,0 +1,223 @@
+// Package blablacar is a client for the BlaBlaCar edge API used by the bot services
+package blablacar
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arseniisemenow/bbc-common/pkg/logging"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+	"github.com/arseniisemenow/bbc-common/pkg/retry"
+)
+
+const (
+	// DefaultBaseURL is the BlaBlaCar edge API
+	DefaultBaseURL = "https://edge.blablacar.com"
+	// DefaultClientVersion is sent in the x-client header
+	DefaultClientVersion = "SPA|1.0.0"
+
+	maxErrorBodyLen = 512
+)
+
+// Client calls the BlaBlaCar API on behalf of one user
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	tokens     TokenSource
+	bookings   BookingRecorder
+	retry      RetryPolicy
+	breakers   *Breakers
+	limiter    UserLimiter
+	maxPages   int
+	middleware []Middleware
+	profile    models.HeaderProfile
+
+	refreshMu sync.Mutex
+
+	mu       sync.Mutex
+	appToken string
+	datadome string // latest anti-bot cookie issued to this client
+}
+
+// Option configures a Client created by NewClient
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client, e.g. to configure timeouts or a proxy
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) { c.httpClient = client }
+}
+
+// WithBaseURL overrides DefaultBaseURL, e.g. for a test server
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) { c.baseURL = baseURL }
+}
+
+// WithTokens authenticates requests with the user's stored tokens; refreshed tokens
+// are kept in memory only, use WithTokenSource to persist them
+func WithTokens(tokens *models.UserTokens) Option {
+	return WithTokenSource(NewStoredTokens(tokens, nil))
+}
+
+// WithLocale sets the locale and currency of returned place names and prices, e.g. "de_DE" and "EUR"
+func WithLocale(locale, currency string) Option {
+	return func(c *Client) {
+		c.profile.Locale = locale
+		c.profile.Currency = currency
+	}
+}
+
+// NewClient creates a client; most endpoints require WithTokens
+func NewClient(opts ...Option) *Client {
+	c := &Client{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		baseURL:    DefaultBaseURL,
+		profile: models.HeaderProfile{
+			UserAgent:     DefaultUserAgent,
+			ClientVersion: DefaultClientVersion,
+			VisitorID:     uuid.NewString(),
+			Locale:        "en_GB",
+			Currency:      "EUR",
+		},
+		retry:    DefaultRetryPolicy,
+		breakers: DefaultBreakers,
+		limiter:  DefaultUserLimiter,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.applyMiddleware()
+	return c
+}
+
+// userTokens returns the tokens authenticating user requests
+func (c *Client) userTokens(ctx context.Context) (*models.UserTokens, error) {
+	if c.tokens == nil {
+		return nil, ErrNotAuthenticated
+	}
+	return c.tokens.Tokens(ctx)
+}
+
+// do performs a request against path and decodes the JSON response into out, which may be nil;
+// tokens authenticate the request unless nil. GET requests are retried on upstream failures.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}, tokens *models.UserTokens) error {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	policy := retry.Policy{MaxAttempts: 1}
+	if method == http.MethodGet {
+		policy = c.retry.policy(method, path)
+	}
+	return policy.Do(ctx, func(ctx context.Context) error {
+		return c.attempt(ctx, method, u, path, data, out, tokens)
+	})
+}
+
+// attempt performs a single HTTP exchange guarded by the host's circuit breaker
+func (c *Client) attempt(ctx context.Context, method, u, path string, data []byte, out interface{}, tokens *models.UserTokens) error {
+	var reader io.Reader
+	if data != nil {
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.setHeaders(req, tokens)
+	if tokens != nil {
+		c.setAuthHeaders(req, tokens)
+	}
+
+	host := req.URL.Host
+	if c.breakers != nil && !c.breakers.allow(host) {
+		return ErrCircuitOpen
+	}
+	err = c.exchange(req, path, out)
+	if c.breakers != nil {
+		c.breakers.record(host, isUpstreamFailure(err))
+	}
+	return err
+}
+
+func (c *Client) exchange(req *http.Request, path string, out interface{}) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// the URL may contain an API key, keep it out of the error
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to call %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	c.captureCookies(resp)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLen))
+		return newAPIError(resp, path, data)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", path, err)
+	}
+	return nil
+}
+
+// setHeaders sets the identifying headers of the profile effective for the request
+func (c *Client) setHeaders(req *http.Request, tokens *models.UserTokens) {
+	profile := c.requestProfile(req.Context(), tokens)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", profile.UserAgent)
+	req.Header.Set("x-client", profile.ClientVersion)
+	req.Header.Set("x-locale", profile.Locale)
+	req.Header.Set("x-currency", profile.Currency)
+	req.Header.Set("x-visitor-id", profile.VisitorID)
+}
+
+func (c *Client) setAuthHeaders(req *http.Request, tokens *models.UserTokens) {
+	req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	if tokens.Datadome != "" {
+		req.AddCookie(&http.Cookie{Name: "datadome", Value: tokens.Datadome})
+	}
+}
+
+// captureCookies keeps the datadome cookie so it can be stored with the user's tokens
+func (c *Client) captureCookies(resp *http.Response) {
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == "datadome" && cookie.Value != "" {
+			c.mu.Lock()
+			c.datadome = cookie.Value
+			c.mu.Unlock()
+		}
+	}
+}
+
+// logger returns the package's logger for ctx
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Component(ctx, "blablacar")
+}
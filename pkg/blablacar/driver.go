@@ -0,0 +1,70 @@
+package blablacar
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// Verification badges of a driver profile
+const (
+	BadgeIDVerified    = "id_verified"
+	BadgeEmailVerified = "email_verified"
+	BadgePhoneVerified = "phone_verified"
+)
+
+// DriverProfile is the public profile of a driver
+type DriverProfile struct {
+	ID          string  `json:"id"`
+	DisplayName string  `json:"display_name"`
+	Rating      float64 `json:"rating"`
+	RatingCount int     `json:"rating_count"`
+	// RatingBreakdown maps stars (1-5) to the number of ratings given
+	RatingBreakdown map[int]int `json:"rating_breakdown"`
+	Badges          []string    `json:"badges"`
+	RidesCount      int         `json:"rides_count"`
+	MemberSince     string      `json:"member_since,omitempty"` // YYYY-MM
+	Bio             string      `json:"bio,omitempty"`
+}
+
+// HasBadge reports whether the profile carries badge, e.g. BadgeIDVerified
+func (p DriverProfile) HasBadge(badge string) bool {
+	for _, b := range p.Badges {
+		if b == badge {
+			return true
+		}
+	}
+	return false
+}
+
+// MeetsRating reports whether the driver is rated at least min; unrated drivers do not
+// meet any positive minimum
+func (p DriverProfile) MeetsRating(min float64) bool {
+	if min <= 0 {
+		return true
+	}
+	return p.RatingCount > 0 && p.Rating >= min
+}
+
+// Enrich fills the driver fields of info that search results left empty
+func (p DriverProfile) Enrich(info *models.TripInfo) {
+	if info.DriverName == "" {
+		info.DriverName = p.DisplayName
+	}
+	if info.DriverRating == 0 && p.RatingCount > 0 {
+		info.DriverRating = p.Rating
+	}
+}
+
+// GetDriverProfile returns the public profile of a driver, e.g. Trip.Driver.ID
+func (c *Client) GetDriverProfile(ctx context.Context, driverID string) (*DriverProfile, error) {
+	var profile DriverProfile
+	path := "/user/v3/" + url.PathEscape(driverID) + "/profile"
+	if err := c.doAuthenticated(ctx, http.MethodGet, path, nil, nil, &profile); err != nil {
+		return nil, fmt.Errorf("failed to get driver %s: %w", driverID, err)
+	}
+	return &profile, nil
+}
@@ -0,0 +1,112 @@
+package blablacar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/logging"
+)
+
+var (
+	ErrNotAuthenticated = errors.New("no BlaBlaCar tokens available")
+	ErrAuthExpired      = errors.New("blablacar: access token expired or revoked")
+	ErrCaptchaRequired  = errors.New("blablacar: request blocked by anti-bot challenge")
+	ErrRateLimited      = errors.New("blablacar: rate limited")
+	ErrTripNotFound     = errors.New("blablacar: trip not found")
+	ErrSoldOut          = errors.New("blablacar: no seats left")
+)
+
+// APIError is returned for non-2xx responses; errors.Is matches it against the
+// sentinel of its class, e.g. ErrRateLimited
+type APIError struct {
+	StatusCode int
+	// Code is the upstream error code from the response body, if any
+	Code string
+	Body string
+	// Path is the requested endpoint without query
+	Path string
+	// RetryAfter is the delay requested by a 429 response
+	RetryAfter time.Duration
+}
+
+// Error includes the body with credentials redacted, as auth endpoints may echo tokens
+func (e *APIError) Error() string {
+	body := logging.Redact(e.Body)
+	if e.Code != "" {
+		return fmt.Sprintf("blablacar: unexpected status %d (%s): %s", e.StatusCode, e.Code, body)
+	}
+	return fmt.Sprintf("blablacar: unexpected status %d: %s", e.StatusCode, body)
+}
+
+// Is reports whether target is the sentinel of e's class
+func (e *APIError) Is(target error) bool {
+	return target != nil && target == e.Kind()
+}
+
+// Kind returns the sentinel classifying e, or nil for other failures
+func (e *APIError) Kind() error {
+	for _, rule := range apiErrorRules {
+		if e.StatusCode != rule.status || !strings.HasPrefix(e.Path, rule.pathPrefix) {
+			continue
+		}
+		if rule.fragment == "" || strings.Contains(strings.ToLower(e.Code+" "+e.Body), rule.fragment) {
+			return rule.sentinel
+		}
+	}
+	return nil
+}
+
+// apiErrorRules map statuses, endpoints and body fragments to sentinels; the first match wins
+var apiErrorRules = []struct {
+	status     int
+	pathPrefix string
+	fragment   string
+	sentinel   error
+}{
+	{http.StatusUnauthorized, "", "", ErrAuthExpired},
+	{http.StatusForbidden, "", "captcha", ErrCaptchaRequired},
+	{http.StatusForbidden, "", "datadome", ErrCaptchaRequired},
+	{http.StatusTooManyRequests, "", "", ErrRateLimited},
+	{http.StatusNotFound, "/trip/", "", ErrTripNotFound},
+	{http.StatusConflict, "/trip/", "", ErrSoldOut},
+	{http.StatusGone, "/trip/", "", ErrSoldOut},
+}
+
+// newAPIError builds the error for a non-2xx response with the given (truncated) body
+func newAPIError(resp *http.Response, path string, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode, Body: string(body), Path: path, Code: upstreamCode(body)}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return apiErr
+}
+
+// upstreamCode extracts the error code from bodies like {"error": {"code": "..."}} or {"code": "..."}
+func upstreamCode(body []byte) string {
+	var parsed struct {
+		Code  string          `json:"code"`
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &parsed) != nil {
+		return ""
+	}
+	if parsed.Code != "" {
+		return parsed.Code
+	}
+	var nested struct {
+		Code string `json:"code"`
+	}
+	if json.Unmarshal(parsed.Error, &nested) == nil && nested.Code != "" {
+		return nested.Code
+	}
+	var plain string
+	if json.Unmarshal(parsed.Error, &plain) == nil {
+		return plain
+	}
+	return ""
+}
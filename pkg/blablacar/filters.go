@@ -0,0 +1,73 @@
+package blablacar
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// HasFilters reports whether req restricts results beyond route, date and seats
+func (r SearchRequest) HasFilters() bool {
+	return r.MaxPrice > 0 || r.DepartureTimeFrom != "" || r.DepartureTimeTo != "" || len(r.TransportTypes) > 0 || r.MinDriverRating > 0
+}
+
+// Matches reports whether trip satisfies the filters of r; trips whose price or
+// departure time cannot be read are kept
+func (r SearchRequest) Matches(trip Trip) bool {
+	if r.MaxPrice > 0 {
+		if price, err := trip.Price.Money(); err == nil && price.Float() > r.MaxPrice {
+			return false
+		}
+	}
+	if len(r.TransportTypes) > 0 && trip.TransportType != "" && !slices.Contains(r.TransportTypes, trip.TransportType) {
+		return false
+	}
+	if r.MinDriverRating > 0 && trip.Driver != nil && trip.Driver.Rating > 0 && trip.Driver.Rating < r.MinDriverRating {
+		return false
+	}
+	if clock := departureClock(trip); clock != "" {
+		if r.DepartureTimeFrom != "" && clock < r.DepartureTimeFrom {
+			return false
+		}
+		if r.DepartureTimeTo != "" && clock > r.DepartureTimeTo {
+			return false
+		}
+	}
+	return true
+}
+
+// filterPages passes only the trips matching req on to fn
+func filterPages(req SearchRequest, fn PageFunc) PageFunc {
+	if !req.HasFilters() {
+		return fn
+	}
+	return func(trips []Trip) error {
+		matching := make([]Trip, 0, len(trips))
+		for _, trip := range trips {
+			if req.Matches(trip) {
+				matching = append(matching, trip)
+			}
+		}
+		return fn(matching)
+	}
+}
+
+// departureClock returns the local "HH:MM" of a departure such as "2024-06-14T18:30:00"
+func departureClock(trip Trip) string {
+	raw := trip.Origin().DateTime
+	i := strings.IndexAny(raw, "T ")
+	if i < 0 || len(raw) < i+6 {
+		return ""
+	}
+	return raw[i+1 : i+6]
+}
+
+// filtersCacheKey encodes the filters of req for searchCacheKey
+func filtersCacheKey(req SearchRequest) string {
+	if !req.HasFilters() {
+		return ""
+	}
+	types := slices.Clone(req.TransportTypes)
+	slices.Sort(types)
+	return fmt.Sprintf("%g|%s-%s|%s|%g", req.MaxPrice, req.DepartureTimeFrom, req.DepartureTimeTo, strings.Join(types, ","), req.MinDriverRating)
+}
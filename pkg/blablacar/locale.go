@@ -0,0 +1,112 @@
+package blablacar
+
+import (
+	"context"
+	"strings"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// defaultRegions maps languages to the BlaBlaCar market usually meant by them
+var defaultRegions = map[string]string{
+	"cs": "CZ",
+	"de": "DE",
+	"en": "GB",
+	"es": "ES",
+	"fr": "FR",
+	"hr": "HR",
+	"hu": "HU",
+	"it": "IT",
+	"nl": "NL",
+	"pl": "PL",
+	"pt": "PT",
+	"ro": "RO",
+	"ru": "RU",
+	"sk": "SK",
+	"tr": "TR",
+	"uk": "UA",
+}
+
+// regionCurrencies maps markets to their currency; other markets use EUR
+var regionCurrencies = map[string]string{
+	"BR": "BRL",
+	"CZ": "CZK",
+	"GB": "GBP",
+	"HU": "HUF",
+	"IN": "INR",
+	"MX": "MXN",
+	"PL": "PLN",
+	"RO": "RON",
+	"RS": "RSD",
+	"RU": "RUB",
+	"TR": "TRY",
+	"UA": "UAH",
+}
+
+// LocaleFor turns a user's language such as Telegram's "fr" or "pt-BR" into a BlaBlaCar
+// locale such as "fr_FR" or "pt_BR"; unknown languages get "en_GB"
+func LocaleFor(lang string) string {
+	lang = strings.TrimSpace(strings.ReplaceAll(lang, "-", "_"))
+	base, region, _ := strings.Cut(lang, "_")
+	base = strings.ToLower(base)
+	if region == "" {
+		region = defaultRegions[base]
+	}
+	if base == "" || region == "" {
+		return "en_GB"
+	}
+	return base + "_" + strings.ToUpper(region)
+}
+
+// CurrencyFor returns the currency of a locale's market, e.g. "PLN" for "pl_PL"
+func CurrencyFor(locale string) string {
+	_, region, _ := strings.Cut(locale, "_")
+	if currency, ok := regionCurrencies[strings.ToUpper(region)]; ok {
+		return currency
+	}
+	return "EUR"
+}
+
+// WithUserLocale sets the locale matching lang and the given currency; an empty
+// currency uses the one of the locale's market
+func WithUserLocale(lang, currency string) Option {
+	locale := LocaleFor(lang)
+	if currency == "" {
+		currency = CurrencyFor(locale)
+	}
+	return WithLocale(locale, strings.ToUpper(currency))
+}
+
+type localeContextKey struct{}
+
+// ContextWithLocale makes requests issued with ctx ask for place names in lang and prices
+// in currency (empty for the market's), e.g. when one client serves many users
+func ContextWithLocale(ctx context.Context, lang, currency string) context.Context {
+	locale := LocaleFor(lang)
+	if currency == "" {
+		currency = CurrencyFor(locale)
+	}
+	return context.WithValue(ctx, localeContextKey{}, models.HeaderProfile{Locale: locale, Currency: strings.ToUpper(currency)})
+}
+
+// requestProfile returns the headers for a request: a locale from ctx overrides the
+// profile stored with tokens, which overrides the client's
+func (c *Client) requestProfile(ctx context.Context, tokens *models.UserTokens) models.HeaderProfile {
+	profile := c.profile
+	if tokens != nil {
+		profile = mergeProfile(tokens.HeaderProfile, profile)
+	}
+	if override, ok := ctx.Value(localeContextKey{}).(models.HeaderProfile); ok {
+		profile.Locale = override.Locale
+		profile.Currency = override.Currency
+	}
+	return profile
+}
+
+// localeCacheKey distinguishes cached searches made with different ContextWithLocale values
+func localeCacheKey(ctx context.Context) string {
+	if override, ok := ctx.Value(localeContextKey{}).(models.HeaderProfile); ok {
+		return "|" + override.Locale + "|" + override.Currency
+	}
+	return ""
+}
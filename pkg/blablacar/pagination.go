@@ -0,0 +1,64 @@
+package blablacar
+
+import (
+	"context"
+	"errors"
+)
+
+// DefaultMaxPages bounds how many result pages one search follows
+const DefaultMaxPages = 5
+
+// ErrStopPaging can be returned by a PageFunc to end a search without an error
+var ErrStopPaging = errors.New("blablacar: stop paging")
+
+// PageFunc receives the trips of one result page as soon as it arrives
+type PageFunc func(trips []Trip) error
+
+// WithMaxPages sets how many result pages a search follows; 1 disables pagination
+func WithMaxPages(pages int) Option {
+	return func(c *Client) { c.maxPages = pages }
+}
+
+// paginate fetches pages by cursor until the last page, maxPages or fn stops it
+func paginate(ctx context.Context, maxPages int, fetch func(cursor string) (searchResponse, error), fn PageFunc) error {
+	if maxPages <= 0 {
+		maxPages = DefaultMaxPages
+	}
+
+	cursor := ""
+	for page := 0; page < maxPages; page++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		resp, err := fetch(cursor)
+		if err != nil {
+			return err
+		}
+		if err := fn(resp.Trips); err != nil {
+			if errors.Is(err, ErrStopPaging) {
+				return nil
+			}
+			return err
+		}
+		if resp.NextCursor == "" || resp.NextCursor == cursor {
+			return nil
+		}
+		cursor = resp.NextCursor
+	}
+	return nil
+}
+
+// collectPages returns a PageFunc appending to trips, skipping trips already seen on earlier pages
+func collectPages(trips *[]Trip) PageFunc {
+	seen := make(map[string]bool)
+	return func(page []Trip) error {
+		for _, trip := range page {
+			if trip.ID != "" && seen[trip.ID] {
+				continue
+			}
+			seen[trip.ID] = true
+			*trips = append(*trips, trip)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,43 @@
+package blablacar
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// PlaceSuggestion is an autocomplete result, e.g. "berl" -> Berlin
+type PlaceSuggestion struct {
+	ID          string  `json:"id"`
+	DisplayName string  `json:"display_name"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+}
+
+type placesResponse struct {
+	Places []PlaceSuggestion `json:"places"`
+}
+
+// SearchPlaces resolves free text to places usable in SearchRequest; an empty locale
+// uses the one of ctx or the client's. It needs no user tokens.
+func (c *Client) SearchPlaces(ctx context.Context, query, locale string) ([]PlaceSuggestion, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, nil
+	}
+	if locale == "" {
+		locale = c.requestProfile(ctx, nil).Locale
+	}
+
+	params := url.Values{
+		"query":  {query},
+		"locale": {locale},
+	}
+	var resp placesResponse
+	if err := c.do(ctx, http.MethodGet, "/location/autocomplete", params, nil, &resp, nil); err != nil {
+		return nil, fmt.Errorf("failed to search places for %q: %w", query, err)
+	}
+	return resp.Places, nil
+}
@@ -0,0 +1,64 @@
+package blablacar
+
+import (
+	"math/rand/v2"
+
+	"github.com/google/uuid"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// DefaultUserAgent is sent by clients without a configured header profile
+const DefaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36"
+
+// userAgents are current desktop browsers NewHeaderProfile picks from
+var userAgents = []string{
+	DefaultUserAgent,
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Safari/605.1.15",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:127.0) Gecko/20100101 Firefox/127.0",
+	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+}
+
+// NewHeaderProfile creates a profile with a random browser user agent and a new visitor ID,
+// to be stored with a user's tokens and reused for all of their requests
+func NewHeaderProfile(locale, currency string) models.HeaderProfile {
+	return models.HeaderProfile{
+		UserAgent:     userAgents[rand.IntN(len(userAgents))],
+		ClientVersion: DefaultClientVersion,
+		VisitorID:     uuid.NewString(),
+		Locale:        locale,
+		Currency:      currency,
+	}
+}
+
+// WithHeaderProfile sets the headers identifying the client; empty fields keep their defaults.
+// A profile stored with the user's tokens takes precedence.
+func WithHeaderProfile(profile models.HeaderProfile) Option {
+	return func(c *Client) { c.profile = mergeProfile(profile, c.profile) }
+}
+
+// HeaderProfile returns the profile of the client, e.g. to store it with tokens obtained elsewhere
+func (c *Client) HeaderProfile() models.HeaderProfile {
+	return c.profile
+}
+
+// mergeProfile fills the empty fields of profile from fallback
+func mergeProfile(profile, fallback models.HeaderProfile) models.HeaderProfile {
+	if profile.UserAgent == "" {
+		profile.UserAgent = fallback.UserAgent
+	}
+	if profile.ClientVersion == "" {
+		profile.ClientVersion = fallback.ClientVersion
+	}
+	if profile.VisitorID == "" {
+		profile.VisitorID = fallback.VisitorID
+	}
+	if profile.Locale == "" {
+		profile.Locale = fallback.Locale
+	}
+	if profile.Currency == "" {
+		profile.Currency = fallback.Currency
+	}
+	return profile
+}
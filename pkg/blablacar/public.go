@@ -0,0 +1,168 @@
+package blablacar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/config"
+)
+
+// DefaultPublicBaseURL is BlaBlaCar's public partner API
+const DefaultPublicBaseURL = "https://public-api.blablacar.com"
+
+var ErrCoordinatesRequired = errors.New("public API search requires from and to coordinates")
+
+// PublicClient searches trips through the public partner API with an API key.
+// It needs no user credentials but returns less data: no trip IDs, drivers or seat counts.
+type PublicClient struct {
+	client *Client
+	apiKey string
+}
+
+var _ Searcher = (*PublicClient)(nil)
+
+// NewPublicClient creates a public API client; WithHTTPClient, WithBaseURL and WithLocale apply
+func NewPublicClient(apiKey string, opts ...Option) *PublicClient {
+	opts = append([]Option{WithBaseURL(DefaultPublicBaseURL)}, opts...)
+	return &PublicClient{client: NewClient(opts...), apiKey: apiKey}
+}
+
+// SearchTrips returns the trips matching req.From and req.To; the public API does not
+// report free seats, so SeatsLeft is the number of requested seats. The departure time
+// window is applied upstream, the other filters to the results.
+func (p *PublicClient) SearchTrips(ctx context.Context, req SearchRequest) ([]Trip, error) {
+	var trips []Trip
+	if err := p.SearchTripsFunc(ctx, req, collectPages(&trips)); err != nil {
+		return nil, err
+	}
+	return trips, nil
+}
+
+// SearchTripsFunc streams the result pages of req to fn; fn may return ErrStopPaging
+func (p *PublicClient) SearchTripsFunc(ctx context.Context, req SearchRequest, fn PageFunc) error {
+	if req.From.IsZero() || req.To.IsZero() {
+		return ErrCoordinatesRequired
+	}
+	if req.Seats <= 0 {
+		req.Seats = 1
+	}
+	start, end := "00:00:00", "23:59:59"
+	if req.DepartureTimeFrom != "" {
+		start = req.DepartureTimeFrom + ":00"
+	}
+	if req.DepartureTimeTo != "" {
+		end = req.DepartureTimeTo + ":59"
+	}
+	profile := p.client.requestProfile(ctx, nil)
+	query := url.Values{
+		"key":              {p.apiKey},
+		"from_coordinate":  {formatCoordinates(req.From)},
+		"to_coordinate":    {formatCoordinates(req.To)},
+		"start_date_local": {req.Date + "T" + start},
+		"end_date_local":   {req.Date + "T" + end},
+		"requested_seats":  {strconv.Itoa(req.Seats)},
+		"locale":           {profile.Locale},
+		"currency":         {profile.Currency},
+	}
+
+	err := paginate(ctx, p.client.maxPages, func(cursor string) (searchResponse, error) {
+		var resp searchResponse
+		if cursor != "" {
+			query.Set("from_cursor", cursor)
+		}
+		if err := p.client.do(ctx, http.MethodGet, "/api/v3/trips", query, nil, &resp, nil); err != nil {
+			return resp, err
+		}
+		for i := range resp.Trips {
+			trip := &resp.Trips[i]
+			if trip.ID == "" {
+				trip.ID = trip.Link
+			}
+			if trip.SeatsLeft == 0 {
+				trip.SeatsLeft = req.Seats
+			}
+		}
+		return resp, nil
+	}, filterPages(req, fn))
+	if err != nil {
+		return fmt.Errorf("failed to search trips via public API on %s: %w", req.Date, err)
+	}
+	return nil
+}
+
+func formatCoordinates(c Coordinates) string {
+	return strconv.FormatFloat(c.Latitude, 'f', 6, 64) + "," + strconv.FormatFloat(c.Longitude, 'f', 6, 64)
+}
+
+// Backend selects the search implementation
+type Backend string
+
+const (
+	// BackendAuto uses the edge API when tokens are available and the public API otherwise
+	BackendAuto   Backend = ""
+	BackendEdge   Backend = "edge"
+	BackendPublic Backend = "public"
+)
+
+// SearcherConfig selects and configures a Searcher
+type SearcherConfig struct {
+	Backend Backend
+	// APIKey is the public API key
+	APIKey string
+	// Tokens authenticate the edge backend
+	Tokens  TokenSource
+	Options []Option
+	// Cache, when set, shares results of identical searches for CacheTTL
+	Cache    CacheStore
+	CacheTTL time.Duration
+}
+
+// SearcherConfigFromEnv reads BLABLACAR_BACKEND, BLABLACAR_API_KEY and BLABLACAR_CACHE_TTL;
+// invalid values are reported by config.LoadBlaBlaCar and fall back to defaults here
+func SearcherConfigFromEnv() SearcherConfig {
+	cfg, _ := config.LoadBlaBlaCar()
+	return SearcherConfig{
+		Backend:  Backend(cfg.Backend),
+		APIKey:   cfg.APIKey,
+		CacheTTL: cfg.CacheTTL,
+	}
+}
+
+// NewSearcher creates the Searcher selected by cfg
+func NewSearcher(cfg SearcherConfig) (Searcher, error) {
+	searcher, err := newBackendSearcher(cfg)
+	if err != nil || cfg.Cache == nil {
+		return searcher, err
+	}
+	return NewCachedSearcher(searcher, cfg.Cache, cfg.CacheTTL), nil
+}
+
+func newBackendSearcher(cfg SearcherConfig) (Searcher, error) {
+	backend := cfg.Backend
+	if backend == BackendAuto {
+		backend = BackendPublic
+		if cfg.Tokens != nil {
+			backend = BackendEdge
+		}
+	}
+
+	switch backend {
+	case BackendEdge:
+		if cfg.Tokens == nil {
+			return nil, ErrNotAuthenticated
+		}
+		return NewClient(append([]Option{WithTokenSource(cfg.Tokens)}, cfg.Options...)...), nil
+	case BackendPublic:
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("BlaBlaCar public API key not set")
+		}
+		return NewPublicClient(cfg.APIKey, cfg.Options...), nil
+	default:
+		return nil, fmt.Errorf("unknown BlaBlaCar backend %q", backend)
+	}
+}
@@ -0,0 +1,63 @@
+package blablacar
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+	"github.com/arseniisemenow/bbc-common/pkg/ratelimit"
+)
+
+// UserLimiter throttles the authenticated requests made for one user, so aggressive
+// polling does not get the account flagged or blocked by the anti-bot protection
+type UserLimiter interface {
+	// Wait blocks until a request for key may be sent or ctx is done
+	Wait(ctx context.Context, key string) error
+}
+
+// TokenBuckets is an in-memory UserLimiter with one token bucket per user
+type TokenBuckets = ratelimit.Buckets
+
+// NewTokenBuckets allows rps requests per second per user with bursts of up to burst requests
+func NewTokenBuckets(rps float64, burst int) *TokenBuckets {
+	return ratelimit.NewBuckets(rps, burst)
+}
+
+// DefaultUserLimiter is shared by clients created without WithUserLimiter, so several
+// clients acting for the same user draw from one bucket
+var DefaultUserLimiter = NewTokenBuckets(1, 5)
+
+// WindowCounter counts requests per key in fixed windows; ydb.RateLimitStore satisfies it
+type WindowCounter = ratelimit.Counter
+
+// SharedLimiter is a UserLimiter backed by a WindowCounter, limiting a user across all
+// function instances. Counter errors are logged and let the request through.
+type SharedLimiter = ratelimit.Shared
+
+// NewSharedLimiter allows limit requests per user in every window
+func NewSharedLimiter(counter WindowCounter, limit int, window time.Duration) *SharedLimiter {
+	l := ratelimit.NewShared(counter, limit, window)
+	l.Prefix = "blablacar:"
+	return l
+}
+
+// WithUserLimiter throttles authenticated requests with limiter instead of DefaultUserLimiter;
+// nil disables throttling
+func WithUserLimiter(limiter UserLimiter) Option {
+	return func(c *Client) { c.limiter = limiter }
+}
+
+// limiterKey identifies the user tokens act for without exposing the tokens themselves
+func limiterKey(tokens *models.UserTokens) string {
+	if tokens.UserID != "" {
+		return tokens.UserID
+	}
+	secret := tokens.RefreshToken
+	if secret == "" {
+		secret = tokens.AccessToken
+	}
+	sum := sha256.Sum256([]byte(secret))
+	return "token:" + hex.EncodeToString(sum[:8])
+}
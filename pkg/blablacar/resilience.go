@@ -0,0 +1,139 @@
+package blablacar
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/retry"
+)
+
+var ErrCircuitOpen = errors.New("blablacar: circuit open after repeated upstream failures")
+
+// RetryPolicy bounds retries of idempotent requests failing with 5xx or network errors
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// DefaultRetryPolicy retries twice with jittered exponential backoff
+var DefaultRetryPolicy = RetryPolicy{MaxRetries: 2, BaseDelay: 500 * time.Millisecond, MaxDelay: 5 * time.Second}
+
+// policy returns the retry policy of idempotent requests, logging each retry
+func (p RetryPolicy) policy(method, path string) retry.Policy {
+	return retry.New(
+		retry.MaxAttempts(p.MaxRetries+1),
+		retry.Backoff(p.BaseDelay, p.MaxDelay),
+		retry.Jitter(),
+		retry.If(func(err error) bool { return isUpstreamFailure(err) && !errors.Is(err, ErrCircuitOpen) }),
+		retry.OnRetry(func(ctx context.Context, attempt int, err error, _ time.Duration) {
+			logger(ctx).Warn("Request failed, retrying", "method", method, "path", path, "attempt", attempt, "retries", p.MaxRetries, "error", err)
+		}),
+	)
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+type hostBreaker struct {
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+// Breakers trips per upstream host after Threshold consecutive failures, rejects calls
+// for Cooldown and then lets a single probe through. One Breakers is shared by all
+// clients by default, so an outage seen by one user's client protects the others.
+type Breakers struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*hostBreaker
+}
+
+// NewBreakers creates a breaker group
+func NewBreakers(threshold int, cooldown time.Duration) *Breakers {
+	return &Breakers{Threshold: threshold, Cooldown: cooldown, hosts: make(map[string]*hostBreaker)}
+}
+
+// DefaultBreakers is used by clients created without WithBreakers
+var DefaultBreakers = NewBreakers(5, 30*time.Second)
+
+// allow reports whether a request to host may proceed
+func (b *Breakers) allow(host string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	hb := b.host(host)
+	switch hb.state {
+	case breakerOpen:
+		if time.Since(hb.openedAt) < b.Cooldown {
+			return false
+		}
+		hb.state = breakerHalfOpen
+		return true
+	case breakerHalfOpen:
+		// a probe is already in flight
+		return false
+	default:
+		return true
+	}
+}
+
+// record updates the breaker of host with the outcome of a request
+func (b *Breakers) record(host string, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	hb := b.host(host)
+	if !failed {
+		hb.state, hb.failures = breakerClosed, 0
+		return
+	}
+	hb.failures++
+	if hb.state == breakerHalfOpen || hb.failures >= b.Threshold {
+		hb.state, hb.openedAt = breakerOpen, time.Now()
+	}
+}
+
+func (b *Breakers) host(host string) *hostBreaker {
+	hb, ok := b.hosts[host]
+	if !ok {
+		hb = &hostBreaker{}
+		b.hosts[host] = hb
+	}
+	return hb
+}
+
+// WithRetryPolicy overrides DefaultRetryPolicy
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) { c.retry = policy }
+}
+
+// WithBreakers overrides DefaultBreakers; nil disables circuit breaking
+func WithBreakers(breakers *Breakers) Option {
+	return func(c *Client) { c.breakers = breakers }
+}
+
+// isUpstreamFailure reports whether err counts against the circuit breaker and may be retried
+func isUpstreamFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	// network errors, timeouts and truncated responses
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,208 @@
+package blablacar
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// Transport types of a trip
+const (
+	TransportCarpooling = "CARPOOLING"
+	TransportBus        = "BUS"
+)
+
+// Coordinates locate a place, e.g. from a PlaceSuggestion
+type Coordinates struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// IsZero reports whether c is unset
+func (c Coordinates) IsZero() bool {
+	return c.Latitude == 0 && c.Longitude == 0
+}
+
+// SearchRequest describes a trip search
+type SearchRequest struct {
+	FromPlaceID string
+	ToPlaceID   string
+	Date        string // YYYY-MM-DD
+	Seats       int
+
+	// From and To are required by the public API backend, which does not accept place IDs
+	From Coordinates
+	To   Coordinates
+
+	// Optional filters; those the backend cannot apply upstream are applied to the results
+	MaxPrice          float64  // in the client's currency
+	DepartureTimeFrom string   // HH:MM, local time of departure
+	DepartureTimeTo   string   // HH:MM, inclusive
+	TransportTypes    []string // e.g. TransportBus
+	// MinDriverRating drops trips of drivers rated lower; drivers without a rating in the
+	// results are kept, check them with GetDriverProfile
+	MinDriverRating float64
+}
+
+// Searcher finds trips; Client implements it
+type Searcher interface {
+	SearchTrips(ctx context.Context, req SearchRequest) ([]Trip, error)
+}
+
+// Place is a location on a trip's route
+type Place struct {
+	ID        string  `json:"id,omitempty"`
+	City      string  `json:"city"`
+	Address   string  `json:"address,omitempty"`
+	Latitude  float64 `json:"latitude,omitempty"`
+	Longitude float64 `json:"longitude,omitempty"`
+}
+
+// Waypoint is a stop of a trip with its scheduled local time
+type Waypoint struct {
+	DateTime string `json:"date_time"`
+	Place    Place  `json:"place"`
+}
+
+// Price is an amount in a currency, e.g. {"12.50", "EUR"}
+type Price struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// Money parses the price; it fails for missing or malformed amounts
+func (p Price) Money() (models.Money, error) {
+	return models.ParseMoney(p.Amount, p.Currency)
+}
+
+func (p Price) String() string {
+	if p.Amount == "" {
+		return ""
+	}
+	return p.Amount + " " + p.Currency
+}
+
+// Driver is the trip's driver as shown in search results
+type Driver struct {
+	ID          string  `json:"id"`
+	DisplayName string  `json:"display_name"`
+	Rating      float64 `json:"rating,omitempty"`
+}
+
+// Trip is a search result
+type Trip struct {
+	ID                string     `json:"id"`
+	Link              string     `json:"link"`
+	TransportType     string     `json:"transport_type"`
+	Waypoints         []Waypoint `json:"waypoints"`
+	Price             Price      `json:"price"`
+	Driver            *Driver    `json:"driver,omitempty"`
+	SeatsLeft         int        `json:"seats_left"`
+	DurationInSeconds int        `json:"duration_in_seconds"`
+}
+
+type searchResponse struct {
+	Trips      []Trip `json:"trips"`
+	NextCursor string `json:"next_cursor"`
+}
+
+var _ Searcher = (*Client)(nil)
+
+// SearchTrips returns the trips matching req, following result pages up to WithMaxPages
+func (c *Client) SearchTrips(ctx context.Context, req SearchRequest) ([]Trip, error) {
+	var trips []Trip
+	if err := c.SearchTripsFunc(ctx, req, collectPages(&trips)); err != nil {
+		return nil, err
+	}
+	return trips, nil
+}
+
+// SearchTripsFunc streams the result pages of req to fn; fn may return ErrStopPaging
+func (c *Client) SearchTripsFunc(ctx context.Context, req SearchRequest, fn PageFunc) error {
+	if req.Seats <= 0 {
+		req.Seats = 1
+	}
+	query := url.Values{
+		"from_place_id":   {req.FromPlaceID},
+		"to_place_id":     {req.ToPlaceID},
+		"departure_date":  {req.Date},
+		"requested_seats": {strconv.Itoa(req.Seats)},
+		"search_uuid":     {uuid.NewString()},
+	}
+
+	err := paginate(ctx, c.maxPages, func(cursor string) (searchResponse, error) {
+		var resp searchResponse
+		if cursor != "" {
+			query.Set("from_cursor", cursor)
+		}
+		err := c.doAuthenticated(ctx, http.MethodGet, "/trip/search/v7", query, nil, &resp)
+		return resp, err
+	}, filterPages(req, fn))
+	if err != nil {
+		return fmt.Errorf("failed to search trips %s -> %s on %s: %w", req.FromPlaceID, req.ToPlaceID, req.Date, err)
+	}
+	return nil
+}
+
+// Origin returns the first waypoint
+func (t Trip) Origin() Waypoint {
+	if len(t.Waypoints) == 0 {
+		return Waypoint{}
+	}
+	return t.Waypoints[0]
+}
+
+// Destination returns the last waypoint
+func (t Trip) Destination() Waypoint {
+	if len(t.Waypoints) == 0 {
+		return Waypoint{}
+	}
+	return t.Waypoints[len(t.Waypoints)-1]
+}
+
+// TripInfo maps the trip to the model used for notifications
+func (t Trip) TripInfo() models.TripInfo {
+	info := models.TripInfo{
+		ID:             t.ID,
+		FromPlaceName:  t.Origin().Place.City,
+		ToPlaceName:    t.Destination().Place.City,
+		DepartureTime:  t.Origin().DateTime,
+		ArrivalTime:    t.Destination().DateTime,
+		SeatsAvailable: t.SeatsLeft,
+		IsBus:          t.TransportType == TransportBus,
+		DeepLink:       t.Link,
+	}
+	if price, err := t.Price.Money(); err == nil {
+		info.Price = price
+	}
+	if t.DurationInSeconds > 0 {
+		info.Duration = formatDuration(time.Duration(t.DurationInSeconds) * time.Second)
+	}
+	if t.Driver != nil {
+		info.DriverName = t.Driver.DisplayName
+		info.DriverRating = t.Driver.Rating
+	}
+	return info
+}
+
+// TripInfos maps trips to notification models
+func TripInfos(trips []Trip) []models.TripInfo {
+	infos := make([]models.TripInfo, 0, len(trips))
+	for _, t := range trips {
+		infos = append(infos, t.TripInfo())
+	}
+	return infos
+}
+
+// formatDuration renders d as e.g. "2h05"
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("%dh%02d", int(d.Hours()), int(d.Minutes())%60)
+}
@@ -0,0 +1,144 @@
+package blablacar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// TokenSource provides the tokens of the user a Client acts for
+type TokenSource interface {
+	// Tokens returns the current tokens
+	Tokens(ctx context.Context) (*models.UserTokens, error)
+	// Update replaces the tokens after the client refreshed them
+	Update(ctx context.Context, tokens *models.UserTokens) error
+}
+
+// PersistFunc stores refreshed tokens, e.g. ydb.StoreUserTokens
+type PersistFunc func(ctx context.Context, tokens *models.UserTokens) error
+
+// StoredTokens is a TokenSource holding tokens in memory and persisting every update
+type StoredTokens struct {
+	mu      sync.RWMutex
+	tokens  *models.UserTokens
+	persist PersistFunc
+}
+
+// NewStoredTokens creates a token source; persist may be nil
+func NewStoredTokens(tokens *models.UserTokens, persist PersistFunc) *StoredTokens {
+	return &StoredTokens{tokens: tokens, persist: persist}
+}
+
+// Tokens returns the current tokens or ErrNotAuthenticated
+func (s *StoredTokens) Tokens(ctx context.Context) (*models.UserTokens, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.tokens == nil || s.tokens.AccessToken == "" {
+		return nil, ErrNotAuthenticated
+	}
+	return s.tokens, nil
+}
+
+// Update stores tokens and persists them
+func (s *StoredTokens) Update(ctx context.Context, tokens *models.UserTokens) error {
+	s.mu.Lock()
+	s.tokens = tokens
+	s.mu.Unlock()
+	if s.persist == nil {
+		return nil
+	}
+	if err := s.persist(ctx, tokens); err != nil {
+		return fmt.Errorf("failed to persist refreshed tokens: %w", err)
+	}
+	return nil
+}
+
+// WithTokenSource authenticates requests with tokens from source, refreshing them on 401
+func WithTokenSource(source TokenSource) Option {
+	return func(c *Client) { c.tokens = source }
+}
+
+// RefreshTokens exchanges the refresh token of tokens for a new access token, keeping
+// the other fields of tokens; it neither updates the client nor persists the result
+func (c *Client) RefreshTokens(ctx context.Context, tokens *models.UserTokens) (*models.UserTokens, error) {
+	if tokens.RefreshToken == "" {
+		return nil, ErrNotAuthenticated
+	}
+
+	var resp tokenResponse
+	body := map[string]string{"grant_type": "refresh_token", "refresh_token": tokens.RefreshToken}
+	var appAuth *models.UserTokens
+	if tokens.AppToken != "" {
+		appAuth = &models.UserTokens{AccessToken: tokens.AppToken, Datadome: tokens.Datadome}
+	}
+	if err := c.do(ctx, http.MethodPost, "/auth/v1/token", nil, body, &resp, appAuth); err != nil {
+		return nil, fmt.Errorf("failed to refresh tokens: %w", err)
+	}
+
+	refreshed := *tokens
+	refreshed.AccessToken = resp.AccessToken
+	if resp.RefreshToken != "" {
+		refreshed.RefreshToken = resp.RefreshToken
+	}
+	if datadome := c.currentDatadome(); datadome != "" {
+		refreshed.Datadome = datadome
+	}
+	refreshed.UpdatedAt = time.Now()
+	return &refreshed, nil
+}
+
+// doAuthenticated performs a user request, refreshing the tokens once when it fails with 401
+func (c *Client) doAuthenticated(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	tokens, err := c.userTokens(ctx)
+	if err != nil {
+		return err
+	}
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx, limiterKey(tokens)); err != nil {
+			return err
+		}
+	}
+
+	err = c.do(ctx, method, path, query, body, out, tokens)
+	if !errors.Is(err, ErrAuthExpired) || tokens.RefreshToken == "" {
+		return err
+	}
+
+	tokens, refreshErr := c.refresh(ctx, tokens)
+	if refreshErr != nil {
+		logger(ctx).Error("Token refresh failed", "user_id", tokens.UserID, "error", refreshErr)
+		return err
+	}
+	return c.do(ctx, method, path, query, body, out, tokens)
+}
+
+// refresh replaces stale tokens, unless a concurrent request already did
+func (c *Client) refresh(ctx context.Context, stale *models.UserTokens) (*models.UserTokens, error) {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	current, err := c.tokens.Tokens(ctx)
+	if err != nil {
+		return stale, err
+	}
+	if current.AccessToken != stale.AccessToken {
+		return current, nil
+	}
+
+	refreshed, err := c.RefreshTokens(ctx, current)
+	if err != nil {
+		return stale, err
+	}
+	if err := c.tokens.Update(ctx, refreshed); err != nil {
+		// the new tokens work even though they could not be stored
+		logger(ctx).Error("Failed to store refreshed tokens", "error", err)
+	}
+	logger(ctx).Info("Refreshed tokens", "user_id", refreshed.UserID)
+	return refreshed, nil
+}
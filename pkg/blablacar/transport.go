@@ -0,0 +1,237 @@
+package blablacar
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Middleware wraps the transport used for every upstream request, e.g. for logging or tracing
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to http.RoundTripper
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip calls f
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WithMiddleware wraps the client's transport; the first middleware sees requests first.
+// The HTTP client passed to WithHTTPClient is copied, not modified.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(c *Client) { c.middleware = append(c.middleware, middleware...) }
+}
+
+// applyMiddleware installs the configured middleware on a copy of the HTTP client
+func (c *Client) applyMiddleware() {
+	if len(c.middleware) == 0 {
+		return
+	}
+	transport := c.httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		transport = c.middleware[i](transport)
+	}
+	client := *c.httpClient
+	client.Transport = transport
+	c.httpClient = &client
+}
+
+// sensitiveParams are query parameters RedactURL masks
+var sensitiveParams = []string{"key", "api_key", "token", "access_token", "refresh_token", "password", "code"}
+
+// RedactURL formats u with credentials in the query masked
+func RedactURL(u *url.URL) string {
+	redacted := *u
+	query := u.Query()
+	for _, name := range sensitiveParams {
+		if query.Has(name) {
+			query.Set(name, "REDACTED")
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	redacted.User = nil
+	return redacted.String()
+}
+
+// LoggingMiddleware logs every request with its status, duration and request ID; URLs are
+// redacted and headers, which carry tokens and cookies, are never logged
+func LoggingMiddleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				logger(req.Context()).Warn("Request failed", "method", req.Method, "url", RedactURL(req.URL), "duration", time.Since(start).Round(time.Millisecond), "error", err)
+				return resp, err
+			}
+			logger(req.Context()).Info("Request done", "method", req.Method, "url", RedactURL(req.URL), "status", resp.StatusCode, "duration", time.Since(start).Round(time.Millisecond))
+			return resp, nil
+		})
+	}
+}
+
+// idSegment matches path segments that identify a resource, e.g. trip IDs
+var idSegment = regexp.MustCompile(`/[^/]*\d[^/]*`)
+
+// endpointLabel reduces a path to its route, e.g. "/trip/v3/123-abc/booking" -> "/trip/v3/:id/booking"
+func endpointLabel(path string) string {
+	return idSegment.ReplaceAllStringFunc(path, func(segment string) string {
+		if len(segment) <= 3 {
+			return segment // version segments such as "/v3"
+		}
+		return "/:id"
+	})
+}
+
+// TransportMetrics collects upstream request statistics; register it with a Prometheus
+// registry and install it with WithMiddleware(m.Middleware())
+type TransportMetrics struct {
+	requests *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+}
+
+// NewTransportMetrics creates metrics named <namespace>_blablacar_*
+func NewTransportMetrics(namespace string) *TransportMetrics {
+	return &TransportMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "blablacar",
+			Name:      "requests_total",
+			Help:      "Upstream requests by endpoint and status code (\"error\" for transport failures).",
+		}, []string{"endpoint", "status"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "blablacar",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of single upstream requests.",
+			Buckets:   []float64{0.1, 0.25, 0.5, 1, 2, 5, 10, 30},
+		}, []string{"endpoint"}),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (m *TransportMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.requests.Describe(ch)
+	m.latency.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (m *TransportMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.requests.Collect(ch)
+	m.latency.Collect(ch)
+}
+
+// Middleware records every request passing through
+func (m *TransportMetrics) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			endpoint := endpointLabel(req.URL.Path)
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			m.latency.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+			status := "error"
+			if err == nil {
+				status = strconv.Itoa(resp.StatusCode)
+			}
+			m.requests.WithLabelValues(endpoint, status).Inc()
+			return resp, err
+		})
+	}
+}
+
+// StartSpanFunc starts a trace span, returning the span's context and a function ending it;
+// adapt a tracer such as OpenTelemetry's to it. ctx carries the request ID, see
+// requestid.From, to tag the span with.
+type StartSpanFunc func(ctx context.Context, name string) (context.Context, func(err error))
+
+// TracingMiddleware wraps every request in a span named e.g. "blablacar GET /trip/v3/:id"
+func TracingMiddleware(start StartSpanFunc) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			ctx, end := start(req.Context(), "blablacar "+req.Method+" "+endpointLabel(req.URL.Path))
+			resp, err := next.RoundTrip(req.WithContext(ctx))
+			end(err)
+			return resp, err
+		})
+	}
+}
+
+// Exchange is a recorded request with its response
+type Exchange struct {
+	Method       string
+	URL          string // redacted
+	RequestBody  string
+	Status       int
+	ResponseBody string
+	Duration     time.Duration
+	Err          error
+}
+
+// Recorder keeps the exchanges passing through its middleware, e.g. to capture
+// responses as fixtures for blablacartest. Request bodies may contain credentials.
+type Recorder struct {
+	mu        sync.Mutex
+	exchanges []Exchange
+}
+
+// Middleware records every exchange
+func (r *Recorder) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			ex := Exchange{Method: req.Method, URL: RedactURL(req.URL)}
+			if req.GetBody != nil {
+				if body, err := req.GetBody(); err == nil {
+					data, _ := io.ReadAll(body)
+					body.Close()
+					ex.RequestBody = string(data)
+				}
+			}
+
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			ex.Duration = time.Since(start)
+			ex.Err = err
+			if err == nil {
+				ex.Status = resp.StatusCode
+				data, readErr := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				ex.ResponseBody = string(data)
+				resp.Body = io.NopCloser(bytes.NewReader(data))
+				if readErr != nil {
+					ex.Err = readErr
+				}
+			}
+
+			r.mu.Lock()
+			r.exchanges = append(r.exchanges, ex)
+			r.mu.Unlock()
+			return resp, err
+		})
+	}
+}
+
+// Exchanges returns the recorded exchanges
+func (r *Recorder) Exchanges() []Exchange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Exchange(nil), r.exchanges...)
+}
+
+// Reset discards the recorded exchanges
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.exchanges = nil
+	r.mu.Unlock()
+}
@@ -0,0 +1,65 @@
+package blablacar
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// MeetingPoint is where a passenger is picked up or dropped off;
+// pass its coordinates to telegram.SendVenue or telegram.FormatMeetingPoint
+type MeetingPoint struct {
+	Place       Place  `json:"place"`
+	DateTime    string `json:"date_time"`
+	Description string `json:"description,omitempty"`
+}
+
+// PriceDetails breaks down the price of one seat
+type PriceDetails struct {
+	Total      Price `json:"total"`
+	PerSeat    Price `json:"per_seat"`
+	ServiceFee Price `json:"service_fee"`
+}
+
+// Vehicle is the car used for the trip
+type Vehicle struct {
+	Make  string `json:"make"`
+	Model string `json:"model"`
+	Color string `json:"color,omitempty"`
+}
+
+// TripDetails is the full information about one trip
+type TripDetails struct {
+	Trip
+	Pickup       MeetingPoint `json:"pickup"`
+	Dropoff      MeetingPoint `json:"dropoff"`
+	PriceDetails PriceDetails `json:"price_details"`
+	Vehicle      *Vehicle     `json:"vehicle,omitempty"`
+	// Amenities are flags such as "pets_allowed", "smoking_allowed" or "max_two_in_back"
+	Amenities      []string `json:"amenities"`
+	Comment        string   `json:"comment,omitempty"`
+	AutoAccept     bool     `json:"auto_accept"`
+	TotalSeats     int      `json:"total_seats"`
+	DriverVerified bool     `json:"driver_verified"`
+}
+
+// HasAmenity reports whether the trip lists amenity
+func (d TripDetails) HasAmenity(amenity string) bool {
+	for _, a := range d.Amenities {
+		if a == amenity {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTripDetails returns the full information about a trip found by SearchTrips
+func (c *Client) GetTripDetails(ctx context.Context, tripID string) (*TripDetails, error) {
+	var details TripDetails
+	path := "/trip/v3/" + url.PathEscape(tripID)
+	if err := c.doAuthenticated(ctx, http.MethodGet, path, nil, nil, &details); err != nil {
+		return nil, fmt.Errorf("failed to get trip %s: %w", tripID, err)
+	}
+	return &details, nil
+}
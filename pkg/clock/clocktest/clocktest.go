@@ -0,0 +1,125 @@
+// Package clocktest provides a manually advanced clock.Clock for tests
+package clocktest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/clock"
+)
+
+// Clock is a fake clock that only moves when Advance or Set is called; timers fire
+// once the time reaches their deadline
+type Clock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*timer
+}
+
+var _ clock.Clock = (*Clock)(nil)
+
+// New creates a fake clock set to now
+func New(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now implements clock.Clock
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After implements clock.Clock
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+// NewTimer implements clock.Clock
+func (c *Clock) NewTimer(d time.Duration) clock.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &timer{clock: c, ch: make(chan time.Time, 1)}
+	c.schedule(t, d)
+	return t
+}
+
+// Advance moves the clock forward by d, firing due timers in deadline order
+func (c *Clock) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set moves the clock to now, firing due timers in deadline order
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+	sort.SliceStable(c.timers, func(i, j int) bool { return c.timers[i].deadline.Before(c.timers[j].deadline) })
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.deadline.After(now) {
+			pending = append(pending, t)
+			continue
+		}
+		select {
+		case t.ch <- t.deadline:
+		default:
+		}
+	}
+	c.timers = pending
+}
+
+// Timers returns the number of timers that have not fired or been stopped, e.g. to wait
+// until the code under test is blocked on one
+func (c *Clock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// schedule registers t to fire after d; c.mu must be held
+func (c *Clock) schedule(t *timer, d time.Duration) {
+	t.deadline = c.now.Add(d)
+	if d <= 0 {
+		select {
+		case t.ch <- t.deadline:
+		default:
+		}
+		return
+	}
+	c.timers = append(c.timers, t)
+}
+
+// unschedule removes t, reporting whether it was pending; c.mu must be held
+func (c *Clock) unschedule(t *timer) bool {
+	for i, pending := range c.timers {
+		if pending == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type timer struct {
+	clock    *Clock
+	ch       chan time.Time
+	deadline time.Time
+}
+
+func (t *timer) C() <-chan time.Time { return t.ch }
+
+func (t *timer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.unschedule(t)
+}
+
+func (t *timer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.clock.unschedule(t)
+	t.clock.schedule(t, d)
+	return active
+}
@@ -0,0 +1,360 @@
+// Package config loads the typed configuration of the bot services from the environment,
+// with defaults and validation
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/logging"
+	"github.com/arseniisemenow/bbc-common/pkg/secrets"
+)
+
+// Environment variables read by Load
+const (
+	EnvYDBEndpoint           = "YDB_ENDPOINT"
+	EnvYDBDatabase           = "YDB_DATABASE"
+	EnvTelegramBotToken      = "TELEGRAM_BOT_TOKEN"
+	EnvTelegramAdminChatID   = "TELEGRAM_ADMIN_CHAT_ID"
+	EnvTelegramWebhookSecret = "TELEGRAM_WEBHOOK_SECRET"
+	EnvBlaBlaCarBackend      = "BLABLACAR_BACKEND"
+	EnvBlaBlaCarAPIKey       = "BLABLACAR_API_KEY"
+	EnvBlaBlaCarCacheTTL     = "BLABLACAR_CACHE_TTL"
+	EnvPollerConcurrency     = "POLLER_CONCURRENCY"
+	EnvPollerTaskTimeout     = "POLLER_TASK_TIMEOUT"
+	EnvPollerQueueURL        = "POLLER_QUEUE_URL"
+	EnvFeatures              = "FEATURES"
+	EnvTokenEncryptionKey    = "TOKEN_ENCRYPTION_KEY"
+)
+
+// defaultSecrets is the provider of secrets missing from the environment, shared so the
+// Lockbox payload is fetched once per cold start
+var defaultSecrets = sync.OnceValue(secrets.FromEnv)
+
+var (
+	ErrMissing = errors.New("required variable not set")
+	ErrInvalid = errors.New("invalid value")
+)
+
+// Lookup returns the value of a configuration variable and whether it is set
+type Lookup func(key string) (string, bool)
+
+// Section names a part of the configuration a service may require; the other sections
+// have no required variables
+type Section string
+
+const (
+	SectionYDB      Section = "ydb"
+	SectionTelegram Section = "telegram"
+)
+
+// Option configures Load
+type Option func(*loader)
+
+type loader struct {
+	lookup   Lookup
+	secrets  secrets.Provider
+	ctx      context.Context
+	required []Section
+}
+
+// WithLookup reads variables from lookup instead of the environment
+func WithLookup(lookup Lookup) Option {
+	return func(l *loader) { l.lookup = lookup }
+}
+
+// WithSecrets reads unset secrets such as TELEGRAM_BOT_TOKEN from p, nil to only use
+// variables; by default they come from secrets.FromEnv
+func WithSecrets(ctx context.Context, p secrets.Provider) Option {
+	return func(l *loader) { l.ctx, l.secrets = ctx, p }
+}
+
+// Require makes Load fail when required variables of the sections are missing
+func Require(sections ...Section) Option {
+	return func(l *loader) { l.required = append(l.required, sections...) }
+}
+
+// YDB configures the database connection
+type YDB struct {
+	Endpoint string
+	Database string
+}
+
+// ConnectionString returns the DSN of the database
+func (c YDB) ConnectionString() string {
+	return c.Endpoint + "/?database=" + c.Database
+}
+
+// Validate checks that the connection is fully configured
+func (c YDB) Validate() error {
+	return errors.Join(required(EnvYDBEndpoint, c.Endpoint), required(EnvYDBDatabase, c.Database))
+}
+
+// Telegram configures the bot
+type Telegram struct {
+	BotToken string
+	// AdminChatID receives operational alerts, 0 if unset
+	AdminChatID int64
+	// WebhookSecret is the secret_token webhook calls must carry, empty to accept any
+	WebhookSecret string
+}
+
+// Validate checks that the bot token is set
+func (c Telegram) Validate() error {
+	return required(EnvTelegramBotToken, c.BotToken)
+}
+
+// BlaBlaCar configures trip search
+type BlaBlaCar struct {
+	// Backend is "edge", "public" or empty to choose by the available credentials
+	Backend string
+	APIKey  string
+	// CacheTTL is how long identical searches share results, the searcher's default if 0
+	CacheTTL time.Duration
+}
+
+// Validate checks the backend and that the public backend has an API key
+func (c BlaBlaCar) Validate() error {
+	if !slices.Contains([]string{"", "edge", "public"}, c.Backend) {
+		return fmt.Errorf("%s: %w %q, want edge or public", EnvBlaBlaCarBackend, ErrInvalid, c.Backend)
+	}
+	if c.Backend == "public" {
+		return required(EnvBlaBlaCarAPIKey, c.APIKey)
+	}
+	return nil
+}
+
+// Poller tunes the subscription poller; zero values select the poller's defaults
+type Poller struct {
+	Concurrency int
+	TaskTimeout time.Duration
+	// QueueURL, when set, fans checks out through the queue instead of running them
+	QueueURL string
+}
+
+// Validate checks that the tuning is not negative
+func (c Poller) Validate() error {
+	var errs []error
+	if c.Concurrency < 0 {
+		errs = append(errs, fmt.Errorf("%s: %w %d, must not be negative", EnvPollerConcurrency, ErrInvalid, c.Concurrency))
+	}
+	if c.TaskTimeout < 0 {
+		errs = append(errs, fmt.Errorf("%s: %w %s, must not be negative", EnvPollerTaskTimeout, ErrInvalid, c.TaskTimeout))
+	}
+	return errors.Join(errs...)
+}
+
+// Config is the configuration of a service
+type Config struct {
+	YDB       YDB
+	Telegram  Telegram
+	BlaBlaCar BlaBlaCar
+	Poller    Poller
+	// TokenEncryptionKey encrypts stored user tokens
+	TokenEncryptionKey string
+	// Features are the enabled feature flags, listed comma-separated in FEATURES
+	Features map[string]bool
+	LogLevel slog.Level
+}
+
+// Enabled reports whether a feature flag is on
+func (c *Config) Enabled(feature string) bool {
+	return c.Features[strings.ToLower(feature)]
+}
+
+// Load reads the configuration and validates it: malformed values always fail, missing
+// ones only in sections passed to Require
+func Load(opts ...Option) (*Config, error) {
+	l := loader{lookup: os.LookupEnv, secrets: defaultSecrets(), ctx: context.Background()}
+	for _, opt := range opts {
+		opt(&l)
+	}
+	e := env{lookup: l.lookup, secrets: l.secrets, ctx: l.ctx}
+
+	cfg := &Config{
+		YDB:                e.ydb(),
+		Telegram:           e.telegram(),
+		BlaBlaCar:          e.blablacar(),
+		Poller:             e.poller(),
+		TokenEncryptionKey: e.secret(EnvTokenEncryptionKey),
+		Features:           e.features(),
+	}
+	level, err := logging.ParseLevel(e.string(logging.LevelEnv))
+	if err != nil {
+		e.fail(logging.LevelEnv, err)
+	}
+	cfg.LogLevel = level
+
+	errs := append(e.errs, cfg.BlaBlaCar.Validate(), cfg.Poller.Validate())
+	for _, section := range l.required {
+		switch section {
+		case SectionYDB:
+			errs = append(errs, cfg.YDB.Validate())
+		case SectionTelegram:
+			errs = append(errs, cfg.Telegram.Validate())
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// LoadYDB reads the YDB section from the environment without validating it
+func LoadYDB() YDB {
+	e := env{lookup: os.LookupEnv}
+	return e.ydb()
+}
+
+// LoadTelegram reads the Telegram section from the environment and the default secrets
+// provider, failing on malformed values
+func LoadTelegram() (Telegram, error) {
+	e := env{lookup: os.LookupEnv, secrets: defaultSecrets(), ctx: context.Background()}
+	cfg := e.telegram()
+	return cfg, errors.Join(e.errs...)
+}
+
+// LoadBlaBlaCar reads and validates the BlaBlaCar section from the environment and the
+// default secrets provider
+func LoadBlaBlaCar() (BlaBlaCar, error) {
+	e := env{lookup: os.LookupEnv, secrets: defaultSecrets(), ctx: context.Background()}
+	cfg := e.blablacar()
+	return cfg, errors.Join(append(e.errs, cfg.Validate())...)
+}
+
+// String formats the configuration with secrets replaced by fingerprints
+func (c *Config) String() string {
+	features := make([]string, 0, len(c.Features))
+	for feature, on := range c.Features {
+		if on {
+			features = append(features, feature)
+		}
+	}
+	slices.Sort(features)
+	return fmt.Sprintf("ydb{endpoint=%s database=%s} telegram{bot_token=%s admin_chat_id=%d webhook_secret=%s} "+
+		"blablacar{backend=%q api_key=%s cache_ttl=%s} poller{concurrency=%d task_timeout=%s queue_url=%s} token_encryption_key=%s features=%v log_level=%s",
+		c.YDB.Endpoint, c.YDB.Database,
+		logging.Fingerprint(c.Telegram.BotToken), c.Telegram.AdminChatID, logging.Fingerprint(c.Telegram.WebhookSecret),
+		c.BlaBlaCar.Backend, logging.Fingerprint(c.BlaBlaCar.APIKey), c.BlaBlaCar.CacheTTL,
+		c.Poller.Concurrency, c.Poller.TaskTimeout, c.Poller.QueueURL,
+		logging.Fingerprint(c.TokenEncryptionKey),
+		features, c.LogLevel)
+}
+
+// env parses variables, collecting errors of malformed values
+type env struct {
+	lookup  Lookup
+	secrets secrets.Provider
+	ctx     context.Context
+	errs    []error
+}
+
+func (e *env) fail(key string, err error) {
+	e.errs = append(e.errs, fmt.Errorf("%s: %w", key, err))
+}
+
+func (e *env) string(key string) string {
+	value, _ := e.lookup(key)
+	return strings.TrimSpace(value)
+}
+
+// secret reads a secret variable, asking the secrets provider when it is unset; found
+// values are registered for redaction
+func (e *env) secret(key string) string {
+	value := e.string(key)
+	if value == "" && e.secrets != nil {
+		var err error
+		value, err = e.secrets.Secret(e.ctx, key)
+		if err != nil && !errors.Is(err, secrets.ErrNotFound) {
+			e.fail(key, err)
+		}
+		if err != nil {
+			value = ""
+		}
+	}
+	logging.Register(value)
+	return value
+}
+
+func (e *env) int(key string, def int) int {
+	raw := e.string(key)
+	if raw == "" {
+		return def
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		e.fail(key, fmt.Errorf("%w %q, want an integer", ErrInvalid, raw))
+		return def
+	}
+	return value
+}
+
+func (e *env) duration(key string, def time.Duration) time.Duration {
+	raw := e.string(key)
+	if raw == "" {
+		return def
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil {
+		e.fail(key, fmt.Errorf("%w %q, want a duration such as 30s", ErrInvalid, raw))
+		return def
+	}
+	return value
+}
+
+func (e *env) ydb() YDB {
+	return YDB{Endpoint: e.string(EnvYDBEndpoint), Database: e.string(EnvYDBDatabase)}
+}
+
+func (e *env) telegram() Telegram {
+	cfg := Telegram{BotToken: e.secret(EnvTelegramBotToken), WebhookSecret: e.secret(EnvTelegramWebhookSecret)}
+	if raw := e.string(EnvTelegramAdminChatID); raw != "" {
+		chatID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			e.fail(EnvTelegramAdminChatID, fmt.Errorf("%w %q, want a chat ID", ErrInvalid, raw))
+		}
+		cfg.AdminChatID = chatID
+	}
+	return cfg
+}
+
+func (e *env) blablacar() BlaBlaCar {
+	return BlaBlaCar{
+		Backend:  strings.ToLower(e.string(EnvBlaBlaCarBackend)),
+		APIKey:   e.secret(EnvBlaBlaCarAPIKey),
+		CacheTTL: e.duration(EnvBlaBlaCarCacheTTL, 0),
+	}
+}
+
+func (e *env) poller() Poller {
+	return Poller{
+		Concurrency: e.int(EnvPollerConcurrency, 0),
+		TaskTimeout: e.duration(EnvPollerTaskTimeout, 0),
+		QueueURL:    e.string(EnvPollerQueueURL),
+	}
+}
+
+func (e *env) features() map[string]bool {
+	features := make(map[string]bool)
+	for _, feature := range strings.Split(e.string(EnvFeatures), ",") {
+		if feature = strings.ToLower(strings.TrimSpace(feature)); feature != "" {
+			features[feature] = true
+		}
+	}
+	return features
+}
+
+func required(key, value string) error {
+	if value == "" {
+		return fmt.Errorf("%s: %w", key, ErrMissing)
+	}
+	return nil
+}
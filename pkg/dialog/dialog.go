@@ -0,0 +1,152 @@
+package dialog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/clock"
+	"github.com/arseniisemenow/bbc-common/pkg/ydb"
+)
+
+// Done is returned by a step handler to finish the dialog
+const Done = ""
+
+// DefaultTimeout is used when New is given a non-positive timeout
+const DefaultTimeout = 30 * time.Minute
+
+// CancelCommand aborts the active dialog from any step
+const CancelCommand = "/cancel"
+
+var ErrNoActiveDialog = errors.New("no active dialog")
+
+// Store persists per-chat dialog state; *ydb.KVStore satisfies it
+type Store interface {
+	Put(ctx context.Context, key, value string, ttl time.Duration) error
+	Get(ctx context.Context, key string) (string, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// State is the persisted progress of a dialog for one chat
+type State struct {
+	Step      string            `json:"step"`
+	Data      map[string]string `json:"data"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// StepHandler processes user input for the current step, may record partial
+// data in state, and returns the next step name or Done
+type StepHandler func(ctx context.Context, chatID int64, input string, state *State) (next string, err error)
+
+// Machine is a multi-step dialog whose state survives between function invocations
+type Machine struct {
+	store   Store
+	steps   map[string]StepHandler
+	timeout time.Duration
+
+	// OnCancel is called after the user sends /cancel
+	OnCancel func(ctx context.Context, chatID int64) error
+}
+
+// New creates a dialog whose state expires after timeout of inactivity
+func New(store Store, timeout time.Duration) *Machine {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Machine{store: store, steps: make(map[string]StepHandler), timeout: timeout}
+}
+
+// Step registers the handler for a step
+func (m *Machine) Step(name string, handler StepHandler) {
+	m.steps[name] = handler
+}
+
+// Start begins the dialog for a chat at the given step, discarding any previous progress
+func (m *Machine) Start(ctx context.Context, chatID int64, step string) error {
+	if _, ok := m.steps[step]; !ok {
+		return fmt.Errorf("unknown dialog step %q", step)
+	}
+	return m.save(ctx, chatID, &State{Step: step, Data: make(map[string]string)})
+}
+
+// Current returns the active state for a chat or ErrNoActiveDialog
+func (m *Machine) Current(ctx context.Context, chatID int64) (*State, error) {
+	raw, err := m.store.Get(ctx, stateKey(chatID))
+	if errors.Is(err, ydb.ErrKeyNotFound) {
+		return nil, ErrNoActiveDialog
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dialog state for chat %d: %w", chatID, err)
+	}
+
+	var state State
+	if err := json.Unmarshal([]byte(raw), &state); err != nil {
+		return nil, fmt.Errorf("failed to decode dialog state for chat %d: %w", chatID, err)
+	}
+	if clock.From(ctx).Now().Sub(state.UpdatedAt) > m.timeout {
+		return nil, ErrNoActiveDialog
+	}
+	return &state, nil
+}
+
+// Handle feeds input to the active step. It reports false when the chat has
+// no active dialog, so the caller can fall through to regular command handling.
+func (m *Machine) Handle(ctx context.Context, chatID int64, input string) (bool, error) {
+	state, err := m.Current(ctx, chatID)
+	if errors.Is(err, ErrNoActiveDialog) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if strings.EqualFold(strings.TrimSpace(input), CancelCommand) {
+		return true, m.Cancel(ctx, chatID)
+	}
+
+	handler, ok := m.steps[state.Step]
+	if !ok {
+		log.Printf("[Dialog] Chat %d is at unknown step %q, resetting", chatID, state.Step)
+		return true, m.store.Delete(ctx, stateKey(chatID))
+	}
+
+	next, err := handler(ctx, chatID, input, state)
+	if err != nil {
+		return true, err
+	}
+	if next == Done {
+		return true, m.store.Delete(ctx, stateKey(chatID))
+	}
+
+	state.Step = next
+	return true, m.save(ctx, chatID, state)
+}
+
+// Cancel aborts the active dialog for a chat
+func (m *Machine) Cancel(ctx context.Context, chatID int64) error {
+	if err := m.store.Delete(ctx, stateKey(chatID)); err != nil {
+		return fmt.Errorf("failed to delete dialog state for chat %d: %w", chatID, err)
+	}
+	if m.OnCancel != nil {
+		return m.OnCancel(ctx, chatID)
+	}
+	return nil
+}
+
+func (m *Machine) save(ctx context.Context, chatID int64, state *State) error {
+	state.UpdatedAt = clock.From(ctx).Now()
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode dialog state: %w", err)
+	}
+	return m.store.Put(ctx, stateKey(chatID), string(raw), m.timeout)
+}
+
+func stateKey(chatID int64) string {
+	return strconv.FormatInt(chatID, 10)
+}
@@ -0,0 +1,113 @@
+// Package fixtures builds valid model values for tests, e.g.
+//
+//	sub := fixtures.Subscription().ForChat(1).From("Berlin").OnDate("2025-07-01").Build()
+//
+// Builders start from deterministic defaults that pass the models' Validate, so a test
+// only spells out the fields it is about
+package fixtures
+
+import (
+	"strings"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// Defaults of the builders
+const (
+	ChatID   int64 = 1
+	Date           = "2025-07-01"
+	Currency       = "EUR"
+	FromCity       = "Berlin"
+	ToCity         = "Munich"
+)
+
+// Epoch is the creation time of built values; the fake clock in tests usually starts here
+var Epoch = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// mustDate parses a "2006-01-02" date, panicking on typos in test data
+func mustDate(date string) models.Date {
+	d, err := models.ParseDate(date)
+	if err != nil {
+		panic("fixtures: " + err.Error())
+	}
+	return d
+}
+
+// PlaceID returns the place ID builders use for a city name, e.g. "fixture:berlin";
+// it is stable, so a subscription and a trip built from the same name agree
+func PlaceID(name string) string {
+	return "fixture:" + strings.ToLower(strings.Join(strings.Fields(name), "-"))
+}
+
+// UserBuilder builds a models.User, active by default
+type UserBuilder struct {
+	u models.User
+}
+
+// User starts an active user in chat ChatID
+func User() *UserBuilder {
+	return &UserBuilder{u: models.User{TelegramChatID: ChatID, Status: models.UserStatusActive, CreatedAt: Epoch}}
+}
+
+// ForChat sets the chat ID
+func (b *UserBuilder) ForChat(chatID int64) *UserBuilder {
+	b.u.TelegramChatID = chatID
+	return b
+}
+
+// WithStatus sets the status
+func (b *UserBuilder) WithStatus(status models.UserStatus) *UserBuilder {
+	b.u.Status = status
+	return b
+}
+
+// Unauthenticated marks the user as logged out of BlaBlaCar
+func (b *UserBuilder) Unauthenticated() *UserBuilder {
+	return b.WithStatus(models.UserStatusUnauthenticated)
+}
+
+// Build returns the user
+func (b *UserBuilder) Build() models.User {
+	return b.u
+}
+
+// TokensBuilder builds models.UserTokens
+type TokensBuilder struct {
+	t models.UserTokens
+}
+
+// Tokens starts tokens of chat ChatID with placeholder credentials
+func Tokens() *TokensBuilder {
+	return &TokensBuilder{t: models.UserTokens{
+		TelegramChatID: ChatID,
+		AccessToken:    "access-token",
+		RefreshToken:   "refresh-token",
+		UserID:         "user-1",
+		CreatedAt:      Epoch,
+		UpdatedAt:      Epoch,
+	}}
+}
+
+// ForChat sets the chat ID
+func (b *TokensBuilder) ForChat(chatID int64) *TokensBuilder {
+	b.t.TelegramChatID = chatID
+	return b
+}
+
+// WithAccess sets the access and refresh tokens
+func (b *TokensBuilder) WithAccess(access, refresh string) *TokensBuilder {
+	b.t.AccessToken, b.t.RefreshToken = access, refresh
+	return b
+}
+
+// WithProfile sets the device identity sent with the user's requests
+func (b *TokensBuilder) WithProfile(p models.HeaderProfile) *TokensBuilder {
+	b.t.HeaderProfile = p
+	return b
+}
+
+// Build returns the tokens
+func (b *TokensBuilder) Build() models.UserTokens {
+	return b.t
+}
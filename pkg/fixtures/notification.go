@@ -0,0 +1,78 @@
+package fixtures
+
+import (
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// NotificationBuilder builds a models.Notification
+type NotificationBuilder struct {
+	n models.Notification
+}
+
+// Notification starts a pending notification "notif-1" about "trip-1" for subscription
+// "sub-1" of chat ChatID
+func Notification() *NotificationBuilder {
+	return &NotificationBuilder{n: models.Notification{
+		ID:             "notif-1",
+		TelegramChatID: ChatID,
+		SubscriptionID: "sub-1",
+		TripID:         "trip-1",
+		Status:         models.NotificationStatusPending,
+		CreatedAt:      Epoch,
+	}}
+}
+
+// WithID sets the ID; an empty ID lets the repository assign one
+func (b *NotificationBuilder) WithID(id string) *NotificationBuilder {
+	b.n.ID = id
+	return b
+}
+
+// For takes the chat and subscription IDs from sub
+func (b *NotificationBuilder) For(sub models.SearchSubscription) *NotificationBuilder {
+	b.n.TelegramChatID, b.n.SubscriptionID = sub.TelegramChatID, sub.ID
+	return b
+}
+
+// About sets the trip and snapshots it as shown in the message
+func (b *NotificationBuilder) About(trip models.TripInfo) *NotificationBuilder {
+	b.n.TripID = trip.ID
+	b.n.Snapshot = &models.TripSnapshot{
+		TripID:         trip.ID,
+		Price:          trip.Price,
+		SeatsAvailable: trip.SeatsAvailable,
+		DepartureTime:  trip.DepartureTime,
+		CapturedAt:     b.n.CreatedAt,
+	}
+	return b
+}
+
+// Sent marks the notification sent as the given Telegram message
+func (b *NotificationBuilder) Sent(messageID int) *NotificationBuilder {
+	b.n.Status, b.n.TelegramMessageID = models.NotificationStatusSent, messageID
+	return b
+}
+
+// WithStatus sets the status
+func (b *NotificationBuilder) WithStatus(status models.NotificationStatus) *NotificationBuilder {
+	b.n.Status = status
+	return b
+}
+
+// CreatedAt sets the creation time
+func (b *NotificationBuilder) CreatedAt(t time.Time) *NotificationBuilder {
+	b.n.CreatedAt = t
+	return b
+}
+
+// Build returns the notification; the snapshot is copied, so the builder can be reused
+func (b *NotificationBuilder) Build() models.Notification {
+	n := b.n
+	if n.Snapshot != nil {
+		snapshot := *n.Snapshot
+		n.Snapshot = &snapshot
+	}
+	return n
+}
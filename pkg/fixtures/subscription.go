@@ -0,0 +1,113 @@
+package fixtures
+
+import (
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// SubscriptionBuilder builds a models.SearchSubscription
+type SubscriptionBuilder struct {
+	s models.SearchSubscription
+}
+
+// Subscription starts an active subscription "sub-1" of chat ChatID from FromCity to
+// ToCity on Date for one seat, without filters
+func Subscription() *SubscriptionBuilder {
+	b := &SubscriptionBuilder{s: models.SearchSubscription{
+		ID:             "sub-1",
+		TelegramChatID: ChatID,
+		DepartureDate:  mustDate(Date),
+		RequestedSeats: 1,
+		IsActive:       true,
+		CreatedAt:      Epoch,
+	}}
+	return b.From(FromCity).To(ToCity)
+}
+
+// WithID sets the ID; an empty ID lets the repository assign one
+func (b *SubscriptionBuilder) WithID(id string) *SubscriptionBuilder {
+	b.s.ID = id
+	return b
+}
+
+// ForChat sets the chat ID
+func (b *SubscriptionBuilder) ForChat(chatID int64) *SubscriptionBuilder {
+	b.s.TelegramChatID = chatID
+	return b
+}
+
+// From sets the departure city and its PlaceID
+func (b *SubscriptionBuilder) From(city string) *SubscriptionBuilder {
+	b.s.FromPlaceID, b.s.FromPlaceName = PlaceID(city), city
+	return b
+}
+
+// To sets the destination city and its PlaceID
+func (b *SubscriptionBuilder) To(city string) *SubscriptionBuilder {
+	b.s.ToPlaceID, b.s.ToPlaceName = PlaceID(city), city
+	return b
+}
+
+// OnDate sets the departure date, "2006-01-02"
+func (b *SubscriptionBuilder) OnDate(date string) *SubscriptionBuilder {
+	b.s.DepartureDate = mustDate(date)
+	return b
+}
+
+// Seats sets the number of requested seats
+func (b *SubscriptionBuilder) Seats(n int) *SubscriptionBuilder {
+	b.s.RequestedSeats = n
+	return b
+}
+
+// Inactive deactivates the subscription
+func (b *SubscriptionBuilder) Inactive() *SubscriptionBuilder {
+	b.s.IsActive = false
+	return b
+}
+
+// CheckedAt sets when the poller last searched
+func (b *SubscriptionBuilder) CheckedAt(t time.Time) *SubscriptionBuilder {
+	b.s.LastCheckedAt = &t
+	return b
+}
+
+// MaxPrice filters out trips above amount in major units of currency
+func (b *SubscriptionBuilder) MaxPrice(amount float64, currency string) *SubscriptionBuilder {
+	price := models.NewMoney(amount, currency)
+	b.s.Filters.MaxPrice = &price
+	return b
+}
+
+// Between filters the local departure time, "HH:MM" inclusive; either may be empty
+func (b *SubscriptionBuilder) Between(from, to string) *SubscriptionBuilder {
+	b.s.Filters.DepartureTimeFrom, b.s.Filters.DepartureTimeTo = from, to
+	return b
+}
+
+// Transport restricts trips to models.TransportCarpooling or models.TransportBus
+func (b *SubscriptionBuilder) Transport(transport string) *SubscriptionBuilder {
+	b.s.Filters.Transport = transport
+	return b
+}
+
+// MinRating filters out drivers rated below rating
+func (b *SubscriptionBuilder) MinRating(rating float64) *SubscriptionBuilder {
+	b.s.Filters.MinDriverRating = rating
+	return b
+}
+
+// Build returns the subscription; the filters are copied, so the builder can be reused
+func (b *SubscriptionBuilder) Build() models.SearchSubscription {
+	s := b.s
+	if s.Filters.MaxPrice != nil {
+		price := *s.Filters.MaxPrice
+		s.Filters.MaxPrice = &price
+	}
+	if s.LastCheckedAt != nil {
+		t := *s.LastCheckedAt
+		s.LastCheckedAt = &t
+	}
+	return s
+}
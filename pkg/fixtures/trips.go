@@ -0,0 +1,161 @@
+package fixtures
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// tripTimeLayout is the offset-less local time BlaBlaCar reports departures in
+const tripTimeLayout = "2006-01-02T15:04:05"
+
+// TripBuilder builds a models.TripInfo
+type TripBuilder struct {
+	t        models.TripInfo
+	date     string
+	at       string
+	duration time.Duration
+}
+
+// Trip starts carpooling trip "trip-1" from FromCity to ToCity on Date at 08:00,
+// taking 5h30 for 25.00 EUR, with 3 seats left and a driver rated 4.8
+func Trip() *TripBuilder {
+	return &TripBuilder{
+		t: models.TripInfo{
+			ID:             "trip-1",
+			FromPlaceName:  FromCity,
+			ToPlaceName:    ToCity,
+			Price:          models.NewMoney(25, Currency),
+			DriverName:     "Anna",
+			DriverRating:   4.8,
+			SeatsAvailable: 3,
+		},
+		date:     Date,
+		at:       "08:00",
+		duration: 5*time.Hour + 30*time.Minute,
+	}
+}
+
+// WithID sets the trip ID
+func (b *TripBuilder) WithID(id string) *TripBuilder {
+	b.t.ID = id
+	return b
+}
+
+// From sets the departure city
+func (b *TripBuilder) From(city string) *TripBuilder {
+	b.t.FromPlaceName = city
+	return b
+}
+
+// To sets the destination city
+func (b *TripBuilder) To(city string) *TripBuilder {
+	b.t.ToPlaceName = city
+	return b
+}
+
+// OnDate sets the departure date, "2006-01-02"
+func (b *TripBuilder) OnDate(date string) *TripBuilder {
+	b.date = date
+	return b
+}
+
+// At sets the local departure time, "15:04"
+func (b *TripBuilder) At(clock string) *TripBuilder {
+	b.at = clock
+	return b
+}
+
+// Taking sets the duration, which also moves the arrival
+func (b *TripBuilder) Taking(d time.Duration) *TripBuilder {
+	b.duration = d
+	return b
+}
+
+// Price sets the price in major units of currency
+func (b *TripBuilder) Price(amount float64, currency string) *TripBuilder {
+	b.t.Price = models.NewMoney(amount, currency)
+	return b
+}
+
+// Seats sets the number of seats left
+func (b *TripBuilder) Seats(n int) *TripBuilder {
+	b.t.SeatsAvailable = n
+	return b
+}
+
+// Driver sets the driver's name and rating
+func (b *TripBuilder) Driver(name string, rating float64) *TripBuilder {
+	b.t.DriverName, b.t.DriverRating = name, rating
+	return b
+}
+
+// Bus makes it a bus trip, which has no driver
+func (b *TripBuilder) Bus() *TripBuilder {
+	b.t.IsBus = true
+	b.t.DriverName, b.t.DriverRating = "", 0
+	return b
+}
+
+// Build returns the trip. Departure and arrival are formatted like BlaBlaCar's, and the
+// deep link is derived from the ID unless set.
+func (b *TripBuilder) Build() models.TripInfo {
+	t := b.t
+	departure, err := time.Parse("2006-01-02 15:04", b.date+" "+b.at)
+	if err != nil {
+		panic(fmt.Sprintf("fixtures: invalid trip departure %q %q", b.date, b.at))
+	}
+	t.DepartureTime = departure.Format(tripTimeLayout)
+	t.ArrivalTime = departure.Add(b.duration).Format(tripTimeLayout)
+	d := b.duration.Round(time.Minute)
+	t.Duration = fmt.Sprintf("%dh%02d", int(d.Hours()), int(d.Minutes())%60)
+	if t.DeepLink == "" {
+		t.DeepLink = "https://www.blablacar.com/trip?id=" + t.ID
+	}
+	return t
+}
+
+// HourlyTrips returns n otherwise identical trips on date, departing every hour from
+// 06:00, "trip-1" to "trip-<n>"
+func HourlyTrips(date string, n int) []models.TripInfo {
+	trips := make([]models.TripInfo, n)
+	for i := range trips {
+		trips[i] = Trip().WithID(fmt.Sprintf("trip-%d", i+1)).OnDate(date).At(fmt.Sprintf("%02d:00", (6+i)%24)).Build()
+	}
+	return trips
+}
+
+// Mixed trip IDs, each failing at most one filter of a typical subscription
+const (
+	TripMatching      = "trip-matching"
+	TripExpensive     = "trip-expensive"
+	TripBus           = "trip-bus"
+	TripNight         = "trip-night"
+	TripLowRated      = "trip-low-rated"
+	TripOneSeat       = "trip-one-seat"
+	TripNextDay       = "trip-next-day"
+	TripOtherCurrency = "trip-other-currency"
+)
+
+// MixedTrips returns a search result on date with one trip per filter to exercise
+// matching. Against a subscription on date with Seats(2), MaxPrice(30, "EUR"),
+// Between("07:00", "20:00"), Transport(models.TransportCarpooling) and MinRating(4),
+// TripMatching passes and every other trip fails exactly the filter its ID names, except
+// TripOtherCurrency: it costs 20 PLN, which only a matching.PriceComparer can compare.
+func MixedTrips(date string) []models.TripInfo {
+	next := mustDate(date).AddDays(1).String()
+	base := func(id string) *TripBuilder {
+		return Trip().WithID(id).OnDate(date).At("09:30").Price(20, Currency)
+	}
+	return []models.TripInfo{
+		base(TripMatching).Build(),
+		base(TripExpensive).Price(45, Currency).Build(),
+		base(TripBus).Bus().Build(),
+		base(TripNight).At("23:15").Build(),
+		base(TripLowRated).Driver("Max", 3.2).Build(),
+		base(TripOneSeat).Seats(1).Build(),
+		base(TripNextDay).OnDate(next).Build(),
+		base(TripOtherCurrency).Price(20, "PLN").Build(),
+	}
+}
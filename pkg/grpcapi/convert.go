@@ -0,0 +1,149 @@
+package grpcapi
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/arseniisemenow/bbc-common/pkg/grpcapi/bbcv1"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// UserToProto converts a user to its message
+func UserToProto(u models.User) *bbcv1.User {
+	return &bbcv1.User{
+		TelegramChatId:    u.TelegramChatID,
+		Status:            string(u.Status),
+		CreatedAt:         timestamp(&u.CreatedAt),
+		LastAuthSuccessAt: timestamp(u.LastAuthSuccessAt),
+		LastAuthFailureAt: timestamp(u.LastAuthFailureAt),
+	}
+}
+
+// UserFromProto converts a user message; the status is not validated
+func UserFromProto(m *bbcv1.User) models.User {
+	u := models.User{
+		TelegramChatID:    m.GetTelegramChatId(),
+		Status:            models.UserStatus(m.GetStatus()),
+		LastAuthSuccessAt: timePtr(m.GetLastAuthSuccessAt()),
+		LastAuthFailureAt: timePtr(m.GetLastAuthFailureAt()),
+	}
+	if m.GetCreatedAt() != nil {
+		u.CreatedAt = m.GetCreatedAt().AsTime()
+	}
+	return u
+}
+
+// SubscriptionToProto converts a subscription to its message
+func SubscriptionToProto(s models.SearchSubscription) *bbcv1.Subscription {
+	m := &bbcv1.Subscription{
+		Id:             s.ID,
+		TelegramChatId: s.TelegramChatID,
+		FromPlaceId:    s.FromPlaceID,
+		FromPlaceName:  s.FromPlaceName,
+		ToPlaceId:      s.ToPlaceID,
+		ToPlaceName:    s.ToPlaceName,
+		DepartureDate:  s.DepartureDate.String(),
+		RequestedSeats: int32(s.RequestedSeats),
+		IsActive:       s.IsActive,
+		CreatedAt:      timestamp(&s.CreatedAt),
+		LastCheckedAt:  timestamp(s.LastCheckedAt),
+	}
+	if !s.Filters.IsZero() {
+		f := s.Filters
+		m.Filters = &bbcv1.TripFilters{
+			DepartureTimeFrom: f.DepartureTimeFrom,
+			DepartureTimeTo:   f.DepartureTimeTo,
+			Transport:         f.Transport,
+			MinDriverRating:   f.MinDriverRating,
+		}
+		if f.MaxPrice != nil {
+			m.Filters.MaxPrice = &bbcv1.Money{AmountMinor: f.MaxPrice.AmountMinor, Currency: f.MaxPrice.Currency}
+		}
+	}
+	return m
+}
+
+// SubscriptionFromProto converts a subscription message; a malformed departure date is
+// an error matching models.ErrInvalidDate
+func SubscriptionFromProto(m *bbcv1.Subscription) (models.SearchSubscription, error) {
+	s := models.SearchSubscription{
+		ID:             m.GetId(),
+		TelegramChatID: m.GetTelegramChatId(),
+		FromPlaceID:    m.GetFromPlaceId(),
+		FromPlaceName:  m.GetFromPlaceName(),
+		ToPlaceID:      m.GetToPlaceId(),
+		ToPlaceName:    m.GetToPlaceName(),
+		RequestedSeats: int(m.GetRequestedSeats()),
+		IsActive:       m.GetIsActive(),
+		LastCheckedAt:  timePtr(m.GetLastCheckedAt()),
+	}
+	if m.GetDepartureDate() != "" {
+		date, err := models.ParseDate(m.GetDepartureDate())
+		if err != nil {
+			return s, err
+		}
+		s.DepartureDate = date
+	}
+	if m.GetCreatedAt() != nil {
+		s.CreatedAt = m.GetCreatedAt().AsTime()
+	}
+	if f := m.GetFilters(); f != nil {
+		s.Filters = models.TripFilters{
+			DepartureTimeFrom: f.GetDepartureTimeFrom(),
+			DepartureTimeTo:   f.GetDepartureTimeTo(),
+			Transport:         f.GetTransport(),
+			MinDriverRating:   f.GetMinDriverRating(),
+		}
+		if p := f.GetMaxPrice(); p != nil {
+			s.Filters.MaxPrice = &models.Money{AmountMinor: p.GetAmountMinor(), Currency: p.GetCurrency()}
+		}
+	}
+	return s, nil
+}
+
+// NotificationToProto converts a notification to its message; the trip snapshot is
+// not part of the API
+func NotificationToProto(n models.Notification) *bbcv1.Notification {
+	return &bbcv1.Notification{
+		Id:                n.ID,
+		TelegramChatId:    n.TelegramChatID,
+		SubscriptionId:    n.SubscriptionID,
+		TripId:            n.TripID,
+		TelegramMessageId: int32(n.TelegramMessageID),
+		Status:            string(n.Status),
+		CreatedAt:         timestamp(&n.CreatedAt),
+	}
+}
+
+// NotificationFromProto converts a notification message
+func NotificationFromProto(m *bbcv1.Notification) models.Notification {
+	n := models.Notification{
+		ID:                m.GetId(),
+		TelegramChatID:    m.GetTelegramChatId(),
+		SubscriptionID:    m.GetSubscriptionId(),
+		TripID:            m.GetTripId(),
+		TelegramMessageID: int(m.GetTelegramMessageId()),
+		Status:            models.NotificationStatus(m.GetStatus()),
+	}
+	if m.GetCreatedAt() != nil {
+		n.CreatedAt = m.GetCreatedAt().AsTime()
+	}
+	return n
+}
+
+// timestamp converts t, nil for nil and zero times
+func timestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func timePtr(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
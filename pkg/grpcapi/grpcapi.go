@@ -0,0 +1,147 @@
+// Package grpcapi serves the users, subscriptions and notifications over gRPC, so tools
+// in other languages and other services access them the same way the bot does. The
+// services are defined in proto/bbc/v1/bbc.proto; bbcv1 holds the generated Go code.
+//
+//	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcapi.RecoveryInterceptor(), grpcapi.AuthInterceptor(key)))
+//	grpcapi.Register(srv, ydb.Database{})
+package grpcapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/arseniisemenow/bbc-common/pkg/grpcapi/bbcv1"
+	"github.com/arseniisemenow/bbc-common/pkg/logging"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+	"github.com/arseniisemenow/bbc-common/pkg/recovery"
+	"github.com/arseniisemenow/bbc-common/pkg/ydb"
+)
+
+// UserDatabase is the user data served by UserServer
+type UserDatabase interface {
+	GetUser(ctx context.Context, chatID int64) (*models.User, error)
+	UpsertUser(ctx context.Context, user *models.User) error
+	UpdateUserStatus(ctx context.Context, chatID int64, status models.UserStatus) error
+	GetActiveUsers(ctx context.Context) ([]models.User, error)
+}
+
+// SubscriptionDatabase is the subscription data served by SubscriptionServer
+type SubscriptionDatabase interface {
+	GetSubscriptionsByUser(ctx context.Context, chatID int64) ([]models.SearchSubscription, error)
+	CreateSubscription(ctx context.Context, sub *models.SearchSubscription) error
+	SetSubscriptionActive(ctx context.Context, subID string, active bool) error
+	DeleteSubscription(ctx context.Context, subID string) error
+}
+
+// NotificationDatabase is the notification data served by NotificationServer; a missing
+// notification is nil without an error
+type NotificationDatabase interface {
+	GetNotificationByTrip(ctx context.Context, chatID int64, subID, tripID string) (*models.Notification, error)
+	GetSentNotifications(ctx context.Context, today models.Date) ([]models.Notification, error)
+}
+
+// Database is all data the services wrap; ydb.Database implements it
+type Database interface {
+	UserDatabase
+	SubscriptionDatabase
+	NotificationDatabase
+}
+
+// Register registers the user, subscription and notification services backed by db
+func Register(s grpc.ServiceRegistrar, db Database) {
+	bbcv1.RegisterUserServiceServer(s, NewUserServer(db))
+	bbcv1.RegisterSubscriptionServiceServer(s, NewSubscriptionServer(db))
+	bbcv1.RegisterNotificationServiceServer(s, NewNotificationServer(db))
+}
+
+// Client bundles the clients of the three services on one connection
+type Client struct {
+	Users         bbcv1.UserServiceClient
+	Subscriptions bbcv1.SubscriptionServiceClient
+	Notifications bbcv1.NotificationServiceClient
+}
+
+// NewClient creates the clients on cc, e.g. from grpc.Dial with APIKey credentials
+func NewClient(cc grpc.ClientConnInterface) *Client {
+	return &Client{
+		Users:         bbcv1.NewUserServiceClient(cc),
+		Subscriptions: bbcv1.NewSubscriptionServiceClient(cc),
+		Notifications: bbcv1.NewNotificationServiceClient(cc),
+	}
+}
+
+// APIKey sends key as a bearer token with every call, see AuthInterceptor; use it with
+// grpc.WithPerRPCCredentials
+type APIKey string
+
+// GetRequestMetadata implements credentials.PerRPCCredentials
+func (k APIKey) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(k)}, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials; the services run
+// inside the cloud network, so plain connections are allowed
+func (APIKey) RequireTransportSecurity() bool {
+	return false
+}
+
+// AuthInterceptor rejects calls without the bearer token key with Unauthenticated
+func AuthInterceptor(key string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		var token string
+		if values := md.Get("authorization"); len(values) > 0 {
+			token, _ = strings.CutPrefix(values[0], "Bearer ")
+		}
+		if key == "" || subtle.ConstantTimeCompare([]byte(token), []byte(key)) != 1 {
+			return nil, status.Error(codes.Unauthenticated, "invalid API key")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// RecoveryInterceptor turns a panic of a handler into an Internal error, reporting it
+// like recovery.Do
+func RecoveryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		err = recovery.Do(ctx, func() error {
+			resp, err = handler(ctx, req)
+			return err
+		})
+		var panicErr *recovery.PanicError
+		if errors.As(err, &panicErr) {
+			return nil, status.Error(codes.Internal, "internal error")
+		}
+		return resp, err
+	}
+}
+
+// statusError maps a database error to a gRPC status: validation errors to
+// InvalidArgument, missing records to NotFound and the rest to Internal, logged
+func statusError(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, models.ErrInvalid), errors.Is(err, models.ErrInvalidDate):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ydb.ErrUserNotFound), errors.Is(err, ydb.ErrSubscriptionNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	logger(ctx).Error("Request failed", "error", err)
+	return status.Error(codes.Internal, "internal error")
+}
+
+// logger returns the package's logger for ctx
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Component(ctx, "grpcapi")
+}
@@ -0,0 +1,167 @@
+package grpcapi
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/arseniisemenow/bbc-common/pkg/grpcapi/bbcv1"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// UserServer implements bbcv1.UserServiceServer
+type UserServer struct {
+	bbcv1.UnimplementedUserServiceServer
+	db UserDatabase
+}
+
+// NewUserServer creates a user service backed by db
+func NewUserServer(db UserDatabase) *UserServer {
+	return &UserServer{db: db}
+}
+
+// GetUser returns a user, NotFound if there is none
+func (s *UserServer) GetUser(ctx context.Context, req *bbcv1.GetUserRequest) (*bbcv1.User, error) {
+	user, err := s.db.GetUser(ctx, req.GetTelegramChatId())
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return UserToProto(*user), nil
+}
+
+// UpsertUser stores a user; an invalid one is rejected with InvalidArgument
+func (s *UserServer) UpsertUser(ctx context.Context, req *bbcv1.UpsertUserRequest) (*bbcv1.User, error) {
+	if req.GetUser() == nil {
+		return nil, status.Error(codes.InvalidArgument, "user is required")
+	}
+	user := UserFromProto(req.GetUser())
+	if err := s.db.UpsertUser(ctx, &user); err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return UserToProto(user), nil
+}
+
+// UpdateUserStatus sets a user's status; an unknown one is rejected with InvalidArgument
+func (s *UserServer) UpdateUserStatus(ctx context.Context, req *bbcv1.UpdateUserStatusRequest) (*emptypb.Empty, error) {
+	userStatus, err := models.ParseUserStatus(req.GetStatus())
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	if err := s.db.UpdateUserStatus(ctx, req.GetTelegramChatId(), userStatus); err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// ListActiveUsers returns all active users
+func (s *UserServer) ListActiveUsers(ctx context.Context, _ *bbcv1.ListActiveUsersRequest) (*bbcv1.ListUsersResponse, error) {
+	users, err := s.db.GetActiveUsers(ctx)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	resp := &bbcv1.ListUsersResponse{Users: make([]*bbcv1.User, 0, len(users))}
+	for _, user := range users {
+		resp.Users = append(resp.Users, UserToProto(user))
+	}
+	return resp, nil
+}
+
+// SubscriptionServer implements bbcv1.SubscriptionServiceServer
+type SubscriptionServer struct {
+	bbcv1.UnimplementedSubscriptionServiceServer
+	db SubscriptionDatabase
+}
+
+// NewSubscriptionServer creates a subscription service backed by db
+func NewSubscriptionServer(db SubscriptionDatabase) *SubscriptionServer {
+	return &SubscriptionServer{db: db}
+}
+
+// ListSubscriptions returns a user's subscriptions
+func (s *SubscriptionServer) ListSubscriptions(ctx context.Context, req *bbcv1.ListSubscriptionsRequest) (*bbcv1.ListSubscriptionsResponse, error) {
+	subs, err := s.db.GetSubscriptionsByUser(ctx, req.GetTelegramChatId())
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	resp := &bbcv1.ListSubscriptionsResponse{Subscriptions: make([]*bbcv1.Subscription, 0, len(subs))}
+	for _, sub := range subs {
+		resp.Subscriptions = append(resp.Subscriptions, SubscriptionToProto(sub))
+	}
+	return resp, nil
+}
+
+// CreateSubscription stores a subscription and returns it with its ID; an invalid one is
+// rejected with InvalidArgument
+func (s *SubscriptionServer) CreateSubscription(ctx context.Context, req *bbcv1.CreateSubscriptionRequest) (*bbcv1.Subscription, error) {
+	if req.GetSubscription() == nil {
+		return nil, status.Error(codes.InvalidArgument, "subscription is required")
+	}
+	sub, err := SubscriptionFromProto(req.GetSubscription())
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	if err := s.db.CreateSubscription(ctx, &sub); err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return SubscriptionToProto(sub), nil
+}
+
+// SetSubscriptionActive pauses or resumes a subscription
+func (s *SubscriptionServer) SetSubscriptionActive(ctx context.Context, req *bbcv1.SetSubscriptionActiveRequest) (*emptypb.Empty, error) {
+	if err := s.db.SetSubscriptionActive(ctx, req.GetId(), req.GetActive()); err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// DeleteSubscription deletes a subscription
+func (s *SubscriptionServer) DeleteSubscription(ctx context.Context, req *bbcv1.DeleteSubscriptionRequest) (*emptypb.Empty, error) {
+	if err := s.db.DeleteSubscription(ctx, req.GetId()); err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// NotificationServer implements bbcv1.NotificationServiceServer
+type NotificationServer struct {
+	bbcv1.UnimplementedNotificationServiceServer
+	db NotificationDatabase
+}
+
+// NewNotificationServer creates a notification service backed by db
+func NewNotificationServer(db NotificationDatabase) *NotificationServer {
+	return &NotificationServer{db: db}
+}
+
+// GetNotificationByTrip returns the notification of a subscription about a trip,
+// NotFound if there is none
+func (s *NotificationServer) GetNotificationByTrip(ctx context.Context, req *bbcv1.GetNotificationByTripRequest) (*bbcv1.Notification, error) {
+	notif, err := s.db.GetNotificationByTrip(ctx, req.GetTelegramChatId(), req.GetSubscriptionId(), req.GetTripId())
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	if notif == nil {
+		return nil, status.Errorf(codes.NotFound, "no notification about trip %s", req.GetTripId())
+	}
+	return NotificationToProto(*notif), nil
+}
+
+// ListSentNotifications returns the sent notifications of active subscriptions departing
+// on or after the requested day
+func (s *NotificationServer) ListSentNotifications(ctx context.Context, req *bbcv1.ListSentNotificationsRequest) (*bbcv1.ListNotificationsResponse, error) {
+	today, err := models.ParseDate(req.GetToday())
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	notifs, err := s.db.GetSentNotifications(ctx, today)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	resp := &bbcv1.ListNotificationsResponse{Notifications: make([]*bbcv1.Notification, 0, len(notifs))}
+	for _, notif := range notifs {
+		resp.Notifications = append(resp.Notifications, NotificationToProto(notif))
+	}
+	return resp, nil
+}
@@ -0,0 +1,157 @@
+// Package health serves liveness and readiness probes aggregating checks of the
+// dependencies, such as ydb.Ping, BotClient.Ping and YMQ.Ping
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/logging"
+)
+
+// DefaultTimeout bounds a check registered without its own timeout
+const DefaultTimeout = 3 * time.Second
+
+// Probe paths registered by Register
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+)
+
+// Statuses reported by the probes
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// CheckFunc returns an error when a dependency is unavailable
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name    string
+	fn      CheckFunc
+	timeout time.Duration
+}
+
+// Checker aggregates the readiness checks of a service
+type Checker struct {
+	checks []check
+}
+
+// NewChecker creates a checker without checks, always ready
+func NewChecker() *Checker {
+	return &Checker{}
+}
+
+// Add registers a readiness check bounded by timeout, DefaultTimeout if 0
+func (c *Checker) Add(name string, fn CheckFunc, timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	c.checks = append(c.checks, check{name: name, fn: fn, timeout: timeout})
+	return c
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Report is the body of the probes
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// Healthy reports whether all checks passed
+func (r Report) Healthy() bool {
+	return r.Status == StatusOK
+}
+
+// Run runs all checks in parallel, each within its timeout
+func (c *Checker) Run(ctx context.Context) Report {
+	results := make([]Result, len(c.checks))
+	var wg sync.WaitGroup
+	for i, ch := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = ch.run(ctx)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(c.checks))}
+	for i, ch := range c.checks {
+		report.Checks[ch.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+func (ch check) run(ctx context.Context) Result {
+	ctx, cancel := context.WithTimeout(ctx, ch.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- ch.fn(ctx) }()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		// checks ignoring ctx must not hold the probe
+		err = ctx.Err()
+	}
+
+	result := Result{Status: StatusOK, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = logging.RedactError(err).Error()
+		logger(ctx).Warn("Health check failed", "check", ch.name, "error", err)
+	}
+	return result
+}
+
+// Liveness answers that the process is up without running checks, so a failing
+// dependency does not get the container restarted
+func (c *Checker) Liveness() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, Report{Status: StatusOK})
+	})
+}
+
+// Readiness runs the checks, answering 503 Service Unavailable when one fails
+func (c *Checker) Readiness() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, c.Run(r.Context()))
+	})
+}
+
+// Register serves the liveness probe at /healthz and the readiness probe at /readyz
+func (c *Checker) Register(mux *http.ServeMux) {
+	mux.Handle(LivenessPath, c.Liveness())
+	mux.Handle(ReadinessPath, c.Readiness())
+}
+
+func writeReport(w http.ResponseWriter, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if !report.Healthy() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+// logger returns the package's logger for ctx
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Component(ctx, "health")
+}
@@ -0,0 +1,128 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnauthorized is returned by an AuthFunc rejecting the caller
+var ErrUnauthorized = errors.New("unauthorized")
+
+const (
+	// ResourceManagerEndpoint serves the folders IAM checks the callers against
+	ResourceManagerEndpoint = "https://resource-manager.api.cloud.yandex.net"
+	// DefaultIAMCacheTTL is how long an accepted IAM token is trusted without asking again
+	DefaultIAMCacheTTL = 5 * time.Minute
+)
+
+// AuthFunc authenticates a request, returning an error matching ErrUnauthorized to
+// reject the caller; other errors answer 500
+type AuthFunc func(r *http.Request) error
+
+// bearerToken returns the token of an "Authorization: Bearer" header
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+// StaticToken accepts requests carrying "Authorization: Bearer <token>"; an empty token
+// rejects every request
+func StaticToken(token string) AuthFunc {
+	return func(r *http.Request) error {
+		got, ok := bearerToken(r)
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			return ErrUnauthorized
+		}
+		return nil
+	}
+}
+
+// IAM accepts requests carrying the IAM token of a Yandex Cloud account allowed to view
+// folderID, e.g. "Authorization: Bearer $(yc iam create-token)". Tokens are checked by
+// reading the folder from the Resource Manager and trusted for DefaultIAMCacheTTL.
+func IAM(folderID string, client *http.Client) AuthFunc {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	var (
+		mu       sync.Mutex
+		accepted = make(map[[sha256.Size]byte]time.Time)
+	)
+	return func(r *http.Request) error {
+		token, ok := bearerToken(r)
+		if !ok {
+			return ErrUnauthorized
+		}
+		// keep digests only, so the cache holds no usable tokens
+		key := sha256.Sum256([]byte(token))
+		now := time.Now()
+
+		mu.Lock()
+		expires, ok := accepted[key]
+		mu.Unlock()
+		if ok && now.Before(expires) {
+			return nil
+		}
+
+		if err := checkFolderAccess(r.Context(), client, folderID, token); err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		for k, exp := range accepted {
+			if !now.Before(exp) {
+				delete(accepted, k)
+			}
+		}
+		accepted[key] = now.Add(DefaultIAMCacheTTL)
+		return nil
+	}
+}
+
+// checkFolderAccess reads folderID with token, which succeeds when the token's account
+// has at least viewer access to the folder
+func checkFolderAccess(ctx context.Context, client *http.Client, folderID, token string) error {
+	u := ResourceManagerEndpoint + "/resource-manager/v1/folders/" + url.PathEscape(folderID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create folder request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to check IAM token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return fmt.Errorf("%w: no access to folder %s", ErrUnauthorized, folderID)
+	}
+	return fmt.Errorf("failed to check IAM token: unexpected status %d", resp.StatusCode)
+}
+
+// RequireAuth answers 401 Unauthorized to requests auth rejects
+func RequireAuth(auth AuthFunc, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := auth(r); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,96 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+	"github.com/arseniisemenow/bbc-common/pkg/ydb"
+)
+
+// Error codes of ErrorBody, stable for clients to match
+const (
+	CodeInvalidArgument = "invalid_argument"
+	CodeUnauthorized    = "unauthorized"
+	CodeNotFound        = "not_found"
+	CodeInternal        = "internal"
+)
+
+// ErrorBody is the JSON body of every error response:
+//
+//	{"error": {"code": "invalid_argument", "message": "...", "fields": [...]}}
+type ErrorBody struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes a failed request
+type ErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Fields lists the failed checks of an invalid_argument error, if known
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError is a models.FieldError in JSON
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// errBadRequest marks malformed query parameters and path values
+var errBadRequest = errors.New("invalid parameter")
+
+// writeError answers err: invalid input with 400, rejected callers with 401, missing
+// records with 404 and the rest with 500, logged and without details
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var status int
+	detail := ErrorDetail{Message: err.Error()}
+	switch {
+	case errors.Is(err, errBadRequest), errors.Is(err, models.ErrInvalid), errors.Is(err, models.ErrInvalidDate):
+		status, detail.Code = http.StatusBadRequest, CodeInvalidArgument
+		detail.Fields = fieldErrors(err)
+	case errors.Is(err, ErrUnauthorized):
+		status, detail.Code = http.StatusUnauthorized, CodeUnauthorized
+		// the reason stays in the logs
+		detail.Message = "unauthorized"
+		logger(r.Context()).Info("Rejected request", "path", r.URL.Path, "error", err)
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	case errors.Is(err, ydb.ErrUserNotFound), errors.Is(err, ydb.ErrSubscriptionNotFound):
+		status, detail.Code = http.StatusNotFound, CodeNotFound
+	default:
+		status, detail.Code, detail.Message = http.StatusInternalServerError, CodeInternal, "internal error"
+		logger(r.Context()).Error("Request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+	}
+	writeJSON(w, status, ErrorBody{Error: detail})
+}
+
+// fieldErrors returns the failed checks of a validation error
+func fieldErrors(err error) []FieldError {
+	var fields []*models.FieldError
+	var verr *models.ValidationError
+	var ferr *models.FieldError
+	switch {
+	case errors.As(err, &verr):
+		fields = verr.Fields
+	case errors.As(err, &ferr):
+		fields = []*models.FieldError{ferr}
+	}
+	out := make([]FieldError, 0, len(fields))
+	for _, f := range fields {
+		out = append(out, FieldError{Field: f.Field, Code: f.Code, Message: f.Message})
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// writeJSON answers status with v encoded as JSON
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
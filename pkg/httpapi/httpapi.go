@@ -0,0 +1,201 @@
+// Package httpapi serves the admin REST API behind a small web dashboard: users,
+// subscriptions, usage stats and outbox dead letters. Every response is JSON, errors
+// use ErrorBody and lists are paginated with Page.
+//
+//	api := httpapi.New(ydb.AdminStore{}, httpapi.StaticToken(token))
+//	mux.Handle("/admin/", http.StripPrefix("/admin", api))
+//
+// Routes:
+//
+//	GET  /users                          ?cursor=&limit=
+//	GET  /subscriptions                  ?cursor=&limit=
+//	POST /subscriptions/{id}/deactivate
+//	GET  /stats                          ?since=RFC3339, a week ago by default
+//	GET  /dead-letters                   ?limit=
+//	POST /dead-letters/{id}/redrive
+package httpapi
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/clock"
+	"github.com/arseniisemenow/bbc-common/pkg/logging"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// DefaultStatsPeriod is how far back /stats counts clicks without a since parameter
+const DefaultStatsPeriod = 7 * 24 * time.Hour
+
+// Store is the data the admin API reads and changes; ydb.AdminStore implements it
+type Store interface {
+	// ListUsers returns up to limit users ordered by chat ID after afterChatID, nil for
+	// the first page
+	ListUsers(ctx context.Context, afterChatID *int64, limit int) ([]models.User, error)
+	// ListSubscriptions returns up to limit subscriptions ordered by ID after afterID,
+	// "" for the first page
+	ListSubscriptions(ctx context.Context, afterID string, limit int) ([]models.SearchSubscription, error)
+	SetSubscriptionActive(ctx context.Context, subID string, active bool) error
+	Stats(ctx context.Context, since time.Time) (models.Stats, error)
+	DeadLetters(ctx context.Context, limit int) ([]models.OutboxMessage, error)
+	Redrive(ctx context.Context, id string) error
+}
+
+// API is the admin API handler
+type API struct {
+	store Store
+	clock clock.Clock
+	mux   *http.ServeMux
+	auth  AuthFunc
+}
+
+// Option configures an API
+type Option func(*API)
+
+// WithClock sets the clock the default stats period is measured with
+func WithClock(c clock.Clock) Option {
+	return func(a *API) { a.clock = c }
+}
+
+// New creates the admin API backed by store, answering only requests auth accepts
+func New(store Store, auth AuthFunc, opts ...Option) *API {
+	a := &API{store: store, clock: clock.Real, auth: auth, mux: http.NewServeMux()}
+	for _, opt := range opts {
+		opt(a)
+	}
+	a.mux.HandleFunc("GET /users", a.listUsers)
+	a.mux.HandleFunc("GET /subscriptions", a.listSubscriptions)
+	a.mux.HandleFunc("POST /subscriptions/{id}/deactivate", a.deactivateSubscription)
+	a.mux.HandleFunc("GET /stats", a.stats)
+	a.mux.HandleFunc("GET /dead-letters", a.listDeadLetters)
+	a.mux.HandleFunc("POST /dead-letters/{id}/redrive", a.redrive)
+	a.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotFound, ErrorBody{Error: ErrorDetail{
+			Code:    CodeNotFound,
+			Message: "no route " + r.Method + " " + r.URL.Path,
+		}})
+	})
+	return a
+}
+
+// ServeHTTP implements http.Handler
+func (a *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	RequireAuth(a.auth, a.mux).ServeHTTP(w, r)
+}
+
+func (a *API) listUsers(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePage(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	var after *int64
+	if page.After != "" {
+		chatID, err := strconv.ParseInt(page.After, 10, 64)
+		if err != nil {
+			writeError(w, r, fmt.Errorf("%w: malformed cursor", errBadRequest))
+			return
+		}
+		after = &chatID
+	}
+	users, err := a.store.ListUsers(r.Context(), after, page.Limit+1)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newPage(users, page.Limit, func(u models.User) string {
+		return strconv.FormatInt(u.TelegramChatID, 10)
+	}))
+}
+
+func (a *API) listSubscriptions(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePage(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	subs, err := a.store.ListSubscriptions(r.Context(), page.After, page.Limit+1)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newPage(subs, page.Limit, func(s models.SearchSubscription) string {
+		return s.ID
+	}))
+}
+
+// deactivateSubscription stops polling a subscription, answering 204 No Content; it is
+// idempotent, so an unknown ID succeeds too
+func (a *API) deactivateSubscription(w http.ResponseWriter, r *http.Request) {
+	if err := a.store.SetSubscriptionActive(r.Context(), r.PathValue("id"), false); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	logger(r.Context()).Info("Deactivated subscription", "subscription_id", r.PathValue("id"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// StatsResponse is the body of /stats
+type StatsResponse struct {
+	models.Stats
+	// ClickThroughRate is the share of notifications sent since the period start that
+	// were clicked
+	ClickThroughRate float64 `json:"click_through_rate"`
+}
+
+func (a *API) stats(w http.ResponseWriter, r *http.Request) {
+	since := a.clock.Now().Add(-DefaultStatsPeriod)
+	if s := r.URL.Query().Get("since"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			writeError(w, r, fmt.Errorf("%w: since %q, want RFC 3339", errBadRequest, s))
+			return
+		}
+		since = t
+	}
+	stats, err := a.store.Stats(r.Context(), since)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, StatsResponse{Stats: stats, ClickThroughRate: stats.ClickThrough.Rate()})
+}
+
+// listDeadLetters returns the most recently failed dead letters; the list is bounded by
+// limit and has no next cursor
+func (a *API) listDeadLetters(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePage(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	msgs, err := a.store.DeadLetters(r.Context(), page.Limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if msgs == nil {
+		msgs = []models.OutboxMessage{}
+	}
+	writeJSON(w, http.StatusOK, Page[models.OutboxMessage]{Items: msgs})
+}
+
+// redrive makes a dead letter pending again, answering 204 No Content; messages that
+// are not dead letters are left alone
+func (a *API) redrive(w http.ResponseWriter, r *http.Request) {
+	if err := a.store.Redrive(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	logger(r.Context()).Info("Re-drove dead letter", "outbox_id", r.PathValue("id"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// logger returns the package's logger for ctx
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Component(ctx, "httpapi")
+}
@@ -0,0 +1,64 @@
+package httpapi
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// DefaultPageSize is the number of items of a page without a limit parameter
+	DefaultPageSize = 50
+	// MaxPageSize bounds the limit parameter
+	MaxPageSize = 200
+)
+
+// Page is the JSON body of a list response. NextCursor, when set, is passed as the
+// cursor parameter to get the following page.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// pageRequest holds the pagination parameters of a request, ?cursor=...&limit=N
+type pageRequest struct {
+	// After is the decoded cursor, the key of the last item of the previous page
+	After string
+	Limit int
+}
+
+// parsePage reads the cursor and limit parameters; a missing limit is DefaultPageSize
+// and a larger one than MaxPageSize is lowered to it
+func parsePage(r *http.Request) (pageRequest, error) {
+	page := pageRequest{Limit: DefaultPageSize}
+	if s := r.URL.Query().Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit <= 0 {
+			return page, fmt.Errorf("%w: limit %q, want a positive number", errBadRequest, s)
+		}
+		page.Limit = min(limit, MaxPageSize)
+	}
+	if s := r.URL.Query().Get("cursor"); s != "" {
+		after, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil || len(after) == 0 {
+			return page, fmt.Errorf("%w: malformed cursor", errBadRequest)
+		}
+		page.After = string(after)
+	}
+	return page, nil
+}
+
+// newPage returns the page of items fetched with one more than the limit, so a next
+// cursor is only set when there are more items; key returns the cursor key of an item
+func newPage[T any](items []T, limit int, key func(T) string) Page[T] {
+	page := Page[T]{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		page.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(key(items[limit-1])))
+	}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	return page
+}
@@ -0,0 +1,149 @@
+package i18n
+
+func init() {
+	Register("en", Catalog{
+		"subscription.title":    {Text: "Subscription #%s"},
+		"subscription.date":     {Text: "Date: %s"},
+		"subscription.status":   {Text: "Status: %s"},
+		"subscription.active":   {Text: "✅ Active"},
+		"subscription.inactive": {Text: "❌ Inactive"},
+		"subscriptions.empty":   {Text: "No active subscriptions"},
+		"trip.title":            {Text: "🚗 %s → %s"},
+		"trip.title_bus":        {Text: "🚌 %s → %s"},
+		"trip.departure":        {Text: "Departure: %s"},
+		"trip.arrival":          {Text: "Arrival: %s"},
+		"trip.duration":         {Text: "Duration: %s"},
+		"trip.price":            {Text: "Price: %s"},
+		"trip.driver":           {Text: "Driver: %s"},
+		"trip.driver_rated":     {Text: "Driver: %s (⭐ %.1f)"},
+		"trip.seats": {Forms: map[PluralForm]string{
+			One:   "%d seat available",
+			Other: "%d seats available",
+		}},
+		"trip.book":                 {Text: "Book on BlaBlaCar: %s"},
+		"trip.change.seats":         {Text: "seats %d → %d"},
+		"trip.change.departure":     {Text: "departure %s → %s"},
+		"trip.unavailable.sold_out": {Text: "⛔ No longer available: sold out"},
+		"trip.unavailable.removed":  {Text: "⛔ No longer available: the trip was cancelled"},
+		"digest.title": {Forms: map[PluralForm]string{
+			One:   "🔔 %d new trip %s → %s, %s",
+			Other: "🔔 %d new trips %s → %s, %s",
+		}},
+		"digest.more": {Forms: map[PluralForm]string{
+			One:   "…and %d more trip",
+			Other: "…and %d more trips",
+		}},
+		"digest.pause":       {Text: "⏸ Pause"},
+		"digest.unsubscribe": {Text: "🗑 Unsubscribe"},
+		"error.generic":      {Text: "Something went wrong, please try again later"},
+
+		// field errors, see models.FieldError.Key
+		"validation.from_place_id.required":             {Text: "Choose where you depart from"},
+		"validation.to_place_id.required":               {Text: "Choose your destination"},
+		"validation.to_place_id.same_place":             {Text: "The destination must differ from the departure place"},
+		"validation.departure_date.required":            {Text: "Choose a departure date"},
+		"validation.departure_date.format":              {Text: "Enter the date as YYYY-MM-DD"},
+		"validation.requested_seats.range":              {Text: "Choose between 1 and 8 seats"},
+		"validation.filters.departure_time_from.format": {Text: "Enter the earliest departure time as HH:MM"},
+		"validation.filters.departure_time_to.format":   {Text: "Enter the latest departure time as HH:MM"},
+		"validation.filters.min_driver_rating.range":    {Text: "The driver rating must be between 0 and 5"},
+		"validation.filters.max_price.range":            {Text: "The maximum price must be positive"},
+	}, englishPlural)
+
+	Register("ru", Catalog{
+		"subscription.title":    {Text: "Подписка #%s"},
+		"subscription.date":     {Text: "Дата: %s"},
+		"subscription.status":   {Text: "Статус: %s"},
+		"subscription.active":   {Text: "✅ Активна"},
+		"subscription.inactive": {Text: "❌ Неактивна"},
+		"subscriptions.empty":   {Text: "Нет активных подписок"},
+		"trip.departure":        {Text: "Отправление: %s"},
+		"trip.arrival":          {Text: "Прибытие: %s"},
+		"trip.duration":         {Text: "В пути: %s"},
+		"trip.price":            {Text: "Цена: %s"},
+		"trip.driver":           {Text: "Водитель: %s"},
+		"trip.driver_rated":     {Text: "Водитель: %s (⭐ %.1f)"},
+		"trip.seats": {Forms: map[PluralForm]string{
+			One:  "%d свободное место",
+			Few:  "%d свободных места",
+			Many: "%d свободных мест",
+		}},
+		"trip.book":                 {Text: "Забронировать на BlaBlaCar: %s"},
+		"trip.change.seats":         {Text: "места %d → %d"},
+		"trip.change.departure":     {Text: "отправление %s → %s"},
+		"trip.unavailable.sold_out": {Text: "⛔ Больше недоступно: мест нет"},
+		"trip.unavailable.removed":  {Text: "⛔ Больше недоступно: поездка отменена"},
+		"digest.title": {Forms: map[PluralForm]string{
+			One:  "🔔 %d новая поездка %s → %s, %s",
+			Few:  "🔔 %d новые поездки %s → %s, %s",
+			Many: "🔔 %d новых поездок %s → %s, %s",
+		}},
+		"digest.more": {Forms: map[PluralForm]string{
+			One:  "…и ещё %d поездка",
+			Few:  "…и ещё %d поездки",
+			Many: "…и ещё %d поездок",
+		}},
+		"digest.pause":       {Text: "⏸ Пауза"},
+		"digest.unsubscribe": {Text: "🗑 Отписаться"},
+		"error.generic":      {Text: "Что-то пошло не так, попробуйте позже"},
+
+		// field errors, see models.FieldError.Key
+		"validation.from_place_id.required":             {Text: "Выберите место отправления"},
+		"validation.to_place_id.required":               {Text: "Выберите место назначения"},
+		"validation.to_place_id.same_place":             {Text: "Место назначения должно отличаться от места отправления"},
+		"validation.departure_date.required":            {Text: "Выберите дату поездки"},
+		"validation.departure_date.format":              {Text: "Введите дату в формате ГГГГ-ММ-ДД"},
+		"validation.requested_seats.range":              {Text: "Выберите от 1 до 8 мест"},
+		"validation.filters.departure_time_from.format": {Text: "Введите самое раннее время отправления в формате ЧЧ:ММ"},
+		"validation.filters.departure_time_to.format":   {Text: "Введите самое позднее время отправления в формате ЧЧ:ММ"},
+		"validation.filters.min_driver_rating.range":    {Text: "Рейтинг водителя должен быть от 0 до 5"},
+		"validation.filters.max_price.range":            {Text: "Максимальная цена должна быть больше нуля"},
+	}, russianPlural)
+
+	Register("fr", Catalog{
+		"subscription.title":    {Text: "Abonnement #%s"},
+		"subscription.date":     {Text: "Date : %s"},
+		"subscription.status":   {Text: "Statut : %s"},
+		"subscription.active":   {Text: "✅ Actif"},
+		"subscription.inactive": {Text: "❌ Inactif"},
+		"subscriptions.empty":   {Text: "Aucun abonnement actif"},
+		"trip.departure":        {Text: "Départ : %s"},
+		"trip.arrival":          {Text: "Arrivée : %s"},
+		"trip.duration":         {Text: "Durée : %s"},
+		"trip.price":            {Text: "Prix : %s"},
+		"trip.driver":           {Text: "Conducteur : %s"},
+		"trip.driver_rated":     {Text: "Conducteur : %s (⭐ %.1f)"},
+		"trip.seats": {Forms: map[PluralForm]string{
+			One:   "%d place disponible",
+			Other: "%d places disponibles",
+		}},
+		"trip.book":                 {Text: "Réserver sur BlaBlaCar : %s"},
+		"trip.change.seats":         {Text: "places %d → %d"},
+		"trip.change.departure":     {Text: "départ %s → %s"},
+		"trip.unavailable.sold_out": {Text: "⛔ Plus disponible : complet"},
+		"trip.unavailable.removed":  {Text: "⛔ Plus disponible : le trajet a été annulé"},
+		"digest.title": {Forms: map[PluralForm]string{
+			One:   "🔔 %d nouveau trajet %s → %s, %s",
+			Other: "🔔 %d nouveaux trajets %s → %s, %s",
+		}},
+		"digest.more": {Forms: map[PluralForm]string{
+			One:   "…et %d autre trajet",
+			Other: "…et %d autres trajets",
+		}},
+		"digest.pause":       {Text: "⏸ Pause"},
+		"digest.unsubscribe": {Text: "🗑 Se désabonner"},
+		"error.generic":      {Text: "Une erreur est survenue, veuillez réessayer plus tard"},
+
+		// field errors, see models.FieldError.Key
+		"validation.from_place_id.required":             {Text: "Choisissez votre lieu de départ"},
+		"validation.to_place_id.required":               {Text: "Choisissez votre destination"},
+		"validation.to_place_id.same_place":             {Text: "La destination doit être différente du lieu de départ"},
+		"validation.departure_date.required":            {Text: "Choisissez une date de départ"},
+		"validation.departure_date.format":              {Text: "Saisissez la date au format AAAA-MM-JJ"},
+		"validation.requested_seats.range":              {Text: "Choisissez entre 1 et 8 places"},
+		"validation.filters.departure_time_from.format": {Text: "Saisissez l'heure de départ au plus tôt au format HH:MM"},
+		"validation.filters.departure_time_to.format":   {Text: "Saisissez l'heure de départ au plus tard au format HH:MM"},
+		"validation.filters.min_driver_rating.range":    {Text: "La note du conducteur doit être comprise entre 0 et 5"},
+		"validation.filters.max_price.range":            {Text: "Le prix maximum doit être positif"},
+	}, frenchPlural)
+}
@@ -0,0 +1,157 @@
+// Package i18n provides translated bot texts with plural forms
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// DefaultLanguage is used when a text is missing in the requested language
+const DefaultLanguage = "en"
+
+// PluralForm is a CLDR plural category
+type PluralForm string
+
+const (
+	One   PluralForm = "one"
+	Few   PluralForm = "few"
+	Many  PluralForm = "many"
+	Other PluralForm = "other"
+)
+
+// Message is a translated text; plural messages set Forms, simple ones only Text.
+// Texts are plain: telegram.BotClient escapes whole messages for MarkdownV2, so
+// markup such as *bold* or [links](url) would show literally.
+type Message struct {
+	Text  string
+	Forms map[PluralForm]string
+}
+
+// Catalog maps message keys to texts for one language
+type Catalog map[string]Message
+
+// PluralRule picks the plural form for a count
+type PluralRule func(n int) PluralForm
+
+var (
+	mu       sync.RWMutex
+	catalogs = map[string]Catalog{}
+	rules    = map[string]PluralRule{}
+)
+
+// Register adds or extends the catalog of a language; rule may be nil for English-like plurals
+func Register(lang string, catalog Catalog, rule PluralRule) {
+	lang = Normalize(lang)
+
+	mu.Lock()
+	defer mu.Unlock()
+	existing, ok := catalogs[lang]
+	if !ok {
+		existing = make(Catalog, len(catalog))
+		catalogs[lang] = existing
+	}
+	for key, msg := range catalog {
+		existing[key] = msg
+	}
+	if rule != nil {
+		rules[lang] = rule
+	}
+}
+
+// Languages returns the registered language codes
+func Languages() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	return langs
+}
+
+// Normalize turns a Telegram language_code such as "ru-RU" into a catalog key
+func Normalize(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		lang = lang[:i]
+	}
+	if lang == "" {
+		return DefaultLanguage
+	}
+	return lang
+}
+
+// T returns the text for key in lang formatted with args, falling back to
+// DefaultLanguage and finally to the key itself
+func T(lang, key string, args ...interface{}) string {
+	msg, _ := lookup(lang, key)
+	return format(msg.Text, key, args)
+}
+
+// N returns the plural form of key matching n; n is available to the text as the first argument
+func N(lang, key string, n int, args ...interface{}) string {
+	msg, rule := lookup(lang, key)
+	text, ok := msg.Forms[rule(n)]
+	if !ok {
+		text = msg.Forms[Other]
+	}
+	if text == "" {
+		text = msg.Text
+	}
+	return format(text, key, append([]interface{}{n}, args...))
+}
+
+func lookup(lang, key string) (Message, PluralRule) {
+	lang = Normalize(lang)
+
+	mu.RLock()
+	defer mu.RUnlock()
+	rule, ok := rules[lang]
+	if !ok {
+		rule = englishPlural
+	}
+	if msg, ok := catalogs[lang][key]; ok {
+		return msg, rule
+	}
+	return catalogs[DefaultLanguage][key], englishPlural
+}
+
+func format(text, key string, args []interface{}) string {
+	if text == "" {
+		return key
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+func englishPlural(n int) PluralForm {
+	if n == 1 {
+		return One
+	}
+	return Other
+}
+
+func frenchPlural(n int) PluralForm {
+	if n == 0 || n == 1 {
+		return One
+	}
+	return Other
+}
+
+func russianPlural(n int) PluralForm {
+	if n < 0 {
+		n = -n
+	}
+	mod10, mod100 := n%10, n%100
+	switch {
+	case mod10 == 1 && mod100 != 11:
+		return One
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return Few
+	default:
+		return Many
+	}
+}
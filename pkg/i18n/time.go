@@ -0,0 +1,71 @@
+package i18n
+
+import (
+	"fmt"
+	"time"
+)
+
+type dateNames struct {
+	weekdays [7]string
+	months   [12]string
+	layout   string // weekday, day, month, clock
+}
+
+var dates = map[string]dateNames{
+	"en": {
+		weekdays: [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+		months:   [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		layout:   "%s, %d %s · %s",
+	},
+	"ru": {
+		weekdays: [7]string{"Вс", "Пн", "Вт", "Ср", "Чт", "Пт", "Сб"},
+		months:   [12]string{"янв", "фев", "мар", "апр", "мая", "июн", "июл", "авг", "сен", "окт", "ноя", "дек"},
+		layout:   "%s, %d %s · %s",
+	},
+	"fr": {
+		weekdays: [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+		months:   [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		layout:   "%s %d %s · %s",
+	},
+}
+
+// tripTimeLayouts are the timestamp formats seen in trip data, most specific first
+var tripTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+}
+
+// ParseTripTime parses an ISO timestamp from trip data. The bool reports whether
+// the timestamp carried a UTC offset; without one it is the local time at the trip's origin.
+func ParseTripTime(s string) (time.Time, bool, error) {
+	for i, layout := range tripTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, i == 0, nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("unrecognized trip time %q", s)
+}
+
+// FormatDateTime renders t as e.g. "Fri, 14 Jun · 18:30" in lang
+func FormatDateTime(lang string, t time.Time) string {
+	names, ok := dates[Normalize(lang)]
+	if !ok {
+		names = dates[DefaultLanguage]
+	}
+	return fmt.Sprintf(names.layout, names.weekdays[t.Weekday()], t.Day(), names.months[t.Month()-1], t.Format("15:04"))
+}
+
+// FormatTripTime parses a trip timestamp and renders it in loc and lang.
+// Timestamps without an offset are shown as is; unparseable input is returned unchanged.
+func FormatTripTime(lang, raw string, loc *time.Location) string {
+	t, hasOffset, err := ParseTripTime(raw)
+	if err != nil {
+		return raw
+	}
+	if hasOffset && loc != nil {
+		t = t.In(loc)
+	}
+	return FormatDateTime(lang, t)
+}
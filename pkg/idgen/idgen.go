@@ -0,0 +1,165 @@
+// Package idgen generates the IDs of stored entities. The default ULIDs sort by creation
+// time, which keeps YDB primary keys from scattering; UUIDs issued before stay valid.
+package idgen
+
+import (
+	"crypto/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arseniisemenow/bbc-common/pkg/clock"
+)
+
+// Generator returns new unique IDs
+type Generator interface {
+	NewID() string
+}
+
+// GeneratorFunc adapts a function to Generator
+type GeneratorFunc func() string
+
+// NewID calls f
+func (f GeneratorFunc) NewID() string {
+	return f()
+}
+
+// UUID generates random UUIDv4s, the format of IDs issued before ULIDs
+var UUID Generator = GeneratorFunc(uuid.NewString)
+
+var (
+	defaultMu sync.RWMutex
+	def       Generator = NewULID(nil)
+)
+
+// SetDefault replaces the generator used by New, e.g. with UUID or a fixed sequence in tests
+func SetDefault(g Generator) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	def = g
+}
+
+// New returns an ID from the default generator, a ULID unless SetDefault was called
+func New() string {
+	defaultMu.RLock()
+	g := def
+	defaultMu.RUnlock()
+	return g.NewID()
+}
+
+// Valid reports whether id is a ULID or a UUID
+func Valid(id string) bool {
+	if IsULID(id) {
+		return true
+	}
+	_, err := uuid.Parse(id)
+	return err == nil
+}
+
+// crockford is the ULID alphabet, ordered so that encoded IDs sort like their bytes
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+const ulidLen = 26
+
+// ULID generates ULIDs: a millisecond timestamp followed by 80 random bits, 26
+// characters of Crockford base32. IDs from one generator in the same millisecond
+// increment the random part, so they also sort in creation order.
+type ULID struct {
+	clock clock.Clock
+
+	mu      sync.Mutex
+	lastMs  uint64
+	entropy [10]byte
+}
+
+// NewULID creates a ULID generator reading the time from c, the system clock if nil
+func NewULID(c clock.Clock) *ULID {
+	return &ULID{clock: clock.Or(c)}
+}
+
+// NewID implements Generator
+func (g *ULID) NewID() string {
+	ms := uint64(g.clock.Now().UnixMilli())
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if ms <= g.lastMs && incrementEntropy(&g.entropy) {
+		ms = g.lastMs
+	} else {
+		_, _ = rand.Read(g.entropy[:])
+	}
+	g.lastMs = ms
+
+	var raw [16]byte
+	for i := 0; i < 6; i++ {
+		raw[i] = byte(ms >> (40 - 8*i))
+	}
+	copy(raw[6:], g.entropy[:])
+	return encodeULID(raw)
+}
+
+// incrementEntropy adds one to the 80-bit big-endian e, reporting false on overflow
+func incrementEntropy(e *[10]byte) bool {
+	for i := len(e) - 1; i >= 0; i-- {
+		e[i]++
+		if e[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeULID encodes 128 bits as 26 base32 characters, the first carrying 3 bits
+func encodeULID(raw [16]byte) string {
+	var out [ulidLen]byte
+	// walk the bits from the least significant end, five at a time
+	var acc uint32
+	var bits uint
+	pos := ulidLen - 1
+	for i := len(raw) - 1; i >= 0; i-- {
+		acc |= uint32(raw[i]) << bits
+		bits += 8
+		for bits >= 5 {
+			out[pos] = crockford[acc&31]
+			pos--
+			acc >>= 5
+			bits -= 5
+		}
+	}
+	out[0] = crockford[acc&31]
+	return string(out[:])
+}
+
+// IsULID reports whether id is a well-formed ULID
+func IsULID(id string) bool {
+	if len(id) != ulidLen || strings.IndexByte("01234567", id[0]) < 0 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if strings.IndexByte(crockford, upper(id[i])) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Time returns the creation time encoded in a ULID; ok is false for UUIDs and
+// malformed IDs
+func Time(id string) (t time.Time, ok bool) {
+	if !IsULID(id) {
+		return time.Time{}, false
+	}
+	var ms uint64
+	for i := 0; i < 10; i++ {
+		ms = ms<<5 | uint64(strings.IndexByte(crockford, upper(id[i])))
+	}
+	return time.UnixMilli(int64(ms)), true
+}
+
+func upper(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
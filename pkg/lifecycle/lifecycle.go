@@ -0,0 +1,176 @@
+// Package lifecycle runs the subsystems of a long-running deployment, such as the poller,
+// queue consumers and the outbox drainer, and shuts them down in order
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/logging"
+	"github.com/arseniisemenow/bbc-common/pkg/recovery"
+)
+
+// DefaultTimeout bounds the shutdown of a component without its own timeout
+const DefaultTimeout = 10 * time.Second
+
+var ErrShutdownTimeout = errors.New("shutdown timed out")
+
+// Component is a subsystem managed by a Runner
+type Component struct {
+	Name string
+	// Run works until its context is cancelled; nil for components that only need
+	// closing, such as a connection
+	Run func(ctx context.Context) error
+	// Stop releases resources after Run has returned; optional
+	Stop func(ctx context.Context) error
+	// Timeout bounds waiting for Run to return and Stop together, DefaultTimeout if 0
+	Timeout time.Duration
+}
+
+// Runner starts components together and, on a signal, cancellation of its context or
+// the failure of a component, shuts them down one by one in reverse order of Add.
+// Register dependencies first: the YDB connection before the outbox, the outbox before
+// the consumers and poller feeding it.
+type Runner struct {
+	components []Component
+
+	// Signals trigger the shutdown, SIGTERM and SIGINT by default
+	Signals []os.Signal
+}
+
+// NewRunner creates a runner shutting down on SIGTERM and SIGINT
+func NewRunner() *Runner {
+	return &Runner{Signals: []os.Signal{syscall.SIGTERM, os.Interrupt}}
+}
+
+// Add registers a component
+func (r *Runner) Add(c Component) *Runner {
+	r.components = append(r.components, c)
+	return r
+}
+
+// Go registers a component running fn
+func (r *Runner) Go(name string, fn func(ctx context.Context) error) *Runner {
+	return r.Add(Component{Name: name, Run: fn})
+}
+
+// OnStop registers a component that only needs stop called at shutdown
+func (r *Runner) OnStop(name string, stop func(ctx context.Context) error) *Runner {
+	return r.Add(Component{Name: name, Stop: stop})
+}
+
+// running is a started component
+type running struct {
+	Component
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+// Run starts all components and blocks until they are shut down. It returns the error
+// of the component that failed first, if any, joined with shutdown errors; a shutdown
+// requested by a signal or ctx alone returns nil.
+func (r *Runner) Run(ctx context.Context) error {
+	ctx, stopSignals := signal.NotifyContext(ctx, r.Signals...)
+	defer stopSignals()
+
+	var (
+		failOnce sync.Once
+		failErr  error
+		failed   = make(chan struct{})
+	)
+	started := make([]*running, len(r.components))
+	for i, c := range r.components {
+		// components get their own contexts, so each is cancelled only on its turn
+		runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		rc := &running{Component: c, cancel: cancel, done: make(chan struct{})}
+		started[i] = rc
+		if c.Run == nil {
+			close(rc.done)
+			continue
+		}
+		go func() {
+			defer close(rc.done)
+			rc.err = recovery.Do(runCtx, func() error { return rc.Run(runCtx) })
+			if rc.err != nil && runCtx.Err() == nil {
+				failOnce.Do(func() {
+					failErr = fmt.Errorf("%s: %w", rc.Name, rc.err)
+					close(failed)
+				})
+			}
+		}()
+		logger(ctx).Info("Started component", "name", c.Name)
+	}
+
+	select {
+	case <-ctx.Done():
+		logger(ctx).Info("Shutting down", "cause", context.Cause(ctx))
+	case <-failed:
+		logger(ctx).Error("Component failed, shutting down", "error", failErr)
+	}
+
+	errs := []error{failErr}
+	for i := len(started) - 1; i >= 0; i-- {
+		errs = append(errs, started[i].shutdown(context.WithoutCancel(ctx)))
+	}
+	return errors.Join(errs...)
+}
+
+// shutdown cancels the component, waits for Run to return and calls Stop, all within
+// the component's timeout
+func (rc *running) shutdown(ctx context.Context) error {
+	timeout := rc.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	rc.cancel()
+	select {
+	case <-rc.done:
+	case <-ctx.Done():
+		logger(ctx).Error("Component did not stop in time", "name", rc.Name, "timeout", timeout)
+		return fmt.Errorf("%s: %w after %s", rc.Name, ErrShutdownTimeout, timeout)
+	}
+	if rc.Stop != nil {
+		if err := rc.Stop(ctx); err != nil {
+			logger(ctx).Error("Failed to stop component", "name", rc.Name, "error", err)
+			return fmt.Errorf("%s: %w", rc.Name, err)
+		}
+	}
+	logger(ctx).Info("Stopped component", "name", rc.Name, "duration", time.Since(start))
+	return nil
+}
+
+// Every returns a Run function calling fn immediately and then every interval until
+// cancelled, e.g. for poller.RunOnce; failures and panics are logged and do not stop the loop
+func Every(interval time.Duration, fn func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := recovery.Do(ctx, func() error { return fn(ctx) }); err != nil && ctx.Err() == nil {
+				logger(ctx).Error("Periodic run failed", "error", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+// logger returns the package's logger for ctx
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Component(ctx, "lifecycle")
+}
@@ -0,0 +1,117 @@
+// Package logging configures structured logging on log/slog: JSON lines in the format
+// Yandex Cloud Logging parses from function and container output, with the request and
+// chat ID of the context attached
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/arseniisemenow/bbc-common/pkg/requestid"
+)
+
+// LevelEnv names the environment variable holding the minimum level, e.g. "debug"
+const LevelEnv = "LOG_LEVEL"
+
+// Keys of the fields taken from the context
+const (
+	RequestIDKey = "request_id"
+	ChatIDKey    = "chat_id"
+	ComponentKey = "component"
+)
+
+// Cloud Logging reads the message from "message" and the level from "level"
+const messageKey = "message"
+
+type loggerKey struct{}
+
+type chatIDKey struct{}
+
+// ParseLevel parses debug, info, warn (or warning) and error, case-insensitively; an
+// empty string is info
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("unknown log level %q", s)
+}
+
+// LevelFromEnv returns the level set in LOG_LEVEL, info if unset or invalid
+func LevelFromEnv() slog.Level {
+	level, _ := ParseLevel(os.Getenv(LevelEnv))
+	return level
+}
+
+// NewHandler returns a handler writing JSON lines at level or above to w. Secrets are
+// redacted from every message and attribute, see Redact.
+func NewHandler(w io.Writer, level slog.Leveler) slog.Handler {
+	return slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.MessageKey {
+				a.Key = messageKey
+			}
+			return redactAttr(a)
+		},
+	})
+}
+
+// Init makes a JSON logger writing to stdout at the level of LOG_LEVEL the default of
+// slog and of the log package, and returns it; call it once at startup
+func Init() *slog.Logger {
+	logger := slog.New(NewHandler(os.Stdout, LevelFromEnv()))
+	slog.SetDefault(logger)
+	return logger
+}
+
+// WithLogger returns ctx carrying logger, used by FromContext instead of slog.Default
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// WithChatID returns ctx whose log records carry chatID
+func WithChatID(ctx context.Context, chatID int64) context.Context {
+	return context.WithValue(ctx, chatIDKey{}, chatID)
+}
+
+// ChatID returns the chat ID set with WithChatID
+func ChatID(ctx context.Context) (int64, bool) {
+	chatID, ok := ctx.Value(chatIDKey{}).(int64)
+	return chatID, ok
+}
+
+// FromContext returns the logger of ctx, slog.Default if none, with the request ID and
+// chat ID carried by ctx attached
+func FromContext(ctx context.Context) *slog.Logger {
+	logger, ok := ctx.Value(loggerKey{}).(*slog.Logger)
+	if !ok {
+		logger = slog.Default()
+	}
+	var attrs []any
+	if id := requestid.From(ctx); id != "" {
+		attrs = append(attrs, slog.String(RequestIDKey, id))
+	}
+	if chatID, ok := ChatID(ctx); ok {
+		attrs = append(attrs, slog.Int64(ChatIDKey, chatID))
+	}
+	if len(attrs) == 0 {
+		return logger
+	}
+	return logger.With(attrs...)
+}
+
+// Component returns FromContext(ctx) tagged with the name of the logging package
+func Component(ctx context.Context, name string) *slog.Logger {
+	return FromContext(ctx).With(slog.String(ComponentKey, name))
+}
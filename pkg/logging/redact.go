@@ -0,0 +1,148 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// minSecretLen is the length below which registered values are ignored, so short strings
+// do not get replaced everywhere
+const minSecretLen = 8
+
+var (
+	secretsMu sync.RWMutex
+	secrets   = make(map[string]struct{})
+)
+
+// sensitiveKeys are attribute keys whose values are always logged as fingerprints
+var sensitiveKeys = map[string]bool{
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"app_token":     true,
+	"id_token":      true,
+	"datadome":      true,
+	"cookie":        true,
+	"authorization": true,
+	"password":      true,
+	"secret":        true,
+	"api_key":       true,
+}
+
+// credentialPatterns match credentials by shape; the last group is the secret itself
+var credentialPatterns = []*regexp.Regexp{
+	// key=value and "key": "value" pairs of sensitive names, in URLs, JSON and cookies
+	regexp.MustCompile(`(?i)((?:access_token|refresh_token|app_token|id_token|token|datadome|password|client_secret|api_key)["']?\s*[:=]\s*["']?)([^"'&;,\s}]+)`),
+	regexp.MustCompile(`(?i)(bearer\s+)([A-Za-z0-9._~+/=-]{8,})`),
+	// JWTs
+	regexp.MustCompile(`()(eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*)`),
+	// Telegram bot tokens, e.g. in API URLs
+	regexp.MustCompile(`()(\d{6,}:[A-Za-z0-9_-]{30,})`),
+}
+
+// Fingerprint identifies a secret without revealing it: a short hash, equal for equal
+// secrets, so one token can be followed across log lines; see models.Fingerprint
+func Fingerprint(secret string) string {
+	return models.Fingerprint(secret)
+}
+
+// Secret is a string that prints and logs as its fingerprint
+type Secret string
+
+// String returns the fingerprint
+func (s Secret) String() string {
+	return Fingerprint(string(s))
+}
+
+// GoString returns the fingerprint, also for %#v
+func (s Secret) GoString() string {
+	return s.String()
+}
+
+// LogValue implements slog.LogValuer
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+// Register makes Redact replace the given secrets wherever they appear; meant for a few
+// process-wide secrets such as the bot token, per-user tokens are matched by shape
+func Register(values ...string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, v := range values {
+		if len(v) >= minSecretLen {
+			secrets[v] = struct{}{}
+		}
+	}
+}
+
+// Redact replaces registered secrets and values shaped like credentials in s with
+// their fingerprints
+func Redact(s string) string {
+	secretsMu.RLock()
+	for secret := range secrets {
+		if strings.Contains(s, secret) {
+			s = strings.ReplaceAll(s, secret, Fingerprint(secret))
+		}
+	}
+	secretsMu.RUnlock()
+
+	for _, pattern := range credentialPatterns {
+		s = pattern.ReplaceAllStringFunc(s, func(match string) string {
+			groups := pattern.FindStringSubmatch(match)
+			value := groups[len(groups)-1]
+			if strings.HasPrefix(value, "fp:") {
+				return match
+			}
+			return groups[1] + Fingerprint(value)
+		})
+	}
+	return s
+}
+
+// redactedError keeps the chain of the original error for errors.Is and errors.As
+type redactedError struct {
+	err error
+	msg string
+}
+
+func (e *redactedError) Error() string { return e.msg }
+
+func (e *redactedError) Unwrap() error { return e.err }
+
+// RedactError returns err with its message passed through Redact; errors.Is and
+// errors.As still see the original chain
+func RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	redacted := Redact(msg)
+	if redacted == msg {
+		return err
+	}
+	return &redactedError{err: err, msg: redacted}
+}
+
+// redactAttr fingerprints sensitive attributes and redacts strings and errors
+func redactAttr(a slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(a.Key)] && a.Value.Kind() != slog.KindGroup {
+		if value := a.Value.String(); !strings.HasPrefix(value, "fp:") {
+			a.Value = slog.StringValue(Fingerprint(value))
+		}
+		return a
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(Redact(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			a.Value = slog.StringValue(Redact(err.Error()))
+		}
+	}
+	return a
+}
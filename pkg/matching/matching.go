@@ -0,0 +1,148 @@
+// Package matching decides which found trips a subscription should be notified about
+package matching
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/arseniisemenow/bbc-common/pkg/i18n"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// Filters that can exclude a trip, used in Reason.Filter
+const (
+	FilterSeats         = "seats"
+	FilterDate          = "date"
+	FilterPrice         = "price"
+	FilterDepartureTime = "departure_time"
+	FilterTransport     = "transport"
+	FilterDriverRating  = "driver_rating"
+)
+
+// Reason explains why a filter excluded a trip
+type Reason struct {
+	Filter string
+	Detail string
+}
+
+func (r Reason) String() string {
+	return r.Filter + ": " + r.Detail
+}
+
+// Exclusion is a trip that did not match with every failed filter
+type Exclusion struct {
+	Trip    models.TripInfo
+	Reasons []Reason
+}
+
+// Result splits trips into matches and exclusions, both in input order
+type Result struct {
+	Matches  []models.TripInfo
+	Excluded []Exclusion
+}
+
+// PriceComparer compares prices in different currencies; money.Converter satisfies it
+type PriceComparer interface {
+	Exceeds(ctx context.Context, price, limit models.Money) (bool, error)
+}
+
+// Matcher evaluates subscription filters. The zero value is ready to use; without a
+// Prices comparer, prices in another currency than MaxPrice are not filtered.
+type Matcher struct {
+	Prices PriceComparer
+}
+
+// Match evaluates the filters of sub against trips. Filters that cannot be evaluated,
+// e.g. for an unparseable departure time, let the trip pass.
+func Match(sub models.SearchSubscription, trips []models.TripInfo) Result {
+	return Matcher{}.Match(context.Background(), sub, trips)
+}
+
+// Match evaluates the filters of sub against trips
+func (m Matcher) Match(ctx context.Context, sub models.SearchSubscription, trips []models.TripInfo) Result {
+	var result Result
+	for _, trip := range trips {
+		if reasons := m.Explain(ctx, sub, trip); len(reasons) > 0 {
+			result.Excluded = append(result.Excluded, Exclusion{Trip: trip, Reasons: reasons})
+		} else {
+			result.Matches = append(result.Matches, trip)
+		}
+	}
+	return result
+}
+
+// Explain returns the reasons sub excludes trip, or nil if it matches
+func (m Matcher) Explain(ctx context.Context, sub models.SearchSubscription, trip models.TripInfo) []Reason {
+	var reasons []Reason
+	f := sub.Filters
+
+	if seats := max(sub.RequestedSeats, 1); trip.SeatsAvailable < seats {
+		reasons = append(reasons, Reason{FilterSeats, fmt.Sprintf("%d seats left, %d requested", trip.SeatsAvailable, seats)})
+	}
+
+	date, clock, timeKnown := departure(trip)
+	if timeKnown && !sub.DepartureDate.IsZero() && date != sub.DepartureDate {
+		reasons = append(reasons, Reason{FilterDate, fmt.Sprintf("departs on %s, not %s", date, sub.DepartureDate)})
+	}
+	if timeKnown && f.DepartureTimeFrom != "" && clock < f.DepartureTimeFrom {
+		reasons = append(reasons, Reason{FilterDepartureTime, fmt.Sprintf("departs at %s, before %s", clock, f.DepartureTimeFrom)})
+	}
+	if timeKnown && f.DepartureTimeTo != "" && clock > f.DepartureTimeTo {
+		reasons = append(reasons, Reason{FilterDepartureTime, fmt.Sprintf("departs at %s, after %s", clock, f.DepartureTimeTo)})
+	}
+
+	if f.MaxPrice != nil && !trip.Price.IsZero() {
+		if exceeds, ok := m.exceeds(ctx, trip.Price, *f.MaxPrice); ok && exceeds {
+			reasons = append(reasons, Reason{FilterPrice, fmt.Sprintf("costs %s, more than %s", trip.Price, f.MaxPrice)})
+		}
+	}
+
+	switch {
+	case f.Transport == models.TransportBus && !trip.IsBus:
+		reasons = append(reasons, Reason{FilterTransport, "carpooling, bus requested"})
+	case f.Transport == models.TransportCarpooling && trip.IsBus:
+		reasons = append(reasons, Reason{FilterTransport, "bus, carpooling requested"})
+	}
+
+	if f.MinDriverRating > 0 && !trip.IsBus && trip.DriverRating > 0 && trip.DriverRating < f.MinDriverRating {
+		reasons = append(reasons, Reason{FilterDriverRating, fmt.Sprintf("driver rated %.1f, below %.1f", trip.DriverRating, f.MinDriverRating)})
+	}
+
+	return reasons
+}
+
+// exceeds compares price with limit; ok is false when they cannot be compared
+func (m Matcher) exceeds(ctx context.Context, price, limit models.Money) (exceeds, ok bool) {
+	if price.Currency == limit.Currency {
+		cmp, _ := price.Compare(limit)
+		return cmp > 0, true
+	}
+	if m.Prices == nil {
+		return false, false
+	}
+	exceeds, err := m.Prices.Exceeds(ctx, price, limit)
+	return exceeds, err == nil
+}
+
+// departure returns the local date and clock ("15:04") of a trip's departure
+func departure(trip models.TripInfo) (date models.Date, clock string, ok bool) {
+	t, _, err := i18n.ParseTripTime(trip.DepartureTime)
+	if err != nil {
+		return models.Date{}, "", false
+	}
+	return models.DateOf(t), t.Format("15:04"), true
+}
+
+// Summary renders exclusions for logs, e.g. "trip 123: seats: 1 seats left, 2 requested"
+func (r Result) Summary() string {
+	lines := make([]string, 0, len(r.Excluded))
+	for _, ex := range r.Excluded {
+		reasons := make([]string, 0, len(ex.Reasons))
+		for _, reason := range ex.Reasons {
+			reasons = append(reasons, reason.String())
+		}
+		lines = append(lines, "trip "+ex.Trip.ID+": "+strings.Join(reasons, "; "))
+	}
+	return strings.Join(lines, "\n")
+}
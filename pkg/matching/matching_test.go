@@ -0,0 +1,288 @@
+package matching
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/arseniisemenow/bbc-common/pkg/fixtures"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// typical is the subscription fixtures.MixedTrips is designed against
+func typical() *fixtures.SubscriptionBuilder {
+	return fixtures.Subscription().Seats(2).MaxPrice(30, "EUR").Between("07:00", "20:00").
+		Transport(models.TransportCarpooling).MinRating(4)
+}
+
+// filters returns the filters of reasons in order
+func filters(reasons []Reason) []string {
+	var names []string
+	for _, r := range reasons {
+		names = append(names, r.Filter)
+	}
+	return names
+}
+
+func TestExplainFilters(t *testing.T) {
+	tests := []struct {
+		name string
+		sub  models.SearchSubscription
+		trip models.TripInfo
+		want []string
+	}{
+		{
+			name: "no filters",
+			sub:  fixtures.Subscription().Build(),
+			trip: fixtures.Trip().Build(),
+		},
+		{
+			name: "seats enough",
+			sub:  fixtures.Subscription().Seats(3).Build(),
+			trip: fixtures.Trip().Seats(3).Build(),
+		},
+		{
+			name: "seats too few",
+			sub:  fixtures.Subscription().Seats(2).Build(),
+			trip: fixtures.Trip().Seats(1).Build(),
+			want: []string{FilterSeats},
+		},
+		{
+			name: "seats unset requests one",
+			sub:  fixtures.Subscription().Seats(0).Build(),
+			trip: fixtures.Trip().Seats(0).Build(),
+			want: []string{FilterSeats},
+		},
+		{
+			name: "date other day",
+			sub:  fixtures.Subscription().Build(),
+			trip: fixtures.Trip().OnDate("2025-07-02").Build(),
+			want: []string{FilterDate},
+		},
+		{
+			name: "date unset",
+			sub: func() models.SearchSubscription {
+				sub := fixtures.Subscription().Build()
+				sub.DepartureDate = models.Date{}
+				return sub
+			}(),
+			trip: fixtures.Trip().OnDate("2025-07-02").Build(),
+		},
+		{
+			name: "departure before window",
+			sub:  fixtures.Subscription().Between("07:00", "").Build(),
+			trip: fixtures.Trip().At("06:59").Build(),
+			want: []string{FilterDepartureTime},
+		},
+		{
+			name: "departure after window",
+			sub:  fixtures.Subscription().Between("", "20:00").Build(),
+			trip: fixtures.Trip().At("20:01").Build(),
+			want: []string{FilterDepartureTime},
+		},
+		{
+			name: "departure on window bounds",
+			sub:  fixtures.Subscription().Between("08:00", "08:00").Build(),
+			trip: fixtures.Trip().At("08:00").Build(),
+		},
+		{
+			name: "price below limit",
+			sub:  fixtures.Subscription().MaxPrice(30, "EUR").Build(),
+			trip: fixtures.Trip().Price(30, "EUR").Build(),
+		},
+		{
+			name: "price above limit",
+			sub:  fixtures.Subscription().MaxPrice(30, "EUR").Build(),
+			trip: fixtures.Trip().Price(30.01, "EUR").Build(),
+			want: []string{FilterPrice},
+		},
+		{
+			name: "price unknown",
+			sub:  fixtures.Subscription().MaxPrice(30, "EUR").Build(),
+			trip: func() models.TripInfo {
+				trip := fixtures.Trip().Build()
+				trip.Price = models.Money{}
+				return trip
+			}(),
+		},
+		{
+			name: "transport bus requested",
+			sub:  fixtures.Subscription().Transport(models.TransportBus).Build(),
+			trip: fixtures.Trip().Build(),
+			want: []string{FilterTransport},
+		},
+		{
+			name: "transport carpooling requested",
+			sub:  fixtures.Subscription().Transport(models.TransportCarpooling).Build(),
+			trip: fixtures.Trip().Bus().Build(),
+			want: []string{FilterTransport},
+		},
+		{
+			name: "transport any",
+			sub:  fixtures.Subscription().Build(),
+			trip: fixtures.Trip().Bus().Build(),
+		},
+		{
+			name: "rating below minimum",
+			sub:  fixtures.Subscription().MinRating(4).Build(),
+			trip: fixtures.Trip().Driver("Max", 3.9).Build(),
+			want: []string{FilterDriverRating},
+		},
+		{
+			name: "rating unknown",
+			sub:  fixtures.Subscription().MinRating(4).Build(),
+			trip: fixtures.Trip().Driver("Max", 0).Build(),
+		},
+		{
+			name: "rating ignored for buses",
+			sub:  fixtures.Subscription().MinRating(4).Build(),
+			trip: fixtures.Trip().Bus().Build(),
+		},
+		{
+			name: "unparseable departure skips time filters",
+			sub:  fixtures.Subscription().Between("07:00", "08:00").Build(),
+			trip: func() models.TripInfo {
+				trip := fixtures.Trip().At("23:00").Build()
+				trip.DepartureTime = "tomorrow"
+				return trip
+			}(),
+		},
+		{
+			name: "every failed filter",
+			sub:  typical().Build(),
+			trip: fixtures.Trip().OnDate("2025-07-02").At("23:00").Price(45, "EUR").Seats(1).Driver("Max", 3).Build(),
+			want: []string{FilterSeats, FilterDate, FilterDepartureTime, FilterPrice, FilterDriverRating},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filters(Matcher{}.Explain(context.Background(), tt.sub, tt.trip))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Explain() filters = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExplainDetail(t *testing.T) {
+	sub := fixtures.Subscription().Seats(2).MaxPrice(30, "EUR").Build()
+	trip := fixtures.Trip().Seats(1).Price(45, "EUR").Build()
+
+	got := Matcher{}.Explain(context.Background(), sub, trip)
+	want := []Reason{
+		{FilterSeats, "1 seats left, 2 requested"},
+		{FilterPrice, "costs 45.00 EUR, more than 30.00 EUR"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Explain() = %v, want %v", got, want)
+	}
+}
+
+// fakeComparer compares prices by their amount in minor units, as if the rates were 1
+type fakeComparer struct {
+	err   error
+	calls int
+}
+
+func (c *fakeComparer) Exceeds(_ context.Context, price, limit models.Money) (bool, error) {
+	c.calls++
+	return price.AmountMinor > limit.AmountMinor, c.err
+}
+
+func TestExplainCurrencyMismatch(t *testing.T) {
+	sub := fixtures.Subscription().MaxPrice(30, "EUR").Build()
+
+	tests := []struct {
+		name     string
+		prices   *fakeComparer
+		amount   float64
+		want     []string
+		wantCall bool
+	}{
+		{name: "no comparer passes", amount: 100},
+		{name: "comparer below limit", prices: &fakeComparer{}, amount: 20, wantCall: true},
+		{name: "comparer above limit", prices: &fakeComparer{}, amount: 40, want: []string{FilterPrice}, wantCall: true},
+		{name: "comparer error passes", prices: &fakeComparer{err: errors.New("no rate")}, amount: 40, wantCall: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Matcher{}
+			if tt.prices != nil {
+				m.Prices = tt.prices
+			}
+			trip := fixtures.Trip().Price(tt.amount, "PLN").Build()
+
+			got := filters(m.Explain(context.Background(), sub, trip))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Explain() filters = %v, want %v", got, tt.want)
+			}
+			if called := tt.prices != nil && tt.prices.calls > 0; called != tt.wantCall {
+				t.Errorf("comparer called = %v, want %v", called, tt.wantCall)
+			}
+		})
+	}
+}
+
+func TestExplainSameCurrencySkipsComparer(t *testing.T) {
+	prices := &fakeComparer{}
+	sub := fixtures.Subscription().MaxPrice(30, "EUR").Build()
+
+	Matcher{Prices: prices}.Explain(context.Background(), sub, fixtures.Trip().Price(40, "EUR").Build())
+	if prices.calls != 0 {
+		t.Errorf("comparer called %d times for the same currency", prices.calls)
+	}
+}
+
+func TestMatchMixedTrips(t *testing.T) {
+	sub := typical().Build()
+	trips := fixtures.MixedTrips(fixtures.Date)
+
+	result := Match(sub, trips)
+
+	var matched []string
+	for _, trip := range result.Matches {
+		matched = append(matched, trip.ID)
+	}
+	if want := []string{fixtures.TripMatching, fixtures.TripOtherCurrency}; !slices.Equal(matched, want) {
+		t.Errorf("matches = %v, want %v", matched, want)
+	}
+
+	wantReasons := map[string]string{
+		fixtures.TripExpensive: FilterPrice,
+		fixtures.TripBus:       FilterTransport,
+		fixtures.TripNight:     FilterDepartureTime,
+		fixtures.TripLowRated:  FilterDriverRating,
+		fixtures.TripOneSeat:   FilterSeats,
+		fixtures.TripNextDay:   FilterDate,
+	}
+	if len(result.Excluded) != len(wantReasons) {
+		t.Fatalf("excluded %d trips, want %d:\n%s", len(result.Excluded), len(wantReasons), result.Summary())
+	}
+	for _, ex := range result.Excluded {
+		if got := filters(ex.Reasons); !slices.Equal(got, []string{wantReasons[ex.Trip.ID]}) {
+			t.Errorf("trip %s excluded by %v, want %s", ex.Trip.ID, got, wantReasons[ex.Trip.ID])
+		}
+	}
+}
+
+func TestMatchEmpty(t *testing.T) {
+	result := Match(typical().Build(), nil)
+	if len(result.Matches) != 0 || len(result.Excluded) != 0 {
+		t.Errorf("Match(nil) = %+v, want empty", result)
+	}
+	if s := result.Summary(); s != "" {
+		t.Errorf("Summary() = %q, want empty", s)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	result := Result{Excluded: []Exclusion{
+		{Trip: models.TripInfo{ID: "1"}, Reasons: []Reason{{FilterSeats, "a"}, {FilterPrice, "b"}}},
+		{Trip: models.TripInfo{ID: "2"}, Reasons: []Reason{{FilterDate, "c"}}},
+	}}
+	want := "trip 1: seats: a; price: b\ntrip 2: date: c"
+	if got := result.Summary(); got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,199 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrInvalidDate = errors.New("invalid date")
+
+// dateLayout is the format of dates in JSON, YDB and the BlaBlaCar API
+const dateLayout = "2006-01-02"
+
+// legacyDateLayouts are accepted when decoding stored dates, which were free-form strings
+// before Date; timestamps keep their date part
+var legacyDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-1-2",
+	"02.01.2006",
+}
+
+// Date is a calendar day without a time zone, "2006-01-02" in JSON and YDB. The zero
+// value is unset and encodes as "".
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// ParseDate parses a "2006-01-02" date, rejecting days that do not exist
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse(dateLayout, strings.TrimSpace(s))
+	if err != nil {
+		return Date{}, fmt.Errorf("%w: %q, want YYYY-MM-DD", ErrInvalidDate, s)
+	}
+	return DateOf(t), nil
+}
+
+// parseLegacyDate parses s like ParseDate, falling back to legacyDateLayouts; an empty
+// s is the zero Date
+func parseLegacyDate(s string) (Date, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Date{}, nil
+	}
+	if d, err := ParseDate(s); err == nil {
+		return d, nil
+	}
+	for _, layout := range legacyDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return DateOf(t), nil
+		}
+	}
+	return Date{}, fmt.Errorf("%w: %q", ErrInvalidDate, s)
+}
+
+// DateOf returns the day of t in t's location
+func DateOf(t time.Time) Date {
+	y, m, d := t.Date()
+	return Date{Year: y, Month: m, Day: d}
+}
+
+// Today returns the current day in loc, UTC if nil
+func Today(now time.Time, loc *time.Location) Date {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return DateOf(now.In(loc))
+}
+
+// IsZero reports whether d is unset
+func (d Date) IsZero() bool {
+	return d == Date{}
+}
+
+// Valid reports whether d is an existing day
+func (d Date) Valid() bool {
+	return !d.IsZero() && DateOf(d.In(time.UTC)) == d
+}
+
+// String formats d as "2006-01-02", or "" if unset
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// In returns the start of d in loc, UTC if nil
+func (d Date) In(loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, loc)
+}
+
+// AddDays returns d moved by n days
+func (d Date) AddDays(n int) Date {
+	return DateOf(d.In(time.UTC).AddDate(0, 0, n))
+}
+
+// Compare returns -1, 0 or +1 as d is before, equal to or after other
+func (d Date) Compare(other Date) int {
+	switch {
+	case d.Year != other.Year:
+		return cmpInt(d.Year, other.Year)
+	case d.Month != other.Month:
+		return cmpInt(int(d.Month), int(other.Month))
+	default:
+		return cmpInt(d.Day, other.Day)
+	}
+}
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Before reports whether d is before other
+func (d Date) Before(other Date) bool {
+	return d.Compare(other) < 0
+}
+
+// After reports whether d is after other
+func (d Date) After(other Date) bool {
+	return d.Compare(other) > 0
+}
+
+// IsPast reports whether d has ended in loc at now, UTC if nil. The comparison is done
+// on days in loc, so a departure today is not past even late in the evening, and a
+// server running in UTC agrees with users east or west of it.
+func (d Date) IsPast(now time.Time, loc *time.Location) bool {
+	return d.Before(Today(now, loc))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, also accepting legacy formats
+func (d *Date) UnmarshalText(text []byte) error {
+	parsed, err := parseLegacyDate(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// MarshalJSON encodes d as a "2006-01-02" string
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON decodes a date string, also in the legacy formats; null and "" leave d unset
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*d = Date{}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidDate, data)
+	}
+	return d.UnmarshalText([]byte(s))
+}
+
+// Scan implements sql.Scanner, so YDB rows scan into a Date from Utf8 or Date columns
+func (d *Date) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Date{}
+		return nil
+	case string:
+		return d.UnmarshalText([]byte(v))
+	case []byte:
+		return d.UnmarshalText(v)
+	case time.Time:
+		*d = DateOf(v.UTC())
+		return nil
+	}
+	return fmt.Errorf("%w: cannot scan %T", ErrInvalidDate, src)
+}
+
+// Value implements driver.Valuer, storing d as a "2006-01-02" string
+func (d Date) Value() (driver.Value, error) {
+	return d.String(), nil
+}
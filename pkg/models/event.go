@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// EventType names a usage event
+type EventType string
+
+// Usage events recorded for analytics
+const (
+	EventCommandInvoked      EventType = "command_invoked"
+	EventSubscriptionCreated EventType = "subscription_created"
+	EventSubscriptionDeleted EventType = "subscription_deleted"
+	EventNotificationSent    EventType = "notification_sent"
+	EventNotificationClicked EventType = "notification_clicked"
+)
+
+// Event is something a user did or the bot did for a user, kept for later analysis
+type Event struct {
+	ID     string    `json:"id"`
+	Type   EventType `json:"type"`
+	ChatID int64     `json:"chat_id"`
+	// Properties describe the event, e.g. the command or subscription ID; values must
+	// encode as JSON
+	Properties map[string]any `json:"properties,omitempty"`
+	At         time.Time      `json:"at"`
+}
+
+// Properties of notification events
+const (
+	PropNotificationID = "notification_id"
+	PropTripID         = "trip_id"
+	// PropAction is the button clicked, e.g. "open" or "book"
+	PropAction = "action"
+)
+
+// ClickThroughStats measures how many sent notifications users acted on
+type ClickThroughStats struct {
+	Since time.Time `json:"since"`
+	Sent  int       `json:"sent"`
+	// Clicked counts notifications clicked at least once, Clicks every click
+	Clicked int `json:"clicked"`
+	Clicks  int `json:"clicks"`
+	// ByAction counts the clicks per PropAction
+	ByAction map[string]int `json:"by_action"`
+}
+
+// Rate returns the share of sent notifications that were clicked, 0 if none were sent
+func (s ClickThroughStats) Rate() float64 {
+	if s.Sent == 0 {
+		return 0
+	}
+	return float64(s.Clicked) / float64(s.Sent)
+}
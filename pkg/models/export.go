@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// UserDataExportVersion is the format version of UserDataExport, raised on incompatible
+// changes
+const UserDataExportVersion = 1
+
+// UserDataExport is everything stored about one user, answering a GDPR access request.
+// Credentials appear only as fingerprints, see Fingerprint.
+type UserDataExport struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	User       User      `json:"user"`
+	// Account is the linked BlaBlaCar account, nil if the user never logged in
+	Account         *AccountExport       `json:"blablacar_account,omitempty"`
+	Preferences     Preferences          `json:"preferences"`
+	Subscriptions   []SearchSubscription `json:"subscriptions"`
+	Notifications   []Notification       `json:"notifications"`
+	BookingAttempts []BookingAttempt     `json:"booking_attempts"`
+	Payments        []Payment            `json:"payments"`
+	// Events are the recorded usage events, oldest first
+	Events []Event `json:"events"`
+}
+
+// Preferences are the settings the bot keeps for a user
+type Preferences struct {
+	Locale   string `json:"locale,omitempty"`
+	Currency string `json:"currency,omitempty"`
+}
+
+// AccountExport is the exportable part of UserTokens: the tokens are replaced by
+// fingerprints, which identify them without granting access
+type AccountExport struct {
+	UserID       string    `json:"user_id"`
+	AccessToken  string    `json:"access_token_fingerprint"`
+	RefreshToken string    `json:"refresh_token_fingerprint"`
+	Datadome     string    `json:"datadome_fingerprint,omitempty"`
+	AppToken     string    `json:"app_token_fingerprint,omitempty"`
+	UserAgent    string    `json:"user_agent,omitempty"`
+	VisitorID    string    `json:"visitor_id,omitempty"`
+	LinkedAt     time.Time `json:"linked_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Export returns the tokens with credentials fingerprinted
+func (t UserTokens) Export() AccountExport {
+	return AccountExport{
+		UserID:       t.UserID,
+		AccessToken:  Fingerprint(t.AccessToken),
+		RefreshToken: Fingerprint(t.RefreshToken),
+		Datadome:     Fingerprint(t.Datadome),
+		AppToken:     Fingerprint(t.AppToken),
+		UserAgent:    t.UserAgent,
+		VisitorID:    t.VisitorID,
+		LinkedAt:     t.CreatedAt,
+		UpdatedAt:    t.UpdatedAt,
+	}
+}
+
+// Preferences returns the locale and currency the user's searches run with
+func (t UserTokens) Preferences() Preferences {
+	return Preferences{Locale: t.Locale, Currency: t.Currency}
+}
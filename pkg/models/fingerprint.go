@@ -0,0 +1,17 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Fingerprint identifies a secret without revealing it: a short hash, equal for equal
+// secrets, so one token can be followed across log lines. It lives here rather than in
+// logging so models depends on the standard library only; logging.Fingerprint calls it.
+func Fingerprint(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(secret))
+	return "fp:" + hex.EncodeToString(sum[:4])
+}
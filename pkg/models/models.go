@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
 
 // UserStatus represents the status of a user
 type UserStatus string
@@ -30,21 +34,80 @@ type UserTokens struct {
 	AppToken       string    `json:"app_token,omitempty"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
+
+	// HeaderProfile is the device identity the user's requests are sent with
+	HeaderProfile
+}
+
+// LogValue logs the tokens as fingerprints, see Fingerprint
+func (t UserTokens) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int64("telegram_chat_id", t.TelegramChatID),
+		slog.String("user_id", t.UserID),
+		slog.String("access_token", Fingerprint(t.AccessToken)),
+		slog.String("refresh_token", Fingerprint(t.RefreshToken)),
+		slog.String("datadome", Fingerprint(t.Datadome)),
+		slog.String("app_token", Fingerprint(t.AppToken)),
+	)
+}
+
+// String formats the tokens as fingerprints, so printing them with %v leaks nothing
+func (t UserTokens) String() string {
+	return fmt.Sprintf("UserTokens{chat=%d user=%s access=%s refresh=%s datadome=%s app=%s}",
+		t.TelegramChatID, t.UserID, Fingerprint(t.AccessToken), Fingerprint(t.RefreshToken),
+		Fingerprint(t.Datadome), Fingerprint(t.AppToken))
+}
+
+// HeaderProfile is the set of identifying headers sent to BlaBlaCar; keeping it stable
+// per user makes requests look like one consistent device
+type HeaderProfile struct {
+	UserAgent     string `json:"user_agent,omitempty"`
+	ClientVersion string `json:"client_version,omitempty"`
+	VisitorID     string `json:"visitor_id,omitempty"`
+	Locale        string `json:"locale,omitempty"`
+	Currency      string `json:"currency,omitempty"`
 }
 
 // SearchSubscription represents a user's trip search subscription
 type SearchSubscription struct {
-	ID             string     `json:"id"`
-	TelegramChatID int64      `json:"telegram_chat_id"`
-	FromPlaceID    string     `json:"from_place_id"`
-	FromPlaceName  string     `json:"from_place_name"`
-	ToPlaceID      string     `json:"to_place_id"`
-	ToPlaceName    string     `json:"to_place_name"`
-	DepartureDate  string     `json:"departure_date"`
-	RequestedSeats int        `json:"requested_seats"`
-	IsActive       bool       `json:"is_active"`
-	CreatedAt      time.Time  `json:"created_at"`
-	LastCheckedAt  *time.Time `json:"last_checked_at,omitempty"`
+	ID             string      `json:"id"`
+	TelegramChatID int64       `json:"telegram_chat_id"`
+	FromPlaceID    string      `json:"from_place_id"`
+	FromPlaceName  string      `json:"from_place_name"`
+	ToPlaceID      string      `json:"to_place_id"`
+	ToPlaceName    string      `json:"to_place_name"`
+	DepartureDate  Date        `json:"departure_date"`
+	RequestedSeats int         `json:"requested_seats"`
+	IsActive       bool        `json:"is_active"`
+	CreatedAt      time.Time   `json:"created_at"`
+	LastCheckedAt  *time.Time  `json:"last_checked_at,omitempty"`
+	Filters        TripFilters `json:"filters"`
+	// NextCheckAt is when the poller searches next; nil means as soon as possible
+	NextCheckAt *time.Time `json:"next_check_at,omitempty"`
+	// EmptyChecks counts consecutive checks without matching trips
+	EmptyChecks int `json:"empty_checks"`
+}
+
+// Transport restrictions of TripFilters
+const (
+	TransportAny        = ""
+	TransportCarpooling = "carpooling"
+	TransportBus        = "bus"
+)
+
+// TripFilters narrow down the trips a subscription notifies about; zero values disable a filter
+type TripFilters struct {
+	MaxPrice *Money `json:"max_price,omitempty"`
+	// DepartureTimeFrom and DepartureTimeTo bound the local departure time, "HH:MM" inclusive
+	DepartureTimeFrom string  `json:"departure_time_from,omitempty"`
+	DepartureTimeTo   string  `json:"departure_time_to,omitempty"`
+	Transport         string  `json:"transport,omitempty"`
+	MinDriverRating   float64 `json:"min_driver_rating,omitempty"`
+}
+
+// IsZero reports whether no filter is set
+func (f TripFilters) IsZero() bool {
+	return f.MaxPrice == nil && f.DepartureTimeFrom == "" && f.DepartureTimeTo == "" && f.Transport == "" && f.MinDriverRating == 0
 }
 
 // TripInfo represents a found trip for notifications
@@ -55,7 +118,7 @@ type TripInfo struct {
 	DepartureTime  string  `json:"departure_time"`
 	ArrivalTime    string  `json:"arrival_time"`
 	Duration       string  `json:"duration"`
-	Price          string  `json:"price"`
+	Price          Money   `json:"price"`
 	DriverName     string  `json:"driver_name,omitempty"`
 	DriverRating   float64 `json:"driver_rating,omitempty"`
 	SeatsAvailable int     `json:"seats_available"`
@@ -70,6 +133,90 @@ type Notification struct {
 	SubscriptionID   string     `json:"subscription_id"`
 	TripID           string     `json:"trip_id"`
 	TelegramMessageID int       `json:"telegram_message_id"`
-	Status           string     `json:"status"`
+	Status           NotificationStatus `json:"status"`
 	CreatedAt        time.Time  `json:"created_at"`
+	// Snapshot is the trip as last shown in the message, nil for notifications sent before snapshots
+	Snapshot *TripSnapshot `json:"snapshot,omitempty"`
+}
+
+// NotificationStatus is the delivery state of a notification
+type NotificationStatus string
+
+// Notification statuses; a pending notification is being sent by a dispatcher, a queued
+// one waits in a digest and an unavailable one was edited to say its trip sold out or
+// was removed
+const (
+	NotificationStatusPending     NotificationStatus = "pending"
+	NotificationStatusQueued      NotificationStatus = "queued"
+	NotificationStatusSent        NotificationStatus = "sent"
+	NotificationStatusFailed      NotificationStatus = "failed"
+	NotificationStatusUnavailable NotificationStatus = "unavailable"
+)
+
+// DigestEntry is a matched trip held back by quiet hours or digest mode until DeliverAt
+type DigestEntry struct {
+	ID             string             `json:"id"`
+	TelegramChatID int64              `json:"telegram_chat_id"`
+	NotificationID string             `json:"notification_id"`
+	Subscription   SearchSubscription `json:"subscription"`
+	Trip           TripInfo           `json:"trip"`
+	DeliverAt      time.Time          `json:"deliver_at"`
+	CreatedAt      time.Time          `json:"created_at"`
+}
+
+// OutboxStatus represents the delivery state of a queued message
+type OutboxStatus string
+
+const (
+	OutboxStatusPending OutboxStatus = "pending"
+	OutboxStatusSent    OutboxStatus = "sent"
+	// OutboxStatusFailed marks messages given up on before dead letters existed
+	OutboxStatusFailed OutboxStatus = "failed"
+	// OutboxStatusDeadLetter marks messages given up on, kept until re-driven
+	OutboxStatusDeadLetter OutboxStatus = "dead_letter"
+)
+
+// OutboxMessage is a message queued for durable delivery to Telegram
+type OutboxMessage struct {
+	ID             string       `json:"id"`
+	TelegramChatID int64        `json:"telegram_chat_id"`
+	Payload        string       `json:"payload"`
+	Status         OutboxStatus `json:"status"`
+	Attempts       int          `json:"attempts"`
+	NextAttemptAt  time.Time    `json:"next_attempt_at"`
+	LastError      string       `json:"last_error,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+// Payment records a successful Telegram payment, e.g. for the premium tier
+type Payment struct {
+	ID               string    `json:"id"`
+	TelegramChatID   int64     `json:"telegram_chat_id"`
+	Payload          string    `json:"payload"`
+	Currency         string    `json:"currency"`
+	TotalAmount      int       `json:"total_amount"`
+	ProviderChargeID string    `json:"provider_charge_id"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// TripSnapshot captures the mutable parts of a trip when a notification is sent,
+// so later updates can show what changed
+type TripSnapshot struct {
+	TripID         string    `json:"trip_id"`
+	Price          Money     `json:"price"`
+	SeatsAvailable int       `json:"seats_available"`
+	DepartureTime  string    `json:"departure_time"`
+	CapturedAt     time.Time `json:"captured_at"`
+}
+
+// BookingAttempt records an automatic seat booking attempt and its outcome
+type BookingAttempt struct {
+	ID             string    `json:"id"`
+	TelegramChatID int64     `json:"telegram_chat_id"`
+	TripID         string    `json:"trip_id"`
+	Seats          int       `json:"seats"`
+	Outcome        string    `json:"outcome"`
+	BookingID      string    `json:"booking_id,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
 }
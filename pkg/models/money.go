@@ -0,0 +1,160 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidAmount    = errors.New("invalid money amount")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+// currencyExponents lists currencies without the usual two decimal places
+var currencyExponents = map[string]int{
+	"CLP": 0,
+	"ISK": 0,
+	"JPY": 0,
+	"KRW": 0,
+	"VND": 0,
+}
+
+// Money is an amount in minor units of a currency, e.g. {1250, "EUR"} for 12.50 EUR
+type Money struct {
+	AmountMinor int64  `json:"amount_minor"`
+	Currency    string `json:"currency"`
+}
+
+// UnmarshalJSON accepts the object form and the legacy display string, e.g. "12.50 EUR",
+// that trips and snapshots cached before Money carried in their price
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if len(data) == 0 || data[0] != '"' {
+		type plain Money
+		return json.Unmarshal(data, (*plain)(m))
+	}
+
+	var legacy string
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return err
+	}
+	if legacy = strings.TrimSpace(legacy); legacy == "" {
+		*m = Money{}
+		return nil
+	}
+	amount, currency, _ := strings.Cut(legacy, " ")
+	parsed, err := ParseMoney(amount, strings.TrimSpace(currency))
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// CurrencyExponent returns the number of decimal places of currency's minor unit
+func CurrencyExponent(currency string) int {
+	if exp, ok := currencyExponents[strings.ToUpper(currency)]; ok {
+		return exp
+	}
+	return 2
+}
+
+// ParseMoney parses a decimal amount such as "12.50" or "12,5" without rounding errors;
+// extra decimal places beyond the currency's minor unit are rounded half up
+func ParseMoney(amount, currency string) (Money, error) {
+	amount = strings.ReplaceAll(strings.TrimSpace(amount), ",", ".")
+	negative := strings.HasPrefix(amount, "-")
+	amount = strings.TrimPrefix(amount, "-")
+
+	whole, frac, _ := strings.Cut(amount, ".")
+	if whole == "" && frac == "" || !isDigits(whole) || !isDigits(frac) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	exp := CurrencyExponent(currency)
+	roundUp := len(frac) > exp && frac[exp] >= '5'
+	if len(frac) > exp {
+		frac = frac[:exp]
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+
+	minor, err := strconv.ParseUint(whole+frac, 10, 63)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+	}
+	m := Money{AmountMinor: int64(minor), Currency: strings.ToUpper(currency)}
+	if roundUp {
+		m.AmountMinor++
+	}
+	if negative {
+		m.AmountMinor = -m.AmountMinor
+	}
+	return m, nil
+}
+
+// isDigits reports whether s consists of ASCII digits only; the empty string does
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// NewMoney converts an amount in major units, rounding to the nearest minor unit
+func NewMoney(amount float64, currency string) Money {
+	scale := math.Pow10(CurrencyExponent(currency))
+	return Money{AmountMinor: int64(math.Round(amount * scale)), Currency: strings.ToUpper(currency)}
+}
+
+// IsZero reports whether m is unset
+func (m Money) IsZero() bool {
+	return m.AmountMinor == 0 && m.Currency == ""
+}
+
+// Float returns the amount in major units, for display math and thresholds only
+func (m Money) Float() float64 {
+	return float64(m.AmountMinor) / math.Pow10(CurrencyExponent(m.Currency))
+}
+
+// Amount formats the amount without currency, e.g. "12.50"
+func (m Money) Amount() string {
+	return strconv.FormatFloat(m.Float(), 'f', CurrencyExponent(m.Currency), 64)
+}
+
+// String formats m for display, e.g. "12.50 EUR"; an unset value formats as ""
+func (m Money) String() string {
+	if m.IsZero() {
+		return ""
+	}
+	return m.Amount() + " " + m.Currency
+}
+
+// Compare returns -1, 0 or 1 as m is less than, equal to or greater than other
+func (m Money) Compare(other Money) (int, error) {
+	if m.Currency != other.Currency {
+		return 0, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	switch {
+	case m.AmountMinor < other.AmountMinor:
+		return -1, nil
+	case m.AmountMinor > other.AmountMinor:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Sub returns m - other
+func (m Money) Sub(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return Money{AmountMinor: m.AmountMinor - other.AmountMinor, Currency: m.Currency}, nil
+}
@@ -0,0 +1,12 @@
+package models
+
+// Stats summarizes the users and subscriptions of the bot for the admin dashboard
+type Stats struct {
+	Users               int `json:"users"`
+	ActiveUsers         int `json:"active_users"`
+	Subscriptions       int `json:"subscriptions"`
+	ActiveSubscriptions int `json:"active_subscriptions"`
+	// DeadLetters counts outbox messages given up on, see OutboxStatusDeadLetter
+	DeadLetters  int               `json:"dead_letters"`
+	ClickThrough ClickThroughStats `json:"click_through"`
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UserStatuses lists the valid user statuses
+var UserStatuses = []UserStatus{UserStatusActive, UserStatusInactive, UserStatusUnauthenticated}
+
+// NotificationStatuses lists the valid notification statuses
+var NotificationStatuses = []NotificationStatus{
+	NotificationStatusPending,
+	NotificationStatusQueued,
+	NotificationStatusSent,
+	NotificationStatusFailed,
+	NotificationStatusUnavailable,
+}
+
+// Valid reports whether s is one of UserStatuses
+func (s UserStatus) Valid() bool {
+	for _, known := range UserStatuses {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseUserStatus parses a status case-insensitively; an unknown one is a *FieldError
+// matching ErrInvalid
+func ParseUserStatus(s string) (UserStatus, error) {
+	status := UserStatus(strings.ToLower(strings.TrimSpace(s)))
+	if !status.Valid() {
+		return "", unknownStatus(s)
+	}
+	return status, nil
+}
+
+// Scan implements sql.Scanner for YDB rows. Stored values are taken as is, so rows
+// written before validation still load; check them with Valid.
+func (s *UserStatus) Scan(src interface{}) error {
+	raw, err := scanString(src)
+	*s = UserStatus(raw)
+	return err
+}
+
+// Valid reports whether s is one of NotificationStatuses
+func (s NotificationStatus) Valid() bool {
+	for _, known := range NotificationStatuses {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseNotificationStatus parses a status case-insensitively; an unknown one is a
+// *FieldError matching ErrInvalid
+func ParseNotificationStatus(s string) (NotificationStatus, error) {
+	status := NotificationStatus(strings.ToLower(strings.TrimSpace(s)))
+	if !status.Valid() {
+		return "", unknownStatus(s)
+	}
+	return status, nil
+}
+
+// Scan implements sql.Scanner for YDB rows, taking stored values as is like UserStatus.Scan
+func (s *NotificationStatus) Scan(src interface{}) error {
+	raw, err := scanString(src)
+	*s = NotificationStatus(raw)
+	return err
+}
+
+// Scan implements sql.Scanner for YDB rows, taking stored values as is like UserStatus.Scan
+func (s *OutboxStatus) Scan(src interface{}) error {
+	raw, err := scanString(src)
+	*s = OutboxStatus(raw)
+	return err
+}
+
+func unknownStatus(s string) *FieldError {
+	return &FieldError{Field: "status", Code: CodeInvalid, Message: fmt.Sprintf("unknown status %q", s)}
+}
+
+// scanString converts a scanned Utf8 value; NULL is ""
+func scanString(src interface{}) (string, error) {
+	switch v := src.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	}
+	return "", fmt.Errorf("cannot scan %T into a status", src)
+}
@@ -0,0 +1,190 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrInvalid matches every validation error with errors.Is
+var ErrInvalid = errors.New("invalid value")
+
+// Validation error codes, stable identifiers of the failed check
+const (
+	CodeRequired  = "required"
+	CodeFormat    = "format"
+	CodeRange     = "range"
+	CodeInvalid   = "invalid"
+	CodeSamePlace = "same_place"
+)
+
+// MaxRequestedSeats is the largest number of seats a subscription may ask for
+const MaxRequestedSeats = 8
+
+// maxPlaceIDLen bounds place IDs, which are opaque BlaBlaCar identifiers
+const maxPlaceIDLen = 256
+
+// FieldError is a failed check of one field
+type FieldError struct {
+	// Field is the JSON name of the field, nested fields joined with dots
+	Field string
+	Code  string
+	// Message explains the failure in English, for logs
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// Is reports whether target is ErrInvalid
+func (e *FieldError) Is(target error) bool {
+	return target == ErrInvalid
+}
+
+// Key returns the i18n key of the user-facing message, "validation.<field>.<code>"
+func (e *FieldError) Key() string {
+	return "validation." + e.Field + "." + e.Code
+}
+
+// ValidationError lists the failed checks of one value; errors.As extracts it, or a
+// single *FieldError
+type ValidationError struct {
+	Entity string
+	Fields []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Error()
+	}
+	return "invalid " + e.Entity + ": " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the field errors
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Fields))
+	for i, f := range e.Fields {
+		errs[i] = f
+	}
+	return errs
+}
+
+// validator collects the field errors of one value
+type validator struct {
+	entity string
+	fields []*FieldError
+}
+
+func (v *validator) fail(field, code, format string, args ...interface{}) {
+	v.fields = append(v.fields, &FieldError{Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) required(field, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		v.fail(field, CodeRequired, "must be set")
+		return false
+	}
+	return true
+}
+
+func (v *validator) chatID(chatID int64) {
+	if chatID == 0 {
+		v.fail("telegram_chat_id", CodeRequired, "must be set")
+	}
+}
+
+func (v *validator) placeID(field, id string) {
+	if !v.required(field, id) {
+		return
+	}
+	if len(id) > maxPlaceIDLen || strings.IndexFunc(id, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) >= 0 {
+		v.fail(field, CodeFormat, "malformed place ID %q", id)
+	}
+}
+
+func (v *validator) clock(field, value string) {
+	if value == "" {
+		return
+	}
+	if _, err := time.Parse("15:04", value); err != nil {
+		v.fail(field, CodeFormat, "invalid time %q, want HH:MM", value)
+	}
+}
+
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Entity: v.entity, Fields: v.fields}
+}
+
+// Validate checks the chat ID and status
+func (u User) Validate() error {
+	v := validator{entity: "user"}
+	v.chatID(u.TelegramChatID)
+	if !u.Status.Valid() {
+		v.fail("status", CodeInvalid, "unknown status %q", u.Status)
+	}
+	return v.err()
+}
+
+// Validate checks the chat ID and that there is an access token
+func (t UserTokens) Validate() error {
+	v := validator{entity: "user tokens"}
+	v.chatID(t.TelegramChatID)
+	v.required("access_token", t.AccessToken)
+	return v.err()
+}
+
+// Validate checks the chat ID, the places, that the departure date is an existing day,
+// the number of seats (0 meaning one) and the filters
+func (s SearchSubscription) Validate() error {
+	v := validator{entity: "subscription"}
+	v.chatID(s.TelegramChatID)
+	v.placeID("from_place_id", s.FromPlaceID)
+	v.placeID("to_place_id", s.ToPlaceID)
+	if s.FromPlaceID != "" && s.FromPlaceID == s.ToPlaceID {
+		v.fail("to_place_id", CodeSamePlace, "destination equals the departure place")
+	}
+	switch {
+	case s.DepartureDate.IsZero():
+		v.fail("departure_date", CodeRequired, "must be set")
+	case !s.DepartureDate.Valid():
+		v.fail("departure_date", CodeFormat, "invalid date %d-%d-%d", s.DepartureDate.Year, s.DepartureDate.Month, s.DepartureDate.Day)
+	}
+	if s.RequestedSeats < 0 || s.RequestedSeats > MaxRequestedSeats {
+		v.fail("requested_seats", CodeRange, "%d seats, want 1 to %d", s.RequestedSeats, MaxRequestedSeats)
+	}
+
+	f := s.Filters
+	v.clock("filters.departure_time_from", f.DepartureTimeFrom)
+	v.clock("filters.departure_time_to", f.DepartureTimeTo)
+	switch f.Transport {
+	case TransportAny, TransportCarpooling, TransportBus:
+	default:
+		v.fail("filters.transport", CodeInvalid, "unknown transport %q", f.Transport)
+	}
+	if f.MinDriverRating < 0 || f.MinDriverRating > 5 {
+		v.fail("filters.min_driver_rating", CodeRange, "rating %.1f, want 0 to 5", f.MinDriverRating)
+	}
+	if f.MaxPrice != nil && f.MaxPrice.AmountMinor <= 0 {
+		v.fail("filters.max_price", CodeRange, "price must be positive")
+	}
+	return v.err()
+}
+
+// Validate checks the chat ID, the subscription and trip IDs and the status
+func (n Notification) Validate() error {
+	v := validator{entity: "notification"}
+	v.chatID(n.TelegramChatID)
+	v.required("subscription_id", n.SubscriptionID)
+	v.required("trip_id", n.TripID)
+	if !n.Status.Valid() {
+		v.fail("status", CodeInvalid, "unknown status %q", n.Status)
+	}
+	return v.err()
+}
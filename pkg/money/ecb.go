@@ -0,0 +1,71 @@
+package money
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	// ECBDailyURL publishes the euro reference rates once per working day
+	ECBDailyURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+	// DefaultECBCacheTTL refreshes ECB rates a few times per day
+	DefaultECBCacheTTL = 6 * time.Hour
+)
+
+// ECBSource fetches the European Central Bank's daily euro reference rates
+type ECBSource struct {
+	HTTPClient *http.Client
+	URL        string
+}
+
+// NewECBSource creates an ECB source wrapped in a cache, as the rates change once a day
+func NewECBSource() *CachedSource {
+	return NewCachedSource(&ECBSource{HTTPClient: &http.Client{Timeout: 10 * time.Second}, URL: ECBDailyURL}, DefaultECBCacheTTL)
+}
+
+type ecbEnvelope struct {
+	Cube struct {
+		Cube struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string  `xml:"currency,attr"`
+				Rate     float64 `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+// Rates fetches the latest rates with EUR as base
+func (s *ECBSource) Rates(ctx context.Context) (Rates, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return Rates{}, fmt.Errorf("failed to create ECB request: %w", err)
+	}
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return Rates{}, fmt.Errorf("failed to fetch ECB rates: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Rates{}, fmt.Errorf("failed to fetch ECB rates: unexpected status %d", resp.StatusCode)
+	}
+
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return Rates{}, fmt.Errorf("failed to decode ECB rates: %w", err)
+	}
+	daily := envelope.Cube.Cube
+	if len(daily.Rates) == 0 {
+		return Rates{}, fmt.Errorf("failed to decode ECB rates: no rates published")
+	}
+
+	rates := Rates{Base: "EUR", Rates: make(map[string]float64, len(daily.Rates))}
+	for _, r := range daily.Rates {
+		rates.Rates[r.Currency] = r.Rate
+	}
+	rates.AsOf, _ = time.Parse("2006-01-02", daily.Time)
+	return rates, nil
+}
@@ -0,0 +1,135 @@
+// Package money converts prices between currencies for comparisons such as price alerts
+package money
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+var ErrUnknownCurrency = errors.New("no exchange rate for currency")
+
+// Rates are exchange rates relative to Base: one unit of Base buys Rates[c] units of c
+type Rates struct {
+	Base  string
+	Rates map[string]float64
+	AsOf  time.Time
+}
+
+// rate returns the units of currency per unit of Base
+func (r Rates) rate(currency string) (float64, bool) {
+	if currency == r.Base {
+		return 1, true
+	}
+	rate, ok := r.Rates[currency]
+	return rate, ok && rate > 0
+}
+
+// RateSource provides current exchange rates
+type RateSource interface {
+	Rates(ctx context.Context) (Rates, error)
+}
+
+// StaticRates is a RateSource with fixed rates, e.g. from configuration or tests
+type StaticRates struct {
+	rates Rates
+}
+
+// NewStaticRates creates a source where one unit of base buys rates[c] units of c
+func NewStaticRates(base string, rates map[string]float64) *StaticRates {
+	return &StaticRates{rates: Rates{Base: base, Rates: rates, AsOf: time.Now()}}
+}
+
+// Rates returns the fixed rates
+func (s *StaticRates) Rates(ctx context.Context) (Rates, error) {
+	return s.rates, nil
+}
+
+// CachedSource keeps the rates of a slower source for a TTL; when a refresh fails,
+// the previous rates are served until they are twice the TTL old
+type CachedSource struct {
+	source RateSource
+	ttl    time.Duration
+
+	mu        sync.Mutex
+	rates     Rates
+	fetchedAt time.Time
+}
+
+// NewCachedSource caches rates from source for ttl
+func NewCachedSource(source RateSource, ttl time.Duration) *CachedSource {
+	return &CachedSource{source: source, ttl: ttl}
+}
+
+// Rates returns cached rates, refreshing them when they are older than the TTL
+func (c *CachedSource) Rates(ctx context.Context) (Rates, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	age := time.Since(c.fetchedAt)
+	if !c.fetchedAt.IsZero() && age < c.ttl {
+		return c.rates, nil
+	}
+	rates, err := c.source.Rates(ctx)
+	if err != nil {
+		if !c.fetchedAt.IsZero() && age < 2*c.ttl {
+			return c.rates, nil
+		}
+		return Rates{}, err
+	}
+	c.rates, c.fetchedAt = rates, time.Now()
+	return rates, nil
+}
+
+// Converter converts money between currencies using a RateSource
+type Converter struct {
+	source RateSource
+}
+
+// NewConverter creates a converter using source
+func NewConverter(source RateSource) *Converter {
+	return &Converter{source: source}
+}
+
+// Convert returns m in currency, rounded to the nearest minor unit
+func (c *Converter) Convert(ctx context.Context, m models.Money, currency string) (models.Money, error) {
+	currency = strings.ToUpper(currency)
+	if m.Currency == currency {
+		return m, nil
+	}
+
+	rates, err := c.source.Rates(ctx)
+	if err != nil {
+		return models.Money{}, fmt.Errorf("failed to load exchange rates: %w", err)
+	}
+	from, ok := rates.rate(m.Currency)
+	if !ok {
+		return models.Money{}, fmt.Errorf("%w: %s", ErrUnknownCurrency, m.Currency)
+	}
+	to, ok := rates.rate(currency)
+	if !ok {
+		return models.Money{}, fmt.Errorf("%w: %s", ErrUnknownCurrency, currency)
+	}
+	return models.NewMoney(m.Float()/from*to, currency), nil
+}
+
+// Compare compares a and b after converting b to a's currency, returning -1, 0 or 1
+// as a is less than, equal to or greater than b
+func (c *Converter) Compare(ctx context.Context, a, b models.Money) (int, error) {
+	converted, err := c.Convert(ctx, b, a.Currency)
+	if err != nil {
+		return 0, err
+	}
+	return a.Compare(converted)
+}
+
+// Exceeds reports whether price is above limit, e.g. a trip in PLN against a MaxPrice in EUR
+func (c *Converter) Exceeds(ctx context.Context, price, limit models.Money) (bool, error) {
+	cmp, err := c.Compare(ctx, price, limit)
+	return cmp > 0, err
+}
@@ -0,0 +1,195 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+	"github.com/arseniisemenow/bbc-common/pkg/requestid"
+	"github.com/arseniisemenow/bbc-common/pkg/telegram"
+)
+
+// digestBatchSize is the number of entries DeliverDigests loads per round
+const digestBatchSize = 200
+
+// DigestStore holds notifications deferred by a Policy; ydb.DigestStore satisfies it
+type DigestStore interface {
+	Add(ctx context.Context, entry *models.DigestEntry) error
+	// Claim returns up to limit entries to deliver at now and leases them until
+	// leaseUntil, so overlapping deliveries skip them; entries still stored then come due
+	// again. Entries of notifications that were already delivered are dropped.
+	Claim(ctx context.Context, now time.Time, limit int, leaseUntil time.Time) ([]models.DigestEntry, error)
+	Remove(ctx context.Context, ids []string) error
+}
+
+// DigestFormatFunc renders the combined message about trips found for sub
+type DigestFormatFunc func(sub models.SearchSubscription, trips []models.TripInfo) (text string, keyboard interface{})
+
+// WithPolicy defers notifications as the chat's policy says, buffering them in digests.
+// A chat whose policy cannot be loaded is notified right away.
+func WithPolicy(policy PolicyFunc, digests DigestStore) Option {
+	return func(d *Dispatcher) {
+		d.policy = policy
+		d.digests = digests
+	}
+}
+
+// WithDigestFormat replaces the default telegram.FormatTripDigest rendering
+func WithDigestFormat(format DigestFormatFunc) Option {
+	return func(d *Dispatcher) { d.formatDigest = format }
+}
+
+// deferral returns when the claimed notification for sub should be delivered, if not now
+func (d *Dispatcher) deferral(ctx context.Context, sub models.SearchSubscription, now time.Time) (time.Time, bool) {
+	if d.policy == nil || d.digests == nil {
+		return time.Time{}, false
+	}
+	policy, err := d.policy(ctx, sub.TelegramChatID)
+	if err != nil {
+		requestid.Logf(ctx, "[Notify] Failed to load delivery policy of chat %d, delivering now: %v", sub.TelegramChatID, err)
+		return time.Time{}, false
+	}
+	return policy.DeferUntil(now)
+}
+
+// queue buffers the claimed notif for delivery at at
+func (d *Dispatcher) queue(ctx context.Context, sub models.SearchSubscription, notif models.Notification, trip models.TripInfo, at time.Time) (Outcome, error) {
+	entry := &models.DigestEntry{
+		ID:             uuid.NewString(),
+		TelegramChatID: sub.TelegramChatID,
+		NotificationID: notif.ID,
+		Subscription:   sub,
+		Trip:           trip,
+		DeliverAt:      at,
+		CreatedAt:      d.clock.Now(),
+	}
+	if err := d.digests.Add(ctx, entry); err != nil {
+		notif.Status = models.NotificationStatusFailed
+		if err := d.store.Complete(ctx, &notif); err != nil {
+			requestid.Logf(ctx, "[Notify] Failed to release notification %s: %v", notif.ID, err)
+		}
+		return "", fmt.Errorf("failed to queue notification about trip %s: %w", trip.ID, err)
+	}
+
+	notif.Status = models.NotificationStatusQueued
+	if err := d.store.Complete(ctx, &notif); err != nil {
+		return "", fmt.Errorf("queued notification about trip %s but failed to record it: %w", trip.ID, err)
+	}
+	return OutcomeQueued, nil
+}
+
+// DigestStats summarizes one DeliverDigests call
+type DigestStats struct {
+	Messages int
+	Trips    int
+	Failed   int
+}
+
+// DeliverDigests sends the deferred notifications due at now, one message per
+// subscription; call it from a timer trigger, e.g. every few minutes. Entries are leased
+// for the claim timeout before sending, so overlapping runs do not send a digest twice.
+// Digests to chats that blocked the bot are dropped, other failed digests are retried
+// once their lease expires. The delivered notifications keep no message ID, so later price or seat changes are
+// announced in a new message instead of editing the digest.
+func (d *Dispatcher) DeliverDigests(ctx context.Context, now time.Time) (DigestStats, error) {
+	var stats DigestStats
+	if d.digests == nil {
+		return stats, nil
+	}
+	ctx, _ = requestid.Ensure(ctx)
+	for {
+		entries, err := d.digests.Claim(ctx, now, digestBatchSize, now.Add(d.claimTimeout))
+		if err != nil {
+			return stats, fmt.Errorf("failed to claim due digest entries: %w", err)
+		}
+
+		for _, group := range groupDigest(entries) {
+			if err := ctx.Err(); err != nil {
+				return stats, err
+			}
+			if err := d.deliverDigest(ctx, group); err != nil {
+				requestid.Logf(ctx, "[Notify] Digest for subscription %s failed: %v", group[0].Subscription.ID, err)
+				stats.Failed++
+				continue
+			}
+			stats.Messages++
+			stats.Trips += len(group)
+		}
+
+		// failed entries stay leased, so the next round claims others
+		if len(entries) < digestBatchSize {
+			return stats, nil
+		}
+	}
+}
+
+// deliverDigest sends one claimed digest and removes its entries. Once sent, the digest
+// counts as delivered even if the removal fails: the notifications are recorded as sent,
+// so the next claim drops the entries instead of sending them again.
+func (d *Dispatcher) deliverDigest(ctx context.Context, group []models.DigestEntry) error {
+	sub := group[0].Subscription
+	trips := make([]models.TripInfo, 0, len(group))
+	ids := make([]string, 0, len(group))
+	for _, entry := range group {
+		trips = append(trips, entry.Trip)
+		ids = append(ids, entry.ID)
+	}
+
+	var text string
+	var keyboard interface{}
+	if d.formatDigest != nil {
+		text, keyboard = d.formatDigest(sub, trips)
+	} else {
+		text, keyboard = telegram.FormatTripDigest(trips, sub, d.lang(ctx, sub.TelegramChatID))
+	}
+	_, sendErr := d.sender.SendMessageWithKeyboard(sub.TelegramChatID, text, keyboard, d.options(ctx)...)
+	permanent := errors.Is(sendErr, telegram.ErrBotBlocked) || errors.Is(sendErr, telegram.ErrChatNotFound)
+	if sendErr != nil && !permanent {
+		return sendErr
+	}
+
+	status := models.NotificationStatusSent
+	if permanent {
+		status = models.NotificationStatusFailed
+	}
+	for _, entry := range group {
+		notif := models.Notification{
+			ID:             entry.NotificationID,
+			TelegramChatID: entry.TelegramChatID,
+			SubscriptionID: sub.ID,
+			TripID:         entry.Trip.ID,
+			Status:         status,
+		}
+		if !permanent {
+			snapshot := d.snapshot(ctx, entry.Trip)
+			notif.Snapshot = &snapshot
+		}
+		if err := d.store.Complete(ctx, &notif); err != nil {
+			requestid.Logf(ctx, "[Notify] Failed to record digest notification %s: %v", notif.ID, err)
+		}
+	}
+	if err := d.digests.Remove(ctx, ids); err != nil {
+		requestid.Logf(ctx, "[Notify] Failed to remove delivered digest entries of subscription %s: %v", sub.ID, err)
+	}
+	return sendErr
+}
+
+// groupDigest splits entries per subscription, keeping the order of first appearance
+func groupDigest(entries []models.DigestEntry) [][]models.DigestEntry {
+	index := make(map[string]int)
+	var groups [][]models.DigestEntry
+	for _, entry := range entries {
+		i, ok := index[entry.Subscription.ID]
+		if !ok {
+			i = len(groups)
+			index[entry.Subscription.ID] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], entry)
+	}
+	return groups
+}
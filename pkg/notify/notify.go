@@ -0,0 +1,288 @@
+// Package notify delivers trip notifications exactly once per chat, subscription and trip:
+// it claims the notification, sends the Telegram message and records its message ID
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/clock"
+	"github.com/arseniisemenow/bbc-common/pkg/i18n"
+	"github.com/arseniisemenow/bbc-common/pkg/idgen"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+	"github.com/arseniisemenow/bbc-common/pkg/requestid"
+	"github.com/arseniisemenow/bbc-common/pkg/telegram"
+)
+
+// DefaultClaimTimeout is how long a pending notification blocks other senders; after it
+// the sender is assumed to have crashed and the notification is sent again
+const DefaultClaimTimeout = 5 * time.Minute
+
+// Outcome is what Dispatch did about a trip
+type Outcome string
+
+const (
+	// OutcomeSent means the message was sent and recorded
+	OutcomeSent Outcome = "sent"
+	// OutcomeDuplicate means the user was already notified about the trip
+	OutcomeDuplicate Outcome = "duplicate"
+	// OutcomeInFlight means another sender, e.g. an overlapping function run, holds the claim
+	OutcomeInFlight Outcome = "in_flight"
+	// OutcomeUpdated means the earlier message was edited to show a price or seats change
+	OutcomeUpdated Outcome = "updated"
+	// OutcomeQueued means the delivery policy deferred the notification to a digest
+	OutcomeQueued Outcome = "queued"
+)
+
+// Store persists notifications; ydb.NotificationStore satisfies it
+type Store interface {
+	// Claim reserves notif for this sender unless it was sent or is pending since
+	// staleBefore; otherwise notif is filled with the stored notification
+	Claim(ctx context.Context, notif *models.Notification, staleBefore time.Time) (bool, error)
+	// Complete records the message ID, status and snapshot of notif
+	Complete(ctx context.Context, notif *models.Notification) error
+}
+
+// FormatFunc renders the message about trip for sub; keyboard may be nil
+type FormatFunc func(sub models.SearchSubscription, trip models.TripInfo) (text string, keyboard interface{})
+
+// UpdateFormatFunc renders the edited message about trip after it changed since prev
+type UpdateFormatFunc func(sub models.SearchSubscription, prev models.TripSnapshot, trip models.TripInfo) (text string, keyboard interface{})
+
+// Option configures a Dispatcher
+type Option func(*Dispatcher)
+
+// WithFormat replaces the default telegram.FormatTripMessage rendering
+func WithFormat(format FormatFunc) Option {
+	return func(d *Dispatcher) { d.format = format }
+}
+
+// WithUpdateFormat replaces the default telegram.FormatTripUpdate rendering of edits
+func WithUpdateFormat(format UpdateFormatFunc) Option {
+	return func(d *Dispatcher) { d.formatUpdate = format }
+}
+
+// WithLanguage renders the default messages in the language stored for each chat
+// instead of i18n.DefaultLanguage
+func WithLanguage(language telegram.LanguageFunc) Option {
+	return func(d *Dispatcher) { d.language = language }
+}
+
+// WithChangeThresholds sets which changes re-notify, telegram.DefaultChangeThresholds by default
+func WithChangeThresholds(th telegram.ChangeThresholds) Option {
+	return func(d *Dispatcher) { d.thresholds = th }
+}
+
+// WithClaimTimeout replaces DefaultClaimTimeout
+func WithClaimTimeout(timeout time.Duration) Option {
+	return func(d *Dispatcher) { d.claimTimeout = timeout }
+}
+
+// WithClock replaces the system clock, e.g. with a clocktest.Clock
+func WithClock(c clock.Clock) Option {
+	return func(d *Dispatcher) { d.clock = c }
+}
+
+// WithSendOptions applies opts to every message, e.g. telegram.Silent()
+func WithSendOptions(opts ...telegram.SendOption) Option {
+	return func(d *Dispatcher) { d.sendOpts = append(d.sendOpts, opts...) }
+}
+
+// Dispatcher sends trip notifications; it satisfies poller.Notifier
+type Dispatcher struct {
+	store        Store
+	sender       telegram.BotSender
+	format       FormatFunc
+	formatUpdate UpdateFormatFunc
+	language     telegram.LanguageFunc
+	thresholds   telegram.ChangeThresholds
+	claimTimeout time.Duration
+	sendOpts     []telegram.SendOption
+	policy       PolicyFunc
+	digests      DigestStore
+	formatDigest DigestFormatFunc
+	clock        clock.Clock
+}
+
+// NewDispatcher creates a dispatcher sending through sender
+func NewDispatcher(store Store, sender telegram.BotSender, opts ...Option) *Dispatcher {
+	d := &Dispatcher{
+		store:        store,
+		sender:       sender,
+		thresholds:   telegram.DefaultChangeThresholds,
+		claimTimeout: DefaultClaimTimeout,
+		clock:        clock.Real,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// options returns the configured send options tagged with the request ID of ctx
+func (d *Dispatcher) options(ctx context.Context) []telegram.SendOption {
+	return append(d.sendOpts[:len(d.sendOpts):len(d.sendOpts)], telegram.WithRequestID(ctx))
+}
+
+// lang returns the language of chatID's messages; a chat whose language cannot be
+// loaded gets i18n.DefaultLanguage
+func (d *Dispatcher) lang(ctx context.Context, chatID int64) string {
+	if d.language == nil {
+		return i18n.DefaultLanguage
+	}
+	lang, err := d.language(ctx, chatID)
+	if err != nil {
+		requestid.Logf(ctx, "[Notify] Failed to load language of chat %d, using %s: %v", chatID, i18n.DefaultLanguage, err)
+		return i18n.DefaultLanguage
+	}
+	return i18n.Normalize(lang)
+}
+
+// Dispatch notifies the subscription's chat about trip unless it already was. A retried
+// call after a failed send sends again, editing a message a previous attempt left behind;
+// a call after a crash between sending and recording re-sends once the claim times out.
+// When the chat was notified and the trip got cheaper or its seats changed since, the
+// earlier message is edited to show the changes instead. Notifications deferred by the
+// chat's Policy are queued for DeliverDigests.
+func (d *Dispatcher) Dispatch(ctx context.Context, sub models.SearchSubscription, trip models.TripInfo) (Outcome, error) {
+	now := d.clock.Now()
+	notif := models.Notification{
+		ID:             idgen.New(),
+		TelegramChatID: sub.TelegramChatID,
+		SubscriptionID: sub.ID,
+		TripID:         trip.ID,
+		CreatedAt:      now,
+	}
+	claimed, err := d.store.Claim(ctx, &notif, now.Add(-d.claimTimeout))
+	if err != nil {
+		return "", err
+	}
+	if !claimed {
+		switch notif.Status {
+		case models.NotificationStatusSent:
+			return d.update(ctx, sub, notif, trip)
+		case models.NotificationStatusQueued:
+			return OutcomeDuplicate, nil
+		}
+		return OutcomeInFlight, nil
+	}
+	if at, deferred := d.deferral(ctx, sub, now); deferred {
+		return d.queue(ctx, sub, notif, trip, at)
+	}
+
+	var text string
+	var keyboard interface{}
+	if d.format != nil {
+		text, keyboard = d.format(sub, trip)
+	} else {
+		text = telegram.FormatTripMessageLang(d.lang(ctx, sub.TelegramChatID), trip)
+	}
+	messageID, sendErr := d.sender.UpsertMessage(sub.TelegramChatID, notif.TelegramMessageID, text, keyboard, d.options(ctx)...)
+	if sendErr != nil {
+		// release the claim so a retry sends right away
+		notif.Status = models.NotificationStatusFailed
+		if err := d.store.Complete(ctx, &notif); err != nil {
+			requestid.Logf(ctx, "[Notify] Failed to release notification %s: %v", notif.ID, err)
+		}
+		return "", fmt.Errorf("failed to send notification about trip %s: %w", trip.ID, sendErr)
+	}
+
+	snapshot := d.snapshot(ctx, trip)
+	notif.TelegramMessageID = messageID
+	notif.Status = models.NotificationStatusSent
+	notif.Snapshot = &snapshot
+	if err := d.store.Complete(ctx, &notif); err != nil {
+		return "", fmt.Errorf("sent notification about trip %s but failed to record it: %w", trip.ID, err)
+	}
+	return OutcomeSent, nil
+}
+
+// snapshot captures trip at the time of d's clock
+func (d *Dispatcher) snapshot(ctx context.Context, trip models.TripInfo) models.TripSnapshot {
+	return telegram.SnapshotTrip(clock.With(ctx, d.clock), trip)
+}
+
+// update edits the sent notif when trip changed materially since its snapshot
+func (d *Dispatcher) update(ctx context.Context, sub models.SearchSubscription, notif models.Notification, trip models.TripInfo) (Outcome, error) {
+	snapshot := d.snapshot(ctx, trip)
+	if notif.Snapshot == nil {
+		// sent before snapshots were kept; start comparing from now on
+		notif.Snapshot = &snapshot
+		if err := d.store.Complete(ctx, &notif); err != nil {
+			return "", fmt.Errorf("failed to record snapshot of trip %s: %w", trip.ID, err)
+		}
+		return OutcomeDuplicate, nil
+	}
+	prev := *notif.Snapshot
+	if !Changed(prev, trip, d.thresholds) {
+		return OutcomeDuplicate, nil
+	}
+
+	var text string
+	var keyboard interface{}
+	if d.formatUpdate != nil {
+		text, keyboard = d.formatUpdate(sub, prev, trip)
+	} else {
+		text = telegram.FormatTripUpdate(d.lang(ctx, sub.TelegramChatID), nil, &prev, trip, d.thresholds)
+	}
+	messageID, err := d.sender.UpsertMessage(sub.TelegramChatID, notif.TelegramMessageID, text, keyboard, d.options(ctx)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to update notification about trip %s: %w", trip.ID, err)
+	}
+
+	notif.TelegramMessageID = messageID
+	notif.Snapshot = &snapshot
+	if err := d.store.Complete(ctx, &notif); err != nil {
+		return "", fmt.Errorf("updated notification about trip %s but failed to record it: %w", trip.ID, err)
+	}
+	return OutcomeUpdated, nil
+}
+
+// Changed reports whether trip differs from prev enough to edit the notification: its
+// price dropped by at least th.MinPriceDelta or its seats changed by at least th.MinSeatsDelta.
+// Price increases alone are shown with the next edit but do not cause one.
+func Changed(prev models.TripSnapshot, trip models.TripInfo, th telegram.ChangeThresholds) bool {
+	if delta, err := trip.Price.Sub(prev.Price); err == nil && !prev.Price.IsZero() && delta.AmountMinor < 0 &&
+		-delta.Float() >= th.MinPriceDelta {
+		return true
+	}
+	seats := trip.SeatsAvailable - prev.SeatsAvailable
+	return seats != 0 && (seats >= th.MinSeatsDelta || -seats >= th.MinSeatsDelta)
+}
+
+// Retract edits the message of a sent notification to say its trip is no longer available
+// for reason, telegram.UnavailableSoldOut or telegram.UnavailableRemoved, removes its
+// buttons and records the notification as unavailable. A message the user deleted is
+// not an error. Should the trip become available again, Dispatch notifies anew.
+func (d *Dispatcher) Retract(ctx context.Context, sub models.SearchSubscription, notif models.Notification, reason string) error {
+	trip := models.TripInfo{ID: notif.TripID, FromPlaceName: sub.FromPlaceName, ToPlaceName: sub.ToPlaceName}
+	if notif.Snapshot != nil {
+		trip.Price = notif.Snapshot.Price
+		trip.DepartureTime = notif.Snapshot.DepartureTime
+	}
+
+	if notif.TelegramMessageID != 0 {
+		text := telegram.FormatTripUnavailable(d.lang(ctx, sub.TelegramChatID), nil, trip, reason)
+		err := d.sender.EditMessage(sub.TelegramChatID, notif.TelegramMessageID, text, d.options(ctx)...)
+		if err == nil {
+			err = d.sender.EditMessageKeyboard(sub.TelegramChatID, notif.TelegramMessageID, nil)
+		}
+		if err != nil && !errors.Is(err, telegram.ErrMessageNotModified) && !errors.Is(err, telegram.ErrMessageNotFound) {
+			return fmt.Errorf("failed to retract notification about trip %s: %w", notif.TripID, err)
+		}
+	}
+
+	notif.Status = models.NotificationStatusUnavailable
+	if err := d.store.Complete(ctx, &notif); err != nil {
+		return fmt.Errorf("retracted notification about trip %s but failed to record it: %w", notif.TripID, err)
+	}
+	return nil
+}
+
+// Notify implements poller.Notifier; duplicates and notifications in flight are not errors
+func (d *Dispatcher) Notify(ctx context.Context, sub models.SearchSubscription, trip models.TripInfo) error {
+	_, err := d.Dispatch(ctx, sub, trip)
+	return err
+}
@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultDigestAt is the local delivery time of digests when Policy.DigestAt is empty
+const DefaultDigestAt = "19:00"
+
+// Policy decides when a user receives trip notifications. The zero value delivers
+// immediately.
+type Policy struct {
+	// QuietFrom and QuietTo bound the local quiet hours as "HH:MM"; the window may wrap
+	// midnight, e.g. "22:00"-"08:00", and is disabled while either is empty
+	QuietFrom string
+	QuietTo   string
+	// Digest collects notifications into one message per subscription at DigestAt
+	Digest   bool
+	DigestAt string
+	// Location of the clock times, UTC if nil
+	Location *time.Location
+}
+
+// PolicyFunc returns the delivery policy of a chat, e.g. from the user's preferences
+type PolicyFunc func(ctx context.Context, chatID int64) (Policy, error)
+
+// Quiet reports whether t falls into the quiet hours
+func (p Policy) Quiet(t time.Time) bool {
+	if p.QuietFrom == "" || p.QuietTo == "" || p.QuietFrom == p.QuietTo {
+		return false
+	}
+	clock := t.In(p.location()).Format("15:04")
+	if p.QuietFrom < p.QuietTo {
+		return clock >= p.QuietFrom && clock < p.QuietTo
+	}
+	return clock >= p.QuietFrom || clock < p.QuietTo
+}
+
+// DeferUntil returns when a notification due at now should be delivered, and false when
+// it should be delivered right away. Digests falling into quiet hours move to their end.
+func (p Policy) DeferUntil(now time.Time) (time.Time, bool) {
+	var at time.Time
+	switch {
+	case p.Digest:
+		digestAt := p.DigestAt
+		if digestAt == "" {
+			digestAt = DefaultDigestAt
+		}
+		at = p.next(digestAt, now)
+	case p.Quiet(now):
+		at = now
+	default:
+		return time.Time{}, false
+	}
+	if p.Quiet(at) {
+		at = p.next(p.QuietTo, at)
+	}
+	return at, true
+}
+
+// Validate checks the clock times and the quiet hours having both ends or none
+func (p Policy) Validate() error {
+	for _, clock := range []string{p.QuietFrom, p.QuietTo, p.DigestAt} {
+		if clock == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04", clock); err != nil {
+			return fmt.Errorf("invalid clock time %q, want HH:MM", clock)
+		}
+	}
+	if (p.QuietFrom == "") != (p.QuietTo == "") {
+		return fmt.Errorf("quiet hours need both a start and an end")
+	}
+	return nil
+}
+
+// next returns the first time after t at which the local clock shows clock
+func (p Policy) next(clock string, t time.Time) time.Time {
+	c, err := time.Parse("15:04", clock)
+	if err != nil {
+		return t
+	}
+	local := t.In(p.location())
+	at := time.Date(local.Year(), local.Month(), local.Day(), c.Hour(), c.Minute(), 0, 0, local.Location())
+	if !at.After(t) {
+		at = at.AddDate(0, 0, 1)
+	}
+	return at
+}
+
+func (p Policy) location() *time.Location {
+	if p.Location == nil {
+		return time.UTC
+	}
+	return p.Location
+}
@@ -0,0 +1,59 @@
+package poller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+	"github.com/arseniisemenow/bbc-common/pkg/queue"
+	"github.com/arseniisemenow/bbc-common/pkg/requestid"
+)
+
+// CheckMessageType is the queue message type of a subscription check
+const CheckMessageType = "poller.check"
+
+// Enqueue publishes a check of every due subscription instead of running them, so a
+// fleet of consumer functions running CheckHandler can process them in parallel
+func Enqueue(ctx context.Context, store Store, producer *queue.Producer) (int, error) {
+	ctx, _ = requestid.Ensure(ctx)
+	subs, err := store.DueSubscriptions(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load due subscriptions: %w", err)
+	}
+
+	envs := make([]queue.Envelope, 0, len(subs))
+	for _, sub := range subs {
+		env, err := queue.NewEnvelope(CheckMessageType, sub)
+		if err != nil {
+			return 0, err
+		}
+		envs = append(envs, env)
+	}
+	if err := producer.PublishEnvelopes(ctx, envs); err != nil {
+		return 0, err
+	}
+	requestid.Logf(ctx, "[Poller] Enqueued %d subscription checks", len(envs))
+	return len(envs), nil
+}
+
+// CheckHandler returns a queue handler checking the subscription carried by a
+// CheckMessageType message; register it with Consumer.Handle(CheckMessageType, ...)
+func CheckHandler(deps Deps) queue.HandlerFunc {
+	timeout := deps.TaskTimeout
+	if timeout <= 0 {
+		timeout = DefaultTaskTimeout
+	}
+	return func(ctx context.Context, msg queue.Message) error {
+		var sub models.SearchSubscription
+		if err := msg.Decode(&sub); err != nil {
+			return err
+		}
+		stats, err := checkWithTimeout(ctx, deps, sub, timeout)
+		if err != nil {
+			return fmt.Errorf("subscription %s of chat %d failed: %w", sub.ID, sub.TelegramChatID, err)
+		}
+		requestid.Logf(ctx, "[Poller] Subscription %s checked: found=%d matched=%d notified=%d",
+			sub.ID, stats.Found, stats.Matched, stats.Notified)
+		return nil
+	}
+}
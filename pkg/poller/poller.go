@@ -0,0 +1,219 @@
+// Package poller runs one polling cycle: it searches trips for due subscriptions,
+// matches them against the subscription filters and notifies about new ones
+package poller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/blablacar"
+	"github.com/arseniisemenow/bbc-common/pkg/clock"
+	"github.com/arseniisemenow/bbc-common/pkg/matching"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+	"github.com/arseniisemenow/bbc-common/pkg/notify"
+	"github.com/arseniisemenow/bbc-common/pkg/requestid"
+)
+
+// Store is the persistence used by the poller; ydb.PollerStore satisfies it
+type Store interface {
+	// DueSubscriptions returns the subscriptions to check in this cycle
+	DueSubscriptions(ctx context.Context) ([]models.SearchSubscription, error)
+	// MarkChecked records that sub was searched at checkedAt and when to search next
+	MarkChecked(ctx context.Context, sub models.SearchSubscription, checkedAt, nextCheckAt time.Time, emptyChecks int) error
+	// Notified reports whether the user was already notified about tripID for subID;
+	// it is not consulted when the Notifier is a Dispatcher or the Store a BatchStore
+	Notified(ctx context.Context, chatID int64, subID, tripID string) (bool, error)
+}
+
+// BatchStore is a Store looking up earlier notifications about many trips at once;
+// ydb.PollerStore satisfies it. The poller then checks all matches of a subscription in
+// one call instead of calling Notified for each.
+type BatchStore interface {
+	Store
+	// NotifiedTrips reports which of tripIDs the user was already notified about for subID
+	NotifiedTrips(ctx context.Context, chatID int64, subID string, tripIDs []string) (map[string]bool, error)
+}
+
+// Notifier delivers a matched trip to the subscription's user
+type Notifier interface {
+	Notify(ctx context.Context, sub models.SearchSubscription, trip models.TripInfo) error
+}
+
+// Dispatcher is a Notifier deduplicating on its own, such as notify.Dispatcher. The poller
+// hands it every matched trip, so it can also update earlier notifications.
+type Dispatcher interface {
+	Dispatch(ctx context.Context, sub models.SearchSubscription, trip models.TripInfo) (notify.Outcome, error)
+}
+
+// NotifierFunc adapts a function to Notifier
+type NotifierFunc func(ctx context.Context, sub models.SearchSubscription, trip models.TripInfo) error
+
+// Notify calls f
+func (f NotifierFunc) Notify(ctx context.Context, sub models.SearchSubscription, trip models.TripInfo) error {
+	return f(ctx, sub, trip)
+}
+
+// Deps are the collaborators of a polling cycle
+type Deps struct {
+	Store Store
+	// Searcher finds trips; wrap it with blablacar.NewCachedSearcher so subscriptions
+	// to the same route and date share one upstream search
+	Searcher blablacar.Searcher
+	Matcher  matching.Matcher
+	Notifier Notifier
+	// Request builds the search for a subscription; nil uses SearchRequestFor
+	Request func(sub models.SearchSubscription) blablacar.SearchRequest
+	// Schedule decides when a subscription is checked next; nil uses DefaultSchedule
+	Schedule *Schedule
+
+	// Concurrency is the maximum number of parallel checks, DefaultConcurrency if 0
+	Concurrency int
+	// TaskTimeout bounds the check of one subscription, DefaultTaskTimeout if 0
+	TaskTimeout time.Duration
+	// Clock schedules the next checks, the system clock if nil; it is also handed to the
+	// Store through the context, see clock.From
+	Clock clock.Clock
+}
+
+// Stats summarizes one cycle
+type Stats struct {
+	Subscriptions int
+	Failed        int
+	Found         int
+	Matched       int
+	Duplicates    int
+	Notified      int
+	Updated       int
+	Queued        int
+}
+
+// SearchRequestFor searches the route, date and seats of sub. Filters are applied by
+// matching afterwards, so subscriptions differing only in filters share cached results.
+func SearchRequestFor(sub models.SearchSubscription) blablacar.SearchRequest {
+	return blablacar.SearchRequest{
+		FromPlaceID: sub.FromPlaceID,
+		ToPlaceID:   sub.ToPlaceID,
+		Date:        sub.DepartureDate.String(),
+		Seats:       max(sub.RequestedSeats, 1),
+	}
+}
+
+// RunOnce checks every due subscription once, e.g. from a timer-triggered function,
+// running checks in parallel as configured in deps. A failing subscription is logged
+// and counted without stopping the cycle; an error is returned when the subscriptions
+// cannot be loaded or ctx ends.
+func RunOnce(ctx context.Context, deps Deps) (Stats, error) {
+	ctx, _ = requestid.Ensure(ctx)
+	if deps.Clock != nil {
+		ctx = clock.With(ctx, deps.Clock)
+	}
+	subs, err := deps.Store.DueSubscriptions(ctx)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to load due subscriptions: %w", err)
+	}
+
+	stats := checkAll(ctx, deps, subs)
+	stats.Subscriptions = len(subs)
+	requestid.Logf(ctx, "[Poller] Cycle done: subscriptions=%d failed=%d found=%d matched=%d duplicates=%d notified=%d updated=%d queued=%d",
+		stats.Subscriptions, stats.Failed, stats.Found, stats.Matched, stats.Duplicates, stats.Notified, stats.Updated, stats.Queued)
+	return stats, ctx.Err()
+}
+
+// check searches, matches and notifies for one subscription
+func check(ctx context.Context, deps Deps, sub models.SearchSubscription) (Stats, error) {
+	var stats Stats
+	request := SearchRequestFor
+	if deps.Request != nil {
+		request = deps.Request
+	}
+
+	trips, err := deps.Searcher.SearchTrips(ctx, request(sub))
+	if err != nil {
+		return stats, fmt.Errorf("search failed: %w", err)
+	}
+
+	result := deps.Matcher.Match(ctx, sub, blablacar.TripInfos(trips))
+	stats.Found = len(trips)
+	stats.Matched = len(result.Matches)
+
+	schedule := DefaultSchedule
+	if deps.Schedule != nil {
+		schedule = *deps.Schedule
+	}
+	now := clock.Or(deps.Clock).Now()
+	emptyChecks := EmptyChecksAfter(sub, stats.Matched)
+	if err := deps.Store.MarkChecked(ctx, sub, now, schedule.Next(sub, now, emptyChecks), emptyChecks); err != nil {
+		requestid.Logf(ctx, "[Poller] Failed to mark subscription %s checked: %v", sub.ID, err)
+	}
+
+	if dispatcher, ok := deps.Notifier.(Dispatcher); ok {
+		return dispatch(ctx, dispatcher, sub, result.Matches, stats)
+	}
+
+	var batch map[string]bool
+	if store, ok := deps.Store.(BatchStore); ok && len(result.Matches) > 0 {
+		tripIDs := make([]string, 0, len(result.Matches))
+		for _, trip := range result.Matches {
+			tripIDs = append(tripIDs, trip.ID)
+		}
+		if batch, err = store.NotifiedTrips(ctx, sub.TelegramChatID, sub.ID, tripIDs); err != nil {
+			return stats, fmt.Errorf("dedup check failed: %w", err)
+		}
+	}
+
+	var errs []error
+	for _, trip := range result.Matches {
+		notified := batch[trip.ID]
+		if batch == nil {
+			notified, err = deps.Store.Notified(ctx, sub.TelegramChatID, sub.ID, trip.ID)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("dedup check for trip %s failed: %w", trip.ID, err))
+				continue
+			}
+		}
+		if notified {
+			stats.Duplicates++
+			continue
+		}
+		if err := deps.Notifier.Notify(ctx, sub, trip); err != nil {
+			errs = append(errs, fmt.Errorf("notification about trip %s failed: %w", trip.ID, err))
+			continue
+		}
+		stats.Notified++
+	}
+	return stats, errors.Join(errs...)
+}
+
+// dispatch hands trips to dispatcher, counting the outcomes into stats
+func dispatch(ctx context.Context, dispatcher Dispatcher, sub models.SearchSubscription, trips []models.TripInfo, stats Stats) (Stats, error) {
+	var errs []error
+	for _, trip := range trips {
+		outcome, err := dispatcher.Dispatch(ctx, sub, trip)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("notification about trip %s failed: %w", trip.ID, err))
+			continue
+		}
+		switch outcome {
+		case notify.OutcomeSent:
+			stats.Notified++
+		case notify.OutcomeUpdated:
+			stats.Updated++
+		case notify.OutcomeQueued:
+			stats.Queued++
+		default:
+			stats.Duplicates++
+		}
+	}
+	return stats, errors.Join(errs...)
+}
+
+func (s *Stats) add(o Stats) {
+	s.Found += o.Found
+	s.Matched += o.Matched
+	s.Duplicates += o.Duplicates
+	s.Notified += o.Notified
+	s.Updated += o.Updated
+	s.Queued += o.Queued
+}
@@ -0,0 +1,102 @@
+package poller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/logging"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+	"github.com/arseniisemenow/bbc-common/pkg/recovery"
+	"github.com/arseniisemenow/bbc-common/pkg/requestid"
+)
+
+const (
+	// DefaultConcurrency is the number of subscriptions checked in parallel
+	DefaultConcurrency = 4
+	// DefaultTaskTimeout bounds the check of one subscription
+	DefaultTaskTimeout = 30 * time.Second
+)
+
+// checkAll checks subs with up to deps.Concurrency workers. The subscriptions of one
+// user are checked one after another by the same worker, so a user's BlaBlaCar
+// account never sees parallel requests.
+func checkAll(ctx context.Context, deps Deps, subs []models.SearchSubscription) Stats {
+	workers := deps.Concurrency
+	if workers <= 0 {
+		workers = DefaultConcurrency
+	}
+	timeout := deps.TaskTimeout
+	if timeout <= 0 {
+		timeout = DefaultTaskTimeout
+	}
+
+	users := groupByUser(subs)
+	tasks := make(chan []models.SearchSubscription)
+	var (
+		mu    sync.Mutex
+		stats Stats
+		wg    sync.WaitGroup
+	)
+	for i := 0; i < min(workers, len(users)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for userSubs := range tasks {
+				for _, sub := range userSubs {
+					if ctx.Err() != nil {
+						break
+					}
+					var result Stats
+					err := recovery.Do(ctx, func() (err error) {
+						result, err = checkWithTimeout(ctx, deps, sub, timeout)
+						return err
+					})
+					if err != nil {
+						requestid.Logf(ctx, "[Poller] Subscription %s of chat %d failed: %v", sub.ID, sub.TelegramChatID, err)
+					}
+					mu.Lock()
+					stats.add(result)
+					if err != nil {
+						stats.Failed++
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for _, userSubs := range users {
+		select {
+		case tasks <- userSubs:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(tasks)
+	wg.Wait()
+	return stats
+}
+
+func checkWithTimeout(ctx context.Context, deps Deps, sub models.SearchSubscription, timeout time.Duration) (Stats, error) {
+	ctx, cancel := context.WithTimeout(logging.WithChatID(ctx, sub.TelegramChatID), timeout)
+	defer cancel()
+	return check(ctx, deps, sub)
+}
+
+// groupByUser splits subs per chat, keeping the order of first appearance
+func groupByUser(subs []models.SearchSubscription) [][]models.SearchSubscription {
+	index := make(map[int64]int)
+	var groups [][]models.SearchSubscription
+	for _, sub := range subs {
+		i, ok := index[sub.TelegramChatID]
+		if !ok {
+			i = len(groups)
+			index[sub.TelegramChatID] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], sub)
+	}
+	return groups
+}
@@ -0,0 +1,138 @@
+package poller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/blablacar"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+	"github.com/arseniisemenow/bbc-common/pkg/requestid"
+	"github.com/arseniisemenow/bbc-common/pkg/telegram"
+)
+
+// ReconcileStore lists what reconciliation re-checks; ydb.PollerStore satisfies it
+type ReconcileStore interface {
+	// SentNotifications returns the sent notifications of upcoming active subscriptions
+	SentNotifications(ctx context.Context) ([]models.Notification, error)
+	ActiveSubscriptions(ctx context.Context) ([]models.SearchSubscription, error)
+}
+
+// AvailabilityChecker tells whether a trip can still be booked; blablacar.Client satisfies it
+type AvailabilityChecker interface {
+	CheckAvailability(ctx context.Context, tripID string, seats int) (blablacar.Availability, error)
+}
+
+// Retractor marks a notification's trip as no longer available; notify.Dispatcher satisfies it
+type Retractor interface {
+	Retract(ctx context.Context, sub models.SearchSubscription, notif models.Notification, reason string) error
+}
+
+// ReconcileDeps are the collaborators of a reconciliation run
+type ReconcileDeps struct {
+	Store     ReconcileStore
+	Checker   AvailabilityChecker
+	Retractor Retractor
+	// TaskTimeout bounds the check of one trip, DefaultTaskTimeout if 0
+	TaskTimeout time.Duration
+}
+
+// ReconcileStats summarizes one reconciliation run
+type ReconcileStats struct {
+	Checked int
+	SoldOut int
+	Removed int
+	Failed  int
+}
+
+// Reconcile re-checks the trips of sent notifications and retracts the notifications of
+// trips that sold out or were removed. Each trip is checked once per run for each number
+// of requested seats. A failing trip is logged and counted without stopping the run.
+func Reconcile(ctx context.Context, deps ReconcileDeps) (ReconcileStats, error) {
+	ctx, _ = requestid.Ensure(ctx)
+	var stats ReconcileStats
+	notifs, err := deps.Store.SentNotifications(ctx)
+	if err != nil {
+		return stats, fmt.Errorf("failed to load sent notifications: %w", err)
+	}
+	if len(notifs) == 0 {
+		return stats, nil
+	}
+	subs, err := deps.Store.ActiveSubscriptions(ctx)
+	if err != nil {
+		return stats, fmt.Errorf("failed to load active subscriptions: %w", err)
+	}
+	byID := make(map[string]models.SearchSubscription, len(subs))
+	for _, sub := range subs {
+		byID[sub.ID] = sub
+	}
+
+	timeout := deps.TaskTimeout
+	if timeout <= 0 {
+		timeout = DefaultTaskTimeout
+	}
+
+	type checkKey struct {
+		tripID string
+		seats  int
+	}
+	checked := make(map[checkKey]blablacar.Availability)
+	for _, notif := range notifs {
+		if ctx.Err() != nil {
+			break
+		}
+		sub, ok := byID[notif.SubscriptionID]
+		if !ok {
+			continue
+		}
+
+		key := checkKey{notif.TripID, max(sub.RequestedSeats, 1)}
+		availability, ok := checked[key]
+		if !ok {
+			availability, err = checkAvailability(ctx, deps.Checker, key.tripID, key.seats, timeout)
+			if err != nil {
+				requestid.Logf(ctx, "[Poller] Availability check of trip %s failed: %v", notif.TripID, err)
+				stats.Failed++
+				continue
+			}
+			checked[key] = availability
+			stats.Checked++
+		}
+
+		reason := unavailableReason(availability)
+		if reason == "" {
+			continue
+		}
+		if err := deps.Retractor.Retract(ctx, sub, notif, reason); err != nil {
+			requestid.Logf(ctx, "[Poller] Failed to retract notification %s: %v", notif.ID, err)
+			stats.Failed++
+			continue
+		}
+		if reason == telegram.UnavailableRemoved {
+			stats.Removed++
+		} else {
+			stats.SoldOut++
+		}
+	}
+
+	requestid.Logf(ctx, "[Poller] Reconciliation done: notifications=%d checked=%d sold_out=%d removed=%d failed=%d",
+		len(notifs), stats.Checked, stats.SoldOut, stats.Removed, stats.Failed)
+	return stats, ctx.Err()
+}
+
+func checkAvailability(ctx context.Context, checker AvailabilityChecker, tripID string, seats int, timeout time.Duration) (blablacar.Availability, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return checker.CheckAvailability(ctx, tripID, seats)
+}
+
+// unavailableReason returns why a trip can no longer be booked, or "" if it can
+func unavailableReason(a blablacar.Availability) string {
+	switch {
+	case a.Removed:
+		return telegram.UnavailableRemoved
+	case !a.Available:
+		return telegram.UnavailableSoldOut
+	}
+	return ""
+}
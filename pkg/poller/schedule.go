@@ -0,0 +1,115 @@
+package poller
+
+import (
+	"math"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// Step is the polling interval used while the departure is at most Before away
+type Step struct {
+	Before   time.Duration
+	Interval time.Duration
+}
+
+// Schedule decides when a subscription is checked next. Checks get more frequent as
+// the departure approaches and back off while searches keep finding nothing.
+type Schedule struct {
+	// Steps in ascending order of Before; departures further away use Far
+	Steps []Step
+	Far   time.Duration
+	// BackoffAfter consecutive empty checks double the interval, up to MaxInterval;
+	// a MaxInterval of 0 leaves the backoff uncapped
+	BackoffAfter int
+	MaxInterval  time.Duration
+	// MinInterval is the shortest interval, DefaultMinInterval if 0; it keeps a schedule
+	// without Far or with a departure just ahead from checking in a loop
+	MinInterval time.Duration
+	// Location of departure dates, UTC if nil
+	Location *time.Location
+}
+
+// DefaultMinInterval is the shortest interval of a schedule without MinInterval
+const DefaultMinInterval = time.Minute
+
+// DefaultSchedule is shared by the bot and the poller, so both agree on next_check_at
+var DefaultSchedule = Schedule{
+	Steps: []Step{
+		{Before: 24 * time.Hour, Interval: 5 * time.Minute},
+		{Before: 3 * 24 * time.Hour, Interval: 15 * time.Minute},
+		{Before: 7 * 24 * time.Hour, Interval: 30 * time.Minute},
+		{Before: 30 * 24 * time.Hour, Interval: 2 * time.Hour},
+	},
+	Far:          6 * time.Hour,
+	BackoffAfter: 3,
+	MaxInterval:  12 * time.Hour,
+	MinInterval:  DefaultMinInterval,
+}
+
+// Interval returns the time between checks for a departure date at now after
+// emptyChecks consecutive checks without matches. Backoff never stretches an
+// interval past MaxInterval or the start of the departure date, and no interval is
+// shorter than MinInterval.
+func (s Schedule) Interval(departureDate models.Date, now time.Time, emptyChecks int) time.Duration {
+	interval := s.Far
+	until, known := s.untilDeparture(departureDate, now)
+	if known {
+		for _, step := range s.Steps {
+			if until <= step.Before {
+				interval = step.Interval
+				break
+			}
+		}
+	}
+
+	minInterval := s.MinInterval
+	if minInterval <= 0 {
+		minInterval = DefaultMinInterval
+	}
+	interval = max(interval, minInterval)
+
+	if s.BackoffAfter > 0 {
+		for n := emptyChecks / s.BackoffAfter; n > 0 && interval <= math.MaxInt64/2; n-- {
+			if s.MaxInterval > 0 && interval >= s.MaxInterval {
+				break
+			}
+			interval *= 2
+		}
+	}
+	if s.MaxInterval > 0 && interval > s.MaxInterval {
+		interval = s.MaxInterval
+	}
+	// a check scheduled after the departure would be useless
+	if known && until > 0 && interval > until {
+		interval = until
+	}
+	return max(interval, minInterval)
+}
+
+// Next returns when sub is checked next after a check at now
+func (s Schedule) Next(sub models.SearchSubscription, now time.Time, emptyChecks int) time.Time {
+	return now.Add(s.Interval(sub.DepartureDate, now, emptyChecks))
+}
+
+// Due reports whether sub should be checked at now
+func (s Schedule) Due(sub models.SearchSubscription, now time.Time) bool {
+	return sub.NextCheckAt == nil || !sub.NextCheckAt.After(now)
+}
+
+// untilDeparture returns the time from now to the start of the departure date
+func (s Schedule) untilDeparture(date models.Date, now time.Time) (time.Duration, bool) {
+	if !date.Valid() {
+		return 0, false
+	}
+	return date.In(s.Location).Sub(now), true
+}
+
+// EmptyChecksAfter returns the count of consecutive empty checks after a check
+// that found matched trips
+func EmptyChecksAfter(sub models.SearchSubscription, matched int) int {
+	if matched > 0 {
+		return 0
+	}
+	return sub.EmptyChecks + 1
+}
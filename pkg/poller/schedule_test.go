@@ -0,0 +1,196 @@
+package poller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/fixtures"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+func TestScheduleInterval(t *testing.T) {
+	departure := models.Date{Year: 2025, Month: time.July, Day: 1}
+	start := departure.In(time.UTC)
+	steps := []Step{
+		{Before: 24 * time.Hour, Interval: 5 * time.Minute},
+		{Before: 7 * 24 * time.Hour, Interval: 30 * time.Minute},
+	}
+
+	tests := []struct {
+		name        string
+		schedule    Schedule
+		date        models.Date
+		now         time.Time
+		emptyChecks int
+		want        time.Duration
+	}{
+		{
+			name:     "near step",
+			schedule: Schedule{Steps: steps, Far: 6 * time.Hour},
+			date:     departure,
+			now:      start.Add(-12 * time.Hour),
+			want:     5 * time.Minute,
+		},
+		{
+			name:     "step bound is inclusive",
+			schedule: Schedule{Steps: steps, Far: 6 * time.Hour},
+			date:     departure,
+			now:      start.Add(-24 * time.Hour),
+			want:     5 * time.Minute,
+		},
+		{
+			name:     "later step",
+			schedule: Schedule{Steps: steps, Far: 6 * time.Hour},
+			date:     departure,
+			now:      start.Add(-3 * 24 * time.Hour),
+			want:     30 * time.Minute,
+		},
+		{
+			name:     "far",
+			schedule: Schedule{Steps: steps, Far: 6 * time.Hour},
+			date:     departure,
+			now:      start.Add(-30 * 24 * time.Hour),
+			want:     6 * time.Hour,
+		},
+		{
+			name:     "unknown date uses far",
+			schedule: Schedule{Steps: steps, Far: 6 * time.Hour},
+			now:      start,
+			want:     6 * time.Hour,
+		},
+		{
+			name:     "departure day uses first step",
+			schedule: Schedule{Steps: steps, Far: 6 * time.Hour},
+			date:     departure,
+			now:      start.Add(3 * time.Hour),
+			want:     5 * time.Minute,
+		},
+		{
+			name:     "clamped to departure",
+			schedule: Schedule{Far: 6 * time.Hour},
+			date:     departure,
+			now:      start.Add(-2 * time.Hour),
+			want:     2 * time.Hour,
+		},
+		{
+			name:        "backoff below threshold",
+			schedule:    Schedule{Far: time.Hour, BackoffAfter: 3, MaxInterval: 12 * time.Hour},
+			now:         start,
+			emptyChecks: 2,
+			want:        time.Hour,
+		},
+		{
+			name:        "backoff doubles",
+			schedule:    Schedule{Far: time.Hour, BackoffAfter: 3, MaxInterval: 12 * time.Hour},
+			now:         start,
+			emptyChecks: 6,
+			want:        4 * time.Hour,
+		},
+		{
+			name:        "backoff capped",
+			schedule:    Schedule{Far: time.Hour, BackoffAfter: 3, MaxInterval: 12 * time.Hour},
+			now:         start,
+			emptyChecks: 30,
+			want:        12 * time.Hour,
+		},
+		{
+			name:        "backoff uncapped without max",
+			schedule:    Schedule{Far: time.Hour, BackoffAfter: 3},
+			now:         start,
+			emptyChecks: 15,
+			want:        32 * time.Hour,
+		},
+		{
+			name:        "backoff without max does not overflow",
+			schedule:    Schedule{Far: time.Hour, BackoffAfter: 1},
+			now:         start,
+			emptyChecks: 1000,
+			want:        time.Hour << 21,
+		},
+		{
+			name:        "backoff clamped to departure",
+			schedule:    Schedule{Far: time.Hour, BackoffAfter: 1, MaxInterval: 12 * time.Hour},
+			date:        departure,
+			now:         start.Add(-3 * time.Hour),
+			emptyChecks: 5,
+			want:        3 * time.Hour,
+		},
+		{
+			name:     "zero far uses minimum",
+			schedule: Schedule{},
+			now:      start,
+			want:     DefaultMinInterval,
+		},
+		{
+			name:     "custom minimum",
+			schedule: Schedule{Steps: steps, MinInterval: 10 * time.Minute},
+			date:     departure,
+			now:      start.Add(-time.Hour),
+			want:     10 * time.Minute,
+		},
+		{
+			name:     "departure just ahead uses minimum",
+			schedule: Schedule{Far: time.Hour},
+			date:     departure,
+			now:      start.Add(-time.Second),
+			want:     DefaultMinInterval,
+		},
+		{
+			name:     "location",
+			schedule: Schedule{Steps: steps, Far: 6 * time.Hour, Location: time.FixedZone("UTC+3", 3*3600)},
+			date:     departure,
+			// 25h before midnight UTC, 22h before midnight UTC+3
+			now:  start.Add(-25 * time.Hour),
+			want: 5 * time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.schedule.Interval(tt.date, tt.now, tt.emptyChecks); got != tt.want {
+				t.Errorf("Interval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	now := fixtures.Epoch
+	sub := fixtures.Subscription().OnDate("2025-06-02").Build()
+
+	// departure is 12h away
+	if got, want := DefaultSchedule.Next(sub, now, 0), now.Add(5*time.Minute); !got.Equal(want) {
+		t.Errorf("Next() = %v, want %v", got, want)
+	}
+	sub.DepartureDate = models.Date{}
+	if got, want := DefaultSchedule.Next(sub, now, 3), now.Add(12*time.Hour); !got.Equal(want) {
+		t.Errorf("Next() without date = %v, want %v", got, want)
+	}
+}
+
+func TestScheduleDue(t *testing.T) {
+	now := fixtures.Epoch
+	sub := fixtures.Subscription().Build()
+	if !DefaultSchedule.Due(sub, now) {
+		t.Error("Due() = false for a subscription never checked")
+	}
+	next := now.Add(time.Minute)
+	sub.NextCheckAt = &next
+	if DefaultSchedule.Due(sub, now) {
+		t.Error("Due() = true before next check")
+	}
+	if !DefaultSchedule.Due(sub, next) {
+		t.Error("Due() = false at next check")
+	}
+}
+
+func TestEmptyChecksAfter(t *testing.T) {
+	sub := fixtures.Subscription().Build()
+	sub.EmptyChecks = 4
+
+	if got := EmptyChecksAfter(sub, 0); got != 5 {
+		t.Errorf("EmptyChecksAfter(no matches) = %d, want 5", got)
+	}
+	if got := EmptyChecksAfter(sub, 2); got != 0 {
+		t.Errorf("EmptyChecksAfter(matches) = %d, want 0", got)
+	}
+}
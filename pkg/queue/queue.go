@@ -0,0 +1,293 @@
+// Package queue fans work out through Yandex Message Queue (SQS-compatible): producers
+// publish typed envelopes, consumers dispatch them to handlers by type
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arseniisemenow/bbc-common/pkg/recovery"
+	"github.com/arseniisemenow/bbc-common/pkg/requestid"
+)
+
+const (
+	// MaxBatchSize is the largest batch SQS accepts for sending and receiving
+	MaxBatchSize = 10
+	// DefaultVisibilityTimeout hides a received message from other consumers while it is handled
+	DefaultVisibilityTimeout = 60 * time.Second
+	// DefaultWaitTime is the long-polling wait of Receive
+	DefaultWaitTime = 20 * time.Second
+)
+
+var ErrUnknownType = errors.New("no handler for message type")
+
+// Envelope wraps every message body: a type naming the handler and its JSON payload
+type Envelope struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+	// RequestID is the ID of the request that published the message, see requestid
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// NewEnvelope encodes payload as a message of type msgType
+func NewEnvelope(msgType string, payload any) (Envelope, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("failed to encode %s payload: %w", msgType, err)
+	}
+	return Envelope{ID: uuid.NewString(), Type: msgType, Payload: data, CreatedAt: time.Now()}, nil
+}
+
+// Decode unmarshals the payload into v
+func (e Envelope) Decode(v any) error {
+	if err := json.Unmarshal(e.Payload, v); err != nil {
+		return fmt.Errorf("failed to decode %s payload: %w", e.Type, err)
+	}
+	return nil
+}
+
+// RawMessage is a message as received from the queue
+type RawMessage struct {
+	MessageID     string
+	ReceiptHandle string
+	Body          string
+	// ReceiveCount is how many times the message was received, including this time
+	ReceiveCount int
+}
+
+// Message is a received envelope
+type Message struct {
+	Envelope
+	MessageID     string
+	ReceiptHandle string
+	ReceiveCount  int
+}
+
+// ParseMessage decodes the envelope in raw's body
+func ParseMessage(raw RawMessage) (Message, error) {
+	msg := Message{MessageID: raw.MessageID, ReceiptHandle: raw.ReceiptHandle, ReceiveCount: raw.ReceiveCount}
+	if err := json.Unmarshal([]byte(raw.Body), &msg.Envelope); err != nil {
+		return msg, fmt.Errorf("failed to decode message %s: %w", raw.MessageID, err)
+	}
+	return msg, nil
+}
+
+// API is the subset of the SQS API used here; YMQ satisfies it
+type API interface {
+	SendBatch(ctx context.Context, queueURL string, bodies []string) error
+	Receive(ctx context.Context, queueURL string, max int, wait, visibility time.Duration) ([]RawMessage, error)
+	Delete(ctx context.Context, queueURL, receiptHandle string) error
+	ChangeVisibility(ctx context.Context, queueURL, receiptHandle string, timeout time.Duration) error
+}
+
+// Producer publishes envelopes to one queue
+type Producer struct {
+	api      API
+	queueURL string
+}
+
+// NewProducer creates a producer for queueURL
+func NewProducer(api API, queueURL string) *Producer {
+	return &Producer{api: api, queueURL: queueURL}
+}
+
+// Publish sends one message of type msgType
+func (p *Producer) Publish(ctx context.Context, msgType string, payload any) error {
+	env, err := NewEnvelope(msgType, payload)
+	if err != nil {
+		return err
+	}
+	return p.PublishEnvelopes(ctx, []Envelope{env})
+}
+
+// PublishEnvelopes sends envs in batches of MaxBatchSize; envelopes without a request ID
+// get the one carried by ctx
+func (p *Producer) PublishEnvelopes(ctx context.Context, envs []Envelope) error {
+	requestID := requestid.From(ctx)
+	for start := 0; start < len(envs); start += MaxBatchSize {
+		batch := envs[start:min(start+MaxBatchSize, len(envs))]
+		bodies := make([]string, 0, len(batch))
+		for _, env := range batch {
+			if env.RequestID == "" {
+				env.RequestID = requestID
+			}
+			data, err := json.Marshal(env)
+			if err != nil {
+				return fmt.Errorf("failed to encode message %s: %w", env.ID, err)
+			}
+			bodies = append(bodies, string(data))
+		}
+		if err := p.api.SendBatch(ctx, p.queueURL, bodies); err != nil {
+			return fmt.Errorf("failed to publish %d messages: %w", len(batch), err)
+		}
+	}
+	return nil
+}
+
+// HandlerFunc processes the payload of one message
+type HandlerFunc func(ctx context.Context, msg Message) error
+
+// Consumer dispatches messages to handlers registered per type. Use HandleBatch from a
+// message-queue trigger, which deletes the batch itself when the function succeeds, or
+// Poll to receive and delete messages directly.
+type Consumer struct {
+	api      API
+	queueURL string
+	handlers map[string]HandlerFunc
+
+	// VisibilityTimeout of received messages, extended while a handler runs
+	VisibilityTimeout time.Duration
+	// Concurrency is the number of messages handled in parallel
+	Concurrency int
+	// Backoff returns how long a failed message stays hidden before it is retried;
+	// after the queue's maxReceiveCount it moves to the dead-letter queue
+	Backoff func(receiveCount int) time.Duration
+}
+
+// NewConsumer creates a consumer for queueURL; api may be nil when only HandleBatch is used
+func NewConsumer(api API, queueURL string) *Consumer {
+	return &Consumer{
+		api:               api,
+		queueURL:          queueURL,
+		handlers:          make(map[string]HandlerFunc),
+		VisibilityTimeout: DefaultVisibilityTimeout,
+		Concurrency:       MaxBatchSize,
+		Backoff: func(receiveCount int) time.Duration {
+			return min(time.Duration(receiveCount)*30*time.Second, 12*time.Hour)
+		},
+	}
+}
+
+// Handle registers fn for messages of type msgType
+func (c *Consumer) Handle(msgType string, fn HandlerFunc) *Consumer {
+	c.handlers[msgType] = fn
+	return c
+}
+
+// Dispatch runs the handler registered for msg's type, with ctx carrying the request ID
+// of the publisher or, for messages without one, a new ID; a panic fails the message
+func (c *Consumer) Dispatch(ctx context.Context, msg Message) error {
+	fn, ok := c.handlers[msg.Type]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownType, msg.Type)
+	}
+	if msg.RequestID != "" {
+		ctx = requestid.With(ctx, msg.RequestID)
+	}
+	ctx, _ = requestid.Ensure(ctx)
+	return recovery.Do(ctx, func() error { return fn(ctx, msg) })
+}
+
+// HandleBatch parses and dispatches raw messages in parallel and returns all errors joined
+func (c *Consumer) HandleBatch(ctx context.Context, raws []RawMessage) error {
+	errs := make([]error, len(raws))
+	c.each(raws, func(i int, raw RawMessage) {
+		msg, err := ParseMessage(raw)
+		if err == nil {
+			err = c.Dispatch(ctx, msg)
+		}
+		if err != nil {
+			errs[i] = fmt.Errorf("message %s: %w", raw.MessageID, err)
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// Poll receives and handles one batch, deleting handled messages and hiding failed ones
+// for Backoff; it returns the number of messages received
+func (c *Consumer) Poll(ctx context.Context) (int, error) {
+	raws, err := c.api.Receive(ctx, c.queueURL, MaxBatchSize, DefaultWaitTime, c.VisibilityTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("failed to receive messages: %w", err)
+	}
+
+	c.each(raws, func(_ int, raw RawMessage) {
+		stop := c.keepInvisible(ctx, raw.ReceiptHandle)
+		msg, err := ParseMessage(raw)
+		msgCtx := requestid.With(ctx, msg.RequestID)
+		if err == nil {
+			err = c.Dispatch(ctx, msg)
+		}
+		stop()
+
+		if err != nil {
+			requestid.Logf(msgCtx, "[Queue] Message %s failed (receive %d): %v", raw.MessageID, raw.ReceiveCount, err)
+			if err := c.api.ChangeVisibility(ctx, c.queueURL, raw.ReceiptHandle, c.Backoff(raw.ReceiveCount)); err != nil {
+				requestid.Logf(msgCtx, "[Queue] Failed to delay retry of message %s: %v", raw.MessageID, err)
+			}
+			return
+		}
+		if err := c.api.Delete(ctx, c.queueURL, raw.ReceiptHandle); err != nil {
+			requestid.Logf(msgCtx, "[Queue] Failed to delete message %s: %v", raw.MessageID, err)
+		}
+	})
+	return len(raws), nil
+}
+
+// Run polls until ctx is cancelled, for long-running deployments
+func (c *Consumer) Run(ctx context.Context) error {
+	for ctx.Err() == nil {
+		if _, err := c.Poll(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("[Queue] Poll failed: %v", err)
+			select {
+			case <-time.After(5 * time.Second):
+			case <-ctx.Done():
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+// keepInvisible extends the visibility of a message every half timeout until stopped,
+// so slow handlers do not let another consumer pick the message up
+func (c *Consumer) keepInvisible(ctx context.Context, receiptHandle string) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(c.VisibilityTimeout / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.api.ChangeVisibility(ctx, c.queueURL, receiptHandle, c.VisibilityTimeout); err != nil {
+					log.Printf("[Queue] Failed to extend visibility: %v", err)
+				}
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// each runs fn for every message with up to c.Concurrency goroutines
+func (c *Consumer) each(raws []RawMessage, fn func(i int, raw RawMessage)) {
+	sem := make(chan struct{}, max(c.Concurrency, 1))
+	var wg sync.WaitGroup
+	for i, raw := range raws {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i, raw)
+		}()
+	}
+	wg.Wait()
+}
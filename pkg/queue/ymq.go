@@ -0,0 +1,241 @@
+package queue
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// YMQEndpoint is the Yandex Message Queue API endpoint
+	YMQEndpoint = "https://message-queue.api.cloud.yandex.net"
+	// YMQRegion is the signing region of Yandex Message Queue
+	YMQRegion = "ru-central1"
+)
+
+var ErrMissingCredentials = errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+
+// YMQ is a client of the SQS query API as served by Yandex Message Queue, authenticated
+// with the static access key of a service account
+type YMQ struct {
+	HTTPClient *http.Client
+	Endpoint   string
+	Region     string
+
+	accessKey string
+	secretKey string
+}
+
+// NewYMQ creates a client signing requests with the given static access key
+func NewYMQ(accessKey, secretKey string) *YMQ {
+	return &YMQ{
+		HTTPClient: &http.Client{Timeout: DefaultWaitTime + 10*time.Second},
+		Endpoint:   YMQEndpoint,
+		Region:     YMQRegion,
+		accessKey:  accessKey,
+		secretKey:  secretKey,
+	}
+}
+
+// NewYMQFromEnv creates a client from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY
+func NewYMQFromEnv() (*YMQ, error) {
+	accessKey, secretKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return nil, ErrMissingCredentials
+	}
+	return NewYMQ(accessKey, secretKey), nil
+}
+
+// APIError is an error returned by the queue service
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("queue API error %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+type sendBatchResponse struct {
+	Failed []struct {
+		ID      string `xml:"Id"`
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	} `xml:"SendMessageBatchResult>BatchResultErrorEntry"`
+}
+
+type receiveResponse struct {
+	Messages []struct {
+		MessageID     string `xml:"MessageId"`
+		ReceiptHandle string `xml:"ReceiptHandle"`
+		Body          string `xml:"Body"`
+		Attributes    []struct {
+			Name  string `xml:"Name"`
+			Value string `xml:"Value"`
+		} `xml:"Attribute"`
+	} `xml:"ReceiveMessageResult>Message"`
+}
+
+type errorResponse struct {
+	Code    string `xml:"Error>Code"`
+	Message string `xml:"Error>Message"`
+}
+
+// SendBatch implements API; at most MaxBatchSize bodies are accepted
+func (q *YMQ) SendBatch(ctx context.Context, queueURL string, bodies []string) error {
+	params := url.Values{}
+	for i, body := range bodies {
+		prefix := "SendMessageBatchRequestEntry." + strconv.Itoa(i+1) + "."
+		params.Set(prefix+"Id", strconv.Itoa(i))
+		params.Set(prefix+"MessageBody", body)
+	}
+
+	var resp sendBatchResponse
+	if err := q.call(ctx, "SendMessageBatch", queueURL, params, &resp); err != nil {
+		return err
+	}
+	if len(resp.Failed) > 0 {
+		f := resp.Failed[0]
+		return fmt.Errorf("%d of %d messages rejected, first %s: %s: %s", len(resp.Failed), len(bodies), f.ID, f.Code, f.Message)
+	}
+	return nil
+}
+
+// Receive implements API
+func (q *YMQ) Receive(ctx context.Context, queueURL string, max int, wait, visibility time.Duration) ([]RawMessage, error) {
+	params := url.Values{
+		"MaxNumberOfMessages": {strconv.Itoa(max)},
+		"WaitTimeSeconds":     {strconv.Itoa(int(wait.Seconds()))},
+		"VisibilityTimeout":   {strconv.Itoa(int(visibility.Seconds()))},
+		"AttributeName.1":     {"ApproximateReceiveCount"},
+	}
+
+	var resp receiveResponse
+	if err := q.call(ctx, "ReceiveMessage", queueURL, params, &resp); err != nil {
+		return nil, err
+	}
+	msgs := make([]RawMessage, 0, len(resp.Messages))
+	for _, m := range resp.Messages {
+		raw := RawMessage{MessageID: m.MessageID, ReceiptHandle: m.ReceiptHandle, Body: m.Body}
+		for _, attr := range m.Attributes {
+			if attr.Name == "ApproximateReceiveCount" {
+				raw.ReceiveCount, _ = strconv.Atoi(attr.Value)
+			}
+		}
+		msgs = append(msgs, raw)
+	}
+	return msgs, nil
+}
+
+// Delete implements API
+func (q *YMQ) Delete(ctx context.Context, queueURL, receiptHandle string) error {
+	return q.call(ctx, "DeleteMessage", queueURL, url.Values{"ReceiptHandle": {receiptHandle}}, nil)
+}
+
+// ChangeVisibility implements API
+func (q *YMQ) ChangeVisibility(ctx context.Context, queueURL, receiptHandle string, timeout time.Duration) error {
+	params := url.Values{
+		"ReceiptHandle":     {receiptHandle},
+		"VisibilityTimeout": {strconv.Itoa(int(timeout.Seconds()))},
+	}
+	return q.call(ctx, "ChangeMessageVisibility", queueURL, params, nil)
+}
+
+// Ping reads an attribute of queueURL to check that the queue is reachable with the
+// credentials, e.g. as a readiness check
+func (q *YMQ) Ping(ctx context.Context, queueURL string) error {
+	return q.call(ctx, "GetQueueAttributes", queueURL, url.Values{"AttributeName.1": {"QueueArn"}}, nil)
+}
+
+// call performs a signed query API action and decodes the XML response into out
+func (q *YMQ) call(ctx context.Context, action, queueURL string, params url.Values, out any) error {
+	params.Set("Action", action)
+	params.Set("Version", "2012-11-05")
+	params.Set("QueueUrl", queueURL)
+	body := params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(q.Endpoint, "/")+"/", strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", action, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	q.sign(req, body, time.Now().UTC())
+
+	resp, err := q.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", action, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %w", action, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e errorResponse
+		_ = xml.Unmarshal(data, &e)
+		return &APIError{StatusCode: resp.StatusCode, Code: e.Code, Message: e.Message}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := xml.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", action, err)
+	}
+	return nil
+}
+
+// sign adds an AWS Signature Version 4 authorization header
+func (q *YMQ) sign(req *http.Request, body string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	const signedHeaders = "content-type;host;x-amz-date"
+	canonical := strings.Join([]string{
+		req.Method,
+		"/",
+		"",
+		"content-type:" + req.Header.Get("Content-Type"),
+		"host:" + req.URL.Host,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		sha256Hex(body),
+	}, "\n")
+
+	scope := date + "/" + q.Region + "/sqs/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex(canonical)
+
+	key := hmacSHA256([]byte("AWS4"+q.secretKey), date)
+	key = hmacSHA256(key, q.Region)
+	key = hmacSHA256(key, "sqs")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+q.accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,188 @@
+// Package ratelimit limits events per key, in memory with token buckets or across
+// function instances with counters in YDB, behind one Limiter interface
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/clock"
+	"github.com/arseniisemenow/bbc-common/pkg/logging"
+)
+
+// Limiter limits the rate of events per key, e.g. per chat or per user
+type Limiter interface {
+	// Wait blocks until an event for key may proceed or ctx is done
+	Wait(ctx context.Context, key string) error
+	// Allow reports whether an event for key may proceed now, counting it if so
+	Allow(ctx context.Context, key string) bool
+}
+
+// maxKeys is the number of tracked keys above which idle ones are dropped
+const maxKeys = 10000
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Buckets is an in-memory Limiter with one token bucket per key. Waiting callers
+// reserve tokens in arrival order, so concurrent callers of one key form a FIFO queue.
+type Buckets struct {
+	rate  float64
+	burst float64
+
+	// Clock refills the buckets, the system clock if nil
+	Clock clock.Clock
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// NewBuckets allows rate events per second per key with bursts of up to burst events
+func NewBuckets(rate float64, burst int) *Buckets {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Buckets{rate: rate, burst: float64(burst), buckets: make(map[string]*bucket)}
+}
+
+// Every allows one event per interval per key, without bursts
+func Every(interval time.Duration) *Buckets {
+	if interval <= 0 {
+		return NewBuckets(0, 1)
+	}
+	return NewBuckets(float64(time.Second)/float64(interval), 1)
+}
+
+// Wait implements Limiter, waiting for a token to be refilled if needed
+func (l *Buckets) Wait(ctx context.Context, key string) error {
+	delay := l.reserve(key)
+	if delay <= 0 {
+		return nil
+	}
+	return sleep(ctx, clock.Or(l.Clock), delay)
+}
+
+// Allow implements Limiter, taking a token only when one is available
+func (l *Buckets) Allow(_ context.Context, key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.refill(key)
+	if l.rate > 0 && b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// reserve takes a token, letting the bucket go negative, and returns how long
+// the caller has to wait for it; concurrent callers thus queue in arrival order
+func (l *Buckets) reserve(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.refill(key)
+	b.tokens--
+	if b.tokens >= 0 || l.rate <= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / l.rate * float64(time.Second))
+}
+
+// refill returns key's bucket topped up for the time passed; l.mu must be held.
+// A rate of 0 disables limiting.
+func (l *Buckets) refill(key string) *bucket {
+	now := clock.Or(l.Clock).Now()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	if l.rate > 0 {
+		b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	}
+	b.last = now
+
+	if len(l.buckets) > maxKeys {
+		for k, other := range l.buckets {
+			if other != b && other.tokens+now.Sub(other.last).Seconds()*l.rate >= l.burst {
+				delete(l.buckets, k)
+			}
+		}
+	}
+	return b
+}
+
+// Counter counts events per key in fixed windows; ydb.RateLimitStore satisfies it
+type Counter interface {
+	Increment(ctx context.Context, key string, windowStart time.Time, window time.Duration) (int, error)
+}
+
+// Shared is a Limiter backed by a Counter, limiting a key across all function
+// instances. Counter errors are logged and let the event through.
+type Shared struct {
+	counter Counter
+	limit   int
+	window  time.Duration
+
+	// Prefix namespaces the keys in the counter, e.g. "blablacar:"
+	Prefix string
+	// Clock tells the current window, the system clock if nil
+	Clock clock.Clock
+}
+
+// NewShared allows limit events per key in every window
+func NewShared(counter Counter, limit int, window time.Duration) *Shared {
+	return &Shared{counter: counter, limit: limit, window: window}
+}
+
+// Wait implements Limiter, waiting for the next window when the current one is full
+func (l *Shared) Wait(ctx context.Context, key string) error {
+	c := clock.Or(l.Clock)
+	for {
+		start := c.Now().Truncate(l.window)
+		if l.count(ctx, key, start) {
+			return nil
+		}
+		if err := sleep(ctx, c, start.Add(l.window).Sub(c.Now())); err != nil {
+			return err
+		}
+	}
+}
+
+// Allow implements Limiter; a refused event still counts in the window
+func (l *Shared) Allow(ctx context.Context, key string) bool {
+	return l.count(ctx, key, clock.Or(l.Clock).Now().Truncate(l.window))
+}
+
+// count counts an event in the window at start and reports whether it is within the limit
+func (l *Shared) count(ctx context.Context, key string, start time.Time) bool {
+	count, err := l.counter.Increment(ctx, l.Prefix+key, start, l.window)
+	if err != nil {
+		logger(ctx).Warn("Rate limit check failed, not throttling", "key", l.Prefix+key, "error", err)
+		return true
+	}
+	return count <= l.limit
+}
+
+// sleep waits for d, returning ctx's error when it is done first
+func sleep(ctx context.Context, c clock.Clock, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := c.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// logger returns the package's logger for ctx
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Component(ctx, "ratelimit")
+}
@@ -0,0 +1,98 @@
+// Package recovery turns panics in handlers, consumers and poller tasks into logged and
+// reported errors, so one bad update does not kill the whole invocation
+package recovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"sync"
+
+	"github.com/arseniisemenow/bbc-common/pkg/logging"
+)
+
+var ErrPanic = errors.New("panic")
+
+// PanicError is a recovered panic; errors.Is(err, ErrPanic) matches it
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Is reports whether target is ErrPanic
+func (e *PanicError) Is(target error) bool {
+	return target == ErrPanic
+}
+
+// Unwrap returns the panic value when it is an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Reporter is told about every recovered panic, e.g. AdminNotifier.ReportPanic
+type Reporter func(ctx context.Context, err *PanicError)
+
+var (
+	reporterMu sync.RWMutex
+	reporter   Reporter
+)
+
+// SetReporter sets the process-wide reporter of recovered panics, nil to only log them
+func SetReporter(r Reporter) {
+	reporterMu.Lock()
+	defer reporterMu.Unlock()
+	reporter = r
+}
+
+// Recover must be deferred directly; it recovers a panic, logs and reports it and
+// stores it in *errp when errp is not nil
+func Recover(ctx context.Context, errp *error) {
+	value := recover()
+	if value == nil {
+		return
+	}
+	err := &PanicError{Value: value, Stack: debug.Stack()}
+	logger(ctx).Error("Recovered panic", "error", err, "stack", string(err.Stack))
+
+	reporterMu.RLock()
+	report := reporter
+	reporterMu.RUnlock()
+	if report != nil {
+		report(ctx, err)
+	}
+	if errp != nil {
+		*errp = err
+	}
+}
+
+// Do calls fn, returning a panic in it as a *PanicError
+func Do(ctx context.Context, fn func() error) (err error) {
+	defer Recover(ctx, &err)
+	return fn()
+}
+
+// Middleware answers 500 Internal Server Error when next panics before writing a response
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := Do(r.Context(), func() error {
+			next.ServeHTTP(w, r)
+			return nil
+		})
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	})
+}
+
+// logger returns the package's logger for ctx
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Component(ctx, "recovery")
+}
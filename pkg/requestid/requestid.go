@@ -0,0 +1,65 @@
+// Package requestid carries a correlation ID through a request's context, so the logs of
+// one webhook call or poller cycle can be found across packages and services
+package requestid
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// Header carries the ID between services
+const Header = "X-Request-Id"
+
+type contextKey struct{}
+
+// New generates an ID
+func New() string {
+	return uuid.NewString()
+}
+
+// With returns ctx carrying id
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// From returns the ID carried by ctx, "" if none
+func From(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Ensure returns ctx with its ID, generating one at an entry point when ctx has none
+func Ensure(ctx context.Context) (context.Context, string) {
+	if id := From(ctx); id != "" {
+		return ctx, id
+	}
+	id := New()
+	return With(ctx, id), id
+}
+
+// Logf logs like log.Printf, appending the ID carried by ctx
+func Logf(ctx context.Context, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if id := From(ctx); id != "" {
+		msg += " request_id=" + id
+	}
+	log.Print(msg)
+}
+
+// Middleware takes the ID of incoming requests from Header, generating one if missing,
+// and echoes it in the response
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if id := r.Header.Get(Header); id != "" {
+			ctx = With(ctx, id)
+		}
+		ctx, id := Ensure(ctx)
+		w.Header().Set(Header, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,210 @@
+// Package retry runs operations again after transient failures, with exponential
+// backoff, optional jitter, an error classifier and server-requested delays
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/clock"
+)
+
+// Classifier reports whether an error is worth retrying
+type Classifier func(err error) bool
+
+// Hint returns the delay a server asked for before the next attempt, e.g. a Retry-After
+type Hint func(err error) (time.Duration, bool)
+
+// Hook is called before waiting for the given (1-based) retry
+type Hook func(ctx context.Context, retry int, err error, wait time.Duration)
+
+// Policy decides whether and when to retry. The zero value makes a single attempt;
+// build policies with New and derive variants with With.
+type Policy struct {
+	// MaxAttempts counts the first attempt, so 1 disables retrying
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry, doubling for each further one
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff and the accepted server hints, unlimited if 0
+	MaxDelay time.Duration
+	// Jitter picks each wait uniformly up to the backoff ("full jitter"), so clients
+	// failing together do not retry together
+	Jitter bool
+	// Retryable classifies errors, retrying all but context errors if nil
+	Retryable Classifier
+	// Hint overrides the backoff with a server-requested delay; a hint above MaxDelay
+	// ends retrying
+	Hint    Hint
+	OnRetry Hook
+	// Clock times the waits, the system clock if nil
+	Clock clock.Clock
+}
+
+// Option configures a Policy
+type Option func(*Policy)
+
+// New creates a policy from opts
+func New(opts ...Option) Policy {
+	var p Policy
+	return p.With(opts...)
+}
+
+// With returns a copy of p with opts applied
+func (p Policy) With(opts ...Option) Policy {
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return p
+}
+
+// MaxAttempts sets the number of attempts including the first
+func MaxAttempts(n int) Option {
+	return func(p *Policy) { p.MaxAttempts = n }
+}
+
+// Backoff sets exponential backoff from base, capped at max unless 0
+func Backoff(base, max time.Duration) Option {
+	return func(p *Policy) { p.BaseDelay, p.MaxDelay = base, max }
+}
+
+// Jitter randomizes the waits
+func Jitter() Option {
+	return func(p *Policy) { p.Jitter = true }
+}
+
+// If retries only errors matching all classifiers
+func If(classifiers ...Classifier) Option {
+	return func(p *Policy) {
+		prev := p.Retryable
+		p.Retryable = func(err error) bool {
+			if prev != nil && !prev(err) {
+				return false
+			}
+			for _, c := range classifiers {
+				if !c(err) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+}
+
+// WithHint sets the source of server-requested delays
+func WithHint(hint Hint) Option {
+	return func(p *Policy) { p.Hint = hint }
+}
+
+// OnRetry adds a hook, e.g. to log retries; hooks run in the order added
+func OnRetry(hook Hook) Option {
+	return func(p *Policy) {
+		prev := p.OnRetry
+		p.OnRetry = func(ctx context.Context, retry int, err error, wait time.Duration) {
+			if prev != nil {
+				prev(ctx, retry, err, wait)
+			}
+			hook(ctx, retry, err, wait)
+		}
+	}
+}
+
+// WithClock times the waits with c
+func WithClock(c clock.Clock) Option {
+	return func(p *Policy) { p.Clock = c }
+}
+
+// permanentError marks an error that must not be retried
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so Do returns it without retrying, whatever the classifier says;
+// Do unwraps it again
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Do runs fn until it succeeds, fails with an error the policy does not retry, the
+// attempts are used up or ctx ends while waiting; it returns the last error of fn
+func (p Policy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+		if attempt >= p.MaxAttempts || !p.retryable(err) {
+			return err
+		}
+
+		wait, ok := p.wait(err, attempt)
+		if !ok {
+			return err
+		}
+		if p.OnRetry != nil {
+			p.OnRetry(ctx, attempt, err, wait)
+		}
+		if !p.sleep(ctx, wait) {
+			return err
+		}
+	}
+}
+
+func (p Policy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// wait returns the delay before the given retry, false when a server hint is too long
+func (p Policy) wait(err error, retry int) (time.Duration, bool) {
+	if p.Hint != nil {
+		if hint, ok := p.Hint(err); ok && hint > 0 {
+			return hint, p.MaxDelay <= 0 || hint <= p.MaxDelay
+		}
+	}
+	return p.Delay(retry), true
+}
+
+// Delay returns the backoff before the given (1-based) retry
+func (p Policy) Delay(retry int) time.Duration {
+	d := p.BaseDelay << min(retry-1, 16)
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	if p.Jitter {
+		d = time.Duration(rand.Int63n(int64(d) + 1))
+	}
+	return d
+}
+
+// sleep waits for d, reporting false when ctx ends first
+func (p Policy) sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := clock.Or(p.Clock).NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
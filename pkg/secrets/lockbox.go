@@ -0,0 +1,175 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// LockboxEndpoint serves the payloads of Lockbox secrets
+	LockboxEndpoint = "https://payload.lockbox.api.cloud.yandex.net"
+	// MetadataTokenURL issues IAM tokens of the service account attached to the function or VM
+	MetadataTokenURL = "http://169.254.169.254/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+// TokenFunc returns an IAM token to authenticate with
+type TokenFunc func(ctx context.Context) (string, error)
+
+// Lockbox reads the entries of one Lockbox secret. The payload is fetched once and kept
+// for CacheTTL, for the life of the process if 0.
+type Lockbox struct {
+	HTTPClient *http.Client
+	Endpoint   string
+	SecretID   string
+	// VersionID pins a version, the current one if empty
+	VersionID string
+	Token     TokenFunc
+	CacheTTL  time.Duration
+
+	mu      sync.Mutex
+	entries map[string]string
+	fetched time.Time
+}
+
+// NewLockbox reads secretID authenticated with the metadata service's IAM token
+func NewLockbox(secretID string) *Lockbox {
+	client := &http.Client{Timeout: 10 * time.Second}
+	return &Lockbox{
+		HTTPClient: client,
+		Endpoint:   LockboxEndpoint,
+		SecretID:   secretID,
+		Token:      MetadataToken(client),
+	}
+}
+
+type lockboxPayload struct {
+	Entries []struct {
+		Key         string `json:"key"`
+		TextValue   string `json:"textValue"`
+		BinaryValue string `json:"binaryValue"`
+	} `json:"entries"`
+}
+
+// Secret implements Provider, looking name up among the entry keys
+func (l *Lockbox) Secret(ctx context.Context, name string) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.entries == nil || (l.CacheTTL > 0 && time.Since(l.fetched) >= l.CacheTTL) {
+		entries, err := l.fetch(ctx)
+		if err != nil {
+			return "", err
+		}
+		l.entries, l.fetched = entries, time.Now()
+	}
+	value, ok := l.entries[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s in lockbox secret %s", ErrNotFound, name, l.SecretID)
+	}
+	return value, nil
+}
+
+func (l *Lockbox) fetch(ctx context.Context) (map[string]string, error) {
+	token, err := l.Token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get IAM token: %w", err)
+	}
+
+	u := l.Endpoint + "/lockbox/v1/secrets/" + url.PathEscape(l.SecretID) + "/payload"
+	if l.VersionID != "" {
+		u += "?versionId=" + url.QueryEscape(l.VersionID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lockbox request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var payload lockboxPayload
+	if err := doJSON(l.HTTPClient, req, &payload); err != nil {
+		return nil, fmt.Errorf("failed to read lockbox secret %s: %w", l.SecretID, err)
+	}
+	entries := make(map[string]string, len(payload.Entries))
+	for _, entry := range payload.Entries {
+		value := entry.TextValue
+		if entry.BinaryValue != "" {
+			data, err := base64.StdEncoding.DecodeString(entry.BinaryValue)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode lockbox entry %s: %w", entry.Key, err)
+			}
+			value = string(data)
+		}
+		entries[entry.Key] = value
+	}
+	return entries, nil
+}
+
+// MetadataToken returns a TokenFunc fetching IAM tokens from the metadata service and
+// reusing each until shortly before it expires
+func MetadataToken(client *http.Client) TokenFunc {
+	var (
+		mu      sync.Mutex
+		token   string
+		expires time.Time
+	)
+	return func(ctx context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if token != "" && time.Now().Before(expires) {
+			return token, nil
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, MetadataTokenURL, nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Metadata-Flavor", "Google")
+		var resp struct {
+			AccessToken string `json:"access_token"`
+			ExpiresIn   int    `json:"expires_in"`
+		}
+		if err := doJSON(client, req, &resp); err != nil {
+			return "", err
+		}
+		token = resp.AccessToken
+		expires = time.Now().Add(time.Duration(resp.ExpiresIn)*time.Second - time.Minute)
+		return token, nil
+	}
+}
+
+// StaticToken returns a TokenFunc always returning token, e.g. the IAM token a function
+// receives in its invocation context
+func StaticToken(token string) TokenFunc {
+	return func(context.Context) (string, error) { return token, nil }
+}
+
+func doJSON(client *http.Client, req *http.Request, out interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		// error bodies carry no secret values
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, truncate(string(data), 200))
+	}
+	return json.Unmarshal(data, out)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
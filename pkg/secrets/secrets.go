@@ -0,0 +1,120 @@
+// Package secrets reads secret values such as the bot token from the environment, files
+// or Yandex Lockbox
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EnvLockboxSecretID names the Lockbox secret FromEnv reads
+const EnvLockboxSecretID = "LOCKBOX_SECRET_ID"
+
+var ErrNotFound = errors.New("secret not found")
+
+// Provider returns the secret stored under name, ErrNotFound if there is none
+type Provider interface {
+	Secret(ctx context.Context, name string) (string, error)
+}
+
+// ProviderFunc adapts a function to Provider
+type ProviderFunc func(ctx context.Context, name string) (string, error)
+
+// Secret calls f
+func (f ProviderFunc) Secret(ctx context.Context, name string) (string, error) {
+	return f(ctx, name)
+}
+
+// Env reads secrets from environment variables of the same name
+type Env struct{}
+
+// Secret implements Provider
+func (Env) Secret(_ context.Context, name string) (string, error) {
+	if value, ok := os.LookupEnv(name); ok && value != "" {
+		return value, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+// File reads secrets from files named after them in Dir, e.g. a mounted secrets volume;
+// a trailing newline is dropped
+type File struct {
+	Dir string
+}
+
+// Secret implements Provider
+func (f File) Secret(_ context.Context, name string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(f.Dir, filepath.Base(name)))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %s: %w", name, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// Chain asks providers in order, returning the first secret found
+type Chain []Provider
+
+// Secret implements Provider
+func (c Chain) Secret(ctx context.Context, name string) (string, error) {
+	for _, p := range c {
+		value, err := p.Secret(ctx, name)
+		if !errors.Is(err, ErrNotFound) {
+			return value, err
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+// Cache remembers the secrets of a provider for a TTL, forever if 0, so a cold start
+// fetches each secret once
+type Cache struct {
+	provider Provider
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	value   string
+	fetched time.Time
+}
+
+// NewCache caches the secrets of provider for ttl
+func NewCache(provider Provider, ttl time.Duration) *Cache {
+	return &Cache{provider: provider, ttl: ttl, entries: make(map[string]cacheEntry)}
+}
+
+// Secret implements Provider; failures are not cached
+func (c *Cache) Secret(ctx context.Context, name string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.entries[name]; ok && (c.ttl <= 0 || time.Since(entry.fetched) < c.ttl) {
+		return entry.value, nil
+	}
+	value, err := c.provider.Secret(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	c.entries[name] = cacheEntry{value: value, fetched: time.Now()}
+	return value, nil
+}
+
+// FromEnv returns the environment, followed by the Lockbox secret in LOCKBOX_SECRET_ID
+// when set, authenticated as the function's service account
+func FromEnv() Provider {
+	chain := Chain{Env{}}
+	if id := os.Getenv(EnvLockboxSecretID); id != "" {
+		chain = append(chain, NewLockbox(id))
+	}
+	return chain
+}
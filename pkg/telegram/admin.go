@@ -0,0 +1,180 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/config"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+	"github.com/arseniisemenow/bbc-common/pkg/recovery"
+)
+
+// AlertLevel is the severity of an admin alert
+type AlertLevel string
+
+const (
+	AlertInfo    AlertLevel = "info"
+	AlertWarning AlertLevel = "warning"
+	AlertError   AlertLevel = "error"
+)
+
+var alertIcons = map[AlertLevel]string{
+	AlertInfo:    "ℹ️",
+	AlertWarning: "⚠️",
+	AlertError:   "🚨",
+}
+
+// DefaultAlertDedupWindow suppresses identical alerts sent within this period
+const DefaultAlertDedupWindow = 10 * time.Minute
+
+// AdminNotifier sends operational alerts to an admin chat or channel,
+// collapsing repeated alerts with the same level and title
+type AdminNotifier struct {
+	sender BotSender
+	chatID int64
+	window time.Duration
+
+	// Service is included in every alert to tell deployments apart
+	Service string
+
+	mu         sync.Mutex
+	lastSent   map[string]time.Time
+	suppressed map[string]int
+}
+
+// NewAdminNotifier creates a notifier posting to chatID
+func NewAdminNotifier(sender BotSender, chatID int64, dedupWindow time.Duration) *AdminNotifier {
+	if dedupWindow <= 0 {
+		dedupWindow = DefaultAlertDedupWindow
+	}
+	return &AdminNotifier{
+		sender:     sender,
+		chatID:     chatID,
+		window:     dedupWindow,
+		lastSent:   make(map[string]time.Time),
+		suppressed: make(map[string]int),
+	}
+}
+
+// NewAdminNotifierFromEnv creates a notifier posting to TELEGRAM_ADMIN_CHAT_ID
+func NewAdminNotifierFromEnv(sender BotSender) (*AdminNotifier, error) {
+	cfg, err := config.LoadTelegram()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.AdminChatID == 0 {
+		return nil, fmt.Errorf("%s: %w", config.EnvTelegramAdminChatID, config.ErrMissing)
+	}
+	return NewAdminNotifier(sender, cfg.AdminChatID, DefaultAlertDedupWindow), nil
+}
+
+// Notify sends an alert unless an identical one was sent within the dedup window. The
+// window is reserved before sending, so concurrent identical alerts send once; a failed
+// send releases it, so the alert is sent again on the next call.
+func (n *AdminNotifier) Notify(ctx context.Context, level AlertLevel, title, details string) error {
+	key := string(level) + "|" + title
+	now := time.Now()
+
+	n.mu.Lock()
+	prev, hadPrev := n.lastSent[key]
+	if hadPrev && now.Sub(prev) < n.window {
+		n.suppressed[key]++
+		n.mu.Unlock()
+		return nil
+	}
+	n.lastSent[key] = now
+	suppressed := n.suppressed[key]
+	delete(n.suppressed, key)
+	n.mu.Unlock()
+
+	var opts []SendOption
+	if level == AlertInfo {
+		opts = append(opts, Silent())
+	}
+	err := n.sender.SendPlainMessage(n.chatID, n.format(level, title, details, suppressed), opts...)
+	if err == nil {
+		return nil
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.lastSent[key].Equal(now) {
+		if hadPrev {
+			n.lastSent[key] = prev
+		} else {
+			delete(n.lastSent, key)
+		}
+	}
+	// the alerts this one carried are reported with the next one, together with any
+	// suppressed while it was being sent
+	n.suppressed[key] += suppressed
+	if n.suppressed[key] == 0 {
+		delete(n.suppressed, key)
+	}
+	return err
+}
+
+// Error reports err under title
+func (n *AdminNotifier) Error(ctx context.Context, title string, err error) error {
+	return n.Notify(ctx, AlertError, title, err.Error())
+}
+
+// Warning reports a warning
+func (n *AdminNotifier) Warning(ctx context.Context, title, details string) error {
+	return n.Notify(ctx, AlertWarning, title, details)
+}
+
+// Info reports an operational event without a notification sound
+func (n *AdminNotifier) Info(ctx context.Context, title, details string) error {
+	return n.Notify(ctx, AlertInfo, title, details)
+}
+
+// DeadLetterHook returns an Outbox.OnDeadLetter hook warning about every message given up on;
+// the dedup window keeps an outage from flooding the admin chat
+func (n *AdminNotifier) DeadLetterHook() func(ctx context.Context, msg models.OutboxMessage, err error) {
+	return func(ctx context.Context, msg models.OutboxMessage, err error) {
+		details := fmt.Sprintf("chat %d, %d attempts: %v\nid %s", msg.TelegramChatID, msg.Attempts, err, msg.ID)
+		if notifyErr := n.Warning(ctx, "Outbox message dead-lettered", details); notifyErr != nil {
+			logger(ctx).Error("Failed to report dead letter", "outbox_id", msg.ID, "error", notifyErr)
+		}
+	}
+}
+
+// ReportPanic alerts about a recovered panic with the top of its stack; pass it to
+// recovery.SetReporter
+func (n *AdminNotifier) ReportPanic(ctx context.Context, err *recovery.PanicError) {
+	stack := string(err.Stack)
+	if len(stack) > 1500 {
+		stack = stack[:1500] + "..."
+	}
+	if notifyErr := n.Error(ctx, "Panic recovered", fmt.Errorf("%w\n%s", err, stack)); notifyErr != nil {
+		logger(ctx).Error("Failed to report panic", "error", notifyErr)
+	}
+}
+
+func (n *AdminNotifier) format(level AlertLevel, title, details string, suppressed int) string {
+	var sb strings.Builder
+	sb.WriteString(alertIcons[level])
+	sb.WriteString(" ")
+	sb.WriteString(strings.ToUpper(string(level)))
+	if n.Service != "" {
+		sb.WriteString(" [")
+		sb.WriteString(n.Service)
+		sb.WriteString("]")
+	}
+	sb.WriteString("\n")
+	sb.WriteString(title)
+	if details != "" {
+		sb.WriteString("\n\n")
+		sb.WriteString(details)
+	}
+	if suppressed > 0 {
+		sb.WriteString(fmt.Sprintf("\n\n(%d similar alerts suppressed)", suppressed))
+	}
+	sb.WriteString("\n")
+	sb.WriteString(time.Now().UTC().Format(time.RFC3339))
+	return sb.String()
+}
@@ -0,0 +1,120 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/arseniisemenow/bbc-common/pkg/logging"
+)
+
+// BroadcastStatus is the outcome of a broadcast for a single chat
+type BroadcastStatus string
+
+const (
+	BroadcastSent    BroadcastStatus = "sent"
+	BroadcastBlocked BroadcastStatus = "blocked"
+	BroadcastFailed  BroadcastStatus = "failed"
+)
+
+// BroadcastMessage is the content sent to every chat
+type BroadcastMessage struct {
+	Text     string
+	Keyboard interface{}
+	Options  []SendOption
+}
+
+// BroadcastResult records what happened for one chat
+type BroadcastResult struct {
+	ChatID    int64
+	Status    BroadcastStatus
+	MessageID int
+	Err       error
+}
+
+// BroadcastReport summarizes a broadcast run
+type BroadcastReport struct {
+	Results []BroadcastResult
+	Sent    int
+	Blocked int
+	Failed  int
+	// Checkpoint is the last processed chat ID; pass it to Resume to continue an interrupted run
+	Checkpoint int64
+}
+
+// Broadcaster sends one message to many chats
+type Broadcaster struct {
+	sender  BotSender
+	limiter *Limiter
+
+	// OnProgress is called after each chat with the number of processed and total chats
+	OnProgress func(done, total int, last BroadcastResult)
+}
+
+// NewBroadcaster creates a broadcaster; limiter may be nil when sender already throttles
+func NewBroadcaster(sender BotSender, limiter *Limiter) *Broadcaster {
+	return &Broadcaster{sender: sender, limiter: limiter}
+}
+
+// Broadcast sends msg to all chats in ascending chat ID order. When ctx is cancelled
+// the partial report is returned together with ctx.Err().
+func (b *Broadcaster) Broadcast(ctx context.Context, chatIDs []int64, msg BroadcastMessage) (*BroadcastReport, error) {
+	return b.broadcast(ctx, chatIDs, msg, nil)
+}
+
+// Resume continues a broadcast after checkpoint, skipping chats that were already processed
+func (b *Broadcaster) Resume(ctx context.Context, chatIDs []int64, msg BroadcastMessage, checkpoint int64) (*BroadcastReport, error) {
+	return b.broadcast(ctx, chatIDs, msg, &checkpoint)
+}
+
+func (b *Broadcaster) broadcast(ctx context.Context, chatIDs []int64, msg BroadcastMessage, checkpoint *int64) (*BroadcastReport, error) {
+	pending := slices.Clone(chatIDs)
+	slices.Sort(pending)
+	pending = slices.Compact(pending)
+	if checkpoint != nil {
+		idx, found := slices.BinarySearch(pending, *checkpoint)
+		if found {
+			idx++
+		}
+		pending = pending[idx:]
+	}
+
+	report := &BroadcastReport{}
+	if checkpoint != nil {
+		report.Checkpoint = *checkpoint
+	}
+
+	for i, chatID := range pending {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if b.limiter != nil {
+			if err := b.limiter.Wait(ctx, chatID); err != nil {
+				return report, err
+			}
+		}
+
+		result := BroadcastResult{ChatID: chatID, Status: BroadcastSent}
+		result.MessageID, result.Err = b.sender.SendMessageWithKeyboard(chatID, msg.Text, msg.Keyboard, msg.Options...)
+		switch {
+		case result.Err == nil:
+			report.Sent++
+		case errors.Is(result.Err, ErrBotBlocked), errors.Is(result.Err, ErrChatNotFound):
+			result.Status = BroadcastBlocked
+			report.Blocked++
+		default:
+			result.Status = BroadcastFailed
+			report.Failed++
+			logger(ctx).Warn("Broadcast to chat failed", logging.ChatIDKey, chatID, "error", result.Err)
+		}
+
+		report.Results = append(report.Results, result)
+		report.Checkpoint = chatID
+		if b.OnProgress != nil {
+			b.OnProgress(i+1, len(pending), result)
+		}
+	}
+
+	logger(ctx).Info("Broadcast finished", "sent", report.Sent, "blocked", report.Blocked, "failed", report.Failed)
+	return report, nil
+}
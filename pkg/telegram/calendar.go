@@ -0,0 +1,169 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/arseniisemenow/bbc-common/pkg/clock"
+)
+
+const (
+	calendarDateLayout  = "2006-01-02"
+	calendarMonthLayout = "2006-01"
+
+	calendarPick  = "d"
+	calendarMonth = "m"
+	calendarNoop  = "x"
+)
+
+// Calendar renders a month-view date picker as an inline keyboard.
+// Callback data is "<action>:d:YYYY-MM-DD" for a picked day,
+// "<action>:m:YYYY-MM" for month navigation and "<action>:x" for inert cells.
+type Calendar struct {
+	action string
+
+	// Min and Max bound the selectable dates (inclusive); zero values mean today and unbounded
+	Min time.Time
+	Max time.Time
+	// Location is used to determine "today"; defaults to UTC
+	Location *time.Location
+}
+
+// CalendarEvent is the decoded result of a calendar callback
+type CalendarEvent struct {
+	// Picked is set when the user chose Date; otherwise Month should be displayed (if non-zero)
+	Picked bool
+	Date   time.Time
+	Month  time.Time
+}
+
+// NewCalendar creates a calendar whose callbacks use the given action
+func NewCalendar(action string) *Calendar {
+	return &Calendar{action: action}
+}
+
+// Keyboard renders the month containing month; today is read from clock.From(ctx)
+func (c *Calendar) Keyboard(ctx context.Context, month time.Time) tba.InlineKeyboardMarkup {
+	minDate, maxDate := c.bounds(ctx)
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	noop := CreateCallbackData(c.action, calendarNoop)
+
+	kb := NewKeyboard().Row().Button(first.Format("January 2006"), noop).Row()
+	for _, day := range []string{"Mo", "Tu", "We", "Th", "Fr", "Sa", "Su"} {
+		kb.Button(day, noop)
+	}
+
+	offset := (int(first.Weekday()) + 6) % 7 // Monday-first
+	kb.Row()
+	for i := 0; i < offset; i++ {
+		kb.Button(" ", noop)
+	}
+	for day := first; day.Month() == first.Month(); day = day.AddDate(0, 0, 1) {
+		if (day.Weekday() == time.Monday) && day.Day() != 1 {
+			kb.Row()
+		}
+		if day.Before(minDate) || (!maxDate.IsZero() && day.After(maxDate)) {
+			kb.Button("·", noop)
+			continue
+		}
+		kb.Button(strconv.Itoa(day.Day()), CreateCallbackData(c.action, calendarPick, day.Format(calendarDateLayout)))
+	}
+	last := first.AddDate(0, 1, -1)
+	for i := (int(last.Weekday()) + 6) % 7; i < 6; i++ {
+		kb.Button(" ", noop)
+	}
+
+	prev := first.AddDate(0, -1, 0)
+	next := first.AddDate(0, 1, 0)
+	kb.Row()
+	if !prev.AddDate(0, 1, -1).Before(minDate) {
+		kb.Button("◀", CreateCallbackData(c.action, calendarMonth, prev.Format(calendarMonthLayout)))
+	} else {
+		kb.Button(" ", noop)
+	}
+	if maxDate.IsZero() || !next.After(maxDate) {
+		kb.Button("▶", CreateCallbackData(c.action, calendarMonth, next.Format(calendarMonthLayout)))
+	} else {
+		kb.Button(" ", noop)
+	}
+
+	return kb.Build()
+}
+
+// Parse decodes callback params (as passed by CallbackRouter) into an event
+func (c *Calendar) Parse(ctx context.Context, params []string) (CalendarEvent, error) {
+	if len(params) == 0 {
+		return CalendarEvent{}, fmt.Errorf("empty calendar callback")
+	}
+
+	switch params[0] {
+	case calendarNoop:
+		return CalendarEvent{}, nil
+	case calendarPick, calendarMonth:
+		if len(params) < 2 {
+			return CalendarEvent{}, fmt.Errorf("calendar callback %q misses its value", params[0])
+		}
+	default:
+		return CalendarEvent{}, fmt.Errorf("unknown calendar callback %q", params[0])
+	}
+
+	if params[0] == calendarMonth {
+		month, err := time.Parse(calendarMonthLayout, params[1])
+		if err != nil {
+			return CalendarEvent{}, fmt.Errorf("invalid calendar month %q: %w", params[1], err)
+		}
+		return CalendarEvent{Month: month}, nil
+	}
+
+	date, err := time.Parse(calendarDateLayout, params[1])
+	if err != nil {
+		return CalendarEvent{}, fmt.Errorf("invalid calendar date %q: %w", params[1], err)
+	}
+	minDate, maxDate := c.bounds(ctx)
+	if date.Before(minDate) || (!maxDate.IsZero() && date.After(maxDate)) {
+		return CalendarEvent{}, fmt.Errorf("date %s is outside the allowed range", params[1])
+	}
+	return CalendarEvent{Picked: true, Date: date}, nil
+}
+
+// Handler returns a callback handler that flips months in place and calls onPick for a chosen date
+func (c *Calendar) Handler(sender BotSender, onPick func(ctx context.Context, query *tba.CallbackQuery, date time.Time) error) CallbackHandlerFunc {
+	return func(ctx context.Context, query *tba.CallbackQuery, params []string) error {
+		event, err := c.Parse(ctx, params)
+		if err != nil {
+			return err
+		}
+		switch {
+		case event.Picked:
+			return onPick(ctx, query, event.Date)
+		case !event.Month.IsZero() && query.Message != nil:
+			return sender.EditMessageKeyboard(query.Message.Chat.ID, query.Message.MessageID, c.Keyboard(ctx, event.Month))
+		default:
+			return nil
+		}
+	}
+}
+
+// bounds returns the selectable range as UTC midnights
+func (c *Calendar) bounds(ctx context.Context) (time.Time, time.Time) {
+	loc := c.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	minDate := c.Min
+	if minDate.IsZero() {
+		minDate = clock.From(ctx).Now().In(loc)
+	}
+	minDate = time.Date(minDate.Year(), minDate.Month(), minDate.Day(), 0, 0, 0, 0, time.UTC)
+
+	maxDate := c.Max
+	if !maxDate.IsZero() {
+		maxDate = time.Date(maxDate.Year(), maxDate.Month(), maxDate.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return minDate, maxDate
+}
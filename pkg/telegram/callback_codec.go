@@ -0,0 +1,211 @@
+package telegram
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// MaxCallbackDataSize is Telegram's limit for callback_data in bytes
+const MaxCallbackDataSize = 64
+
+const (
+	callbackSeparator    = "|"
+	callbackSignatureLen = 6 // bytes of HMAC kept, 8 chars once base64-encoded
+)
+
+var (
+	ErrCallbackDataTooLong   = errors.New("callback data exceeds 64 bytes")
+	ErrCallbackMalformed     = errors.New("malformed callback data")
+	ErrCallbackBadSignature  = errors.New("callback data signature mismatch")
+	ErrCallbackVersionTooNew = errors.New("callback data version is newer than supported")
+)
+
+var callbackEscaper = strings.NewReplacer("%", "%25", "|", "%7C")
+var callbackUnescaper = strings.NewReplacer("%7C", "|", "%25", "%")
+
+// CallbackCodec encodes structs into compact callback data.
+//
+// Fields take part in the encoding when they carry a `cb` tag and are written
+// positionally, so the tag value is only documentation. A `since=N` option
+// marks fields added in schema version N:
+//
+//	type DeleteSub struct {
+//		SubID string `cb:"id"`
+//		Page  int    `cb:"page,since=2"`
+//	}
+//
+// The encoded form is "action|version|field1|field2[|signature]", so actions
+// may keep using the "sub:delete" style understood by ParseCallbackData.
+type CallbackCodec struct {
+	secret         []byte
+	currentVersion int
+}
+
+// NewCallbackCodec creates a codec writing the given schema version;
+// a non-empty secret enables HMAC signing
+func NewCallbackCodec(version int, secret []byte) *CallbackCodec {
+	return &CallbackCodec{secret: secret, currentVersion: version}
+}
+
+// Version returns the schema version the codec writes
+func (c *CallbackCodec) Version() int {
+	return c.currentVersion
+}
+
+// Encode serializes v under action and fails if the result exceeds MaxCallbackDataSize
+func (c *CallbackCodec) Encode(action string, v any) (string, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return "", fmt.Errorf("callback payload must be a struct, got %T", v)
+	}
+
+	parts := []string{callbackEscaper.Replace(action), strconv.Itoa(c.currentVersion)}
+	for _, f := range callbackFields(rv.Type()) {
+		if f.since > c.currentVersion {
+			continue
+		}
+		s, err := formatCallbackValue(rv.Field(f.index))
+		if err != nil {
+			return "", fmt.Errorf("failed to encode field %s: %w", f.name, err)
+		}
+		parts = append(parts, callbackEscaper.Replace(s))
+	}
+
+	data := strings.Join(parts, callbackSeparator)
+	if len(c.secret) > 0 {
+		data += callbackSeparator + c.sign(data)
+	}
+	if len(data) > MaxCallbackDataSize {
+		return "", fmt.Errorf("%w: %d bytes for action %q", ErrCallbackDataTooLong, len(data), action)
+	}
+	return data, nil
+}
+
+// Decode parses data into v and returns the action and the schema version it was written with.
+// Fields introduced after that version are left at their zero value.
+func (c *CallbackCodec) Decode(data string, v any) (action string, version int, err error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return "", 0, fmt.Errorf("callback target must be a pointer to struct, got %T", v)
+	}
+	rv = rv.Elem()
+
+	if len(c.secret) > 0 {
+		idx := strings.LastIndex(data, callbackSeparator)
+		if idx < 0 {
+			return "", 0, ErrCallbackMalformed
+		}
+		if !hmac.Equal([]byte(data[idx+1:]), []byte(c.sign(data[:idx]))) {
+			return "", 0, ErrCallbackBadSignature
+		}
+		data = data[:idx]
+	}
+
+	parts := strings.Split(data, callbackSeparator)
+	if len(parts) < 2 {
+		return "", 0, ErrCallbackMalformed
+	}
+	action = callbackUnescaper.Replace(parts[0])
+	version, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: bad version %q", ErrCallbackMalformed, parts[1])
+	}
+	if version > c.currentVersion {
+		return "", 0, fmt.Errorf("%w: got %d, support up to %d", ErrCallbackVersionTooNew, version, c.currentVersion)
+	}
+
+	values := parts[2:]
+	for _, f := range callbackFields(rv.Type()) {
+		if f.since > version {
+			continue
+		}
+		if len(values) == 0 {
+			return "", 0, fmt.Errorf("%w: missing field %s", ErrCallbackMalformed, f.name)
+		}
+		if err := parseCallbackValue(rv.Field(f.index), callbackUnescaper.Replace(values[0])); err != nil {
+			return "", 0, fmt.Errorf("failed to decode field %s: %w", f.name, err)
+		}
+		values = values[1:]
+	}
+
+	return action, version, nil
+}
+
+func (c *CallbackCodec) sign(data string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:callbackSignatureLen])
+}
+
+type callbackField struct {
+	index int
+	name  string
+	since int
+}
+
+func callbackFields(t reflect.Type) []callbackField {
+	var fields []callbackField
+	for i := 0; i < t.NumField(); i++ {
+		tag, ok := t.Field(i).Tag.Lookup("cb")
+		if !ok || tag == "-" {
+			continue
+		}
+		opts := strings.Split(tag, ",")
+		f := callbackField{index: i, name: opts[0]}
+		for _, opt := range opts[1:] {
+			if n, found := strings.CutPrefix(opt, "since="); found {
+				f.since, _ = strconv.Atoi(n)
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func formatCallbackValue(v reflect.Value) (string, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		if v.Bool() {
+			return "1", nil
+		}
+		return "0", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 36), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 36), nil
+	default:
+		return "", fmt.Errorf("unsupported kind %s", v.Kind())
+	}
+}
+
+func parseCallbackValue(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		v.SetBool(s == "1")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 36, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 36, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	default:
+		return fmt.Errorf("unsupported kind %s", v.Kind())
+	}
+	return nil
+}
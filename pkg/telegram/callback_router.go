@@ -0,0 +1,103 @@
+package telegram
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/arseniisemenow/bbc-common/pkg/recovery"
+)
+
+// CallbackHandlerFunc handles a callback query; params are the ":"-separated
+// parts of the callback data following the matched action
+type CallbackHandlerFunc func(ctx context.Context, query *tba.CallbackQuery, params []string) error
+
+type callbackRoute struct {
+	action  string
+	handler CallbackHandlerFunc
+}
+
+// CallbackRouter dispatches callback queries by action prefix and answers them automatically
+type CallbackRouter struct {
+	sender         BotSender
+	routes         []callbackRoute
+	defaultHandler CallbackHandlerFunc
+
+	// ErrorText is shown to the user when a handler fails
+	ErrorText string
+}
+
+// NewCallbackRouter creates a router answering queries through sender
+func NewCallbackRouter(sender BotSender) *CallbackRouter {
+	return &CallbackRouter{sender: sender, ErrorText: "Something went wrong, please try again"}
+}
+
+// Handle registers a handler for an action such as "sub:delete"; the longest matching action wins
+func (cr *CallbackRouter) Handle(action string, handler CallbackHandlerFunc) {
+	cr.routes = append(cr.routes, callbackRoute{action: action, handler: handler})
+	sort.SliceStable(cr.routes, func(i, j int) bool {
+		return len(cr.routes[i].action) > len(cr.routes[j].action)
+	})
+}
+
+// HandleDefault registers a handler for queries matching no action
+func (cr *CallbackRouter) HandleDefault(handler CallbackHandlerFunc) {
+	cr.defaultHandler = handler
+}
+
+// Dispatch routes a callback query to its handler and answers it. Stored callback tokens
+// are expanded by Router.UseCallbackStore before the query gets here, so query.Data is
+// matched as is.
+func (cr *CallbackRouter) Dispatch(ctx context.Context, query *tba.CallbackQuery) error {
+	handler, params := cr.match(query.Data)
+	if handler == nil {
+		logger(ctx).Warn("No callback handler", "data", query.Data)
+		cr.answer(ctx, query.ID, "")
+		return nil
+	}
+
+	if err := recovery.Do(ctx, func() error { return handler(ctx, query, params) }); err != nil {
+		cr.answer(ctx, query.ID, cr.ErrorText)
+		return err
+	}
+	cr.answer(ctx, query.ID, "")
+	return nil
+}
+
+// HandlerFunc adapts the router for use with Router.HandleCallbacks
+func (cr *CallbackRouter) HandlerFunc() HandlerFunc {
+	return func(ctx context.Context, update *tba.Update) error {
+		if update.CallbackQuery == nil {
+			return nil
+		}
+		return cr.Dispatch(ctx, update.CallbackQuery)
+	}
+}
+
+func (cr *CallbackRouter) match(data string) (CallbackHandlerFunc, []string) {
+	for _, route := range cr.routes {
+		if data == route.action {
+			return route.handler, nil
+		}
+		if rest, ok := strings.CutPrefix(data, route.action+":"); ok {
+			return route.handler, strings.Split(rest, ":")
+		}
+		if strings.HasPrefix(data, route.action+callbackSeparator) {
+			// CallbackCodec payload: the handler decodes query.Data itself
+			return route.handler, nil
+		}
+	}
+	if cr.defaultHandler != nil {
+		_, params := ParseCallbackData(data)
+		return cr.defaultHandler, params
+	}
+	return nil, nil
+}
+
+func (cr *CallbackRouter) answer(ctx context.Context, queryID, text string) {
+	if err := cr.sender.AnswerCallbackQuery(queryID, text); err != nil {
+		logger(ctx).Warn("Failed to answer callback query", "query_id", queryID, "error", err)
+	}
+}
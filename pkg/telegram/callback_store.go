@@ -0,0 +1,74 @@
+package telegram
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// callbackTokenPrefix marks callback data that is a reference into a CallbackStore
+const callbackTokenPrefix = "~"
+
+// PayloadStore persists values with an expiry; *ydb.KVStore satisfies it
+type PayloadStore interface {
+	Put(ctx context.Context, key, value string, ttl time.Duration) error
+	Get(ctx context.Context, key string) (string, error)
+}
+
+// CallbackStore swaps callback payloads that exceed MaxCallbackDataSize for short tokens
+type CallbackStore struct {
+	store PayloadStore
+	ttl   time.Duration
+}
+
+// NewCallbackStore creates a store keeping oversized payloads for ttl
+func NewCallbackStore(store PayloadStore, ttl time.Duration) *CallbackStore {
+	return &CallbackStore{store: store, ttl: ttl}
+}
+
+// Shrink returns data unchanged when it fits into callback_data, otherwise
+// persists it and returns a token that Resolve turns back into data
+func (cs *CallbackStore) Shrink(ctx context.Context, data string) (string, error) {
+	if len(data) <= MaxCallbackDataSize && !strings.HasPrefix(data, callbackTokenPrefix) {
+		return data, nil
+	}
+
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate callback token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	if err := cs.store.Put(ctx, token, data, cs.ttl); err != nil {
+		return "", fmt.Errorf("failed to store callback payload: %w", err)
+	}
+	return callbackTokenPrefix + token, nil
+}
+
+// Resolve expands a token produced by Shrink; other data is returned unchanged
+func (cs *CallbackStore) Resolve(ctx context.Context, data string) (string, error) {
+	token, ok := strings.CutPrefix(data, callbackTokenPrefix)
+	if !ok {
+		return data, nil
+	}
+
+	payload, err := cs.store.Get(ctx, token)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve callback token %s: %w", token, err)
+	}
+	return payload, nil
+}
+
+// Cleanup removes expired payloads when the underlying store supports it
+func (cs *CallbackStore) Cleanup(ctx context.Context) error {
+	cleaner, ok := cs.store.(interface {
+		DeleteExpired(ctx context.Context) error
+	})
+	if !ok {
+		return nil
+	}
+	return cleaner.DeleteExpired(ctx)
+}
@@ -0,0 +1,42 @@
+package telegram
+
+import (
+	"context"
+	"time"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/arseniisemenow/bbc-common/pkg/logging"
+)
+
+// typingRefreshInterval keeps the indicator alive; Telegram clears it after about 5 seconds
+const typingRefreshInterval = 4 * time.Second
+
+// SendChatAction shows a chat action such as tba.ChatTyping to the user
+func (bc *BotClient) SendChatAction(chatID int64, action string) error {
+	return bc.request(tba.NewChatAction(chatID, action))
+}
+
+// WithTyping runs fn while keeping the "typing…" indicator visible in the chat
+func (bc *BotClient) WithTyping(ctx context.Context, chatID int64, fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		ticker := time.NewTicker(typingRefreshInterval)
+		defer ticker.Stop()
+		for {
+			if err := bc.SendChatAction(chatID, tba.ChatTyping); err != nil {
+				logger(ctx).Warn("Failed to send typing action", logging.ChatIDKey, chatID, "error", err)
+				return
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return fn(ctx)
+}
@@ -0,0 +1,117 @@
+package telegram
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/arseniisemenow/bbc-common/pkg/analytics"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// Click actions, recorded in the models.PropAction property of click events
+const (
+	ClickOpen = "open"
+	ClickBook = "book"
+)
+
+// TrackClicks wraps the handler of a notification button whose callback data carries
+// the notification ID as first parameter, e.g. "trip_book:<notification ID>:<trip ID>",
+// recording a models.EventNotificationClicked before next runs and the router answers
+func TrackClicks(emitter *analytics.Emitter, action string, next CallbackHandlerFunc) CallbackHandlerFunc {
+	return func(ctx context.Context, query *tba.CallbackQuery, params []string) error {
+		props := map[string]any{models.PropAction: action}
+		if len(params) > 0 {
+			props[models.PropNotificationID] = params[0]
+		}
+		if len(params) > 1 {
+			props[models.PropTripID] = params[1]
+		}
+		var chatID int64
+		if query.Message != nil {
+			chatID = query.Message.Chat.ID
+		} else if query.From != nil {
+			chatID = query.From.ID
+		}
+		emitter.Track(ctx, models.EventNotificationClicked, chatID, props)
+		return next(ctx, query, params)
+	}
+}
+
+// clickSignatureLen is the number of HMAC bytes kept in tracked links
+const clickSignatureLen = 12
+
+// ClickRedirect serves tracked links: it records a models.EventNotificationClicked and
+// redirects to the link's target. Links are signed, so it cannot be abused as an open
+// redirect. Mount it on a public URL and use Button for the URL buttons of notifications.
+type ClickRedirect struct {
+	emitter *analytics.Emitter
+	baseURL string
+	secret  []byte
+}
+
+// NewClickRedirect creates a redirect served at baseURL, signing links with secret
+func NewClickRedirect(emitter *analytics.Emitter, baseURL string, secret []byte) *ClickRedirect {
+	return &ClickRedirect{emitter: emitter, baseURL: baseURL, secret: secret}
+}
+
+// URL returns a tracked link to target for a click on notif
+func (c *ClickRedirect) URL(notif models.Notification, action, target string) string {
+	q := url.Values{
+		"n": {notif.ID},
+		"c": {strconv.FormatInt(notif.TelegramChatID, 10)},
+		"t": {notif.TripID},
+		"a": {action},
+		"u": {target},
+	}
+	q.Set("s", c.sign(q))
+	sep := "?"
+	if strings.Contains(c.baseURL, "?") {
+		sep = "&"
+	}
+	return c.baseURL + sep + q.Encode()
+}
+
+// Button returns a URL button opening target through a tracked link
+func (c *ClickRedirect) Button(text string, notif models.Notification, action, target string) tba.InlineKeyboardButton {
+	return tba.NewInlineKeyboardButtonURL(text, c.URL(notif, action, target))
+}
+
+// ServeHTTP records the click and redirects; links with a bad signature get 400
+func (c *ClickRedirect) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	target := q.Get("u")
+	if !hmac.Equal([]byte(q.Get("s")), []byte(c.sign(q))) || !isHTTPURL(target) {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	chatID, _ := strconv.ParseInt(q.Get("c"), 10, 64)
+	c.emitter.Track(r.Context(), models.EventNotificationClicked, chatID, map[string]any{
+		models.PropNotificationID: q.Get("n"),
+		models.PropTripID:         q.Get("t"),
+		models.PropAction:         q.Get("a"),
+	})
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// sign returns the signature of the link parameters, all but "s"
+func (c *ClickRedirect) sign(q url.Values) string {
+	mac := hmac.New(sha256.New, c.secret)
+	for _, key := range []string{"n", "c", "t", "a", "u"} {
+		mac.Write([]byte(q.Get(key)))
+		mac.Write([]byte{0})
+	}
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:clickSignatureLen])
+}
+
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
@@ -0,0 +1,67 @@
+package telegram
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Command describes an entry of the bot's command menu
+type Command struct {
+	// Name is the command without the leading slash, e.g. "subscribe"
+	Name string
+	// Description is used when no translation exists for the requested language
+	Description string
+	// Descriptions holds translations keyed by IETF language code, e.g. "ru"
+	Descriptions map[string]string
+}
+
+// RegisterCommands publishes the command menu for a scope and language;
+// a nil scope means the default scope and an empty languageCode means all languages
+func (bc *BotClient) RegisterCommands(commands []Command, scope *tba.BotCommandScope, languageCode string) error {
+	botCommands := make([]tba.BotCommand, 0, len(commands))
+	for _, cmd := range commands {
+		description := cmd.Description
+		if translated, ok := cmd.Descriptions[languageCode]; ok && languageCode != "" {
+			description = translated
+		}
+		botCommands = append(botCommands, tba.BotCommand{
+			Command:     strings.TrimPrefix(cmd.Name, "/"),
+			Description: description,
+		})
+	}
+
+	config := tba.SetMyCommandsConfig{Commands: botCommands, Scope: scope, LanguageCode: languageCode}
+	if err := bc.request(config); err != nil {
+		return fmt.Errorf("failed to register commands for language %q: %w", languageCode, err)
+	}
+	return nil
+}
+
+// RegisterCommandsAllLanguages publishes the default menu plus one menu per translated language
+func (bc *BotClient) RegisterCommandsAllLanguages(commands []Command, scope *tba.BotCommandScope) error {
+	if err := bc.RegisterCommands(commands, scope, ""); err != nil {
+		return err
+	}
+
+	languages := make(map[string]struct{})
+	for _, cmd := range commands {
+		for lang := range cmd.Descriptions {
+			languages[lang] = struct{}{}
+		}
+	}
+	sorted := make([]string, 0, len(languages))
+	for lang := range languages {
+		sorted = append(sorted, lang)
+	}
+	sort.Strings(sorted)
+
+	for _, lang := range sorted {
+		if err := bc.RegisterCommands(commands, scope, lang); err != nil {
+			return err
+		}
+	}
+	return nil
+}
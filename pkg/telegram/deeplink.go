@@ -0,0 +1,86 @@
+package telegram
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// maxStartPayloadLen is Telegram's limit for the /start parameter
+	maxStartPayloadLen = 64
+	startChecksumLen   = 3 // bytes of SHA-256 kept, 4 chars once base64-encoded
+)
+
+var (
+	ErrStartPayloadTooLong = errors.New("start payload exceeds 64 characters")
+	ErrStartPayloadInvalid = errors.New("invalid start payload")
+)
+
+// BuildStartLink returns a t.me deep link that opens the bot and sends /start with
+// payload encoded as URL-safe base64 plus a short checksum
+func BuildStartLink(botUsername string, payload map[string]string) (string, error) {
+	encoded, err := EncodeStartPayload(payload)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://t.me/%s?start=%s", strings.TrimPrefix(botUsername, "@"), encoded), nil
+}
+
+// EncodeStartPayload encodes key-value parameters into a /start parameter
+func EncodeStartPayload(payload map[string]string) (string, error) {
+	values := url.Values{}
+	for k, v := range payload {
+		values.Set(k, v)
+	}
+	raw := []byte(values.Encode())
+
+	sum := sha256.Sum256(raw)
+	encoded := base64.RawURLEncoding.EncodeToString(append(raw, sum[:startChecksumLen]...))
+	if len(encoded) > maxStartPayloadLen {
+		return "", fmt.Errorf("%w: %d characters", ErrStartPayloadTooLong, len(encoded))
+	}
+	return encoded, nil
+}
+
+// DecodeStartPayload reverses EncodeStartPayload, verifying the checksum
+func DecodeStartPayload(encoded string) (map[string]string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(data) < startChecksumLen {
+		return nil, ErrStartPayloadInvalid
+	}
+
+	raw, checksum := data[:len(data)-startChecksumLen], data[len(data)-startChecksumLen:]
+	sum := sha256.Sum256(raw)
+	if string(sum[:startChecksumLen]) != string(checksum) {
+		return nil, ErrStartPayloadInvalid
+	}
+
+	values, err := url.ParseQuery(string(raw))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStartPayloadInvalid, err)
+	}
+	payload := make(map[string]string, len(values))
+	for k := range values {
+		payload[k] = values.Get(k)
+	}
+	return payload, nil
+}
+
+// ParseStartPayload extracts and decodes the deep-link payload of a /start message.
+// It returns nil and no error when the update is not a /start command with a payload.
+func ParseStartPayload(update *tba.Update) (map[string]string, error) {
+	if update.Message == nil || update.Message.Command() != "start" {
+		return nil, nil
+	}
+	arg := strings.TrimSpace(update.Message.CommandArguments())
+	if arg == "" {
+		return nil, nil
+	}
+	return DecodeStartPayload(arg)
+}
@@ -0,0 +1,81 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/arseniisemenow/bbc-common/pkg/i18n"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// maxDigestEntries keeps digests readable and within the keyboard button limit
+const maxDigestEntries = 10
+
+// Callback actions of the digest keyboard; the subscription ID is the only parameter
+var (
+	DigestPauseAction       = "sub_pause"
+	DigestUnsubscribeAction = "sub_delete"
+)
+
+// FormatTripDigest formats several trips found for sub as one compact message with
+// numbered entries, and a keyboard with a link button per entry plus subscription actions
+func FormatTripDigest(trips []models.TripInfo, sub models.SearchSubscription, lang string) (string, tba.InlineKeyboardMarkup) {
+	var sb strings.Builder
+	sb.WriteString(i18n.N(lang, "digest.title", len(trips), sub.FromPlaceName, sub.ToPlaceName, sub.DepartureDate))
+
+	shown := trips
+	if len(shown) > maxDigestEntries {
+		shown = shown[:maxDigestEntries]
+	}
+
+	var links []tba.InlineKeyboardButton
+	for i, trip := range shown {
+		n := strconv.Itoa(i + 1)
+		sb.WriteString("\n")
+		sb.WriteString(n)
+		sb.WriteString(". ")
+		sb.WriteString(formatDigestEntry(trip))
+		if trip.DeepLink != "" {
+			links = append(links, tba.NewInlineKeyboardButtonURL(n, trip.DeepLink))
+		}
+	}
+	if rest := len(trips) - len(shown); rest > 0 {
+		sb.WriteString("\n")
+		sb.WriteString(i18n.N(lang, "digest.more", rest))
+	}
+
+	keyboard := NewKeyboard().
+		Grid(links, 5).
+		Row().
+		Button(i18n.T(lang, "digest.pause"), CreateCallbackData(DigestPauseAction, sub.ID)).
+		Button(i18n.T(lang, "digest.unsubscribe"), CreateCallbackData(DigestUnsubscribeAction, sub.ID)).
+		Build()
+
+	return sb.String(), keyboard
+}
+
+// formatDigestEntry renders a trip on one line, e.g. "🚗 18:30 → 20:30 · 18€ · 💺 3"
+func formatDigestEntry(trip models.TripInfo) string {
+	icon := "🚗"
+	if trip.IsBus {
+		icon = "🚌"
+	}
+	parts := []string{fmt.Sprintf("%s %s → %s", icon, tripClock(trip.DepartureTime), tripClock(trip.ArrivalTime))}
+	if !trip.Price.IsZero() {
+		parts = append(parts, trip.Price.String())
+	}
+	parts = append(parts, fmt.Sprintf("💺 %d", trip.SeatsAvailable))
+	return strings.Join(parts, " · ")
+}
+
+// tripClock returns the "15:04" part of a trip timestamp, or the input when it cannot be parsed
+func tripClock(raw string) string {
+	t, _, err := i18n.ParseTripTime(raw)
+	if err != nil {
+		return raw
+	}
+	return t.Format("15:04")
+}
@@ -0,0 +1,204 @@
+package telegram
+
+import (
+	"context"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+
+	"github.com/arseniisemenow/bbc-common/pkg/i18n"
+	"github.com/arseniisemenow/bbc-common/pkg/logging"
+)
+
+// UpdateKind classifies an incoming update
+type UpdateKind string
+
+const (
+	UpdateMessage       UpdateKind = "message"
+	UpdateEditedMessage UpdateKind = "edited_message"
+	UpdateCallbackQuery UpdateKind = "callback_query"
+	UpdateMyChatMember  UpdateKind = "my_chat_member"
+	UpdateInlineQuery   UpdateKind = "inline_query"
+	UpdateOther         UpdateKind = "other"
+)
+
+// UpdateContext carries the fields handlers usually dig out of an update
+type UpdateContext struct {
+	Kind UpdateKind
+	// ChatID is zero for updates without a chat, e.g. inline queries
+	ChatID int64
+	// User is the sender; nil only for updates without one
+	User *tba.User
+	// Locale is the sender's normalized language code, see i18n.Normalize; the
+	// language stored for the chat takes precedence, see Dispatcher.UseLanguage
+	Locale        string
+	CorrelationID string
+	Update        *tba.Update
+}
+
+type (
+	MessageHandler           func(ctx context.Context, uc *UpdateContext, msg *tba.Message) error
+	CallbackQueryHandler     func(ctx context.Context, uc *UpdateContext, query *tba.CallbackQuery) error
+	ChatMemberHandler        func(ctx context.Context, uc *UpdateContext, member *tba.ChatMemberUpdated) error
+	InlineQueryUpdateHandler func(ctx context.Context, uc *UpdateContext, query *tba.InlineQuery) error
+)
+
+// LanguageFunc returns the language stored for a chat, e.g. ydb.GetUserLanguage,
+// or "" when the user chose none
+type LanguageFunc func(ctx context.Context, chatID int64) (string, error)
+
+type updateContextKey struct{}
+
+// Dispatcher classifies updates and hands each kind to its typed handler.
+// Its Dispatch method is a HandlerFunc, so it can serve as a Router default handler.
+type Dispatcher struct {
+	onMessage       MessageHandler
+	onEditedMessage MessageHandler
+	onCallbackQuery CallbackQueryHandler
+	onMyChatMember  ChatMemberHandler
+	onInlineQuery   InlineQueryUpdateHandler
+	fallback        HandlerFunc
+	language        LanguageFunc
+}
+
+// NewDispatcher creates a dispatcher without handlers; unhandled updates are ignored
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{}
+}
+
+// OnMessage sets the handler for new messages
+func (d *Dispatcher) OnMessage(h MessageHandler) *Dispatcher {
+	d.onMessage = h
+	return d
+}
+
+// OnEditedMessage sets the handler for edited messages
+func (d *Dispatcher) OnEditedMessage(h MessageHandler) *Dispatcher {
+	d.onEditedMessage = h
+	return d
+}
+
+// OnCallbackQuery sets the handler for inline button presses
+func (d *Dispatcher) OnCallbackQuery(h CallbackQueryHandler) *Dispatcher {
+	d.onCallbackQuery = h
+	return d
+}
+
+// OnMyChatMember sets the handler for changes of the bot's own membership, e.g. blocks
+func (d *Dispatcher) OnMyChatMember(h ChatMemberHandler) *Dispatcher {
+	d.onMyChatMember = h
+	return d
+}
+
+// OnInlineQuery sets the handler for inline queries
+func (d *Dispatcher) OnInlineQuery(h InlineQueryUpdateHandler) *Dispatcher {
+	d.onInlineQuery = h
+	return d
+}
+
+// Fallback sets the handler for updates of other kinds or without a typed handler
+func (d *Dispatcher) Fallback(h HandlerFunc) *Dispatcher {
+	d.fallback = h
+	return d
+}
+
+// UseLanguage makes UpdateContext.Locale the language stored for the chat, falling back
+// to the sender's Telegram language when none is stored or it cannot be loaded
+func (d *Dispatcher) UseLanguage(language LanguageFunc) *Dispatcher {
+	d.language = language
+	return d
+}
+
+// Dispatch builds the UpdateContext and calls the handler registered for the update's kind
+func (d *Dispatcher) Dispatch(ctx context.Context, update *tba.Update) error {
+	uc := NewUpdateContext(update)
+	if d.language != nil && uc.ChatID != 0 {
+		lang, err := d.language(ctx, uc.ChatID)
+		switch {
+		case err != nil:
+			logger(ctx).Warn("Failed to load chat language", logging.ChatIDKey, uc.ChatID, "error", err)
+		case lang != "":
+			uc.Locale = i18n.Normalize(lang)
+		}
+	}
+	ctx = context.WithValue(ctx, updateContextKey{}, uc)
+
+	switch {
+	case uc.Kind == UpdateMessage && d.onMessage != nil:
+		return d.onMessage(ctx, uc, update.Message)
+	case uc.Kind == UpdateEditedMessage && d.onEditedMessage != nil:
+		return d.onEditedMessage(ctx, uc, update.EditedMessage)
+	case uc.Kind == UpdateCallbackQuery && d.onCallbackQuery != nil:
+		return d.onCallbackQuery(ctx, uc, update.CallbackQuery)
+	case uc.Kind == UpdateMyChatMember && d.onMyChatMember != nil:
+		return d.onMyChatMember(ctx, uc, update.MyChatMember)
+	case uc.Kind == UpdateInlineQuery && d.onInlineQuery != nil:
+		return d.onInlineQuery(ctx, uc, update.InlineQuery)
+	case d.fallback != nil:
+		return d.fallback(ctx, update)
+	default:
+		return nil
+	}
+}
+
+// ClassifyUpdate returns the kind of update
+func ClassifyUpdate(update *tba.Update) UpdateKind {
+	switch {
+	case update.Message != nil:
+		return UpdateMessage
+	case update.EditedMessage != nil:
+		return UpdateEditedMessage
+	case update.CallbackQuery != nil:
+		return UpdateCallbackQuery
+	case update.MyChatMember != nil:
+		return UpdateMyChatMember
+	case update.InlineQuery != nil:
+		return UpdateInlineQuery
+	default:
+		return UpdateOther
+	}
+}
+
+// NewUpdateContext extracts chat, user and locale from an update and assigns a correlation ID
+func NewUpdateContext(update *tba.Update) *UpdateContext {
+	uc := &UpdateContext{
+		Kind:          ClassifyUpdate(update),
+		CorrelationID: uuid.NewString(),
+		Update:        update,
+	}
+
+	switch uc.Kind {
+	case UpdateMessage:
+		uc.ChatID, uc.User = update.Message.Chat.ID, update.Message.From
+	case UpdateEditedMessage:
+		uc.ChatID, uc.User = update.EditedMessage.Chat.ID, update.EditedMessage.From
+	case UpdateCallbackQuery:
+		// callbacks from inline-mode messages carry no chat
+		if update.CallbackQuery.Message != nil && update.CallbackQuery.Message.Chat != nil {
+			uc.ChatID = update.CallbackQuery.Message.Chat.ID
+		}
+		uc.User = update.CallbackQuery.From
+	case UpdateMyChatMember:
+		uc.ChatID, uc.User = update.MyChatMember.Chat.ID, &update.MyChatMember.From
+	case UpdateInlineQuery:
+		uc.User = update.InlineQuery.From
+	default:
+		if chat := update.FromChat(); chat != nil {
+			uc.ChatID = chat.ID
+		}
+		uc.User = update.SentFrom()
+	}
+
+	locale := ""
+	if uc.User != nil {
+		locale = uc.User.LanguageCode
+	}
+	uc.Locale = i18n.Normalize(locale)
+	return uc
+}
+
+// UpdateContextFrom returns the UpdateContext stored by Dispatcher.Dispatch, e.g. for middlewares
+func UpdateContextFrom(ctx context.Context) (*UpdateContext, bool) {
+	uc, ok := ctx.Value(updateContextKey{}).(*UpdateContext)
+	return uc, ok
+}
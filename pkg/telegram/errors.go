@@ -0,0 +1,74 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/arseniisemenow/bbc-common/pkg/logging"
+)
+
+var (
+	ErrBotBlocked         = errors.New("bot was blocked by the user")
+	ErrChatNotFound       = errors.New("chat not found")
+	ErrMessageNotModified = errors.New("message is not modified")
+	ErrMessageNotFound    = errors.New("message not found")
+	ErrMessageTooLong     = errors.New("message is too long")
+	ErrBadMarkup          = errors.New("can't parse message entities")
+	// ErrUnsupportedKeyboard is returned for keyboards an edit cannot set, such as
+	// reply keyboards; only inline keyboards can be edited
+	ErrUnsupportedKeyboard = errors.New("keyboard cannot be edited")
+	// ErrUnsupportedOption is returned for send options a message kind cannot take,
+	// such as Protected on an invoice
+	ErrUnsupportedOption = errors.New("send option not supported for this message")
+)
+
+// RateLimitedError is returned when Telegram keeps answering 429 after all retries
+type RateLimitedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("rate limited by Telegram, retry after %s", e.RetryAfter)
+}
+
+// apiErrorRules maps Telegram error codes and description fragments to sentinels
+var apiErrorRules = []struct {
+	code     int
+	fragment string
+	sentinel error
+}{
+	{http.StatusForbidden, "bot was blocked by the user", ErrBotBlocked},
+	{http.StatusForbidden, "user is deactivated", ErrBotBlocked},
+	{http.StatusBadRequest, "chat not found", ErrChatNotFound},
+	{http.StatusBadRequest, "message is not modified", ErrMessageNotModified},
+	{http.StatusBadRequest, "message to edit not found", ErrMessageNotFound},
+	{http.StatusBadRequest, "message to delete not found", ErrMessageNotFound},
+	{http.StatusBadRequest, "message is too long", ErrMessageTooLong},
+	{http.StatusBadRequest, "can't parse entities", ErrBadMarkup},
+}
+
+// mapError wraps Telegram API errors with the matching sentinel so callers can use errors.Is;
+// the original *tba.Error stays reachable via errors.As. Transport errors carry the
+// request URL, which embeds the bot token, so messages are redacted.
+func mapError(err error) error {
+	err = logging.RedactError(err)
+	var apiErr *tba.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	if apiErr.Code == http.StatusTooManyRequests {
+		return fmt.Errorf("%w: %w", &RateLimitedError{RetryAfter: time.Duration(apiErr.RetryAfter) * time.Second}, err)
+	}
+	for _, rule := range apiErrorRules {
+		if apiErr.Code == rule.code && strings.Contains(apiErr.Message, rule.fragment) {
+			return fmt.Errorf("%w: %w", rule.sentinel, err)
+		}
+	}
+	return err
+}
@@ -0,0 +1,40 @@
+package telegram
+
+import (
+	"encoding/json"
+	"fmt"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// maxDocumentSize is Telegram's limit of files uploaded by bots
+const maxDocumentSize = 50 << 20
+
+// SendDataExport sends a user's data export, see ydb.ExportUserData, as an indented JSON
+// document named after the export date, with an optional caption
+func (bc *BotClient) SendDataExport(chatID int64, export models.UserDataExport, caption string, opts ...SendOption) (int, error) {
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode data export: %w", err)
+	}
+	if len(data) > maxDocumentSize {
+		return 0, fmt.Errorf("data export of %d bytes exceeds Telegram's %d byte limit", len(data), maxDocumentSize)
+	}
+
+	msg := tba.NewDocument(chatID, tba.FileBytes{
+		Name:  "bbc-data-export-" + export.ExportedAt.Format("2006-01-02") + ".json",
+		Bytes: data,
+	})
+	if caption != "" {
+		msg.Caption = tba.EscapeText(tba.ModeMarkdownV2, caption)
+		msg.ParseMode = "MarkdownV2"
+	}
+
+	sent, err := bc.send(chatID, msg, opts...)
+	if err != nil {
+		return 0, err
+	}
+	return sent.MessageID, nil
+}
@@ -0,0 +1,57 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// MaxDownloadSize is the largest file the Bot API lets bots download
+const MaxDownloadSize = 20 << 20
+
+var ErrFileTooLarge = errors.New("file exceeds the 20 MB download limit")
+
+// DownloadFile resolves fileID with getFile and opens the file contents, e.g. a JSON
+// or CSV document sent by the user; the caller must close the returned reader
+func (bc *BotClient) DownloadFile(ctx context.Context, fileID string) (io.ReadCloser, error) {
+	var file tba.File
+	err := bc.retry.do(ctx, func() error {
+		resp, err := bc.bot.Request(tba.FileConfig{FileID: fileID})
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(resp.Result, &file)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file %s: %w", fileID, mapError(err))
+	}
+	if file.FileSize > MaxDownloadSize {
+		return nil, fmt.Errorf("%w: %d bytes", ErrFileTooLarge, file.FileSize)
+	}
+
+	link := fmt.Sprintf(bc.fileEndpoint, bc.bot.Token, file.FilePath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create download request: %w", err)
+	}
+	resp, err := bc.bot.Client.Do(req)
+	if err != nil {
+		// the URL contains the bot token, keep it out of the error
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("failed to download file %s: %w", fileID, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download file %s: unexpected status %s", fileID, resp.Status)
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,94 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxInlineResults is Telegram's limit of results per inline answer
+const maxInlineResults = 50
+
+// InlineArticle is a text result offered in inline mode, e.g. a trip card
+type InlineArticle struct {
+	ID          string
+	Title       string
+	Description string
+	// Text is the message sent when the user picks the result
+	Text     string
+	Keyboard *tba.InlineKeyboardMarkup
+	ThumbURL string
+}
+
+// InlineAnswer configures how results are cached by Telegram
+type InlineAnswer struct {
+	Results []InlineArticle
+	// CacheSeconds is how long Telegram may cache the answer
+	CacheSeconds int
+	// Personal disables sharing cached results between users
+	Personal bool
+	// NextOffset enables pagination; it is passed back in the next query's Offset
+	NextOffset string
+}
+
+// InlineQueryHandler produces results for an inline query such as "@bbcbot berlin leipzig fri"
+type InlineQueryHandler interface {
+	HandleInlineQuery(ctx context.Context, query *tba.InlineQuery) (InlineAnswer, error)
+}
+
+// InlineQueryHandlerFunc adapts a function to InlineQueryHandler
+type InlineQueryHandlerFunc func(ctx context.Context, query *tba.InlineQuery) (InlineAnswer, error)
+
+// HandleInlineQuery calls f
+func (f InlineQueryHandlerFunc) HandleInlineQuery(ctx context.Context, query *tba.InlineQuery) (InlineAnswer, error) {
+	return f(ctx, query)
+}
+
+// AnswerInlineQuery sends article results for an inline query
+func (bc *BotClient) AnswerInlineQuery(queryID string, answer InlineAnswer) error {
+	results := answer.Results
+	if len(results) > maxInlineResults {
+		results = results[:maxInlineResults]
+	}
+
+	items := make([]interface{}, 0, len(results))
+	for _, r := range results {
+		article := tba.NewInlineQueryResultArticleMarkdownV2(r.ID, r.Title, tba.EscapeText(tba.ModeMarkdownV2, r.Text))
+		article.Description = r.Description
+		article.ReplyMarkup = r.Keyboard
+		article.ThumbURL = r.ThumbURL
+		items = append(items, article)
+	}
+
+	return bc.request(tba.InlineConfig{
+		InlineQueryID: queryID,
+		Results:       items,
+		CacheTime:     answer.CacheSeconds,
+		IsPersonal:    answer.Personal,
+		NextOffset:    answer.NextOffset,
+	})
+}
+
+// InlineHandler adapts an InlineQueryHandler for Router.HandleInlineQueries, answering through bc
+func (bc *BotClient) InlineHandler(handler InlineQueryHandler) HandlerFunc {
+	return func(ctx context.Context, update *tba.Update) error {
+		if update.InlineQuery == nil {
+			return nil
+		}
+		answer, err := handler.HandleInlineQuery(ctx, update.InlineQuery)
+		if err != nil {
+			logger(ctx).Warn("Inline query failed", "query", update.InlineQuery.Query, "error", err)
+			answer = InlineAnswer{}
+		}
+		if answerErr := bc.AnswerInlineQuery(update.InlineQuery.ID, answer); answerErr != nil {
+			return answerErr
+		}
+		return err
+	}
+}
+
+// InlineQueryTerms splits an inline query into lower-cased search terms
+func InlineQueryTerms(query string) []string {
+	return strings.Fields(strings.ToLower(query))
+}
@@ -2,8 +2,20 @@ package telegram
 
 // BotSender defines the interface for sending Telegram messages
 type BotSender interface {
-	SendPlainMessage(chatID int64, text string) error
-	SendMessageWithKeyboard(chatID int64, text string, keyboard interface{}) (int, error)
-	EditMessage(chatID int64, messageID int, text string) error
+	SendPlainMessage(chatID int64, text string, opts ...SendOption) error
+	SendMessageWithKeyboard(chatID int64, text string, keyboard interface{}, opts ...SendOption) (int, error)
+	EditMessage(chatID int64, messageID int, text string, opts ...SendOption) error
+	UpsertMessage(chatID int64, existingMessageID int, text string, keyboard interface{}, opts ...SendOption) (int, error)
 	AnswerCallbackQuery(callbackQueryID, text string) error
+	DeleteMessage(chatID int64, messageID int) error
+	EditMessageKeyboard(chatID int64, messageID int, keyboard interface{}) error
+	SendPhoto(chatID int64, photo, caption string, keyboard interface{}, opts ...SendOption) (int, error)
+	SendMediaGroup(chatID int64, photos []string, caption string, opts ...SendOption) ([]int, error)
+	SendLocation(chatID int64, lat, lon float64, opts ...SendOption) (int, error)
+	SendVenue(chatID int64, title, address string, lat, lon float64, opts ...SendOption) (int, error)
 }
+
+var (
+	_ BotSender = (*BotClient)(nil)
+	_ BotSender = (*Recorder)(nil)
+)
@@ -0,0 +1,116 @@
+package telegram
+
+import (
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// KeyboardBuilder assembles inline keyboards row by row:
+//
+//	kb := telegram.NewKeyboard().
+//		Row().Button("✅ Book", cb).URLButton("Open", link).
+//		Row().Button("🗑 Delete", deleteCb).
+//		Build()
+type KeyboardBuilder struct {
+	rows [][]tba.InlineKeyboardButton
+}
+
+// NewKeyboard creates an empty keyboard builder
+func NewKeyboard() *KeyboardBuilder {
+	return &KeyboardBuilder{}
+}
+
+// Row starts a new row; buttons added afterwards go into it
+func (b *KeyboardBuilder) Row() *KeyboardBuilder {
+	if len(b.rows) == 0 || len(b.rows[len(b.rows)-1]) > 0 {
+		b.rows = append(b.rows, []tba.InlineKeyboardButton{})
+	}
+	return b
+}
+
+// Button adds a callback button to the current row
+func (b *KeyboardBuilder) Button(text, callbackData string) *KeyboardBuilder {
+	return b.add(tba.NewInlineKeyboardButtonData(text, callbackData))
+}
+
+// URLButton adds a link button to the current row
+func (b *KeyboardBuilder) URLButton(text, url string) *KeyboardBuilder {
+	return b.add(tba.NewInlineKeyboardButtonURL(text, url))
+}
+
+// Grid adds buttons as new rows holding at most maxPerRow buttons each
+func (b *KeyboardBuilder) Grid(buttons []tba.InlineKeyboardButton, maxPerRow int) *KeyboardBuilder {
+	for _, row := range Grid(buttons, maxPerRow) {
+		b.Row()
+		for _, button := range row {
+			b.add(button)
+		}
+	}
+	return b
+}
+
+// Rows returns the button rows, suitable for SendInlineKeyboard
+func (b *KeyboardBuilder) Rows() [][]tba.InlineKeyboardButton {
+	rows := make([][]tba.InlineKeyboardButton, 0, len(b.rows))
+	for _, row := range b.rows {
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// Build returns the markup, suitable for SendMessageWithKeyboard and UpsertMessage
+func (b *KeyboardBuilder) Build() tba.InlineKeyboardMarkup {
+	return tba.InlineKeyboardMarkup{InlineKeyboard: b.Rows()}
+}
+
+func (b *KeyboardBuilder) add(button tba.InlineKeyboardButton) *KeyboardBuilder {
+	if len(b.rows) == 0 {
+		b.rows = append(b.rows, []tba.InlineKeyboardButton{})
+	}
+	last := len(b.rows) - 1
+	b.rows[last] = append(b.rows[last], button)
+	return b
+}
+
+// Grid splits buttons into rows of at most maxPerRow buttons
+func Grid(buttons []tba.InlineKeyboardButton, maxPerRow int) [][]tba.InlineKeyboardButton {
+	if maxPerRow <= 0 {
+		maxPerRow = len(buttons)
+	}
+
+	var rows [][]tba.InlineKeyboardButton
+	for start := 0; start < len(buttons); start += maxPerRow {
+		end := min(start+maxPerRow, len(buttons))
+		rows = append(rows, append([]tba.InlineKeyboardButton(nil), buttons[start:end]...))
+	}
+	return rows
+}
+
+// ReplyKeyboardOptions configures a non-inline reply keyboard
+type ReplyKeyboardOptions struct {
+	// Resize fits the keyboard height to its buttons
+	Resize bool
+	// OneTime hides the keyboard after a button is pressed
+	OneTime bool
+	// Placeholder is shown in the input field while the keyboard is active
+	Placeholder string
+}
+
+// NewReplyKeyboard builds a reply keyboard where each row is a list of button texts
+func NewReplyKeyboard(opts ReplyKeyboardOptions, rows ...[]string) tba.ReplyKeyboardMarkup {
+	buttons := make([][]tba.KeyboardButton, 0, len(rows))
+	for _, row := range rows {
+		buttonRow := make([]tba.KeyboardButton, 0, len(row))
+		for _, text := range row {
+			buttonRow = append(buttonRow, tba.NewKeyboardButton(text))
+		}
+		buttons = append(buttons, buttonRow)
+	}
+
+	markup := tba.NewReplyKeyboard(buttons...)
+	markup.ResizeKeyboard = opts.Resize
+	markup.OneTimeKeyboard = opts.OneTime
+	markup.InputFieldPlaceholder = opts.Placeholder
+	return markup
+}
@@ -0,0 +1,54 @@
+package telegram
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/ratelimit"
+)
+
+const (
+	// DefaultGlobalRate is Telegram's documented bulk limit of messages per second
+	DefaultGlobalRate = 30
+	// DefaultChatInterval is the minimal spacing between messages to one chat
+	DefaultChatInterval = time.Second
+)
+
+// Limiter spaces outgoing messages to respect Telegram's global and per-chat limits.
+// Callers reserve slots in arrival order, so concurrent senders form a FIFO queue.
+type Limiter struct {
+	global ratelimit.Limiter
+	chats  ratelimit.Limiter
+}
+
+// NewLimiter creates a limiter allowing globalPerSecond messages overall and one message per chatInterval per chat
+func NewLimiter(globalPerSecond int, chatInterval time.Duration) *Limiter {
+	if globalPerSecond <= 0 {
+		globalPerSecond = DefaultGlobalRate
+	}
+	return &Limiter{
+		global: ratelimit.Every(time.Second / time.Duration(globalPerSecond)),
+		chats:  ratelimit.Every(chatInterval),
+	}
+}
+
+// NewDefaultLimiter creates a limiter with Telegram's default limits
+func NewDefaultLimiter() *Limiter {
+	return NewLimiter(DefaultGlobalRate, DefaultChatInterval)
+}
+
+// NewSharedLimiter creates a limiter drawing from limiters shared with other function
+// instances, e.g. ratelimit.Shared; chats is keyed by chat ID
+func NewSharedLimiter(global, chats ratelimit.Limiter) *Limiter {
+	return &Limiter{global: global, chats: chats}
+}
+
+// Wait blocks until a message to chatID may be sent or ctx is done. The chat's slot
+// is awaited first, so a busy chat does not hold global slots while it waits.
+func (l *Limiter) Wait(ctx context.Context, chatID int64) error {
+	if err := l.chats.Wait(ctx, strconv.FormatInt(chatID, 10)); err != nil {
+		return err
+	}
+	return l.global.Wait(ctx, "")
+}
@@ -0,0 +1,52 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// SendLocation sends a map pin
+func (bc *BotClient) SendLocation(chatID int64, lat, lon float64, opts ...SendOption) (int, error) {
+	sent, err := bc.send(chatID, tba.NewLocation(chatID, lat, lon), opts...)
+	if err != nil {
+		return 0, err
+	}
+	return sent.MessageID, nil
+}
+
+// SendVenue sends a map pin with a title and address, e.g. a pickup point
+func (bc *BotClient) SendVenue(chatID int64, title, address string, lat, lon float64, opts ...SendOption) (int, error) {
+	sent, err := bc.send(chatID, tba.NewVenue(chatID, title, address, lat, lon), opts...)
+	if err != nil {
+		return 0, err
+	}
+	return sent.MessageID, nil
+}
+
+// MapLink returns a link opening the coordinates in a map application
+func MapLink(lat, lon float64) string {
+	return fmt.Sprintf("https://maps.google.com/?q=%.6f,%.6f", lat, lon)
+}
+
+// StaticMapURL returns a static map image centered on the coordinates, usable with SendPhoto
+func StaticMapURL(lat, lon float64) string {
+	return fmt.Sprintf("https://static-maps.yandex.ru/1.x/?ll=%.6f,%.6f&z=15&size=600,400&l=map&pt=%.6f,%.6f,pm2rdm",
+		lon, lat, lon, lat)
+}
+
+// FormatMeetingPoint formats a meeting point as text with a map link, for clients that
+// cannot show location messages or when SendVenue fails
+func FormatMeetingPoint(title, address string, lat, lon float64) string {
+	var sb strings.Builder
+	sb.WriteString("📍 ")
+	sb.WriteString(title)
+	if address != "" && address != title {
+		sb.WriteString("\n")
+		sb.WriteString(address)
+	}
+	sb.WriteString("\n")
+	sb.WriteString(MapLink(lat, lon))
+	return sb.String()
+}
@@ -0,0 +1,70 @@
+package telegram
+
+import (
+	"strings"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// markdownV2Markers are the formatting characters removed by StripMarkdownV2
+const markdownV2Markers = "*_~|`"
+
+// StripMarkdownV2 removes MarkdownV2 formatting, unescaping escaped characters
+// and turning links into "text (url)"
+func StripMarkdownV2(text string) string {
+	var sb strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\' && i+1 < len(runes):
+			i++
+			sb.WriteRune(runes[i])
+		case strings.ContainsRune(markdownV2Markers, r), r == '[':
+		case r == ']' && i+1 < len(runes) && runes[i+1] == '(':
+			end := i + 2
+			for end < len(runes) && runes[end] != ')' {
+				if runes[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			url := strings.ReplaceAll(string(runes[i+2:min(end, len(runes))]), "\\", "")
+			sb.WriteString(" (" + url + ")")
+			i = end
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// plainTextFallback returns c with its text or caption stripped of markup and no parse mode,
+// together with the original text; ok is false for configs without formatted text
+func plainTextFallback(c tba.Chattable) (fallback tba.Chattable, original string, ok bool) {
+	switch m := c.(type) {
+	case tba.MessageConfig:
+		if m.ParseMode == "" {
+			return nil, "", false
+		}
+		original = m.Text
+		m.Text, m.ParseMode = StripMarkdownV2(m.Text), ""
+		return m, original, true
+	case tba.EditMessageTextConfig:
+		if m.ParseMode == "" {
+			return nil, "", false
+		}
+		original = m.Text
+		m.Text, m.ParseMode = StripMarkdownV2(m.Text), ""
+		return m, original, true
+	case tba.PhotoConfig:
+		if m.ParseMode == "" {
+			return nil, "", false
+		}
+		original = m.Caption
+		m.Caption, m.ParseMode = StripMarkdownV2(m.Caption), ""
+		return m, original, true
+	default:
+		return nil, "", false
+	}
+}
@@ -0,0 +1,68 @@
+package telegram
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxMediaGroupSize is Telegram's limit of items in one album
+const maxMediaGroupSize = 10
+
+// fileRef turns a URL or a Telegram file ID into request file data
+func fileRef(ref string) tba.RequestFileData {
+	if strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") {
+		return tba.FileURL(ref)
+	}
+	return tba.FileID(ref)
+}
+
+// SendPhoto sends a photo given as URL or file ID, with an optional caption and keyboard
+func (bc *BotClient) SendPhoto(chatID int64, photo, caption string, keyboard interface{}, opts ...SendOption) (int, error) {
+	msg := tba.NewPhoto(chatID, fileRef(photo))
+	if caption != "" {
+		msg.Caption = tba.EscapeText(tba.ModeMarkdownV2, caption)
+		msg.ParseMode = "MarkdownV2"
+	}
+	msg.ReplyMarkup = keyboard
+
+	sent, err := bc.send(chatID, msg, opts...)
+	if err != nil {
+		return 0, err
+	}
+	return sent.MessageID, nil
+}
+
+// SendMediaGroup sends up to 10 photos as an album; caption is attached to the first photo
+func (bc *BotClient) SendMediaGroup(chatID int64, photos []string, caption string, opts ...SendOption) ([]int, error) {
+	if len(photos) == 0 || len(photos) > maxMediaGroupSize {
+		return nil, fmt.Errorf("media group must contain 1 to %d photos, got %d", maxMediaGroupSize, len(photos))
+	}
+
+	media := make([]interface{}, 0, len(photos))
+	for i, photo := range photos {
+		item := tba.NewInputMediaPhoto(fileRef(photo))
+		if i == 0 && caption != "" {
+			item.Caption = tba.EscapeText(tba.ModeMarkdownV2, caption)
+			item.ParseMode = "MarkdownV2"
+		}
+		media = append(media, item)
+	}
+
+	result, err := bc.deliver(chatID, tba.NewMediaGroup(chatID, media), collectOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	var sent []tba.Message
+	if err := json.Unmarshal(result, &sent); err != nil {
+		return nil, fmt.Errorf("failed to decode sent media group: %w", err)
+	}
+
+	ids := make([]int, 0, len(sent))
+	for _, m := range sent {
+		ids = append(ids, m.MessageID)
+	}
+	return ids, nil
+}
@@ -0,0 +1,106 @@
+package telegram
+
+import (
+	"context"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/arseniisemenow/bbc-common/pkg/logging"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// MembershipEvent is a change of the bot's own membership in a chat
+type MembershipEvent string
+
+const (
+	// MembershipBlocked and MembershipUnblocked happen in private chats
+	MembershipBlocked   MembershipEvent = "blocked"
+	MembershipUnblocked MembershipEvent = "unblocked"
+	// MembershipAdded and MembershipRemoved happen in groups and channels
+	MembershipAdded   MembershipEvent = "added"
+	MembershipRemoved MembershipEvent = "removed"
+)
+
+// MembershipHandler reacts to the bot being blocked, unblocked, added or removed
+type MembershipHandler func(ctx context.Context, chatID int64, event MembershipEvent) error
+
+// ParseMembershipEvent interprets a my_chat_member update; ok is false for other
+// updates and for changes that are not a block, unblock, addition or removal
+func ParseMembershipEvent(update *tba.Update) (chatID int64, event MembershipEvent, ok bool) {
+	if update.MyChatMember == nil {
+		return 0, "", false
+	}
+	event, ok = membershipEvent(update.MyChatMember)
+	return update.MyChatMember.Chat.ID, event, ok
+}
+
+func membershipEvent(m *tba.ChatMemberUpdated) (MembershipEvent, bool) {
+	wasIn, isIn := isMemberStatus(m.OldChatMember.Status), isMemberStatus(m.NewChatMember.Status)
+
+	if m.Chat.IsPrivate() {
+		switch {
+		case m.NewChatMember.WasKicked():
+			return MembershipBlocked, true
+		case m.OldChatMember.WasKicked() && isIn:
+			return MembershipUnblocked, true
+		}
+		return "", false
+	}
+
+	switch {
+	case !wasIn && isIn:
+		return MembershipAdded, true
+	case wasIn && !isIn:
+		return MembershipRemoved, true
+	}
+	return "", false
+}
+
+func isMemberStatus(status string) bool {
+	switch status {
+	case "creator", "administrator", "member", "restricted":
+		return true
+	default:
+		return false
+	}
+}
+
+// HandlerFunc adapts h for Router.HandleMyChatMember or a Dispatcher fallback
+func (h MembershipHandler) HandlerFunc() HandlerFunc {
+	return func(ctx context.Context, update *tba.Update) error {
+		chatID, event, ok := ParseMembershipEvent(update)
+		if !ok {
+			return nil
+		}
+		return h(ctx, chatID, event)
+	}
+}
+
+// ChatMemberHandler adapts h for Dispatcher.OnMyChatMember
+func (h MembershipHandler) ChatMemberHandler() ChatMemberHandler {
+	return func(ctx context.Context, uc *UpdateContext, member *tba.ChatMemberUpdated) error {
+		event, ok := membershipEvent(member)
+		if !ok {
+			return nil
+		}
+		return h(ctx, member.Chat.ID, event)
+	}
+}
+
+// SyncUserStatus returns a MembershipHandler keeping user status in step with blocks, e.g.
+//
+//	router.HandleMyChatMember(telegram.SyncUserStatus(ydb.UpdateUserStatus).HandlerFunc())
+func SyncUserStatus(updateStatus func(ctx context.Context, chatID int64, status models.UserStatus) error) MembershipHandler {
+	return func(ctx context.Context, chatID int64, event MembershipEvent) error {
+		switch event {
+		case MembershipBlocked:
+			logger(logging.WithChatID(ctx, chatID)).Info("Chat blocked the bot, marking user inactive")
+			return updateStatus(ctx, chatID, models.UserStatusInactive)
+		case MembershipUnblocked:
+			logger(logging.WithChatID(ctx, chatID)).Info("Chat unblocked the bot, marking user active")
+			return updateStatus(ctx, chatID, models.UserStatusActive)
+		default:
+			return nil
+		}
+	}
+}
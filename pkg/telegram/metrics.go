@@ -0,0 +1,125 @@
+package telegram
+
+import (
+	"errors"
+	"time"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Request kinds used as the "kind" label
+const (
+	kindSend           = "send"
+	kindEdit           = "edit"
+	kindCallbackAnswer = "callback_answer"
+	kindOther          = "other"
+)
+
+// Metrics collects Bot API request statistics; register it with a Prometheus registry
+// and pass it to WithMetrics. A nil *Metrics records nothing.
+type Metrics struct {
+	requests    *prometheus.CounterVec
+	rateLimited prometheus.Counter
+	blocked     prometheus.Counter
+	latency     *prometheus.HistogramVec
+}
+
+// NewMetrics creates metrics named <namespace>_telegram_*
+func NewMetrics(namespace string) *Metrics {
+	return &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "telegram",
+			Name:      "requests_total",
+			Help:      "Bot API requests by kind (send, edit, callback_answer, other) and final result.",
+		}, []string{"kind", "result"}),
+		rateLimited: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "telegram",
+			Name:      "rate_limited_total",
+			Help:      "Bot API responses with status 429, including retried ones.",
+		}),
+		blocked: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "telegram",
+			Name:      "blocked_total",
+			Help:      "Sends that failed because the user blocked the bot.",
+		}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "telegram",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of single Bot API request attempts.",
+			Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10},
+		}, []string{"kind"}),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.requests.Describe(ch)
+	m.rateLimited.Describe(ch)
+	m.blocked.Describe(ch)
+	m.latency.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.requests.Collect(ch)
+	m.rateLimited.Collect(ch)
+	m.blocked.Collect(ch)
+	m.latency.Collect(ch)
+}
+
+// observeAttempt records the latency of one request attempt and whether it hit a 429
+func (m *Metrics) observeAttempt(kind string, start time.Time, err error) {
+	if m == nil {
+		return
+	}
+	m.latency.WithLabelValues(kind).Observe(time.Since(start).Seconds())
+	if _, limited := retryAfter(err); limited {
+		m.rateLimited.Inc()
+	}
+}
+
+// observeResult records the final outcome of a request after retries and error mapping
+func (m *Metrics) observeResult(kind string, err error) {
+	if m == nil {
+		return
+	}
+	m.requests.WithLabelValues(kind, resultLabel(err)).Inc()
+	if errors.Is(err, ErrBotBlocked) {
+		m.blocked.Inc()
+	}
+}
+
+func resultLabel(err error) string {
+	var limited *RateLimitedError
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, ErrBotBlocked):
+		return "blocked"
+	case errors.As(err, &limited):
+		return "rate_limited"
+	case errors.Is(err, ErrChatNotFound), errors.Is(err, ErrMessageNotFound):
+		return "not_found"
+	case errors.Is(err, ErrMessageNotModified):
+		return "not_modified"
+	default:
+		return "error"
+	}
+}
+
+// requestKind returns the metrics label for c, or fallback for configs that are not edits or callback answers
+func requestKind(c tba.Chattable, fallback string) string {
+	switch c.(type) {
+	case tba.EditMessageTextConfig, tba.EditMessageReplyMarkupConfig, tba.EditMessageCaptionConfig:
+		return kindEdit
+	case tba.CallbackConfig:
+		return kindCallbackAnswer
+	default:
+		return fallback
+	}
+}
@@ -0,0 +1,232 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/arseniisemenow/bbc-common/pkg/requestid"
+)
+
+// SendOptions are per-message delivery options
+type SendOptions struct {
+	// DisableNotification delivers the message silently
+	DisableNotification bool
+	// ProtectContent forbids forwarding and saving the message
+	ProtectContent bool
+	// DisableWebPagePreview suppresses link previews in text messages
+	DisableWebPagePreview bool
+	// ReplyToMessageID threads the message under an earlier one; the message is
+	// still sent if the original was deleted
+	ReplyToMessageID int
+	// MessageThreadID targets a forum topic in a supergroup
+	MessageThreadID int
+	// RequestID tags the delivery's log lines with the request that caused it
+	RequestID string
+}
+
+// SendOption configures SendOptions
+type SendOption func(*SendOptions)
+
+// Silent delivers the message without a notification sound
+func Silent() SendOption {
+	return func(o *SendOptions) { o.DisableNotification = true }
+}
+
+// Protected forbids forwarding and saving the message
+func Protected() SendOption {
+	return func(o *SendOptions) { o.ProtectContent = true }
+}
+
+// NoWebPagePreview suppresses link previews
+func NoWebPagePreview() SendOption {
+	return func(o *SendOptions) { o.DisableWebPagePreview = true }
+}
+
+// ReplyTo sends the message as a reply to messageID
+func ReplyTo(messageID int) SendOption {
+	return func(o *SendOptions) { o.ReplyToMessageID = messageID }
+}
+
+// InTopic sends the message into a forum topic
+func InTopic(threadID int) SendOption {
+	return func(o *SendOptions) { o.MessageThreadID = threadID }
+}
+
+// WithRequestID tags the delivery with the request ID carried by ctx, see requestid
+func WithRequestID(ctx context.Context) SendOption {
+	return func(o *SendOptions) { o.RequestID = requestid.From(ctx) }
+}
+
+func collectOptions(opts []SendOption) SendOptions {
+	var o SendOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// context returns the context of the delivery, carrying its request ID
+func (o SendOptions) context() context.Context {
+	if o.RequestID == "" {
+		return context.Background()
+	}
+	return requestid.With(context.Background(), o.RequestID)
+}
+
+// apply sets the options natively supported by the bot API library on c
+func (o SendOptions) apply(c tba.Chattable) tba.Chattable {
+	switch m := c.(type) {
+	case tba.MessageConfig:
+		o.applyBase(&m.BaseChat)
+		m.DisableWebPagePreview = o.DisableWebPagePreview
+		return m
+	case tba.EditMessageTextConfig:
+		m.DisableWebPagePreview = o.DisableWebPagePreview
+		return m
+	case tba.PhotoConfig:
+		o.applyBase(&m.BaseChat)
+		return m
+	case tba.DocumentConfig:
+		o.applyBase(&m.BaseChat)
+		return m
+	case tba.LocationConfig:
+		o.applyBase(&m.BaseChat)
+		return m
+	case tba.VenueConfig:
+		o.applyBase(&m.BaseChat)
+		return m
+	case tba.InvoiceConfig:
+		o.applyBase(&m.BaseChat)
+		return m
+	case tba.SendPollConfig:
+		o.applyBase(&m.BaseChat)
+		return m
+	case tba.MediaGroupConfig:
+		m.DisableNotification = o.DisableNotification
+		m.ReplyToMessageID = o.ReplyToMessageID
+		return m
+	default:
+		return c
+	}
+}
+
+func (o SendOptions) applyBase(base *tba.BaseChat) {
+	base.DisableNotification = o.DisableNotification
+	if o.ReplyToMessageID != 0 {
+		base.ReplyToMessageID = o.ReplyToMessageID
+		base.AllowSendingWithoutReply = true
+	}
+}
+
+// forEdit drops the options an edit cannot change, so options meant for a new message
+// can be passed to an edit of it
+func forEdit() SendOption {
+	return func(o *SendOptions) {
+		o.ProtectContent = false
+		o.MessageThreadID = 0
+	}
+}
+
+// needsRawRequest reports whether some option is unknown to the bot API library
+func (o SendOptions) needsRawRequest() bool {
+	return o.ProtectContent || o.MessageThreadID != 0
+}
+
+// addRawParams adds the options the bot API library cannot express
+func (o SendOptions) addRawParams(params tba.Params) {
+	params.AddBool("protect_content", o.ProtectContent)
+	params.AddNonZero("message_thread_id", o.MessageThreadID)
+}
+
+// call performs the request, building parameters by hand when options require it;
+// message kinds without a raw encoding fail with ErrUnsupportedOption.
+func (bc *BotClient) call(c tba.Chattable, o SendOptions) (result json.RawMessage, err error) {
+	start := time.Now()
+	defer func() { bc.metrics.observeAttempt(requestKind(c, kindSend), start, err) }()
+
+	var endpoint string
+	var params tba.Params
+	if o.needsRawRequest() {
+		var err error
+		endpoint, params, err = rawParams(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if params == nil {
+		resp, err := bc.bot.Request(c)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Result, nil
+	}
+
+	o.addRawParams(params)
+
+	resp, err := bc.bot.MakeRequest(endpoint, params)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Result, nil
+}
+
+// rawParams mirrors the library's parameter encoding for the message kinds this package
+// sends with options the library cannot express
+func rawParams(c tba.Chattable) (string, tba.Params, error) {
+	params := make(tba.Params)
+
+	addBase := func(base tba.BaseChat) error {
+		params.AddNonZero64("chat_id", base.ChatID)
+		params.AddNonZero("reply_to_message_id", base.ReplyToMessageID)
+		params.AddBool("disable_notification", base.DisableNotification)
+		params.AddBool("allow_sending_without_reply", base.AllowSendingWithoutReply)
+		return params.AddInterface("reply_markup", base.ReplyMarkup)
+	}
+
+	switch m := c.(type) {
+	case tba.MessageConfig:
+		params.AddNonEmpty("text", m.Text)
+		params.AddNonEmpty("parse_mode", m.ParseMode)
+		params.AddBool("disable_web_page_preview", m.DisableWebPagePreview)
+		return "sendMessage", params, addBase(m.BaseChat)
+	case tba.PhotoConfig:
+		if m.File.NeedsUpload() {
+			return "", nil, fmt.Errorf("photo uploads do not support raw send options")
+		}
+		params["photo"] = m.File.SendData()
+		params.AddNonEmpty("caption", m.Caption)
+		params.AddNonEmpty("parse_mode", m.ParseMode)
+		return "sendPhoto", params, addBase(m.BaseChat)
+	case tba.DocumentConfig:
+		if m.File.NeedsUpload() || m.Thumb != nil {
+			return "", nil, fmt.Errorf("document uploads do not support raw send options")
+		}
+		params["document"] = m.File.SendData()
+		params.AddNonEmpty("caption", m.Caption)
+		params.AddNonEmpty("parse_mode", m.ParseMode)
+		params.AddBool("disable_content_type_detection", m.DisableContentTypeDetection)
+		return "sendDocument", params, addBase(m.BaseChat)
+	case tba.LocationConfig:
+		params.AddNonZeroFloat("latitude", m.Latitude)
+		params.AddNonZeroFloat("longitude", m.Longitude)
+		return "sendLocation", params, addBase(m.BaseChat)
+	case tba.VenueConfig:
+		params.AddNonZeroFloat("latitude", m.Latitude)
+		params.AddNonZeroFloat("longitude", m.Longitude)
+		params["title"] = m.Title
+		params["address"] = m.Address
+		return "sendVenue", params, addBase(m.BaseChat)
+	case tba.MediaGroupConfig:
+		params.AddNonZero64("chat_id", m.ChatID)
+		params.AddBool("disable_notification", m.DisableNotification)
+		params.AddNonZero("reply_to_message_id", m.ReplyToMessageID)
+		return "sendMediaGroup", params, params.AddInterface("media", m.Media)
+	default:
+		// e.g. edits: the message keeps the protection and topic it was sent with
+		return "", nil, fmt.Errorf("%w: %T takes no protect_content or message_thread_id", ErrUnsupportedOption, c)
+	}
+}
@@ -0,0 +1,259 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/arseniisemenow/bbc-common/pkg/clock"
+	"github.com/arseniisemenow/bbc-common/pkg/idgen"
+	"github.com/arseniisemenow/bbc-common/pkg/logging"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// OutboxStore persists queued messages; ydb.OutboxStore satisfies it
+type OutboxStore interface {
+	Enqueue(ctx context.Context, msg *models.OutboxMessage) error
+	// Claim returns up to limit pending messages due at now and leases them until
+	// leaseUntil, so overlapping drains skip them; MarkSent and MarkAttempt end the lease
+	Claim(ctx context.Context, now time.Time, limit int, leaseUntil time.Time) ([]models.OutboxMessage, error)
+	MarkSent(ctx context.Context, id string) error
+	MarkAttempt(ctx context.Context, id string, status models.OutboxStatus, attempts int, nextAttemptAt time.Time, lastError string) error
+	// DeadLetters returns up to limit messages given up on, most recent first
+	DeadLetters(ctx context.Context, limit int) ([]models.OutboxMessage, error)
+	// Redrive makes a dead letter pending again with a fresh attempt budget
+	Redrive(ctx context.Context, id string) error
+}
+
+// QueuedMessage is the payload stored in the outbox
+type QueuedMessage struct {
+	Text     string                    `json:"text"`
+	Keyboard *tba.InlineKeyboardMarkup `json:"keyboard,omitempty"`
+	Options  SendOptions               `json:"options"`
+}
+
+// DrainStats summarizes one Drain call
+type DrainStats struct {
+	Sent    int
+	Retried int
+	// Failed counts messages given up on and moved to the dead letters
+	Failed int
+}
+
+// Outbox is a durable send queue: messages are persisted first and delivered by Drain,
+// so a Telegram outage or function timeout does not lose them
+type Outbox struct {
+	store   OutboxStore
+	sender  BotSender
+	limiter *Limiter
+
+	// MaxAttempts after which a message becomes a dead letter
+	MaxAttempts int
+	// BatchSize is the number of messages fetched per round
+	BatchSize int
+	// ClaimTimeout is how long claimed messages are hidden from other drains; a message
+	// still unsent after it, e.g. because the drain crashed, is sent again
+	ClaimTimeout time.Duration
+	// Backoff returns the delay before the given (1-based) retry attempt
+	Backoff func(attempt int) time.Duration
+	// OnDeadLetter, if set, is called for every message given up on, e.g. to alert admins
+	OnDeadLetter func(ctx context.Context, msg models.OutboxMessage, err error)
+	// Clock schedules sends and retries, clock.Real by default
+	Clock clock.Clock
+}
+
+// NewOutbox creates an outbox delivering through sender; limiter may be nil when sender already throttles
+func NewOutbox(store OutboxStore, sender BotSender, limiter *Limiter) *Outbox {
+	return &Outbox{
+		store:        store,
+		sender:       sender,
+		limiter:      limiter,
+		MaxAttempts:  5,
+		BatchSize:    50,
+		ClaimTimeout: 5 * time.Minute,
+		Backoff: func(attempt int) time.Duration {
+			return time.Duration(1<<min(attempt, 10)) * 30 * time.Second
+		},
+		Clock: clock.Real,
+	}
+}
+
+// Enqueue persists a message for delivery as soon as possible and returns its ID
+func (o *Outbox) Enqueue(ctx context.Context, chatID int64, msg QueuedMessage) (string, error) {
+	return o.EnqueueAt(ctx, chatID, msg, o.Clock.Now())
+}
+
+// EnqueueAt persists a message for delivery not before at and returns its ID
+func (o *Outbox) EnqueueAt(ctx context.Context, chatID int64, msg QueuedMessage, at time.Time) (string, error) {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode queued message: %w", err)
+	}
+
+	record := &models.OutboxMessage{
+		ID:             idgen.New(),
+		TelegramChatID: chatID,
+		Payload:        string(payload),
+		Status:         models.OutboxStatusPending,
+		NextAttemptAt:  at,
+		CreatedAt:      o.Clock.Now(),
+	}
+	if err := o.store.Enqueue(ctx, record); err != nil {
+		return "", fmt.Errorf("failed to enqueue message for chat %d: %w", chatID, err)
+	}
+	return record.ID, nil
+}
+
+// Drain delivers due messages until none are left or ctx is done. Messages are claimed
+// before they are sent, so drains running at once never send the same message.
+func (o *Outbox) Drain(ctx context.Context) (DrainStats, error) {
+	var stats DrainStats
+	for {
+		now := o.Clock.Now()
+		msgs, err := o.store.Claim(ctx, now, o.BatchSize, now.Add(o.ClaimTimeout))
+		if err != nil {
+			return stats, fmt.Errorf("failed to load due outbox messages: %w", err)
+		}
+		if len(msgs) == 0 {
+			return stats, nil
+		}
+
+		for _, msg := range msgs {
+			if err := ctx.Err(); err != nil {
+				return stats, err
+			}
+			if err := o.deliver(ctx, msg, &stats); err != nil {
+				return stats, err
+			}
+		}
+
+		if len(msgs) < o.BatchSize {
+			return stats, nil
+		}
+	}
+}
+
+// Run drains the outbox every interval until ctx is cancelled, for long-running deployments
+func (o *Outbox) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if stats, err := o.Drain(ctx); err != nil && ctx.Err() == nil {
+			logger(ctx).Error("Outbox drain failed", "error", err)
+		} else if stats.Sent+stats.Retried+stats.Failed > 0 {
+			logger(ctx).Info("Outbox drained", "sent", stats.Sent, "retried", stats.Retried, "failed", stats.Failed)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// deliver sends one message and records the outcome; only store errors and the end of
+// ctx are returned. A payload that cannot be decoded is dead-lettered right away, and a
+// wait for the limiter cut short by ctx leaves the message's attempts untouched.
+func (o *Outbox) deliver(ctx context.Context, msg models.OutboxMessage, stats *DrainStats) error {
+	var queued QueuedMessage
+	if err := json.Unmarshal([]byte(msg.Payload), &queued); err != nil {
+		return o.deadLetter(ctx, msg, msg.Attempts+1, fmt.Errorf("failed to decode queued message: %w", err), stats)
+	}
+	var sendErr error
+	if o.limiter != nil {
+		sendErr = o.limiter.Wait(ctx, msg.TelegramChatID)
+		if err := ctx.Err(); err != nil {
+			// the claim lapses and the next drain sends the message
+			return err
+		}
+	}
+	if sendErr == nil {
+		var keyboard interface{}
+		if queued.Keyboard != nil {
+			keyboard = *queued.Keyboard
+		}
+		_, sendErr = o.sender.SendMessageWithKeyboard(msg.TelegramChatID, queued.Text, keyboard, queued.Options.asOptions()...)
+	}
+
+	if sendErr == nil {
+		stats.Sent++
+		return o.store.MarkSent(ctx, msg.ID)
+	}
+
+	attempts := msg.Attempts + 1
+	permanent := errors.Is(sendErr, ErrBotBlocked) || errors.Is(sendErr, ErrChatNotFound)
+	if permanent || attempts >= o.MaxAttempts {
+		return o.deadLetter(ctx, msg, attempts, sendErr, stats)
+	}
+
+	next := o.Clock.Now().Add(o.Backoff(attempts))
+	var limited *RateLimitedError
+	if errors.As(sendErr, &limited) && limited.RetryAfter > 0 {
+		next = o.Clock.Now().Add(limited.RetryAfter)
+	}
+
+	stats.Retried++
+	logger(ctx).Warn("Outbox message failed, retrying", "outbox_id", msg.ID, logging.ChatIDKey, msg.TelegramChatID, "attempt", attempts, "next_attempt_at", next, "error", sendErr)
+	return o.store.MarkAttempt(ctx, msg.ID, models.OutboxStatusPending, attempts, next, sendErr.Error())
+}
+
+// deadLetter gives up on msg after attempts because of err
+func (o *Outbox) deadLetter(ctx context.Context, msg models.OutboxMessage, attempts int, err error, stats *DrainStats) error {
+	stats.Failed++
+	logger(ctx).Error("Outbox message dead-lettered", "outbox_id", msg.ID, logging.ChatIDKey, msg.TelegramChatID, "attempts", attempts, "error", err)
+	if storeErr := o.store.MarkAttempt(ctx, msg.ID, models.OutboxStatusDeadLetter, attempts, o.Clock.Now(), err.Error()); storeErr != nil {
+		return storeErr
+	}
+	if o.OnDeadLetter != nil {
+		msg.Status = models.OutboxStatusDeadLetter
+		msg.Attempts = attempts
+		msg.LastError = err.Error()
+		o.OnDeadLetter(ctx, msg, err)
+	}
+	return nil
+}
+
+// ListDeadLetters returns up to limit messages given up on, most recent first, with
+// their attempt count and last error
+func (o *Outbox) ListDeadLetters(ctx context.Context, limit int) ([]models.OutboxMessage, error) {
+	msgs, err := o.store.DeadLetters(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox dead letters: %w", err)
+	}
+	return msgs, nil
+}
+
+// Redrive queues dead letters for delivery on the next Drain, e.g. from an admin
+// command after an outage; IDs that are not dead letters are ignored
+func (o *Outbox) Redrive(ctx context.Context, ids ...string) error {
+	for _, id := range ids {
+		if err := o.store.Redrive(ctx, id); err != nil {
+			return fmt.Errorf("failed to redrive outbox message %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// RedriveAll queues up to limit dead letters for delivery and returns how many
+func (o *Outbox) RedriveAll(ctx context.Context, limit int) (int, error) {
+	msgs, err := o.ListDeadLetters(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+	for i, msg := range msgs {
+		if err := o.store.Redrive(ctx, msg.ID); err != nil {
+			return i, fmt.Errorf("failed to redrive outbox message %s: %w", msg.ID, err)
+		}
+	}
+	return len(msgs), nil
+}
+
+// asOptions turns stored options back into SendOption values
+func (o SendOptions) asOptions() []SendOption {
+	return []SendOption{func(target *SendOptions) { *target = o }}
+}
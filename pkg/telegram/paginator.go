@@ -0,0 +1,109 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// PageSource loads the full list of rendered items for a chat
+type PageSource func(ctx context.Context, chatID int64) ([]string, error)
+
+// Paginator renders long lists as pages with ⏮ ◀ x/y ▶ ⏭ navigation.
+// The page number travels in callback data as "<action>:<page>".
+type Paginator struct {
+	sender   BotSender
+	action   string
+	pageSize int
+	source   PageSource
+
+	// Header is prepended to every page
+	Header string
+	// EmptyText is shown when the source returns no items
+	EmptyText string
+}
+
+// NewPaginator creates a paginator; register Handler under action with a CallbackRouter
+func NewPaginator(sender BotSender, action string, pageSize int, source PageSource) *Paginator {
+	if pageSize <= 0 {
+		pageSize = 5
+	}
+	return &Paginator{
+		sender:    sender,
+		action:    action,
+		pageSize:  pageSize,
+		source:    source,
+		EmptyText: "Nothing to show",
+	}
+}
+
+// Send shows the first page to chatID and returns the message ID
+func (p *Paginator) Send(ctx context.Context, chatID int64) (int, error) {
+	return p.show(ctx, chatID, 0, 0)
+}
+
+// Handler returns a callback handler that switches the message to the requested page
+func (p *Paginator) Handler() CallbackHandlerFunc {
+	return func(ctx context.Context, query *tba.CallbackQuery, params []string) error {
+		if query.Message == nil || len(params) == 0 {
+			return nil
+		}
+		page, err := strconv.Atoi(params[0])
+		if err != nil {
+			return fmt.Errorf("invalid page %q: %w", params[0], err)
+		}
+		_, err = p.show(ctx, query.Message.Chat.ID, query.Message.MessageID, page)
+		return err
+	}
+}
+
+// Render builds the text and navigation keyboard for page (0-based) of items
+func (p *Paginator) Render(items []string, page int) (string, tba.InlineKeyboardMarkup) {
+	if len(items) == 0 {
+		return p.EmptyText, NewKeyboard().Build()
+	}
+
+	pages := (len(items) + p.pageSize - 1) / p.pageSize
+	page = max(0, min(page, pages-1))
+
+	start := page * p.pageSize
+	end := min(start+p.pageSize, len(items))
+
+	var sb strings.Builder
+	if p.Header != "" {
+		sb.WriteString(p.Header)
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString(strings.Join(items[start:end], "\n\n"))
+
+	if pages == 1 {
+		return sb.String(), NewKeyboard().Build()
+	}
+
+	kb := NewKeyboard().Row()
+	if page > 0 {
+		kb.Button("⏮", p.callback(0)).Button("◀", p.callback(page-1))
+	}
+	kb.Button(fmt.Sprintf("%d/%d", page+1, pages), p.callback(page))
+	if page < pages-1 {
+		kb.Button("▶", p.callback(page+1)).Button("⏭", p.callback(pages-1))
+	}
+	return sb.String(), kb.Build()
+}
+
+func (p *Paginator) show(ctx context.Context, chatID int64, messageID, page int) (int, error) {
+	items, err := p.source(ctx, chatID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load items for chat %d: %w", chatID, err)
+	}
+
+	text, keyboard := p.Render(items, page)
+	return p.sender.UpsertMessage(chatID, messageID, text, keyboard)
+}
+
+func (p *Paginator) callback(page int) string {
+	return CreateCallbackData(p.action, strconv.Itoa(page))
+}
@@ -0,0 +1,66 @@
+package telegram
+
+import (
+	"time"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// Invoice describes a product offered for payment, e.g. a premium subscription
+type Invoice struct {
+	Title         string
+	Description   string
+	Payload       string // echoed back in pre-checkout and successful payment updates
+	ProviderToken string
+	Currency      string
+	// Prices are in the smallest currency units, e.g. 499 for 4.99 EUR
+	Prices   []tba.LabeledPrice
+	PhotoURL string
+}
+
+// SendInvoice sends an invoice and returns the message ID
+func (bc *BotClient) SendInvoice(chatID int64, invoice Invoice, opts ...SendOption) (int, error) {
+	c := tba.NewInvoice(chatID, invoice.Title, invoice.Description, invoice.Payload,
+		invoice.ProviderToken, "", invoice.Currency, invoice.Prices)
+	c.PhotoURL = invoice.PhotoURL
+	// the library sends a nil slice as "null", which Telegram fails to parse
+	c.SuggestedTipAmounts = []int{}
+
+	sent, err := bc.send(chatID, c, opts...)
+	if err != nil {
+		return 0, err
+	}
+	return sent.MessageID, nil
+}
+
+// AnswerPreCheckoutQuery confirms or rejects a checkout; errorMessage is shown to the user when rejecting
+func (bc *BotClient) AnswerPreCheckoutQuery(queryID string, ok bool, errorMessage string) error {
+	c := tba.PreCheckoutConfig{
+		PreCheckoutQueryID: queryID,
+		OK:                 ok,
+	}
+	if !ok {
+		c.ErrorMessage = errorMessage
+	}
+	return bc.request(c)
+}
+
+// ParseSuccessfulPayment converts a successful payment message into a Payment record.
+// It returns nil when the update does not carry a payment.
+func ParseSuccessfulPayment(update *tba.Update) *models.Payment {
+	if update.Message == nil || update.Message.SuccessfulPayment == nil {
+		return nil
+	}
+	p := update.Message.SuccessfulPayment
+	return &models.Payment{
+		ID:               p.TelegramPaymentChargeID,
+		TelegramChatID:   update.Message.Chat.ID,
+		Payload:          p.InvoicePayload,
+		Currency:         p.Currency,
+		TotalAmount:      p.TotalAmount,
+		ProviderChargeID: p.ProviderPaymentChargeID,
+		CreatedAt:        time.Unix(int64(update.Message.Date), 0),
+	}
+}
@@ -0,0 +1,69 @@
+package telegram
+
+import (
+	"context"
+	"time"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// PollOptions configures a poll sent with SendPoll
+type PollOptions struct {
+	// Anonymous polls produce no poll_answer updates, so feedback polls leave it false
+	Anonymous       bool
+	MultipleAnswers bool
+	// Quiz makes CorrectOption the right answer, revealing Explanation on a wrong one
+	Quiz          bool
+	CorrectOption int
+	Explanation   string
+	// OpenPeriod closes the poll automatically, between 5 and 600 seconds
+	OpenPeriod time.Duration
+}
+
+// SentPoll identifies a sent poll; answers refer to it by PollID only
+type SentPoll struct {
+	PollID    string
+	MessageID int
+}
+
+// PollAnswerHandler handles a user's answer to a non-anonymous poll;
+// OptionIDs is empty when the vote was retracted
+type PollAnswerHandler func(ctx context.Context, answer *tba.PollAnswer) error
+
+// SendPoll sends a poll, e.g. "Did you get the ride?" after a booked trip
+func (bc *BotClient) SendPoll(chatID int64, question string, answers []string, poll PollOptions, opts ...SendOption) (SentPoll, error) {
+	c := tba.NewPoll(chatID, question, answers...)
+	c.IsAnonymous = poll.Anonymous
+	c.AllowsMultipleAnswers = poll.MultipleAnswers
+	if poll.Quiz {
+		c.Type = "quiz"
+		c.CorrectOptionID = int64(poll.CorrectOption)
+		c.Explanation = poll.Explanation
+	}
+	c.OpenPeriod = int(poll.OpenPeriod / time.Second)
+
+	sent, err := bc.send(chatID, c, opts...)
+	if err != nil {
+		return SentPoll{}, err
+	}
+	result := SentPoll{MessageID: sent.MessageID}
+	if sent.Poll != nil {
+		result.PollID = sent.Poll.ID
+	}
+	return result, nil
+}
+
+// StopPoll closes a poll so no further answers are accepted
+func (bc *BotClient) StopPoll(chatID int64, messageID int) error {
+	return bc.request(tba.NewStopPoll(chatID, messageID))
+}
+
+// HandlerFunc adapts h for Router.HandlePollAnswers
+func (h PollAnswerHandler) HandlerFunc() HandlerFunc {
+	return func(ctx context.Context, update *tba.Update) error {
+		if update.PollAnswer == nil {
+			return nil
+		}
+		return h(ctx, update.PollAnswer)
+	}
+}
@@ -0,0 +1,245 @@
+package telegram
+
+import (
+	"strings"
+	"sync"
+)
+
+// Kinds of calls captured by Recorder
+const (
+	RecordSend           = "send"
+	RecordEdit           = "edit"
+	RecordEditKeyboard   = "edit_keyboard"
+	RecordDelete         = "delete"
+	RecordPhoto          = "photo"
+	RecordMediaGroup     = "media_group"
+	RecordLocation       = "location"
+	RecordVenue          = "venue"
+	RecordCallbackAnswer = "callback_answer"
+)
+
+// RecordedMessage is one call captured by Recorder
+type RecordedMessage struct {
+	Kind      string
+	ChatID    int64
+	MessageID int
+	Text      string
+	ParseMode string
+	Keyboard  interface{}
+	Options   SendOptions
+	// CallbackQueryID is set for RecordCallbackAnswer
+	CallbackQueryID string
+}
+
+// TB is the subset of testing.TB used by the assertion helpers
+type TB interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// Recorder is an in-memory BotSender for tests that captures every outgoing call
+type Recorder struct {
+	mu        sync.Mutex
+	messages  []RecordedMessage
+	nextID    int
+	failNext  []error
+	messageOf map[int]int64
+}
+
+// NewRecorder creates an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{nextID: 1, messageOf: make(map[int]int64)}
+}
+
+// FailNext makes the next call return err instead of succeeding
+func (r *Recorder) FailNext(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failNext = append(r.failNext, err)
+}
+
+// Messages returns all captured calls in order
+func (r *Recorder) Messages() []RecordedMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]RecordedMessage(nil), r.messages...)
+}
+
+// MessagesTo returns captured calls for one chat
+func (r *Recorder) MessagesTo(chatID int64) []RecordedMessage {
+	var out []RecordedMessage
+	for _, m := range r.Messages() {
+		if m.ChatID == chatID {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// Last returns the most recent call, if any
+func (r *Recorder) Last() (RecordedMessage, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.messages) == 0 {
+		return RecordedMessage{}, false
+	}
+	return r.messages[len(r.messages)-1], true
+}
+
+// Reset returns the recorder to its state after NewRecorder: captured calls, pending
+// failures and message IDs are forgotten
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = nil
+	r.failNext = nil
+	r.nextID = 1
+	r.messageOf = make(map[int]int64)
+}
+
+// AssertSentContaining fails the test unless some message to chatID contains substr
+func (r *Recorder) AssertSentContaining(t TB, chatID int64, substr string) {
+	t.Helper()
+	for _, m := range r.MessagesTo(chatID) {
+		if strings.Contains(m.Text, substr) {
+			return
+		}
+	}
+	t.Errorf("no message to chat %d contains %q; got %d messages", chatID, substr, len(r.MessagesTo(chatID)))
+}
+
+// AssertNotSentContaining fails the test if any message to chatID contains substr
+func (r *Recorder) AssertNotSentContaining(t TB, chatID int64, substr string) {
+	t.Helper()
+	for _, m := range r.MessagesTo(chatID) {
+		if strings.Contains(m.Text, substr) {
+			t.Errorf("unexpected message to chat %d containing %q: %q", chatID, substr, m.Text)
+			return
+		}
+	}
+}
+
+// AssertCount fails the test unless exactly n calls of kind were made to chatID
+func (r *Recorder) AssertCount(t TB, chatID int64, kind string, n int) {
+	t.Helper()
+	got := 0
+	for _, m := range r.MessagesTo(chatID) {
+		if m.Kind == kind {
+			got++
+		}
+	}
+	if got != n {
+		t.Errorf("expected %d %s calls to chat %d, got %d", n, kind, chatID, got)
+	}
+}
+
+// AssertNothingSent fails the test if any call was captured
+func (r *Recorder) AssertNothingSent(t TB) {
+	t.Helper()
+	if msgs := r.Messages(); len(msgs) > 0 {
+		t.Errorf("expected no calls, got %d (first: %+v)", len(msgs), msgs[0])
+	}
+}
+
+func (r *Recorder) record(m RecordedMessage, opts []SendOption) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.failNext) > 0 {
+		err := r.failNext[0]
+		r.failNext = r.failNext[1:]
+		return 0, err
+	}
+
+	m.Options = collectOptions(opts)
+	if m.MessageID == 0 && m.Kind != RecordCallbackAnswer {
+		m.MessageID = r.nextID
+		r.nextID++
+		r.messageOf[m.MessageID] = m.ChatID
+	}
+	r.messages = append(r.messages, m)
+	return m.MessageID, nil
+}
+
+// SendPlainMessage records a text message
+func (r *Recorder) SendPlainMessage(chatID int64, text string, opts ...SendOption) error {
+	_, err := r.record(RecordedMessage{Kind: RecordSend, ChatID: chatID, Text: text, ParseMode: "MarkdownV2"}, opts)
+	return err
+}
+
+// SendMessageWithKeyboard records a text message with a keyboard
+func (r *Recorder) SendMessageWithKeyboard(chatID int64, text string, keyboard interface{}, opts ...SendOption) (int, error) {
+	return r.record(RecordedMessage{Kind: RecordSend, ChatID: chatID, Text: text, ParseMode: "MarkdownV2", Keyboard: keyboard}, opts)
+}
+
+// EditMessage records a text edit
+func (r *Recorder) EditMessage(chatID int64, messageID int, text string, opts ...SendOption) error {
+	_, err := r.record(RecordedMessage{Kind: RecordEdit, ChatID: chatID, MessageID: messageID, Text: text, ParseMode: "MarkdownV2"}, opts)
+	return err
+}
+
+// UpsertMessage records an edit when messageID is known to the recorder and a send otherwise
+func (r *Recorder) UpsertMessage(chatID int64, existingMessageID int, text string, keyboard interface{}, opts ...SendOption) (int, error) {
+	r.mu.Lock()
+	_, known := r.messageOf[existingMessageID]
+	r.mu.Unlock()
+
+	if existingMessageID == 0 || !known {
+		return r.SendMessageWithKeyboard(chatID, text, keyboard, opts...)
+	}
+	return r.record(RecordedMessage{Kind: RecordEdit, ChatID: chatID, MessageID: existingMessageID, Text: text, ParseMode: "MarkdownV2", Keyboard: keyboard}, opts)
+}
+
+// AnswerCallbackQuery records a callback answer
+func (r *Recorder) AnswerCallbackQuery(callbackQueryID, text string) error {
+	_, err := r.record(RecordedMessage{Kind: RecordCallbackAnswer, CallbackQueryID: callbackQueryID, Text: text}, nil)
+	return err
+}
+
+// DeleteMessage records a deletion
+func (r *Recorder) DeleteMessage(chatID int64, messageID int) error {
+	_, err := r.record(RecordedMessage{Kind: RecordDelete, ChatID: chatID, MessageID: messageID}, nil)
+	return err
+}
+
+// EditMessageKeyboard records a keyboard-only edit; like BotClient it rejects keyboards
+// other than inline ones with ErrUnsupportedKeyboard
+func (r *Recorder) EditMessageKeyboard(chatID int64, messageID int, keyboard interface{}) error {
+	if _, err := editKeyboardMarkup(keyboard); err != nil {
+		return err
+	}
+	_, err := r.record(RecordedMessage{Kind: RecordEditKeyboard, ChatID: chatID, MessageID: messageID, Keyboard: keyboard}, nil)
+	return err
+}
+
+// SendPhoto records a photo; Text holds the caption
+func (r *Recorder) SendPhoto(chatID int64, photo, caption string, keyboard interface{}, opts ...SendOption) (int, error) {
+	return r.record(RecordedMessage{Kind: RecordPhoto, ChatID: chatID, Text: caption, ParseMode: "MarkdownV2", Keyboard: keyboard}, opts)
+}
+
+// SendMediaGroup records an album as a single call; one message ID is returned per photo
+func (r *Recorder) SendMediaGroup(chatID int64, photos []string, caption string, opts ...SendOption) ([]int, error) {
+	id, err := r.record(RecordedMessage{Kind: RecordMediaGroup, ChatID: chatID, Text: caption, ParseMode: "MarkdownV2"}, opts)
+	if err != nil {
+		return nil, err
+	}
+	ids := []int{id}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := 1; i < len(photos); i++ {
+		ids = append(ids, r.nextID)
+		r.messageOf[r.nextID] = chatID
+		r.nextID++
+	}
+	return ids, nil
+}
+
+// SendLocation records a location
+func (r *Recorder) SendLocation(chatID int64, lat, lon float64, opts ...SendOption) (int, error) {
+	return r.record(RecordedMessage{Kind: RecordLocation, ChatID: chatID, Text: MapLink(lat, lon)}, opts)
+}
+
+// SendVenue records a venue; Text holds the formatted meeting point
+func (r *Recorder) SendVenue(chatID int64, title, address string, lat, lon float64, opts ...SendOption) (int, error) {
+	return r.record(RecordedMessage{Kind: RecordVenue, ChatID: chatID, Text: FormatMeetingPoint(title, address, lat, lon)}, opts)
+}
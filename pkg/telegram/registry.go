@@ -0,0 +1,108 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type botContextKey struct{}
+
+type registeredBot struct {
+	name   string
+	client *BotClient
+	router *Router
+}
+
+// BotRegistry manages several bots (e.g. "prod", "staging", "admin") and routes
+// updates to the router registered for the bot that received them
+type BotRegistry struct {
+	mu      sync.RWMutex
+	byName  map[string]*registeredBot
+	byToken map[string]*registeredBot
+}
+
+// NewBotRegistry creates an empty registry
+func NewBotRegistry() *BotRegistry {
+	return &BotRegistry{
+		byName:  make(map[string]*registeredBot),
+		byToken: make(map[string]*registeredBot),
+	}
+}
+
+// Register adds a bot under name together with the router handling its updates
+func (r *BotRegistry) Register(name string, client *BotClient, router *Router) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.byName[name]; exists {
+		return fmt.Errorf("bot %q is already registered", name)
+	}
+	bot := &registeredBot{name: name, client: client, router: router}
+	r.byName[name] = bot
+	r.byToken[client.bot.Token] = bot
+	return nil
+}
+
+// Get returns the client registered under name
+func (r *BotRegistry) Get(name string) (*BotClient, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	bot, ok := r.byName[name]
+	if !ok {
+		return nil, false
+	}
+	return bot.client, true
+}
+
+// NameByToken resolves a bot token, e.g. taken from a webhook path, to the registered name
+func (r *BotRegistry) NameByToken(token string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	bot, ok := r.byToken[token]
+	if !ok {
+		return "", false
+	}
+	return bot.name, true
+}
+
+// Names returns the registered bot names in sorted order
+func (r *BotRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.byName))
+	for name := range r.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Dispatch hands an update received by the named bot to its router;
+// handlers can retrieve the receiving bot with BotFromContext
+func (r *BotRegistry) Dispatch(ctx context.Context, name string, update *tba.Update) error {
+	r.mu.RLock()
+	bot, ok := r.byName[name]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("bot %q is not registered", name)
+	}
+	if bot.router == nil {
+		return nil
+	}
+
+	ctx = context.WithValue(ctx, botContextKey{}, bot)
+	return bot.router.Dispatch(ctx, update)
+}
+
+// BotFromContext returns the bot that received the update being handled
+func BotFromContext(ctx context.Context) (client *BotClient, name string, ok bool) {
+	bot, ok := ctx.Value(botContextKey{}).(*registeredBot)
+	if !ok {
+		return nil, "", false
+	}
+	return bot.client, bot.name, true
+}
@@ -0,0 +1,50 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/arseniisemenow/bbc-common/pkg/retry"
+)
+
+// RetryPolicy controls how requests rejected with 429 Too Many Requests are retried
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt; 0 disables retrying
+	MaxRetries int
+	// MaxWait caps a single wait; a longer retry_after is returned to the caller as an error
+	MaxWait time.Duration
+}
+
+// DefaultRetryPolicy is used by clients unless overridden with SetRetryPolicy
+var DefaultRetryPolicy = RetryPolicy{MaxRetries: 3, MaxWait: 30 * time.Second}
+
+// do runs fn, sleeping for the server-provided retry_after (or exponential backoff
+// when absent) between attempts that failed with 429
+func (p RetryPolicy) do(ctx context.Context, fn func() error) error {
+	policy := retry.New(
+		retry.MaxAttempts(p.MaxRetries+1),
+		retry.Backoff(time.Second, p.MaxWait),
+		retry.If(func(err error) bool {
+			_, limited := retryAfter(err)
+			return limited
+		}),
+		retry.WithHint(retryAfter),
+		retry.OnRetry(func(ctx context.Context, attempt int, _ error, wait time.Duration) {
+			logger(ctx).Warn("Rate limited, retrying", "wait", wait, "attempt", attempt, "max_retries", p.MaxRetries)
+		}),
+	)
+	return policy.Do(ctx, func(context.Context) error { return fn() })
+}
+
+// retryAfter reports whether err is a 429 response and how long Telegram asked to wait
+func retryAfter(err error) (time.Duration, bool) {
+	var apiErr *tba.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusTooManyRequests {
+		return 0, false
+	}
+	return time.Duration(apiErr.RetryAfter) * time.Second, true
+}
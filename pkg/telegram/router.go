@@ -0,0 +1,260 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/arseniisemenow/bbc-common/pkg/analytics"
+	"github.com/arseniisemenow/bbc-common/pkg/i18n"
+	"github.com/arseniisemenow/bbc-common/pkg/logging"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+	"github.com/arseniisemenow/bbc-common/pkg/ratelimit"
+	"github.com/arseniisemenow/bbc-common/pkg/recovery"
+	"github.com/arseniisemenow/bbc-common/pkg/requestid"
+)
+
+// HandlerFunc handles a single incoming update
+type HandlerFunc func(ctx context.Context, update *tba.Update) error
+
+// Middleware wraps a HandlerFunc with additional behaviour
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Router dispatches updates to command handlers through a middleware chain
+type Router struct {
+	handlers       map[string]HandlerFunc
+	defaultHandler HandlerFunc
+	middlewares    []Middleware
+	callbackStore  *CallbackStore
+	callbacks      *CallbackRouter
+	inlineQueries  HandlerFunc
+	myChatMember   HandlerFunc
+	pollAnswers    HandlerFunc
+}
+
+// NewRouter creates an empty command router
+func NewRouter() *Router {
+	return &Router{handlers: make(map[string]HandlerFunc)}
+}
+
+// Handle registers a handler for a command, e.g. "/subscribe"
+func (r *Router) Handle(command string, handler HandlerFunc) {
+	r.handlers[normalizeCommand(command)] = handler
+}
+
+// HandleDefault registers a handler for updates that match no command
+func (r *Router) HandleDefault(handler HandlerFunc) {
+	r.defaultHandler = handler
+}
+
+// Use appends middleware; middleware runs in the order it was added
+func (r *Router) Use(middlewares ...Middleware) {
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
+// HandleCallbacks routes all callback queries to the given callback router
+func (r *Router) HandleCallbacks(cr *CallbackRouter) {
+	r.callbacks = cr
+}
+
+// HandleInlineQueries routes all inline queries to handler, see BotClient.InlineHandler
+func (r *Router) HandleInlineQueries(handler HandlerFunc) {
+	r.inlineQueries = handler
+}
+
+// HandleMyChatMember routes changes of the bot's membership, e.g. blocks, to handler;
+// see MembershipHandler.HandlerFunc
+func (r *Router) HandleMyChatMember(handler HandlerFunc) {
+	r.myChatMember = handler
+}
+
+// HandlePollAnswers routes answers to non-anonymous polls to handler;
+// see PollAnswerHandler.HandlerFunc
+func (r *Router) HandlePollAnswers(handler HandlerFunc) {
+	r.pollAnswers = handler
+}
+
+// UseCallbackStore makes the router expand stored callback tokens before dispatching;
+// a token that does not resolve is answered with the callback router's ErrorText
+func (r *Router) UseCallbackStore(store *CallbackStore) {
+	r.callbackStore = store
+}
+
+// Dispatch routes an update to the matching handler; ctx gets a request ID unless it
+// already carries one, e.g. from the webhook call, and the chat ID of the update for logging.
+// A panic of the handler is returned as a *recovery.PanicError.
+func (r *Router) Dispatch(ctx context.Context, update *tba.Update) error {
+	ctx, _ = requestid.Ensure(ctx)
+	if chatID, ok := ChatIDFromUpdate(update); ok {
+		ctx = logging.WithChatID(ctx, chatID)
+	}
+	if r.callbackStore != nil && update.CallbackQuery != nil {
+		data, err := r.callbackStore.Resolve(ctx, update.CallbackQuery.Data)
+		if err != nil {
+			// e.g. an expired token: stop the client's spinner and tell the user
+			if r.callbacks != nil {
+				r.callbacks.answer(ctx, update.CallbackQuery.ID, r.callbacks.ErrorText)
+			}
+			return err
+		}
+		update.CallbackQuery.Data = data
+	}
+
+	handler := r.resolve(update)
+	if handler == nil {
+		return nil
+	}
+
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		handler = r.middlewares[i](handler)
+	}
+	return recovery.Do(ctx, func() error { return handler(ctx, update) })
+}
+
+func (r *Router) resolve(update *tba.Update) HandlerFunc {
+	if update.CallbackQuery != nil && r.callbacks != nil {
+		return r.callbacks.HandlerFunc()
+	}
+	if update.InlineQuery != nil && r.inlineQueries != nil {
+		return r.inlineQueries
+	}
+	if update.MyChatMember != nil && r.myChatMember != nil {
+		return r.myChatMember
+	}
+	if update.PollAnswer != nil && r.pollAnswers != nil {
+		return r.pollAnswers
+	}
+	if update.Message != nil && update.Message.IsCommand() {
+		if handler, ok := r.handlers[normalizeCommand(update.Message.Command())]; ok {
+			return handler
+		}
+	}
+	return r.defaultHandler
+}
+
+func normalizeCommand(command string) string {
+	return strings.ToLower(strings.TrimPrefix(command, "/"))
+}
+
+// CommandArgs returns the whitespace-separated arguments of a command message
+func CommandArgs(update *tba.Update) []string {
+	if update.Message == nil || !update.Message.IsCommand() {
+		return nil
+	}
+	return strings.Fields(update.Message.CommandArguments())
+}
+
+// ChatIDFromUpdate returns the chat ID the update originated from
+func ChatIDFromUpdate(update *tba.Update) (int64, bool) {
+	chat := update.FromChat()
+	if chat == nil {
+		return 0, false
+	}
+	return chat.ID, true
+}
+
+// LoggingMiddleware logs every update together with its handling time and error; the
+// records carry the request and chat ID set by Dispatch
+func LoggingMiddleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, update *tba.Update) error {
+			start := time.Now()
+			err := next(ctx, update)
+			if err != nil {
+				logger(ctx).Error("Update failed", "update_id", update.UpdateID, "duration", time.Since(start), "error", err)
+			} else {
+				logger(ctx).Info("Update handled", "update_id", update.UpdateID, "duration", time.Since(start))
+			}
+			return err
+		}
+	}
+}
+
+// AnalyticsMiddleware records a models.EventCommandInvoked event for every command
+// message, with the command (without slash) in the "command" property
+func AnalyticsMiddleware(emitter *analytics.Emitter) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, update *tba.Update) error {
+			if update.Message != nil && update.Message.IsCommand() {
+				emitter.Track(ctx, models.EventCommandInvoked, update.Message.Chat.ID, map[string]any{
+					"command": normalizeCommand(update.Message.Command()),
+				})
+			}
+			return next(ctx, update)
+		}
+	}
+}
+
+// RecoveryMiddleware turns a panic of a handler into an error and tells the user that
+// something went wrong in their language; add it first so it covers the other middleware
+func RecoveryMiddleware(sender BotSender) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, update *tba.Update) error {
+			err := recovery.Do(ctx, func() error { return next(ctx, update) })
+			if !errors.Is(err, recovery.ErrPanic) {
+				return err
+			}
+			if chatID, ok := ChatIDFromUpdate(update); ok {
+				lang := ""
+				if user := update.SentFrom(); user != nil {
+					lang = user.LanguageCode
+				}
+				if sendErr := sender.SendPlainMessage(chatID, i18n.T(i18n.Normalize(lang), "error.generic")); sendErr != nil {
+					logger(ctx).Warn("Failed to send error message", "error", sendErr)
+				}
+			}
+			return err
+		}
+	}
+}
+
+// AuthMiddleware lets an update through only when allow returns true for its chat;
+// otherwise denied is called (if set)
+func AuthMiddleware(allow func(ctx context.Context, chatID int64) (bool, error), denied HandlerFunc) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, update *tba.Update) error {
+			chatID, ok := ChatIDFromUpdate(update)
+			if !ok {
+				return nil
+			}
+			allowed, err := allow(ctx, chatID)
+			if err != nil {
+				return err
+			}
+			if !allowed {
+				if denied != nil {
+					return denied(ctx, update)
+				}
+				return nil
+			}
+			return next(ctx, update)
+		}
+	}
+}
+
+// RateLimitMiddleware drops updates from a chat arriving more often than once per interval
+func RateLimitMiddleware(interval time.Duration) Middleware {
+	return LimitMiddleware(ratelimit.Every(interval), nil)
+}
+
+// LimitMiddleware passes an update on only when limiter allows its chat, keyed by chat
+// ID; otherwise denied is called (if set), e.g. to ask the user to slow down
+func LimitMiddleware(limiter ratelimit.Limiter, denied HandlerFunc) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, update *tba.Update) error {
+			chatID, ok := ChatIDFromUpdate(update)
+			if ok && !limiter.Allow(ctx, strconv.FormatInt(chatID, 10)) {
+				logger(ctx).Warn("Rate limit: dropping update", "update_id", update.UpdateID)
+				if denied != nil {
+					return denied(ctx, update)
+				}
+				return nil
+			}
+			return next(ctx, update)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package telegram
+
+import (
+	"context"
+	"time"
+)
+
+// Scheduler sends messages at a later time, e.g. "your trip is tomorrow" reminders.
+// Messages are persisted in the outbox and delivered by Drain, which is meant to run
+// from a timer-triggered Cloud Function.
+type Scheduler struct {
+	outbox *Outbox
+}
+
+// NewScheduler creates a scheduler on top of outbox
+func NewScheduler(outbox *Outbox) *Scheduler {
+	return &Scheduler{outbox: outbox}
+}
+
+// SendAt schedules msg for delivery to chatID not before t and returns its outbox ID.
+// Delivery precision is bounded by how often Drain runs.
+func (s *Scheduler) SendAt(ctx context.Context, chatID int64, msg QueuedMessage, t time.Time) (string, error) {
+	return s.outbox.EnqueueAt(ctx, chatID, msg, t)
+}
+
+// SendIn schedules msg for delivery to chatID after d
+func (s *Scheduler) SendIn(ctx context.Context, chatID int64, msg QueuedMessage, d time.Duration) (string, error) {
+	return s.SendAt(ctx, chatID, msg, s.outbox.Clock.Now().Add(d))
+}
+
+// Drain delivers all messages that are due; call it from a timer trigger, e.g. every minute
+func (s *Scheduler) Drain(ctx context.Context) error {
+	stats, err := s.outbox.Drain(ctx)
+	if stats.Sent+stats.Retried+stats.Failed > 0 {
+		logger(ctx).Info("Scheduled messages drained", "sent", stats.Sent, "retried", stats.Retried, "failed", stats.Failed)
+	}
+	return err
+}
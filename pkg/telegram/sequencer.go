@@ -0,0 +1,38 @@
+package telegram
+
+import "sync"
+
+// chatSequencer serializes work per chat in the order it was submitted, so that
+// e.g. a "searching…" message never arrives after its result.
+// The zero value is ready to use.
+type chatSequencer struct {
+	mu    sync.Mutex
+	tails map[int64]chan struct{}
+}
+
+// acquire waits until all earlier work for chatID has finished;
+// the caller must call release once its own work is done
+func (s *chatSequencer) acquire(chatID int64) (release func()) {
+	done := make(chan struct{})
+
+	s.mu.Lock()
+	if s.tails == nil {
+		s.tails = make(map[int64]chan struct{})
+	}
+	prev := s.tails[chatID]
+	s.tails[chatID] = done
+	s.mu.Unlock()
+
+	if prev != nil {
+		<-prev
+	}
+
+	return func() {
+		s.mu.Lock()
+		if s.tails[chatID] == done {
+			delete(s.tails, chatID)
+		}
+		s.mu.Unlock()
+		close(done)
+	}
+}
@@ -1,54 +1,264 @@
 package telegram
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"log/slog"
+	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/arseniisemenow/bbc-common/pkg/config"
+	"github.com/arseniisemenow/bbc-common/pkg/i18n"
+	"github.com/arseniisemenow/bbc-common/pkg/logging"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
 )
 
 // BotClient wraps the Telegram bot API
 type BotClient struct {
-	bot *tba.BotAPI
+	bot     *tba.BotAPI
+	limiter *Limiter
+	retry   RetryPolicy
+	order   chatSequencer
+	metrics *Metrics
+
+	// fileEndpoint is the download URL format with token and file path verbs
+	fileEndpoint string
+
+	onBlocked BlockedHandler
+}
+
+// BlockedHandler is called when a send fails because the user blocked the bot
+type BlockedHandler func(ctx context.Context, chatID int64)
+
+// Option configures a BotClient created by NewBotClient
+type Option func(*clientConfig)
+
+type clientConfig struct {
+	httpClient  tba.HTTPClient
+	apiEndpoint string
+	debug       bool
+	limiter     *Limiter
+	retry       RetryPolicy
+	metrics     *Metrics
+}
+
+// WithHTTPClient sets the HTTP client, e.g. to configure timeouts or a proxy
+func WithHTTPClient(client tba.HTTPClient) Option {
+	return func(c *clientConfig) { c.httpClient = client }
+}
+
+// WithAPIEndpoint points the client at a self-hosted Bot API server, e.g. "http://localhost:8081".
+// A full format string with two %s verbs (token, method) is used as is.
+func WithAPIEndpoint(endpoint string) Option {
+	return func(c *clientConfig) {
+		if !strings.Contains(endpoint, "%s") {
+			endpoint = strings.TrimSuffix(endpoint, "/") + "/bot%s/%s"
+		}
+		c.apiEndpoint = endpoint
+	}
+}
+
+// WithDebug logs every request and response of the underlying library
+func WithDebug(debug bool) Option {
+	return func(c *clientConfig) { c.debug = debug }
 }
 
-// NewBotClientFromEnv creates a new bot client from environment variable
-func NewBotClientFromEnv() (*BotClient, error) {
-	token := os.Getenv("TELEGRAM_BOT_TOKEN")
+// WithLimiter throttles outgoing messages
+func WithLimiter(limiter *Limiter) Option {
+	return func(c *clientConfig) { c.limiter = limiter }
+}
+
+// WithRetryPolicy overrides DefaultRetryPolicy
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *clientConfig) { c.retry = policy }
+}
+
+// WithMetrics records request counters and latencies in metrics
+func WithMetrics(metrics *Metrics) Option {
+	return func(c *clientConfig) { c.metrics = metrics }
+}
+
+// NewBotClient creates a new bot client for token
+func NewBotClient(token string, opts ...Option) (*BotClient, error) {
 	if token == "" {
-		return nil, fmt.Errorf("TELEGRAM_BOT_TOKEN not set")
+		return nil, fmt.Errorf("bot token is empty")
+	}
+
+	cfg := clientConfig{
+		httpClient:  &http.Client{Timeout: 30 * time.Second},
+		apiEndpoint: tba.APIEndpoint,
+		retry:       DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	// request URLs embed the token, keep it out of logged errors
+	logging.Register(token)
+
+	bot, err := tba.NewBotAPIWithClient(token, cfg.apiEndpoint, cfg.httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bot: %w", logging.RedactError(err))
+	}
+	bot.Debug = cfg.debug
+
+	return &BotClient{
+		bot:          bot,
+		limiter:      cfg.limiter,
+		retry:        cfg.retry,
+		metrics:      cfg.metrics,
+		fileEndpoint: strings.Replace(cfg.apiEndpoint, "/bot%s/%s", "/file/bot%s/%s", 1),
+	}, nil
+}
+
+// NewBotClientFromEnv creates a new bot client with the token in TELEGRAM_BOT_TOKEN
+func NewBotClientFromEnv(opts ...Option) (*BotClient, error) {
+	cfg, err := config.LoadTelegram()
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return NewBotClient(cfg.BotToken, opts...)
+}
+
+// Ping calls getMe to check that the Bot API is reachable and the token valid, e.g. as
+// a readiness check
+func (bc *BotClient) Ping(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := bc.bot.GetMe()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return mapError(err)
+	case <-ctx.Done():
+		return ctx.Err()
 	}
+}
+
+// SetLimiter enables throttling of outgoing messages; nil disables it
+func (bc *BotClient) SetLimiter(limiter *Limiter) {
+	bc.limiter = limiter
+}
+
+// SetRetryPolicy configures retries of requests rejected with 429 Too Many Requests
+func (bc *BotClient) SetRetryPolicy(policy RetryPolicy) {
+	bc.retry = policy
+}
 
-	bot, err := tba.NewBotAPI(token)
+// OnBotBlocked registers a handler invoked whenever a send fails with ErrBotBlocked
+func (bc *BotClient) OnBotBlocked(handler BlockedHandler) {
+	bc.onBlocked = handler
+}
+
+// DeactivateOnBlock returns a BlockedHandler marking the user inactive, e.g.
+//
+//	bc.OnBotBlocked(telegram.DeactivateOnBlock(ydb.UpdateUserStatus))
+func DeactivateOnBlock(updateStatus func(ctx context.Context, chatID int64, status models.UserStatus) error) BlockedHandler {
+	return func(ctx context.Context, chatID int64) {
+		ctx = logging.WithChatID(ctx, chatID)
+		logger(ctx).Info("Chat blocked the bot, marking user inactive")
+		if err := updateStatus(ctx, chatID, models.UserStatusInactive); err != nil {
+			logger(ctx).Error("Failed to mark chat inactive", "error", err)
+		}
+	}
+}
+
+// send throttles and sends a message to chatID, retrying when rate limited
+func (bc *BotClient) send(chatID int64, c tba.Chattable, opts ...SendOption) (tba.Message, error) {
+	var sent tba.Message
+	result, err := bc.deliver(chatID, c, collectOptions(opts))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create bot: %w", err)
+		return sent, err
+	}
+	if err := json.Unmarshal(result, &sent); err != nil {
+		return sent, fmt.Errorf("failed to decode sent message: %w", err)
 	}
+	return sent, nil
+}
 
-	return &BotClient{bot: bot}, nil
+// deliver throttles and performs a request on behalf of chatID, retrying when rate limited;
+// requests to the same chat are performed one at a time in submit order
+func (bc *BotClient) deliver(chatID int64, c tba.Chattable, opts SendOptions) (json.RawMessage, error) {
+	release := bc.order.acquire(chatID)
+	defer release()
+
+	ctx := logging.WithChatID(opts.context(), chatID)
+	if bc.limiter != nil {
+		if err := bc.limiter.Wait(ctx, chatID); err != nil {
+			return nil, err
+		}
+	}
+
+	c = opts.apply(c)
+	var result json.RawMessage
+	err := bc.retry.do(ctx, func() error {
+		var err error
+		result, err = bc.call(c, opts)
+		return err
+	})
+	err = mapError(err)
+	if errors.Is(err, ErrBadMarkup) {
+		if fallback, original, ok := plainTextFallback(c); ok {
+			logger(ctx).Warn("Bad markup, resending as plain text", "error", err, "text", original)
+			err = bc.retry.do(ctx, func() error {
+				var err error
+				result, err = bc.call(fallback, opts)
+				return err
+			})
+			err = mapError(err)
+		}
+	}
+	bc.metrics.observeResult(requestKind(c, kindSend), err)
+	if errors.Is(err, ErrBotBlocked) && bc.onBlocked != nil {
+		bc.onBlocked(ctx, chatID)
+	}
+	return result, err
+}
+
+// request performs an API call that does not produce a message, retrying when rate limited
+func (bc *BotClient) request(c tba.Chattable) error {
+	kind := requestKind(c, kindOther)
+	err := bc.retry.do(context.Background(), func() error {
+		start := time.Now()
+		_, err := bc.bot.Request(c)
+		bc.metrics.observeAttempt(kind, start, err)
+		return err
+	})
+	err = mapError(err)
+	bc.metrics.observeResult(kind, err)
+	return err
 }
 
 // SendPlainMessage sends a simple text message
-func (bc *BotClient) SendPlainMessage(chatID int64, text string) error {
+func (bc *BotClient) SendPlainMessage(chatID int64, text string, opts ...SendOption) error {
 	escapedText := tba.EscapeText(tba.ModeMarkdownV2, text)
 
 	msg := tba.NewMessage(chatID, escapedText)
 	msg.ParseMode = "MarkdownV2"
 
-	_, err := bc.bot.Send(msg)
+	_, err := bc.send(chatID, msg, opts...)
 	return err
 }
 
 // SendMessageWithKeyboard sends a message with an inline keyboard
-func (bc *BotClient) SendMessageWithKeyboard(chatID int64, text string, keyboard interface{}) (int, error) {
+func (bc *BotClient) SendMessageWithKeyboard(chatID int64, text string, keyboard interface{}, opts ...SendOption) (int, error) {
 	escapedText := tba.EscapeText(tba.ModeMarkdownV2, text)
 
 	msg := tba.NewMessage(chatID, escapedText)
 	msg.ParseMode = "MarkdownV2"
 	msg.ReplyMarkup = keyboard
 
-	sent, err := bc.bot.Send(msg)
+	sent, err := bc.send(chatID, msg, opts...)
 	if err != nil {
 		return 0, err
 	}
@@ -56,32 +266,109 @@ func (bc *BotClient) SendMessageWithKeyboard(chatID int64, text string, keyboard
 }
 
 // EditMessage edits an existing message
-func (bc *BotClient) EditMessage(chatID int64, messageID int, text string) error {
+func (bc *BotClient) EditMessage(chatID int64, messageID int, text string, opts ...SendOption) error {
 	escapedText := tba.EscapeText(tba.ModeMarkdownV2, text)
 
 	msg := tba.NewEditMessageText(chatID, messageID, escapedText)
 	msg.ParseMode = "MarkdownV2"
 
-	_, err := bc.bot.Send(msg)
+	_, err := bc.send(chatID, msg, opts...)
+	return err
+}
+
+// SendReplyKeyboard sends a message with a reply keyboard built from button texts
+func (bc *BotClient) SendReplyKeyboard(chatID int64, text string, opts ReplyKeyboardOptions, rows ...[]string) (int, error) {
+	return bc.SendMessageWithKeyboard(chatID, text, NewReplyKeyboard(opts, rows...))
+}
+
+// RemoveKeyboard sends a message that hides the current reply keyboard
+func (bc *BotClient) RemoveKeyboard(chatID int64, text string, opts ...SendOption) error {
+	_, err := bc.SendMessageWithKeyboard(chatID, text, tba.NewRemoveKeyboard(true), opts...)
 	return err
 }
 
+// DeleteMessage deletes a message
+func (bc *BotClient) DeleteMessage(chatID int64, messageID int) error {
+	return bc.request(tba.NewDeleteMessage(chatID, messageID))
+}
+
+// EditMessageKeyboard replaces only the inline keyboard of a message; nil removes all
+// buttons. Keyboards other than inline ones are rejected with ErrUnsupportedKeyboard.
+func (bc *BotClient) EditMessageKeyboard(chatID int64, messageID int, keyboard interface{}) error {
+	markup, err := editKeyboardMarkup(keyboard)
+	if err != nil {
+		return err
+	}
+
+	msg := tba.NewEditMessageReplyMarkup(chatID, messageID, *markup)
+	_, err = bc.send(chatID, msg)
+	return err
+}
+
+// UpsertMessage edits existingMessageID when it is non-zero and sends a new message
+// when there is nothing to edit; it returns the ID of the message now showing text
+func (bc *BotClient) UpsertMessage(chatID int64, existingMessageID int, text string, keyboard interface{}, opts ...SendOption) (int, error) {
+	if existingMessageID == 0 {
+		return bc.SendMessageWithKeyboard(chatID, text, keyboard, opts...)
+	}
+
+	escapedText := tba.EscapeText(tba.ModeMarkdownV2, text)
+
+	msg := tba.NewEditMessageText(chatID, existingMessageID, escapedText)
+	msg.ParseMode = "MarkdownV2"
+	msg.ReplyMarkup = inlineMarkup(keyboard)
+
+	_, err := bc.send(chatID, msg, append(opts[:len(opts):len(opts)], forEdit())...)
+	switch {
+	case err == nil, errors.Is(err, ErrMessageNotModified):
+		return existingMessageID, nil
+	case errors.Is(err, ErrMessageNotFound):
+		logger(logging.WithChatID(collectOptions(opts).context(), chatID)).Info("Message not found for edit, sending new one", "message_id", existingMessageID)
+		return bc.SendMessageWithKeyboard(chatID, text, keyboard, opts...)
+	default:
+		return 0, err
+	}
+}
+
+// inlineMarkup extracts an inline keyboard from a keyboard value, as edits accept no other kind
+func inlineMarkup(keyboard interface{}) *tba.InlineKeyboardMarkup {
+	switch kb := keyboard.(type) {
+	case tba.InlineKeyboardMarkup:
+		return &kb
+	case *tba.InlineKeyboardMarkup:
+		return kb
+	default:
+		return nil
+	}
+}
+
+// editKeyboardMarkup returns the markup EditMessageKeyboard sets for keyboard, an empty
+// one for nil
+func editKeyboardMarkup(keyboard interface{}) (*tba.InlineKeyboardMarkup, error) {
+	if markup := inlineMarkup(keyboard); markup != nil {
+		return markup, nil
+	}
+	if keyboard == nil || keyboard == (*tba.InlineKeyboardMarkup)(nil) {
+		return &tba.InlineKeyboardMarkup{InlineKeyboard: [][]tba.InlineKeyboardButton{}}, nil
+	}
+	return nil, fmt.Errorf("%w: %T", ErrUnsupportedKeyboard, keyboard)
+}
+
 // AnswerCallbackQuery answers a callback query
 func (bc *BotClient) AnswerCallbackQuery(callbackQueryID, text string) error {
 	callback := tba.NewCallback(callbackQueryID, text)
-	_, err := bc.bot.Request(callback)
-	return err
+	return bc.request(callback)
 }
 
 // SendInlineKeyboard sends a message with inline buttons
-func (bc *BotClient) SendInlineKeyboard(chatID int64, text string, buttons [][]tba.InlineKeyboardButton) (int, error) {
+func (bc *BotClient) SendInlineKeyboard(chatID int64, text string, buttons [][]tba.InlineKeyboardButton, opts ...SendOption) (int, error) {
 	escapedText := tba.EscapeText(tba.ModeMarkdownV2, text)
 
 	msg := tba.NewMessage(chatID, escapedText)
 	msg.ParseMode = "MarkdownV2"
 	msg.ReplyMarkup = tba.NewInlineKeyboardMarkup(buttons...)
 
-	sent, err := bc.bot.Send(msg)
+	sent, err := bc.send(chatID, msg, opts...)
 	if err != nil {
 		return 0, err
 	}
@@ -90,8 +377,58 @@ func (bc *BotClient) SendInlineKeyboard(chatID int64, text string, buttons [][]t
 
 // FormatTripMessage formats a trip notification message
 func FormatTripMessage(trip interface{}) string {
-	// This will be implemented based on the trip structure
-	return ""
+	return FormatTripMessageLang(i18n.DefaultLanguage, trip)
+}
+
+// FormatTripMessageLang formats a trip notification message in lang;
+// trip must be a models.TripInfo or *models.TripInfo
+func FormatTripMessageLang(lang string, trip interface{}) string {
+	return FormatTripMessageIn(lang, nil, trip)
+}
+
+// FormatTripMessageIn formats a trip notification message in lang with times
+// converted to the user's timezone loc; a nil loc keeps the trip's own offset
+func FormatTripMessageIn(lang string, loc *time.Location, trip interface{}) string {
+	var t *models.TripInfo
+	switch v := trip.(type) {
+	case models.TripInfo:
+		t = &v
+	case *models.TripInfo:
+		t = v
+	}
+	if t == nil {
+		return ""
+	}
+
+	titleKey := "trip.title"
+	if t.IsBus {
+		titleKey = "trip.title_bus"
+	}
+	lines := []string{i18n.T(lang, titleKey, t.FromPlaceName, t.ToPlaceName)}
+	if t.DepartureTime != "" {
+		lines = append(lines, i18n.T(lang, "trip.departure", i18n.FormatTripTime(lang, t.DepartureTime, loc)))
+	}
+	if t.ArrivalTime != "" {
+		lines = append(lines, i18n.T(lang, "trip.arrival", i18n.FormatTripTime(lang, t.ArrivalTime, loc)))
+	}
+	if t.Duration != "" {
+		lines = append(lines, i18n.T(lang, "trip.duration", t.Duration))
+	}
+	if !t.Price.IsZero() {
+		lines = append(lines, i18n.T(lang, "trip.price", t.Price.String()))
+	}
+	if t.DriverName != "" {
+		if t.DriverRating > 0 {
+			lines = append(lines, i18n.T(lang, "trip.driver_rated", t.DriverName, t.DriverRating))
+		} else {
+			lines = append(lines, i18n.T(lang, "trip.driver", t.DriverName))
+		}
+	}
+	lines = append(lines, i18n.N(lang, "trip.seats", t.SeatsAvailable))
+	if t.DeepLink != "" {
+		lines = append(lines, "", i18n.T(lang, "trip.book", t.DeepLink))
+	}
+	return strings.Join(lines, "\n")
 }
 
 // ParseCallbackData parses callback data in format "action:param1:param2"
@@ -110,18 +447,29 @@ func CreateCallbackData(action string, params ...string) string {
 
 // FormatSubscriptionMessage formats a subscription for display
 func FormatSubscriptionMessage(id, from, to, date string, isActive bool) string {
-	status := "✅ Active"
+	return FormatSubscriptionMessageLang(i18n.DefaultLanguage, id, from, to, date, isActive)
+}
+
+// FormatSubscriptionMessageLang formats a subscription for display in lang
+func FormatSubscriptionMessageLang(lang, id, from, to, date string, isActive bool) string {
+	status := i18n.T(lang, "subscription.active")
 	if !isActive {
-		status = "❌ Inactive"
+		status = i18n.T(lang, "subscription.inactive")
 	}
-	return fmt.Sprintf("*Subscription #%s*\n%s → %s\nDate: %s\nStatus: %s",
-		id[:8], from, to, date, status)
+	return fmt.Sprintf("%s\n%s → %s\n%s\n%s",
+		i18n.T(lang, "subscription.title", id[:8]), from, to,
+		i18n.T(lang, "subscription.date", date), i18n.T(lang, "subscription.status", status))
 }
 
 // FormatSubscriptionsList formats a list of subscriptions
 func FormatSubscriptionsList(subscriptions []string) string {
+	return FormatSubscriptionsListLang(i18n.DefaultLanguage, subscriptions)
+}
+
+// FormatSubscriptionsListLang formats a list of subscriptions in lang
+func FormatSubscriptionsListLang(lang string, subscriptions []string) string {
 	if len(subscriptions) == 0 {
-		return "No active subscriptions"
+		return i18n.T(lang, "subscriptions.empty")
 	}
 	return strings.Join(subscriptions, "\n\n")
 }
@@ -130,3 +478,8 @@ func FormatSubscriptionsList(subscriptions []string) string {
 func GetChatIDFromString(s string) (int64, error) {
 	return strconv.ParseInt(s, 10, 64)
 }
+
+// logger returns the package's logger for ctx
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Component(ctx, "telegram")
+}
@@ -0,0 +1,93 @@
+package telegram
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/clock"
+	"github.com/arseniisemenow/bbc-common/pkg/i18n"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// ChangeThresholds suppresses insignificant differences between trip snapshots
+type ChangeThresholds struct {
+	// MinPriceDelta is the smallest absolute price change shown
+	MinPriceDelta float64
+	// MinSeatsDelta is the smallest change in available seats shown
+	MinSeatsDelta int
+}
+
+// DefaultChangeThresholds ignores price jitter below one currency unit
+var DefaultChangeThresholds = ChangeThresholds{MinPriceDelta: 1, MinSeatsDelta: 1}
+
+// SnapshotTrip captures the fields of trip compared by FormatTripChanges at the time of
+// the clock of ctx
+func SnapshotTrip(ctx context.Context, trip models.TripInfo) models.TripSnapshot {
+	return models.TripSnapshot{
+		TripID:         trip.ID,
+		Price:          trip.Price,
+		SeatsAvailable: trip.SeatsAvailable,
+		DepartureTime:  trip.DepartureTime,
+		CapturedAt:     clock.From(ctx).Now(),
+	}
+}
+
+// FormatTripChanges renders the significant differences between prev and trip,
+// e.g. "📉 22.00 EUR → 18.00 EUR" and "🔺 seats 3 → 1"; it returns nil when nothing notable changed
+func FormatTripChanges(lang string, prev models.TripSnapshot, trip models.TripInfo, th ChangeThresholds) []string {
+	var lines []string
+
+	if prev.Price != trip.Price {
+		change := prev.Price.String() + " → " + trip.Price.String()
+		delta, err := trip.Price.Sub(prev.Price)
+		switch {
+		case err != nil || prev.Price.IsZero() || trip.Price.IsZero():
+			lines = append(lines, "💶 "+change)
+		case math.Abs(delta.Float()) < th.MinPriceDelta:
+		case delta.AmountMinor < 0:
+			lines = append(lines, "📉 "+change)
+		default:
+			lines = append(lines, "📈 "+change)
+		}
+	}
+
+	delta := trip.SeatsAvailable - prev.SeatsAvailable
+	if delta != 0 && (delta >= th.MinSeatsDelta || -delta >= th.MinSeatsDelta) {
+		lines = append(lines, "🔺 "+i18n.T(lang, "trip.change.seats", prev.SeatsAvailable, trip.SeatsAvailable))
+	}
+
+	if prev.DepartureTime != "" && prev.DepartureTime != trip.DepartureTime {
+		lines = append(lines, "🕒 "+i18n.T(lang, "trip.change.departure",
+			i18n.FormatTripTime(lang, prev.DepartureTime, nil), i18n.FormatTripTime(lang, trip.DepartureTime, nil)))
+	}
+
+	return lines
+}
+
+// FormatTripUpdate formats a trip message for editing an earlier notification,
+// with the changes since prev shown on top; a nil prev formats the trip alone
+func FormatTripUpdate(lang string, loc *time.Location, prev *models.TripSnapshot, trip models.TripInfo, th ChangeThresholds) string {
+	message := FormatTripMessageIn(lang, loc, trip)
+	if prev == nil {
+		return message
+	}
+	changes := FormatTripChanges(lang, *prev, trip, th)
+	if len(changes) == 0 {
+		return message
+	}
+	return strings.Join(changes, "\n") + "\n\n" + message
+}
+
+// Reasons a notified trip can no longer be booked, for FormatTripUnavailable
+const (
+	UnavailableSoldOut = "sold_out"
+	UnavailableRemoved = "removed"
+)
+
+// FormatTripUnavailable formats a trip message for editing an earlier notification
+// about a trip that sold out or was removed, with a banner saying so on top
+func FormatTripUnavailable(lang string, loc *time.Location, trip models.TripInfo, reason string) string {
+	return i18n.T(lang, "trip.unavailable."+reason) + "\n\n" + FormatTripMessageIn(lang, loc, trip)
+}
@@ -0,0 +1,329 @@
+// Package webhooks posts signed JSON events, such as created subscriptions and matched
+// trips, to URLs configured by users or operators, so other systems can react to them
+// beyond Telegram. Each delivery carries an HMAC signature receivers check with Verify:
+//
+//	d := webhooks.NewDispatcher(webhooks.StaticEndpoints{{URL: url, Secret: secret}})
+//	err := d.Dispatch(ctx, webhooks.TripMatched(sub, trip))
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/clock"
+	"github.com/arseniisemenow/bbc-common/pkg/idgen"
+	"github.com/arseniisemenow/bbc-common/pkg/logging"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+	"github.com/arseniisemenow/bbc-common/pkg/queue"
+	"github.com/arseniisemenow/bbc-common/pkg/retry"
+)
+
+// EventType names a webhook event
+type EventType string
+
+// Events posted to webhooks
+const (
+	EventSubscriptionCreated EventType = "subscription.created"
+	EventTripMatched         EventType = "trip.matched"
+	EventNotificationSent    EventType = "notification.sent"
+)
+
+var ErrInvalidEndpoint = errors.New("invalid webhook endpoint")
+
+// Event is the JSON body of a delivery; the constructors, e.g. TripMatched, assign the ID
+type Event struct {
+	// ID identifies the event across retries, see DeliveryHeader
+	ID        string    `json:"id"`
+	Type      EventType `json:"type"`
+	ChatID    int64     `json:"telegram_chat_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// TripMatch is the data of a trip.matched event
+type TripMatch struct {
+	SubscriptionID string          `json:"subscription_id"`
+	Trip           models.TripInfo `json:"trip"`
+}
+
+// SubscriptionCreated returns the event of a new subscription
+func SubscriptionCreated(sub models.SearchSubscription) Event {
+	return Event{ID: idgen.New(), Type: EventSubscriptionCreated, ChatID: sub.TelegramChatID, Data: sub}
+}
+
+// TripMatched returns the event of a trip found for a subscription
+func TripMatched(sub models.SearchSubscription, trip models.TripInfo) Event {
+	return Event{
+		ID:     idgen.New(),
+		Type:   EventTripMatched,
+		ChatID: sub.TelegramChatID,
+		Data:   TripMatch{SubscriptionID: sub.ID, Trip: trip},
+	}
+}
+
+// NotificationSent returns the event of a notification delivered to Telegram
+func NotificationSent(notif models.Notification) Event {
+	return Event{ID: idgen.New(), Type: EventNotificationSent, ChatID: notif.TelegramChatID, Data: notif}
+}
+
+// Endpoint is a URL events are posted to
+type Endpoint struct {
+	URL string `json:"url"`
+	// Secret signs the deliveries, see Sign
+	Secret string `json:"-"`
+	// Events are the types posted, all if empty
+	Events []EventType `json:"events,omitempty"`
+	// ChatID limits a user's endpoint to the user's events; operator endpoints leave it
+	// 0 and receive the events of all users
+	ChatID int64 `json:"telegram_chat_id,omitempty"`
+}
+
+// Validate checks that the URL is absolute HTTPS and a secret is set
+func (e Endpoint) Validate() error {
+	u, err := url.Parse(e.URL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("%w: url %q, want https://...", ErrInvalidEndpoint, e.URL)
+	}
+	if e.Secret == "" {
+		return fmt.Errorf("%w: %s has no secret", ErrInvalidEndpoint, u.Host)
+	}
+	return nil
+}
+
+// Wants reports whether ev is posted to the endpoint
+func (e Endpoint) Wants(ev Event) bool {
+	if e.ChatID != 0 && e.ChatID != ev.ChatID {
+		return false
+	}
+	return len(e.Events) == 0 || slices.Contains(e.Events, ev.Type)
+}
+
+// EndpointSource returns the endpoints an event of chatID may go to: the chat's own
+// and the operators'. The Dispatcher filters them with Endpoint.Wants.
+type EndpointSource interface {
+	Endpoints(ctx context.Context, chatID int64) ([]Endpoint, error)
+}
+
+// StaticEndpoints is a fixed list of endpoints, e.g. operator endpoints from configuration
+type StaticEndpoints []Endpoint
+
+// Endpoints implements EndpointSource
+func (s StaticEndpoints) Endpoints(context.Context, int64) ([]Endpoint, error) {
+	return s, nil
+}
+
+// DeliveryError is a delivery answered with a status other than 2xx
+type DeliveryError struct {
+	URL        string
+	StatusCode int
+	// RetryAfter is the delay the receiver asked for, 0 if none
+	RetryAfter time.Duration
+}
+
+func (e *DeliveryError) Error() string {
+	return fmt.Sprintf("webhook %s answered %d", e.URL, e.StatusCode)
+}
+
+// Temporary reports whether the receiver may accept the delivery later: on 408, 429
+// and 5xx
+func (e *DeliveryError) Temporary() bool {
+	return e.StatusCode == http.StatusRequestTimeout || e.StatusCode == http.StatusTooManyRequests ||
+		e.StatusCode >= 500
+}
+
+// DefaultTimeout bounds one delivery attempt
+const DefaultTimeout = 10 * time.Second
+
+// DefaultRetryPolicy retries failed deliveries three times with jittered backoff,
+// honouring Retry-After up to a minute
+var DefaultRetryPolicy = retry.New(
+	retry.MaxAttempts(4),
+	retry.Backoff(time.Second, time.Minute),
+	retry.Jitter(),
+	retry.If(retryable),
+	retry.WithHint(func(err error) (time.Duration, bool) {
+		var derr *DeliveryError
+		if errors.As(err, &derr) && derr.RetryAfter > 0 {
+			return derr.RetryAfter, true
+		}
+		return 0, false
+	}),
+)
+
+// retryable reports whether a delivery failed temporarily; network errors are
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var derr *DeliveryError
+	if errors.As(err, &derr) {
+		return derr.Temporary()
+	}
+	return true
+}
+
+// Dispatcher posts events to the endpoints of a source
+type Dispatcher struct {
+	source EndpointSource
+	client *http.Client
+	retry  retry.Policy
+	clock  clock.Clock
+}
+
+// Option configures a Dispatcher
+type Option func(*Dispatcher)
+
+// WithHTTPClient posts with client instead of one timing out after DefaultTimeout
+func WithHTTPClient(client *http.Client) Option {
+	return func(d *Dispatcher) { d.client = client }
+}
+
+// WithRetryPolicy replaces DefaultRetryPolicy
+func WithRetryPolicy(policy retry.Policy) Option {
+	return func(d *Dispatcher) { d.retry = policy }
+}
+
+// WithClock stamps and signs events with c
+func WithClock(c clock.Clock) Option {
+	return func(d *Dispatcher) { d.clock = c }
+}
+
+// NewDispatcher creates a dispatcher posting to the endpoints of source
+func NewDispatcher(source EndpointSource, opts ...Option) *Dispatcher {
+	d := &Dispatcher{
+		source: source,
+		client: &http.Client{Timeout: DefaultTimeout},
+		retry:  DefaultRetryPolicy,
+		clock:  clock.Real,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Dispatch posts ev to every endpoint wanting it, in parallel, assigning an ID and the
+// current time when unset. Invalid endpoints are skipped with a warning; the errors of
+// failed deliveries are joined.
+func (d *Dispatcher) Dispatch(ctx context.Context, ev Event) error {
+	if ev.ID == "" {
+		ev.ID = idgen.New()
+	}
+	if ev.CreatedAt.IsZero() {
+		ev.CreatedAt = d.clock.Now().UTC()
+	}
+
+	endpoints, err := d.source.Endpoints(ctx, ev.ChatID)
+	if err != nil {
+		return fmt.Errorf("failed to get webhook endpoints: %w", err)
+	}
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook event %s: %w", ev.ID, err)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, ep := range endpoints {
+		if !ep.Wants(ev) {
+			continue
+		}
+		if err := ep.Validate(); err != nil {
+			logger(ctx).Warn("Skipping webhook endpoint", "error", err)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := d.retry.Do(ctx, func(ctx context.Context) error {
+				return d.post(ctx, ep, ev, body)
+			})
+			if err != nil {
+				logger(ctx).Warn("Webhook delivery failed", "event", ev.Type, "event_id", ev.ID, "error", err)
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// post makes one delivery attempt, signed at the time of the attempt
+func (d *Dispatcher) post(ctx context.Context, ep Endpoint, ev Event, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(body))
+	if err != nil {
+		return retry.Permanent(fmt.Errorf("failed to create webhook request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(ep.Secret, d.clock.Now(), body))
+	req.Header.Set(EventHeader, string(ev.Type))
+	req.Header.Set(DeliveryHeader, ev.ID)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		// the URL may carry credentials of the receiver, so only its cause is kept
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to post webhook %s: %w", redactURL(ep.URL), err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	derr := &DeliveryError{URL: redactURL(ep.URL), StatusCode: resp.StatusCode}
+	if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s > 0 {
+		derr.RetryAfter = time.Duration(s) * time.Second
+	}
+	return derr
+}
+
+// redactURL drops the query and user info of a URL, which may carry tokens
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "<invalid url>"
+	}
+	return u.Scheme + "://" + u.Host + u.Path
+}
+
+// EventMessageType is the queue message type of an event to dispatch
+const EventMessageType = "webhooks.event"
+
+// Handler returns a queue handler dispatching the Event of an EventMessageType message,
+// so deliveries and their retries run off the request path; publish events with
+// Producer.Publish(ctx, EventMessageType, ev). A failed message is redelivered to all
+// endpoints, which drop duplicates by DeliveryHeader.
+func Handler(d *Dispatcher) queue.HandlerFunc {
+	return func(ctx context.Context, msg queue.Message) error {
+		var ev Event
+		if err := msg.Decode(&ev); err != nil {
+			return err
+		}
+		return d.Dispatch(ctx, ev)
+	}
+}
+
+// logger returns the package's logger for ctx
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Component(ctx, "webhooks")
+}
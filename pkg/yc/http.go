@@ -0,0 +1,264 @@
+package yc
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	tba "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/arseniisemenow/bbc-common/pkg/recovery"
+	"github.com/arseniisemenow/bbc-common/pkg/requestid"
+)
+
+// TelegramSecretHeader carries the secret_token set with setWebhook
+const TelegramSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+var ErrInvalidSecret = errors.New("invalid webhook secret token")
+
+// HTTPRequest is the event of a function called over HTTP or through API Gateway
+type HTTPRequest struct {
+	HTTPMethod                      string              `json:"httpMethod"`
+	Path                            string              `json:"path"`
+	URL                             string              `json:"url"`
+	Headers                         map[string]string   `json:"headers"`
+	MultiValueHeaders               map[string][]string `json:"multiValueHeaders"`
+	QueryStringParameters           map[string]string   `json:"queryStringParameters"`
+	MultiValueQueryStringParameters map[string][]string `json:"multiValueQueryStringParameters"`
+	// PathParams are the path parameters extracted by API Gateway
+	PathParams     map[string]string `json:"pathParams"`
+	RequestContext struct {
+		Identity struct {
+			SourceIP  string `json:"sourceIp"`
+			UserAgent string `json:"userAgent"`
+		} `json:"identity"`
+		HTTPMethod       string `json:"httpMethod"`
+		RequestID        string `json:"requestId"`
+		RequestTime      string `json:"requestTime"`
+		RequestTimeEpoch int64  `json:"requestTimeEpoch"`
+	} `json:"requestContext"`
+	Body            string `json:"body"`
+	IsBase64Encoded bool   `json:"isBase64Encoded"`
+}
+
+// Header returns the first value of a header, matching its name case-insensitively
+func (r HTTPRequest) Header(name string) string {
+	for key, value := range r.Headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	for key, values := range r.MultiValueHeaders {
+		if strings.EqualFold(key, name) && len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// Query returns the first value of a query parameter
+func (r HTTPRequest) Query(name string) string {
+	if value, ok := r.QueryStringParameters[name]; ok {
+		return value
+	}
+	if values := r.MultiValueQueryStringParameters[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// BodyBytes returns the body, decoding it when the platform delivered it base64-encoded
+func (r HTTPRequest) BodyBytes() ([]byte, error) {
+	if !r.IsBase64Encoded {
+		return []byte(r.Body), nil
+	}
+	data, err := base64.StdEncoding.DecodeString(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode base64 body: %w", err)
+	}
+	return data, nil
+}
+
+// DecodeJSON unmarshals the body into v
+func (r HTTPRequest) DecodeJSON(v any) error {
+	data, err := r.BodyBytes()
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode request body: %w", err)
+	}
+	return nil
+}
+
+// TelegramUpdate decodes a Telegram webhook call; a non-empty secret must match the
+// secret token header, otherwise ErrInvalidSecret is returned
+func (r HTTPRequest) TelegramUpdate(secret string) (*tba.Update, error) {
+	if secret != "" && subtle.ConstantTimeCompare([]byte(r.Header(TelegramSecretHeader)), []byte(secret)) != 1 {
+		return nil, ErrInvalidSecret
+	}
+	var update tba.Update
+	if err := r.DecodeJSON(&update); err != nil {
+		return nil, err
+	}
+	return &update, nil
+}
+
+// WithRequestID returns ctx carrying the request ID of the call: the caller's
+// requestid.Header if set, otherwise the ID the platform assigned
+func (r HTTPRequest) WithRequestID(ctx context.Context) context.Context {
+	if id := r.Header(requestid.Header); id != "" {
+		return requestid.With(ctx, id)
+	}
+	if r.RequestContext.RequestID != "" {
+		return requestid.With(ctx, r.RequestContext.RequestID)
+	}
+	ctx, _ = requestid.Ensure(ctx)
+	return ctx
+}
+
+// Request converts the event to an *http.Request, e.g. to reuse net/http handlers; its
+// context carries the request ID, see WithRequestID
+func (r HTTPRequest) Request(ctx context.Context) (*http.Request, error) {
+	ctx = r.WithRequestID(ctx)
+	body, err := r.BodyBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	target := r.URL
+	if target == "" {
+		target = r.Path
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request URL %q: %w", target, err)
+	}
+	query := u.Query()
+	for name, values := range r.MultiValueQueryStringParameters {
+		query[name] = values
+	}
+	for name, value := range r.QueryStringParameters {
+		if _, ok := query[name]; !ok {
+			query.Set(name, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, r.HTTPMethod, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, values := range r.MultiValueHeaders {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	for name, value := range r.Headers {
+		if req.Header.Get(name) == "" {
+			req.Header.Set(name, value)
+		}
+	}
+	req.RemoteAddr = r.RequestContext.Identity.SourceIP
+	for name, value := range r.PathParams {
+		req.SetPathValue(name, value)
+	}
+	return req, nil
+}
+
+// HTTPResponse is what a function returns to an HTTP call
+type HTTPResponse struct {
+	StatusCode        int                 `json:"statusCode"`
+	Headers           map[string]string   `json:"headers,omitempty"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders,omitempty"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+}
+
+// TextResponse answers status with a plain text body
+func TextResponse(status int, body string) *HTTPResponse {
+	return &HTTPResponse{
+		StatusCode: status,
+		Headers:    map[string]string{"Content-Type": "text/plain; charset=utf-8"},
+		Body:       body,
+	}
+}
+
+// JSONResponse answers status with v encoded as JSON
+func JSONResponse(status int, v any) (*HTTPResponse, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode response: %w", err)
+	}
+	return &HTTPResponse{
+		StatusCode: status,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(data),
+	}, nil
+}
+
+// OK answers 200 with an empty body, e.g. to acknowledge a Telegram webhook
+func OK() *HTTPResponse {
+	return &HTTPResponse{StatusCode: http.StatusOK}
+}
+
+// Serve runs a net/http handler for the event and returns its response; binary bodies
+// are returned base64-encoded and a panic answers 500
+func Serve(ctx context.Context, handler http.Handler, event HTTPRequest) (*HTTPResponse, error) {
+	req, err := event.Request(ctx)
+	if err != nil {
+		return nil, err
+	}
+	w := &responseWriter{header: make(http.Header)}
+	recovery.Middleware(handler).ServeHTTP(w, req)
+	return w.response(), nil
+}
+
+// responseWriter buffers a handler's response
+type responseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *responseWriter) Write(data []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(data)
+}
+
+func (w *responseWriter) response() *HTTPResponse {
+	resp := &HTTPResponse{StatusCode: w.status, MultiValueHeaders: w.header}
+	if resp.StatusCode == 0 {
+		resp.StatusCode = http.StatusOK
+	}
+	if isText(w.header.Get("Content-Type")) {
+		resp.Body = w.body.String()
+	} else {
+		resp.Body = base64.StdEncoding.EncodeToString(w.body.Bytes())
+		resp.IsBase64Encoded = true
+	}
+	return resp
+}
+
+// isText reports whether a content type can be returned as a plain string body
+func isText(contentType string) bool {
+	return contentType == "" || strings.HasPrefix(contentType, "text/") ||
+		strings.Contains(contentType, "json") || strings.Contains(contentType, "xml")
+}
@@ -0,0 +1,162 @@
+// Package yc decodes the events Yandex Cloud Functions are invoked with: timer and
+// message-queue triggers and HTTP calls, directly or through API Gateway
+package yc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/queue"
+)
+
+// Event types of trigger messages
+const (
+	EventTypeTimer        = "yandex.cloud.events.serverless.triggers.TimerMessage"
+	EventTypeQueueMessage = "yandex.cloud.events.messagequeue.QueueMessage"
+)
+
+// Kind tells which kind of event a function was invoked with
+type Kind string
+
+const (
+	KindTimer   Kind = "timer"
+	KindQueue   Kind = "queue"
+	KindHTTP    Kind = "http"
+	KindUnknown Kind = "unknown"
+)
+
+var ErrUnexpectedEvent = errors.New("unexpected event kind")
+
+// EventMetadata is common to all trigger messages
+type EventMetadata struct {
+	EventID   string    `json:"event_id"`
+	EventType string    `json:"event_type"`
+	CreatedAt time.Time `json:"created_at"`
+	CloudID   string    `json:"cloud_id"`
+	FolderID  string    `json:"folder_id"`
+}
+
+// TimerEvent is the event of a timer trigger
+type TimerEvent struct {
+	Messages []TimerMessage `json:"messages"`
+}
+
+// TimerMessage is one firing of a timer trigger
+type TimerMessage struct {
+	EventMetadata EventMetadata `json:"event_metadata"`
+	Details       struct {
+		TriggerID string `json:"trigger_id"`
+		// Payload is the text configured on the trigger
+		Payload string `json:"payload"`
+	} `json:"details"`
+}
+
+// DecodePayload unmarshals a JSON payload configured on the trigger into v
+func (m TimerMessage) DecodePayload(v any) error {
+	if err := json.Unmarshal([]byte(m.Details.Payload), v); err != nil {
+		return fmt.Errorf("failed to decode timer payload of trigger %s: %w", m.Details.TriggerID, err)
+	}
+	return nil
+}
+
+// QueueEvent is the event of a message-queue trigger, carrying up to the trigger's batch size
+type QueueEvent struct {
+	Messages []QueueMessage `json:"messages"`
+}
+
+// QueueMessage is one message delivered by a message-queue trigger
+type QueueMessage struct {
+	EventMetadata EventMetadata `json:"event_metadata"`
+	Details       struct {
+		QueueID string `json:"queue_id"`
+		Message struct {
+			MessageID         string                      `json:"message_id"`
+			MD5OfBody         string                      `json:"md5_of_body"`
+			Body              string                      `json:"body"`
+			Attributes        map[string]string           `json:"attributes"`
+			MessageAttributes map[string]MessageAttribute `json:"message_attributes"`
+		} `json:"message"`
+	} `json:"details"`
+}
+
+// MessageAttribute is a user-defined attribute of a queue message
+type MessageAttribute struct {
+	DataType    string `json:"dataType"`
+	StringValue string `json:"stringValue"`
+}
+
+// RawMessages converts the messages for queue.Consumer.HandleBatch. The trigger deletes
+// them when the function succeeds and redelivers the whole batch when it fails.
+func (e QueueEvent) RawMessages() []queue.RawMessage {
+	raws := make([]queue.RawMessage, 0, len(e.Messages))
+	for _, m := range e.Messages {
+		msg := m.Details.Message
+		count, _ := strconv.Atoi(msg.Attributes["ApproximateReceiveCount"])
+		raws = append(raws, queue.RawMessage{MessageID: msg.MessageID, Body: msg.Body, ReceiveCount: count})
+	}
+	return raws
+}
+
+// Detect tells the kind of a raw event, for functions attached to several triggers
+func Detect(data []byte) Kind {
+	var probe struct {
+		HTTPMethod string `json:"httpMethod"`
+		Messages   []struct {
+			EventMetadata struct {
+				EventType string `json:"event_type"`
+			} `json:"event_metadata"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return KindUnknown
+	}
+	switch {
+	case probe.HTTPMethod != "":
+		return KindHTTP
+	case len(probe.Messages) > 0 && probe.Messages[0].EventMetadata.EventType == EventTypeTimer:
+		return KindTimer
+	case len(probe.Messages) > 0 && probe.Messages[0].EventMetadata.EventType == EventTypeQueueMessage:
+		return KindQueue
+	}
+	return KindUnknown
+}
+
+// ParseTimerEvent decodes a timer trigger event
+func ParseTimerEvent(data []byte) (TimerEvent, error) {
+	var event TimerEvent
+	if err := parse(data, KindTimer, &event); err != nil {
+		return TimerEvent{}, err
+	}
+	return event, nil
+}
+
+// ParseQueueEvent decodes a message-queue trigger event
+func ParseQueueEvent(data []byte) (QueueEvent, error) {
+	var event QueueEvent
+	if err := parse(data, KindQueue, &event); err != nil {
+		return QueueEvent{}, err
+	}
+	return event, nil
+}
+
+// ParseHTTPRequest decodes an HTTP call event
+func ParseHTTPRequest(data []byte) (HTTPRequest, error) {
+	var req HTTPRequest
+	if err := parse(data, KindHTTP, &req); err != nil {
+		return HTTPRequest{}, err
+	}
+	return req, nil
+}
+
+func parse(data []byte, want Kind, v any) error {
+	if kind := Detect(data); kind != want {
+		return fmt.Errorf("%w: got %s, want %s", ErrUnexpectedEvent, kind, want)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %s event: %w", want, err)
+	}
+	return nil
+}
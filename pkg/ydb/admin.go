@@ -0,0 +1,163 @@
+package ydb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// ListUsers retrieves up to limit users ordered by chat ID, starting after afterChatID;
+// nil starts from the first user
+func ListUsers(ctx context.Context, afterChatID *int64, limit int, opts ...ReadOption) ([]models.User, error) {
+	sql := TablePathPrefix("") + `
+		DECLARE $after AS Optional<Int64>;
+		DECLARE $limit AS Uint64;
+
+		SELECT telegram_chat_id, status, created_at, last_auth_success_at, last_auth_failure_at
+		FROM users
+		WHERE $after IS NULL OR telegram_chat_id > $after
+		ORDER BY telegram_chat_id
+		LIMIT $limit;
+	`
+
+	after := types.NullValue(types.TypeInt64)
+	if afterChatID != nil {
+		after = types.OptionalValue(types.Int64Value(*afterChatID))
+	}
+	params := []table.ParameterOption{
+		table.ValueParam("$after", after),
+		table.ValueParam("$limit", types.Uint64Value(uint64(limit))),
+	}
+
+	res, err := read(ctx, sql, opts, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer res.Close()
+
+	var users []models.User
+	for res.NextRow() {
+		user, err := scanUser(res)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// ListSubscriptions retrieves up to limit subscriptions of all users ordered by ID,
+// starting after afterID; "" starts from the first subscription
+func ListSubscriptions(ctx context.Context, afterID string, limit int, opts ...ReadOption) ([]models.SearchSubscription, error) {
+	sql := TablePathPrefix("") + `
+		DECLARE $after AS Utf8;
+		DECLARE $limit AS Uint64;
+
+		SELECT id, telegram_chat_id, from_place_id, from_place_name, to_place_id, to_place_name, departure_date, requested_seats, is_active, created_at, last_checked_at, filters,
+			next_check_at, empty_checks
+		FROM search_subscriptions
+		WHERE id > $after
+		ORDER BY id
+		LIMIT $limit;
+	`
+
+	params := []table.ParameterOption{
+		table.ValueParam("$after", types.TextValue(afterID)),
+		table.ValueParam("$limit", types.Uint64Value(uint64(limit))),
+	}
+
+	res, err := read(ctx, sql, opts, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query subscriptions: %w", err)
+	}
+	defer res.Close()
+
+	var subs []models.SearchSubscription
+	for res.NextRow() {
+		sub, err := scanSubscription(res)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+
+	return subs, nil
+}
+
+// GetStats counts the users, subscriptions and dead letters, with the click-through of
+// notifications sent since the given time
+func GetStats(ctx context.Context, since time.Time) (models.Stats, error) {
+	var stats models.Stats
+	sql := TablePathPrefix("") + `
+		$users = (SELECT COUNT(*) FROM users);
+		$active_users = (SELECT COUNT(*) FROM users WHERE status = "active");
+		$subscriptions = (SELECT COUNT(*) FROM search_subscriptions);
+		$active_subscriptions = (SELECT COUNT(*) FROM search_subscriptions WHERE is_active);
+		$dead_letters = (SELECT COUNT(*) FROM outbox VIEW idx_status_next_attempt
+			WHERE status = "dead_letter" OR status = "failed");
+
+		SELECT $users, $active_users, $subscriptions, $active_subscriptions, $dead_letters;
+	`
+
+	res, err := Query(ctx, sql)
+	if err != nil {
+		return stats, fmt.Errorf("failed to count stats: %w", err)
+	}
+	defer res.Close()
+
+	if res.NextRow() {
+		var users, activeUsers, subs, activeSubs, deadLetters uint64
+		err = res.Scan(&users, &activeUsers, &subs, &activeSubs, &deadLetters)
+		if err != nil {
+			return stats, fmt.Errorf("failed to scan stats: %w", err)
+		}
+		stats.Users, stats.ActiveUsers = int(users), int(activeUsers)
+		stats.Subscriptions, stats.ActiveSubscriptions = int(subs), int(activeSubs)
+		stats.DeadLetters = int(deadLetters)
+	}
+
+	stats.ClickThrough, err = GetClickThroughStats(ctx, since)
+	if err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
+
+// AdminStore exposes the data of the admin API as an httpapi.Store
+type AdminStore struct{}
+
+// ListUsers calls ListUsers
+func (AdminStore) ListUsers(ctx context.Context, afterChatID *int64, limit int) ([]models.User, error) {
+	return ListUsers(ctx, afterChatID, limit)
+}
+
+// ListSubscriptions calls ListSubscriptions
+func (AdminStore) ListSubscriptions(ctx context.Context, afterID string, limit int) ([]models.SearchSubscription, error) {
+	return ListSubscriptions(ctx, afterID, limit)
+}
+
+// SetSubscriptionActive calls SetSubscriptionActive
+func (AdminStore) SetSubscriptionActive(ctx context.Context, subID string, active bool) error {
+	return SetSubscriptionActive(ctx, subID, active)
+}
+
+// Stats calls GetStats
+func (AdminStore) Stats(ctx context.Context, since time.Time) (models.Stats, error) {
+	return GetStats(ctx, since)
+}
+
+// DeadLetters calls GetOutboxDeadLetters
+func (AdminStore) DeadLetters(ctx context.Context, limit int) ([]models.OutboxMessage, error) {
+	return GetOutboxDeadLetters(ctx, limit)
+}
+
+// Redrive calls RedriveOutboxMessage
+func (AdminStore) Redrive(ctx context.Context, id string) error {
+	return RedriveOutboxMessage(ctx, id)
+}
@@ -0,0 +1,113 @@
+package ydb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// The booking_attempts table logs automatic booking attempts:
+//
+//	CREATE TABLE booking_attempts (
+//		id Utf8 NOT NULL,
+//		telegram_chat_id Int64 NOT NULL,
+//		trip_id Utf8 NOT NULL,
+//		seats Int32 NOT NULL,
+//		outcome Utf8 NOT NULL,
+//		booking_id Utf8,
+//		error Utf8,
+//		created_at Datetime NOT NULL,
+//		PRIMARY KEY (id),
+//		INDEX idx_telegram_chat_id GLOBAL ON (telegram_chat_id)
+//	);
+
+// CreateBookingAttempt stores a booking attempt
+func CreateBookingAttempt(ctx context.Context, attempt *models.BookingAttempt) error {
+	sql := TablePathPrefix("") + `
+		DECLARE $id AS Utf8;
+		DECLARE $telegram_chat_id AS Int64;
+		DECLARE $trip_id AS Utf8;
+		DECLARE $seats AS Int32;
+		DECLARE $outcome AS Utf8;
+		DECLARE $booking_id AS Optional<Utf8>;
+		DECLARE $error AS Optional<Utf8>;
+		DECLARE $created_at AS Datetime;
+
+		INSERT INTO booking_attempts (id, telegram_chat_id, trip_id, seats, outcome, booking_id, error, created_at)
+		VALUES ($id, $telegram_chat_id, $trip_id, $seats, $outcome, $booking_id, $error, $created_at);
+	`
+
+	params := []table.ParameterOption{
+		table.ValueParam("$id", types.TextValue(attempt.ID)),
+		table.ValueParam("$telegram_chat_id", types.Int64Value(attempt.TelegramChatID)),
+		table.ValueParam("$trip_id", types.TextValue(attempt.TripID)),
+		table.ValueParam("$seats", types.Int32Value(int32(attempt.Seats))),
+		table.ValueParam("$outcome", types.TextValue(attempt.Outcome)),
+		table.ValueParam("$booking_id", optionalText(nonEmpty(attempt.BookingID))),
+		table.ValueParam("$error", optionalText(nonEmpty(attempt.Error))),
+		table.ValueParam("$created_at", types.DatetimeValue(uint32(attempt.CreatedAt.Unix()))),
+	}
+
+	return Exec(ctx, sql, params...)
+}
+
+// GetBookingAttemptsByUser retrieves a user's booking attempts, newest first
+func GetBookingAttemptsByUser(ctx context.Context, chatID int64) ([]models.BookingAttempt, error) {
+	sql := TablePathPrefix("") + `
+		DECLARE $telegram_chat_id AS Int64;
+
+		SELECT id, telegram_chat_id, trip_id, seats, outcome, booking_id, error, created_at
+		FROM booking_attempts VIEW idx_telegram_chat_id
+		WHERE telegram_chat_id = $telegram_chat_id
+		ORDER BY created_at DESC;
+	`
+
+	params := []table.ParameterOption{
+		table.ValueParam("$telegram_chat_id", types.Int64Value(chatID)),
+	}
+
+	res, err := Query(ctx, sql, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query booking attempts: %w", err)
+	}
+	defer res.Close()
+
+	var attempts []models.BookingAttempt
+	for res.NextRow() {
+		var attempt models.BookingAttempt
+		var bookingID, errText *string
+		err = res.Scan(&attempt.ID, &attempt.TelegramChatID, &attempt.TripID, &attempt.Seats,
+			&attempt.Outcome, &bookingID, &errText, &attempt.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan booking attempt: %w", err)
+		}
+		if bookingID != nil {
+			attempt.BookingID = *bookingID
+		}
+		if errText != nil {
+			attempt.Error = *errText
+		}
+		attempts = append(attempts, attempt)
+	}
+
+	return attempts, nil
+}
+
+// BookingAttemptStore exposes the booking attempt functions as a blablacar.BookingRecorder
+type BookingAttemptStore struct{}
+
+// RecordBookingAttempt calls CreateBookingAttempt
+func (BookingAttemptStore) RecordBookingAttempt(ctx context.Context, attempt *models.BookingAttempt) error {
+	return CreateBookingAttempt(ctx, attempt)
+}
+
+func nonEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
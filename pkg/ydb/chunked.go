@@ -0,0 +1,124 @@
+package ydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+const (
+	// DefaultChunkSize is the number of keys bound per query by QueryChunked; it keeps
+	// the parameters small and, for unique keys, the rows below YDB's limit of 1000 per
+	// result set
+	DefaultChunkSize = 500
+	// maxParallelChunks bounds the queries QueryChunked runs at once, so a large batch
+	// does not take every session of the pool
+	maxParallelChunks = 4
+)
+
+// ChunkedQuery looks up rows by a list of keys, see QueryChunked
+type ChunkedQuery[K comparable, R any] struct {
+	// SQL declares $keys as a List of the key type and selects the rows, e.g.
+	// "... WHERE telegram_chat_id IN $keys"
+	SQL string
+	// Key converts a key to its YDB value
+	Key func(K) types.Value
+	// Scan reads the current row
+	Scan func(res result.Result) (R, error)
+	// Params are bound to every query besides $keys
+	Params []table.ParameterOption
+	// ChunkSize is the number of keys per query, DefaultChunkSize if 0
+	ChunkSize int
+}
+
+// QueryChunked runs q for keys in chunks, several at once, and returns the rows of all
+// chunks in no particular order. Duplicate keys are looked up once. A chunk whose result
+// set was truncated fails with result.ErrTruncated rather than dropping rows silently;
+// lower ChunkSize when a key matches many rows.
+func QueryChunked[K comparable, R any](ctx context.Context, q ChunkedQuery[K, R], keys []K) ([]R, error) {
+	keys = uniqueKeys(keys)
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	size := q.ChunkSize
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		rows []R
+		errs []error
+		sem  = make(chan struct{}, maxParallelChunks)
+	)
+	for chunk := range slices.Chunk(keys, size) {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			chunkRows, err := queryChunk(ctx, q, chunk)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			rows = append(rows, chunkRows...)
+		}()
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// queryChunk runs q for one chunk of keys
+func queryChunk[K comparable, R any](ctx context.Context, q ChunkedQuery[K, R], keys []K) ([]R, error) {
+	values := make([]types.Value, 0, len(keys))
+	for _, k := range keys {
+		values = append(values, q.Key(k))
+	}
+	params := append(slices.Clip(q.Params), table.ValueParam("$keys", types.ListValue(values...)))
+
+	res, err := Query(ctx, q.SQL, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	if res.CurrentResultSet().Truncated() {
+		return nil, fmt.Errorf("%d keys: %w", len(keys), result.ErrTruncated)
+	}
+
+	var rows []R
+	for res.NextRow() {
+		row, err := q.Scan(res)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, res.Err()
+}
+
+// uniqueKeys returns keys without duplicates, in their first order
+func uniqueKeys[K comparable](keys []K) []K {
+	seen := make(map[K]bool, len(keys))
+	unique := make([]K, 0, len(keys))
+	for _, k := range keys {
+		if !seen[k] {
+			seen[k] = true
+			unique = append(unique, k)
+		}
+	}
+	return unique
+}
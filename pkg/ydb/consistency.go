@@ -0,0 +1,51 @@
+package ydb
+
+import (
+	"context"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result"
+)
+
+// Consistency is how fresh the rows returned by a list method are
+type Consistency int
+
+const (
+	// Serializable reads see every committed write, including the caller's own, so a
+	// subscription listed right after CreateSearchSubscription returned is included. It
+	// is the default; pass it explicitly where a list follows a write, e.g. in the bot.
+	Serializable Consistency = iota
+	// Stale reads may miss writes of the last seconds but take no locks and are served by
+	// any replica. Use them for background scans and reports, never for a list shown to
+	// the user who just changed it.
+	Stale
+)
+
+// ReadOption configures a list method
+type ReadOption func(*readOptions)
+
+type readOptions struct {
+	consistency Consistency
+}
+
+// WithConsistency reads with c
+func WithConsistency(c Consistency) ReadOption {
+	return func(o *readOptions) { o.consistency = c }
+}
+
+// txControl returns the transaction control reading with the consistency of opts
+func txControl(opts []ReadOption) *table.TransactionControl {
+	var o readOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.consistency == Stale {
+		return table.TxControl(table.BeginTx(table.WithStaleReadOnly()), table.CommitTx())
+	}
+	return table.DefaultTxControl()
+}
+
+// read executes a read-only query with the consistency of opts and returns the result set
+func read(ctx context.Context, sql string, opts []ReadOption, params ...table.ParameterOption) (result.Result, error) {
+	return query(ctx, txControl(opts), sql, params...)
+}
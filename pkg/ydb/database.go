@@ -0,0 +1,61 @@
+package ydb
+
+import (
+	"context"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// Database exposes the users, subscriptions and notifications as a grpcapi.Database
+type Database struct{}
+
+// GetUser calls GetUserByTelegramChatID
+func (Database) GetUser(ctx context.Context, chatID int64) (*models.User, error) {
+	return GetUserByTelegramChatID(ctx, chatID)
+}
+
+// UpsertUser calls UpsertUser
+func (Database) UpsertUser(ctx context.Context, user *models.User) error {
+	return UpsertUser(ctx, user)
+}
+
+// UpdateUserStatus calls UpdateUserStatus
+func (Database) UpdateUserStatus(ctx context.Context, chatID int64, status models.UserStatus) error {
+	return UpdateUserStatus(ctx, chatID, status)
+}
+
+// GetActiveUsers calls GetActiveUsers
+func (Database) GetActiveUsers(ctx context.Context) ([]models.User, error) {
+	return GetActiveUsers(ctx)
+}
+
+// GetSubscriptionsByUser calls GetSearchSubscriptionsByUser with serializable reads, as
+// the bot lists subscriptions right after creating one
+func (Database) GetSubscriptionsByUser(ctx context.Context, chatID int64) ([]models.SearchSubscription, error) {
+	return GetSearchSubscriptionsByUser(ctx, chatID, WithConsistency(Serializable))
+}
+
+// CreateSubscription calls CreateSearchSubscription
+func (Database) CreateSubscription(ctx context.Context, sub *models.SearchSubscription) error {
+	return CreateSearchSubscription(ctx, sub)
+}
+
+// SetSubscriptionActive calls SetSubscriptionActive
+func (Database) SetSubscriptionActive(ctx context.Context, subID string, active bool) error {
+	return SetSubscriptionActive(ctx, subID, active)
+}
+
+// DeleteSubscription calls DeleteSearchSubscription
+func (Database) DeleteSubscription(ctx context.Context, subID string) error {
+	return DeleteSearchSubscription(ctx, subID)
+}
+
+// GetNotificationByTrip calls GetNotificationByTrip
+func (Database) GetNotificationByTrip(ctx context.Context, chatID int64, subID, tripID string) (*models.Notification, error) {
+	return GetNotificationByTrip(ctx, chatID, subID, tripID)
+}
+
+// GetSentNotifications calls GetSentNotifications
+func (Database) GetSentNotifications(ctx context.Context, today models.Date) ([]models.Notification, error) {
+	return GetSentNotifications(ctx, today)
+}
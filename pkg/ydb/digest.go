@@ -0,0 +1,243 @@
+package ydb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// The digest_entries table holds matched trips waiting for quiet hours to end or for the
+// daily digest; payload is the JSON of the subscription and trip:
+//
+//	CREATE TABLE digest_entries (
+//		id Utf8 NOT NULL,
+//		telegram_chat_id Int64 NOT NULL,
+//		notification_id Utf8 NOT NULL,
+//		payload Utf8 NOT NULL,
+//		deliver_at Datetime NOT NULL,
+//		created_at Datetime NOT NULL,
+//		PRIMARY KEY (id),
+//		INDEX idx_deliver_at GLOBAL ON (deliver_at)
+//	);
+
+type digestPayload struct {
+	Subscription models.SearchSubscription `json:"subscription"`
+	Trip         models.TripInfo           `json:"trip"`
+}
+
+// AddDigestEntry stores a trip for later delivery
+func AddDigestEntry(ctx context.Context, entry *models.DigestEntry) error {
+	payload, err := json.Marshal(digestPayload{Subscription: entry.Subscription, Trip: entry.Trip})
+	if err != nil {
+		return fmt.Errorf("failed to encode digest entry: %w", err)
+	}
+
+	sql := TablePathPrefix("") + `
+		DECLARE $id AS Utf8;
+		DECLARE $telegram_chat_id AS Int64;
+		DECLARE $notification_id AS Utf8;
+		DECLARE $payload AS Utf8;
+		DECLARE $deliver_at AS Datetime;
+		DECLARE $created_at AS Datetime;
+
+		UPSERT INTO digest_entries (id, telegram_chat_id, notification_id, payload, deliver_at, created_at)
+		VALUES ($id, $telegram_chat_id, $notification_id, $payload, $deliver_at, $created_at);
+	`
+
+	params := []table.ParameterOption{
+		table.ValueParam("$id", types.TextValue(entry.ID)),
+		table.ValueParam("$telegram_chat_id", types.Int64Value(entry.TelegramChatID)),
+		table.ValueParam("$notification_id", types.TextValue(entry.NotificationID)),
+		table.ValueParam("$payload", types.TextValue(string(payload))),
+		table.ValueParam("$deliver_at", types.DatetimeValue(uint32(entry.DeliverAt.Unix()))),
+		table.ValueParam("$created_at", types.DatetimeValue(uint32(entry.CreatedAt.Unix()))),
+	}
+
+	return Exec(ctx, sql, params...)
+}
+
+// GetDueDigestEntries retrieves entries to deliver at now, oldest first
+func GetDueDigestEntries(ctx context.Context, now time.Time, limit int) ([]models.DigestEntry, error) {
+	sql := TablePathPrefix("") + `
+		DECLARE $now AS Datetime;
+		DECLARE $limit AS Uint64;
+
+		SELECT id, telegram_chat_id, notification_id, payload, deliver_at, created_at
+		FROM digest_entries VIEW idx_deliver_at
+		WHERE deliver_at <= $now
+		ORDER BY deliver_at
+		LIMIT $limit;
+	`
+
+	params := []table.ParameterOption{
+		table.ValueParam("$now", types.DatetimeValue(uint32(now.Unix()))),
+		table.ValueParam("$limit", types.Uint64Value(uint64(limit))),
+	}
+
+	res, err := Query(ctx, sql, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due digest entries: %w", err)
+	}
+	defer res.Close()
+
+	var entries []models.DigestEntry
+	for res.NextRow() {
+		var entry models.DigestEntry
+		var payload string
+		err = res.Scan(&entry.ID, &entry.TelegramChatID, &entry.NotificationID, &payload, &entry.DeliverAt, &entry.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan digest entry: %w", err)
+		}
+		if err = decodeDigestPayload(&entry, payload); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// decodeDigestPayload fills the subscription and trip of entry from its payload column
+func decodeDigestPayload(entry *models.DigestEntry, payload string) error {
+	var decoded digestPayload
+	if err := json.Unmarshal([]byte(payload), &decoded); err != nil {
+		return fmt.Errorf("failed to decode digest entry %s: %w", entry.ID, err)
+	}
+	entry.Subscription = decoded.Subscription
+	entry.Trip = decoded.Trip
+	return nil
+}
+
+// ClaimDigestEntries retrieves up to limit entries to deliver at now, oldest first, and
+// moves their deliver_at to leaseUntil within one transaction, so overlapping deliveries
+// skip them; entries that are not removed after delivery come due again then. Entries
+// whose notification was already sent or failed are left over from a delivery whose
+// removal failed: they are deleted instead of returned.
+func ClaimDigestEntries(ctx context.Context, now time.Time, limit int, leaseUntil time.Time) ([]models.DigestEntry, error) {
+	selectSQL := TablePathPrefix("") + `
+		DECLARE $now AS Datetime;
+		DECLARE $limit AS Uint64;
+
+		SELECT d.id AS id, d.telegram_chat_id AS telegram_chat_id, d.notification_id AS notification_id,
+			d.payload AS payload, d.deliver_at AS deliver_at, d.created_at AS created_at, n.status AS status
+		FROM digest_entries VIEW idx_deliver_at AS d
+		LEFT JOIN notifications AS n ON n.id = d.notification_id
+		WHERE d.deliver_at <= $now
+		ORDER BY deliver_at
+		LIMIT $limit;
+	`
+	updateSQL := TablePathPrefix("") + `
+		DECLARE $claimed AS List<Utf8>;
+		DECLARE $delivered AS List<Utf8>;
+		DECLARE $lease_until AS Datetime;
+
+		UPDATE digest_entries SET deliver_at = $lease_until WHERE id IN $claimed;
+		DELETE FROM digest_entries WHERE id IN $delivered;
+	`
+
+	var entries []models.DigestEntry
+	err := DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		entries = nil
+		res, err := tx.Execute(ctx, selectSQL, table.NewQueryParameters(
+			table.ValueParam("$now", types.DatetimeValue(uint32(now.Unix()))),
+			table.ValueParam("$limit", types.Uint64Value(uint64(limit))),
+		))
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err = res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+
+		claimed := []types.Value{}
+		delivered := []types.Value{}
+		for res.NextRow() {
+			var entry models.DigestEntry
+			var payload string
+			var status *string
+			err = res.Scan(&entry.ID, &entry.TelegramChatID, &entry.NotificationID, &payload, &entry.DeliverAt, &entry.CreatedAt, &status)
+			if err != nil {
+				return fmt.Errorf("failed to scan digest entry: %w", err)
+			}
+			if status != nil && *status != string(models.NotificationStatusQueued) && *status != string(models.NotificationStatusPending) {
+				delivered = append(delivered, types.TextValue(entry.ID))
+				continue
+			}
+			if err = decodeDigestPayload(&entry, payload); err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+			claimed = append(claimed, types.TextValue(entry.ID))
+		}
+		if len(claimed) == 0 && len(delivered) == 0 {
+			return nil
+		}
+
+		_, err = tx.Execute(ctx, updateSQL, table.NewQueryParameters(
+			table.ValueParam("$claimed", listOrEmpty(claimed)),
+			table.ValueParam("$delivered", listOrEmpty(delivered)),
+			table.ValueParam("$lease_until", types.DatetimeValue(uint32(leaseUntil.Unix()))),
+		))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due digest entries: %w", err)
+	}
+	return entries, nil
+}
+
+// listOrEmpty returns a List<Utf8> of values, typed even when there are none
+func listOrEmpty(values []types.Value) types.Value {
+	if len(values) == 0 {
+		return types.ZeroValue(types.List(types.TypeText))
+	}
+	return types.ListValue(values...)
+}
+
+// DeleteDigestEntries removes delivered entries
+func DeleteDigestEntries(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	sql := TablePathPrefix("") + `
+		DECLARE $ids AS List<Utf8>;
+
+		DELETE FROM digest_entries WHERE id IN $ids;
+	`
+
+	values := make([]types.Value, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, types.TextValue(id))
+	}
+	params := []table.ParameterOption{
+		table.ValueParam("$ids", types.ListValue(values...)),
+	}
+
+	return Exec(ctx, sql, params...)
+}
+
+// DigestStore exposes the digest_entries table as a notify.DigestStore
+type DigestStore struct{}
+
+// Add calls AddDigestEntry
+func (DigestStore) Add(ctx context.Context, entry *models.DigestEntry) error {
+	return AddDigestEntry(ctx, entry)
+}
+
+// Claim calls ClaimDigestEntries
+func (DigestStore) Claim(ctx context.Context, now time.Time, limit int, leaseUntil time.Time) ([]models.DigestEntry, error) {
+	return ClaimDigestEntries(ctx, now, limit, leaseUntil)
+}
+
+// Remove calls DeleteDigestEntries
+func (DigestStore) Remove(ctx context.Context, ids []string) error {
+	return DeleteDigestEntries(ctx, ids)
+}
@@ -7,4 +7,5 @@ var (
 	ErrUserNotFound     = errors.New("user not found")
 	ErrTokensNotFound   = errors.New("tokens not found")
 	ErrSubscriptionNotFound = errors.New("subscription not found")
+	ErrKeyNotFound      = errors.New("key not found")
 )
@@ -0,0 +1,189 @@
+package ydb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// The events table collects usage events for analytics, see pkg/analytics:
+//
+//	CREATE TABLE events (
+//		id Utf8 NOT NULL,
+//		type Utf8 NOT NULL,
+//		telegram_chat_id Int64 NOT NULL,
+//		properties Utf8,
+//		created_at Datetime NOT NULL,
+//		PRIMARY KEY (id),
+//		INDEX idx_type_created_at GLOBAL ON (type, created_at),
+//		INDEX idx_telegram_chat_id GLOBAL ON (telegram_chat_id)
+//	);
+
+// InsertEvents stores events in one statement; writing an event again, e.g. on a retried
+// batch, replaces it
+func InsertEvents(ctx context.Context, events []models.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	sql := TablePathPrefix("") + `
+		DECLARE $events AS List<Struct<
+			id: Utf8,
+			type: Utf8,
+			telegram_chat_id: Int64,
+			properties: Optional<Utf8>,
+			created_at: Datetime
+		>>;
+
+		UPSERT INTO events (id, type, telegram_chat_id, properties, created_at)
+		SELECT id, type, telegram_chat_id, properties, created_at FROM AS_TABLE($events);
+	`
+
+	rows := make([]types.Value, 0, len(events))
+	for _, ev := range events {
+		var properties *string
+		if len(ev.Properties) > 0 {
+			data, err := json.Marshal(ev.Properties)
+			if err != nil {
+				return fmt.Errorf("failed to encode properties of event %s: %w", ev.ID, err)
+			}
+			encoded := string(data)
+			properties = &encoded
+		}
+		rows = append(rows, types.StructValue(
+			types.StructFieldValue("id", types.TextValue(ev.ID)),
+			types.StructFieldValue("type", types.TextValue(string(ev.Type))),
+			types.StructFieldValue("telegram_chat_id", types.Int64Value(ev.ChatID)),
+			types.StructFieldValue("properties", optionalText(properties)),
+			types.StructFieldValue("created_at", types.DatetimeValue(uint32(ev.At.Unix()))),
+		))
+	}
+	params := []table.ParameterOption{
+		table.ValueParam("$events", types.ListValue(rows...)),
+	}
+
+	if err := Exec(ctx, sql, params...); err != nil {
+		return fmt.Errorf("failed to insert %d events: %w", len(events), err)
+	}
+	return nil
+}
+
+// EventStore exposes the events table as an analytics.Sink
+type EventStore struct{}
+
+// Write calls InsertEvents
+func (EventStore) Write(ctx context.Context, events []models.Event) error {
+	return InsertEvents(ctx, events)
+}
+
+// GetClickThroughStats counts the notifications sent since the given time and the
+// clicks recorded on them, see telegram.TrackClicks. Notifications later marked
+// unavailable count as sent.
+func GetClickThroughStats(ctx context.Context, since time.Time) (models.ClickThroughStats, error) {
+	stats := models.ClickThroughStats{Since: since, ByAction: make(map[string]int)}
+	params := []table.ParameterOption{
+		table.ValueParam("$since", types.DatetimeValue(uint32(since.Unix()))),
+	}
+
+	sentSQL := TablePathPrefix("") + `
+		DECLARE $since AS Datetime;
+
+		SELECT COUNT(*) FROM notifications
+		WHERE created_at >= $since AND status IN ("sent", "unavailable");
+	`
+	res, err := Query(ctx, sentSQL, params...)
+	if err != nil {
+		return stats, fmt.Errorf("failed to count sent notifications: %w", err)
+	}
+	defer res.Close()
+	if res.NextRow() {
+		var sent uint64
+		if err = res.Scan(&sent); err != nil {
+			return stats, fmt.Errorf("failed to scan sent notifications: %w", err)
+		}
+		stats.Sent = int(sent)
+	}
+
+	// ROLLUP adds a total row, with a NULL action, counting distinct notifications overall
+	clicksSQL := TablePathPrefix("") + `
+		DECLARE $since AS Datetime;
+		DECLARE $type AS Utf8;
+
+		SELECT action, COUNT(*) AS clicks, COUNT(DISTINCT notification_id) AS clicked
+		FROM (
+			SELECT
+				JSON_VALUE(CAST(properties AS Json), "$.action") AS action,
+				JSON_VALUE(CAST(properties AS Json), "$.notification_id") AS notification_id
+			FROM events VIEW idx_type_created_at
+			WHERE type = $type AND created_at >= $since
+		)
+		GROUP BY ROLLUP(action);
+	`
+	res, err = Query(ctx, clicksSQL, append(params,
+		table.ValueParam("$type", types.TextValue(string(models.EventNotificationClicked))))...)
+	if err != nil {
+		return stats, fmt.Errorf("failed to count notification clicks: %w", err)
+	}
+	defer res.Close()
+	for res.NextRow() {
+		var action *string
+		var clicks, clicked uint64
+		if err = res.Scan(&action, &clicks, &clicked); err != nil {
+			return stats, fmt.Errorf("failed to scan notification clicks: %w", err)
+		}
+		if action == nil {
+			stats.Clicks, stats.Clicked = int(clicks), int(clicked)
+			continue
+		}
+		stats.ByAction[*action] = int(clicks)
+	}
+
+	return stats, nil
+}
+
+// GetEventsByUser retrieves the usage events of a chat, oldest first
+func GetEventsByUser(ctx context.Context, chatID int64) ([]models.Event, error) {
+	sql := TablePathPrefix("") + `
+		DECLARE $telegram_chat_id AS Int64;
+
+		SELECT id, type, telegram_chat_id, properties, created_at
+		FROM events VIEW idx_telegram_chat_id
+		WHERE telegram_chat_id = $telegram_chat_id
+		ORDER BY created_at;
+	`
+
+	params := []table.ParameterOption{
+		table.ValueParam("$telegram_chat_id", types.Int64Value(chatID)),
+	}
+
+	res, err := Query(ctx, sql, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events of user: %w", err)
+	}
+	defer res.Close()
+
+	var events []models.Event
+	for res.NextRow() {
+		var ev models.Event
+		var eventType string
+		var properties *string
+		if err = res.Scan(&ev.ID, &eventType, &ev.ChatID, &properties, &ev.At); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		ev.Type = models.EventType(eventType)
+		if properties != nil && *properties != "" {
+			if err = json.Unmarshal([]byte(*properties), &ev.Properties); err != nil {
+				return nil, fmt.Errorf("failed to decode properties of event %s: %w", ev.ID, err)
+			}
+		}
+		events = append(events, ev)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,204 @@
+package ydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/arseniisemenow/bbc-common/pkg/clock"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// ErrInvalidExport is returned by ImportUserData for an export it refuses to restore
+var ErrInvalidExport = errors.New("invalid user data export")
+
+// ExportUserData gathers everything stored about a user into one document for a GDPR
+// access request: the user, the linked BlaBlaCar account with its tokens fingerprinted,
+// preferences, subscriptions, notifications, booking attempts, payments and usage
+// events. It returns ErrUserNotFound for unknown chats.
+func ExportUserData(ctx context.Context, chatID int64) (models.UserDataExport, error) {
+	export := models.UserDataExport{
+		Version:    models.UserDataExportVersion,
+		ExportedAt: clock.From(ctx).Now().UTC(),
+	}
+
+	user, err := GetUserByTelegramChatID(ctx, chatID)
+	if err != nil {
+		return export, err
+	}
+	export.User = *user
+
+	tokens, err := GetUserTokens(ctx, chatID)
+	switch {
+	case errors.Is(err, ErrTokensNotFound):
+	case err != nil:
+		return export, err
+	default:
+		account := tokens.Export()
+		export.Account = &account
+		export.Preferences = tokens.Preferences()
+	}
+
+	if export.Subscriptions, err = GetSearchSubscriptionsByUser(ctx, chatID); err != nil {
+		return export, err
+	}
+	if export.Notifications, err = GetNotificationsByUser(ctx, chatID); err != nil {
+		return export, err
+	}
+	if export.BookingAttempts, err = GetBookingAttemptsByUser(ctx, chatID); err != nil {
+		return export, err
+	}
+	if export.Payments, err = GetPaymentsByUser(ctx, chatID); err != nil {
+		return export, err
+	}
+	if export.Events, err = GetEventsByUser(ctx, chatID); err != nil {
+		return export, err
+	}
+
+	// empty lists encode as [] rather than null
+	export.Subscriptions = nonNil(export.Subscriptions)
+	export.Notifications = nonNil(export.Notifications)
+	export.BookingAttempts = nonNil(export.BookingAttempts)
+	export.Payments = nonNil(export.Payments)
+	export.Events = nonNil(export.Events)
+
+	logger(ctx).Info("Exported user data", "telegram_chat_id", chatID,
+		"subscriptions", len(export.Subscriptions), "notifications", len(export.Notifications),
+		"events", len(export.Events))
+	return export, nil
+}
+
+// ImportUserData restores an export of ExportUserData, e.g. into another environment:
+// the user, subscriptions, notifications, booking attempts, payments and events. Records
+// that already exist are kept, so an import can be repeated. The BlaBlaCar account is
+// not restored, as only token fingerprints are exported; the user logs in again. It
+// returns the number of records written, and ErrInvalidExport without writing anything
+// for an export of an unknown version or holding records of another chat.
+func ImportUserData(ctx context.Context, export models.UserDataExport) (int, error) {
+	if err := validateExport(export); err != nil {
+		return 0, err
+	}
+	chatID := export.User.TelegramChatID
+	user := export.User
+	if err := UpsertUser(ctx, &user); err != nil {
+		return 0, err
+	}
+	written := 1
+
+	subs, err := GetSearchSubscriptionsByUser(ctx, chatID)
+	if err != nil {
+		return written, err
+	}
+	existing := make(map[string]bool)
+	for _, sub := range subs {
+		existing[sub.ID] = true
+	}
+	for _, sub := range export.Subscriptions {
+		if existing[sub.ID] {
+			continue
+		}
+		if err := CreateSearchSubscription(ctx, &sub); err != nil {
+			return written, fmt.Errorf("failed to import subscription %s: %w", sub.ID, err)
+		}
+		written++
+	}
+
+	notifs, err := GetNotificationsByUser(ctx, chatID)
+	if err != nil {
+		return written, err
+	}
+	clear(existing)
+	for _, notif := range notifs {
+		existing[notif.ID] = true
+	}
+	for _, notif := range export.Notifications {
+		if existing[notif.ID] {
+			continue
+		}
+		if err := CreateNotification(ctx, &notif); err != nil {
+			return written, fmt.Errorf("failed to import notification %s: %w", notif.ID, err)
+		}
+		written++
+	}
+
+	attempts, err := GetBookingAttemptsByUser(ctx, chatID)
+	if err != nil {
+		return written, err
+	}
+	clear(existing)
+	for _, attempt := range attempts {
+		existing[attempt.ID] = true
+	}
+	for _, attempt := range export.BookingAttempts {
+		if existing[attempt.ID] {
+			continue
+		}
+		if err := CreateBookingAttempt(ctx, &attempt); err != nil {
+			return written, fmt.Errorf("failed to import booking attempt %s: %w", attempt.ID, err)
+		}
+		written++
+	}
+
+	// payments and events are upserted, so existing ones are rewritten unchanged
+	for _, payment := range export.Payments {
+		if err := CreatePayment(ctx, &payment); err != nil {
+			return written, fmt.Errorf("failed to import payment %s: %w", payment.ID, err)
+		}
+		written++
+	}
+	if err := InsertEvents(ctx, export.Events); err != nil {
+		return written, err
+	}
+	written += len(export.Events)
+
+	logger(ctx).Info("Imported user data", "telegram_chat_id", chatID, "records", written)
+	return written, nil
+}
+
+// validateExport checks the version of export and that every record belongs to its user
+func validateExport(export models.UserDataExport) error {
+	if export.Version < 1 || export.Version > models.UserDataExportVersion {
+		return fmt.Errorf("%w: unsupported version %d, want 1 to %d", ErrInvalidExport, export.Version, models.UserDataExportVersion)
+	}
+	chatID := export.User.TelegramChatID
+	if chatID == 0 {
+		return fmt.Errorf("%w: no user chat ID", ErrInvalidExport)
+	}
+
+	foreign := func(kind, id string, recordChatID int64) error {
+		return fmt.Errorf("%w: %s %s belongs to chat %d, not %d", ErrInvalidExport, kind, id, recordChatID, chatID)
+	}
+	for _, sub := range export.Subscriptions {
+		if sub.TelegramChatID != chatID {
+			return foreign("subscription", sub.ID, sub.TelegramChatID)
+		}
+	}
+	for _, notif := range export.Notifications {
+		if notif.TelegramChatID != chatID {
+			return foreign("notification", notif.ID, notif.TelegramChatID)
+		}
+	}
+	for _, attempt := range export.BookingAttempts {
+		if attempt.TelegramChatID != chatID {
+			return foreign("booking attempt", attempt.ID, attempt.TelegramChatID)
+		}
+	}
+	for _, payment := range export.Payments {
+		if payment.TelegramChatID != chatID {
+			return foreign("payment", payment.ID, payment.TelegramChatID)
+		}
+	}
+	for _, ev := range export.Events {
+		if ev.ChatID != chatID {
+			return foreign("event", ev.ID, ev.ChatID)
+		}
+	}
+	return nil
+}
+
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
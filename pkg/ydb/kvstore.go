@@ -0,0 +1,125 @@
+package ydb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+
+	"github.com/arseniisemenow/bbc-common/pkg/clock"
+)
+
+// KVStore is a namespaced key-value store with per-entry expiry, backed by the kv_store table:
+//
+//	CREATE TABLE kv_store (
+//		namespace Utf8 NOT NULL,
+//		key Utf8 NOT NULL,
+//		value Utf8 NOT NULL,
+//		expires_at Datetime,
+//		PRIMARY KEY (namespace, key)
+//	);
+//
+// Expired entries are never returned; DeleteExpired removes them physically
+// (alternatively enable YDB TTL on expires_at).
+type KVStore struct {
+	Namespace string
+}
+
+// NewKVStore creates a store scoped to namespace
+func NewKVStore(namespace string) *KVStore {
+	return &KVStore{Namespace: namespace}
+}
+
+// Put stores value under key for ttl
+func (s *KVStore) Put(ctx context.Context, key, value string, ttl time.Duration) error {
+	sql := TablePathPrefix("") + `
+		DECLARE $namespace AS Utf8;
+		DECLARE $key AS Utf8;
+		DECLARE $value AS Utf8;
+		DECLARE $expires_at AS Datetime;
+
+		UPSERT INTO kv_store (namespace, key, value, expires_at)
+		VALUES ($namespace, $key, $value, $expires_at);
+	`
+
+	params := []table.ParameterOption{
+		table.ValueParam("$namespace", types.TextValue(s.Namespace)),
+		table.ValueParam("$key", types.TextValue(key)),
+		table.ValueParam("$value", types.TextValue(value)),
+		table.ValueParam("$expires_at", types.DatetimeValue(uint32(clock.From(ctx).Now().Add(ttl).Unix()))),
+	}
+
+	return Exec(ctx, sql, params...)
+}
+
+// Get returns the value stored under key, or ErrKeyNotFound if it is missing or expired
+func (s *KVStore) Get(ctx context.Context, key string) (string, error) {
+	sql := TablePathPrefix("") + `
+		DECLARE $namespace AS Utf8;
+		DECLARE $key AS Utf8;
+		DECLARE $now AS Datetime;
+
+		SELECT value
+		FROM kv_store
+		WHERE namespace = $namespace AND key = $key AND expires_at > $now;
+	`
+
+	params := []table.ParameterOption{
+		table.ValueParam("$namespace", types.TextValue(s.Namespace)),
+		table.ValueParam("$key", types.TextValue(key)),
+		table.ValueParam("$now", types.DatetimeValue(uint32(clock.From(ctx).Now().Unix()))),
+	}
+
+	res, err := Query(ctx, sql, params...)
+	if err != nil {
+		return "", fmt.Errorf("failed to query kv entry %s/%s: %w", s.Namespace, key, err)
+	}
+	defer res.Close()
+
+	if res.NextRow() {
+		var value string
+		if err = res.Scan(&value); err != nil {
+			return "", fmt.Errorf("failed to scan kv entry: %w", err)
+		}
+		return value, nil
+	}
+
+	return "", ErrKeyNotFound
+}
+
+// Delete removes key
+func (s *KVStore) Delete(ctx context.Context, key string) error {
+	sql := TablePathPrefix("") + `
+		DECLARE $namespace AS Utf8;
+		DECLARE $key AS Utf8;
+
+		DELETE FROM kv_store WHERE namespace = $namespace AND key = $key;
+	`
+
+	params := []table.ParameterOption{
+		table.ValueParam("$namespace", types.TextValue(s.Namespace)),
+		table.ValueParam("$key", types.TextValue(key)),
+	}
+
+	return Exec(ctx, sql, params...)
+}
+
+// DeleteExpired removes all expired entries in the namespace
+func (s *KVStore) DeleteExpired(ctx context.Context) error {
+	sql := TablePathPrefix("") + `
+		DECLARE $namespace AS Utf8;
+		DECLARE $now AS Datetime;
+
+		DELETE FROM kv_store WHERE namespace = $namespace AND expires_at <= $now;
+	`
+
+	params := []table.ParameterOption{
+		table.ValueParam("$namespace", types.TextValue(s.Namespace)),
+		table.ValueParam("$now", types.DatetimeValue(uint32(clock.From(ctx).Now().Unix()))),
+	}
+
+	logger(ctx).Info("KVStore: deleting expired entries", "namespace", s.Namespace)
+	return Exec(ctx, sql, params...)
+}
@@ -0,0 +1,372 @@
+package ydb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// ClaimNotification reserves the notification about notif's trip for one sender. Within a
+// transaction it looks up the notification for the chat, subscription and trip; a sent or
+// queued one, or a pending one created after staleBefore, is copied into notif and not claimed.
+// Otherwise the notification is stored as pending, keeping the ID, message ID and snapshot
+// of a failed or abandoned attempt, and claimed is true.
+func ClaimNotification(ctx context.Context, notif *models.Notification, staleBefore time.Time) (claimed bool, err error) {
+	selectSQL := TablePathPrefix("") + `
+		DECLARE $telegram_chat_id AS Int64;
+		DECLARE $subscription_id AS Utf8;
+		DECLARE $trip_id AS Utf8;
+
+		SELECT id, telegram_message_id, status, created_at, snapshot
+		FROM notifications
+		WHERE telegram_chat_id = $telegram_chat_id AND subscription_id = $subscription_id AND trip_id = $trip_id;
+	`
+	upsertSQL := TablePathPrefix("") + `
+		DECLARE $id AS Utf8;
+		DECLARE $telegram_chat_id AS Int64;
+		DECLARE $subscription_id AS Utf8;
+		DECLARE $trip_id AS Utf8;
+		DECLARE $telegram_message_id AS Int32;
+		DECLARE $status AS Utf8;
+		DECLARE $created_at AS Datetime;
+
+		UPSERT INTO notifications (id, telegram_chat_id, subscription_id, trip_id, telegram_message_id, status, created_at)
+		VALUES ($id, $telegram_chat_id, $subscription_id, $trip_id, $telegram_message_id, $status, $created_at);
+	`
+
+	err = DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		claimed = false
+		res, err := tx.Execute(ctx, selectSQL, table.NewQueryParameters(
+			table.ValueParam("$telegram_chat_id", types.Int64Value(notif.TelegramChatID)),
+			table.ValueParam("$subscription_id", types.TextValue(notif.SubscriptionID)),
+			table.ValueParam("$trip_id", types.TextValue(notif.TripID)),
+		))
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err = res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+
+		if res.NextRow() {
+			var existing models.Notification
+			var createdAt uint32
+			var snapshot *string
+			if err = res.Scan(&existing.ID, &existing.TelegramMessageID, &existing.Status, &createdAt, &snapshot); err != nil {
+				return fmt.Errorf("failed to scan notification: %w", err)
+			}
+			existing.CreatedAt = time.Unix(int64(createdAt), 0)
+			if existing.Snapshot, err = decodeSnapshot(snapshot); err != nil {
+				return fmt.Errorf("failed to decode snapshot of notification %s: %w", existing.ID, err)
+			}
+
+			notif.ID = existing.ID
+			notif.TelegramMessageID = existing.TelegramMessageID
+			notif.Snapshot = existing.Snapshot
+			if existing.Status == models.NotificationStatusSent || existing.Status == models.NotificationStatusQueued ||
+				(existing.Status == models.NotificationStatusPending && existing.CreatedAt.After(staleBefore)) {
+				notif.Status = existing.Status
+				notif.CreatedAt = existing.CreatedAt
+				return nil
+			}
+		}
+
+		notif.Status = models.NotificationStatusPending
+		_, err = tx.Execute(ctx, upsertSQL, table.NewQueryParameters(
+			table.ValueParam("$id", types.TextValue(notif.ID)),
+			table.ValueParam("$telegram_chat_id", types.Int64Value(notif.TelegramChatID)),
+			table.ValueParam("$subscription_id", types.TextValue(notif.SubscriptionID)),
+			table.ValueParam("$trip_id", types.TextValue(notif.TripID)),
+			table.ValueParam("$telegram_message_id", types.Int32Value(int32(notif.TelegramMessageID))),
+			table.ValueParam("$status", types.TextValue(string(notif.Status))),
+			table.ValueParam("$created_at", types.DatetimeValue(uint32(notif.CreatedAt.Unix()))),
+		))
+		if err != nil {
+			return err
+		}
+		claimed = true
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to claim notification for trip %s: %w", notif.TripID, err)
+	}
+	return claimed, nil
+}
+
+// CompleteNotification records the message ID, status and snapshot of a notification;
+// an unknown status is rejected with a *models.FieldError
+func CompleteNotification(ctx context.Context, notif *models.Notification) error {
+	if _, err := models.ParseNotificationStatus(string(notif.Status)); err != nil {
+		return fmt.Errorf("failed to complete notification %s: %w", notif.ID, err)
+	}
+	snapshot, err := encodeSnapshot(notif.Snapshot)
+	if err != nil {
+		return err
+	}
+
+	sql := TablePathPrefix("") + `
+		DECLARE $id AS Utf8;
+		DECLARE $telegram_message_id AS Int32;
+		DECLARE $status AS Utf8;
+		DECLARE $snapshot AS Optional<Utf8>;
+
+		UPDATE notifications
+		SET telegram_message_id = $telegram_message_id, status = $status, snapshot = $snapshot
+		WHERE id = $id;
+	`
+
+	params := []table.ParameterOption{
+		table.ValueParam("$id", types.TextValue(notif.ID)),
+		table.ValueParam("$telegram_message_id", types.Int32Value(int32(notif.TelegramMessageID))),
+		table.ValueParam("$status", types.TextValue(string(notif.Status))),
+		table.ValueParam("$snapshot", optionalText(snapshot)),
+	}
+
+	return Exec(ctx, sql, params...)
+}
+
+// encodeSnapshot stores a trip snapshot as JSON in the optional snapshot column
+func encodeSnapshot(snapshot *models.TripSnapshot) (*string, error) {
+	if snapshot == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode trip snapshot: %w", err)
+	}
+	encoded := string(data)
+	return &encoded, nil
+}
+
+func decodeSnapshot(raw *string) (*models.TripSnapshot, error) {
+	if raw == nil || *raw == "" {
+		return nil, nil
+	}
+	var snapshot models.TripSnapshot
+	if err := json.Unmarshal([]byte(*raw), &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// NotificationStore exposes the notifications table as a notify.Store
+type NotificationStore struct{}
+
+// Claim calls ClaimNotification
+func (NotificationStore) Claim(ctx context.Context, notif *models.Notification, staleBefore time.Time) (bool, error) {
+	return ClaimNotification(ctx, notif, staleBefore)
+}
+
+// Complete calls CompleteNotification
+func (NotificationStore) Complete(ctx context.Context, notif *models.Notification) error {
+	return CompleteNotification(ctx, notif)
+}
+
+// GetSentNotifications retrieves the sent notifications of active subscriptions departing
+// on or after today, i.e. the trips still worth watching
+func GetSentNotifications(ctx context.Context, today models.Date, opts ...ReadOption) ([]models.Notification, error) {
+	sql := TablePathPrefix("") + `
+		DECLARE $today AS Utf8;
+
+		SELECT n.id AS id, n.telegram_chat_id AS telegram_chat_id, n.subscription_id AS subscription_id,
+			n.trip_id AS trip_id, n.telegram_message_id AS telegram_message_id, n.status AS status,
+			n.created_at AS created_at, n.snapshot AS snapshot
+		FROM notifications AS n
+		JOIN search_subscriptions AS s ON n.subscription_id = s.id
+		WHERE n.status = "sent" AND s.is_active = true AND s.departure_date >= $today;
+	`
+
+	params := []table.ParameterOption{
+		table.ValueParam("$today", types.TextValue(today.String())),
+	}
+
+	res, err := read(ctx, sql, opts, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sent notifications: %w", err)
+	}
+	defer res.Close()
+
+	var notifs []models.Notification
+	for res.NextRow() {
+		notif, err := scanNotification(res)
+		if err != nil {
+			return nil, err
+		}
+		notifs = append(notifs, notif)
+	}
+
+	return notifs, nil
+}
+
+// GetNotificationsByUser retrieves all notifications sent to a chat, oldest first
+func GetNotificationsByUser(ctx context.Context, chatID int64, opts ...ReadOption) ([]models.Notification, error) {
+	sql := TablePathPrefix("") + `
+		DECLARE $telegram_chat_id AS Int64;
+
+		SELECT id, telegram_chat_id, subscription_id, trip_id, telegram_message_id, status, created_at, snapshot
+		FROM notifications
+		WHERE telegram_chat_id = $telegram_chat_id
+		ORDER BY created_at;
+	`
+
+	params := []table.ParameterOption{
+		table.ValueParam("$telegram_chat_id", types.Int64Value(chatID)),
+	}
+
+	res, err := read(ctx, sql, opts, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notifications of user: %w", err)
+	}
+	defer res.Close()
+
+	var notifs []models.Notification
+	for res.NextRow() {
+		notif, err := scanNotification(res)
+		if err != nil {
+			return nil, err
+		}
+		notifs = append(notifs, notif)
+	}
+
+	return notifs, nil
+}
+
+// scanNotification reads a row selected with the columns of GetNotificationByTrip
+func scanNotification(res result.Result) (models.Notification, error) {
+	var notif models.Notification
+	var createdAt uint32
+	var snapshot *string
+	err := res.Scan(&notif.ID, &notif.TelegramChatID, &notif.SubscriptionID,
+		&notif.TripID, &notif.TelegramMessageID, &notif.Status, &createdAt, &snapshot)
+	if err != nil {
+		return notif, fmt.Errorf("failed to scan notification: %w", err)
+	}
+	notif.CreatedAt = time.Unix(int64(createdAt), 0)
+	if notif.Snapshot, err = decodeSnapshot(snapshot); err != nil {
+		return notif, fmt.Errorf("failed to decode snapshot of notification %s: %w", notif.ID, err)
+	}
+	return notif, nil
+}
+
+// GetNotifiedTrips reports which of tripIDs the subscription already notified its user
+// about, in one lookup per chunk instead of one per trip
+func GetNotifiedTrips(ctx context.Context, chatID int64, subID string, tripIDs []string) (map[string]bool, error) {
+	q := ChunkedQuery[string, string]{
+		SQL: TablePathPrefix("") + `
+			DECLARE $telegram_chat_id AS Int64;
+			DECLARE $subscription_id AS Utf8;
+			DECLARE $keys AS List<Utf8>;
+
+			SELECT DISTINCT trip_id
+			FROM notifications
+			WHERE telegram_chat_id = $telegram_chat_id AND subscription_id = $subscription_id AND trip_id IN $keys;
+		`,
+		Key: func(tripID string) types.Value { return types.TextValue(tripID) },
+		Scan: func(res result.Result) (string, error) {
+			var tripID string
+			if err := res.Scan(&tripID); err != nil {
+				return "", fmt.Errorf("failed to scan notified trip: %w", err)
+			}
+			return tripID, nil
+		},
+		Params: []table.ParameterOption{
+			table.ValueParam("$telegram_chat_id", types.Int64Value(chatID)),
+			table.ValueParam("$subscription_id", types.TextValue(subID)),
+		},
+	}
+
+	rows, err := QueryChunked(ctx, q, tripIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notified trips: %w", err)
+	}
+	notified := make(map[string]bool, len(rows))
+	for _, tripID := range rows {
+		notified[tripID] = true
+	}
+	return notified, nil
+}
+
+// GetTripSnapshots retrieves the snapshots the notifications with the given IDs were
+// last sent with, keyed by notification ID; notifications without a snapshot are missing
+func GetTripSnapshots(ctx context.Context, notifIDs []string) (map[string]models.TripSnapshot, error) {
+	type row struct {
+		id       string
+		snapshot *models.TripSnapshot
+	}
+	q := ChunkedQuery[string, row]{
+		SQL: TablePathPrefix("") + `
+			DECLARE $keys AS List<Utf8>;
+
+			SELECT id, snapshot
+			FROM notifications
+			WHERE id IN $keys AND snapshot IS NOT NULL;
+		`,
+		Key: func(id string) types.Value { return types.TextValue(id) },
+		Scan: func(res result.Result) (row, error) {
+			var (
+				r   row
+				raw *string
+			)
+			if err := res.Scan(&r.id, &raw); err != nil {
+				return r, fmt.Errorf("failed to scan notification snapshot: %w", err)
+			}
+			var err error
+			if r.snapshot, err = decodeSnapshot(raw); err != nil {
+				return r, fmt.Errorf("failed to decode snapshot of notification %s: %w", r.id, err)
+			}
+			return r, nil
+		},
+	}
+
+	rows, err := QueryChunked(ctx, q, notifIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification snapshots: %w", err)
+	}
+	snapshots := make(map[string]models.TripSnapshot, len(rows))
+	for _, r := range rows {
+		if r.snapshot != nil {
+			snapshots[r.id] = *r.snapshot
+		}
+	}
+	return snapshots, nil
+}
+
+// DeleteNotificationsBefore deletes the notifications created before the given time and
+// returns how many there were. Notifications also prevent repeated alerts, so purge only
+// those of trips that have departed.
+func DeleteNotificationsBefore(ctx context.Context, before time.Time) (int, error) {
+	sql := TablePathPrefix("") + `
+		DECLARE $before AS Datetime;
+
+		$old = SELECT id FROM notifications WHERE created_at < $before;
+
+		DELETE FROM notifications ON SELECT id FROM $old;
+		SELECT COUNT(*) FROM $old;
+	`
+
+	params := []table.ParameterOption{
+		table.ValueParam("$before", types.DatetimeValue(uint32(before.Unix()))),
+	}
+
+	res, err := Query(ctx, sql, params...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete notifications: %w", err)
+	}
+	defer res.Close()
+
+	var deleted uint64
+	if res.NextRow() {
+		if err = res.Scan(&deleted); err != nil {
+			return 0, fmt.Errorf("failed to scan deleted notifications: %w", err)
+		}
+	}
+	return int(deleted), nil
+}
@@ -0,0 +1,261 @@
+package ydb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+
+	"github.com/arseniisemenow/bbc-common/pkg/clock"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// The outbox table holds messages queued for durable delivery:
+//
+//	CREATE TABLE outbox (
+//		id Utf8 NOT NULL,
+//		telegram_chat_id Int64 NOT NULL,
+//		payload Utf8 NOT NULL,
+//		status Utf8 NOT NULL,
+//		attempts Int32 NOT NULL,
+//		next_attempt_at Datetime NOT NULL,
+//		last_error Utf8,
+//		created_at Datetime NOT NULL,
+//		PRIMARY KEY (id),
+//		INDEX idx_status_next_attempt GLOBAL ON (status, next_attempt_at)
+//	);
+
+// EnqueueOutboxMessage inserts a pending outbox message
+func EnqueueOutboxMessage(ctx context.Context, msg *models.OutboxMessage) error {
+	sql := TablePathPrefix("") + `
+		DECLARE $id AS Utf8;
+		DECLARE $telegram_chat_id AS Int64;
+		DECLARE $payload AS Utf8;
+		DECLARE $status AS Utf8;
+		DECLARE $attempts AS Int32;
+		DECLARE $next_attempt_at AS Datetime;
+		DECLARE $created_at AS Datetime;
+
+		INSERT INTO outbox (id, telegram_chat_id, payload, status, attempts, next_attempt_at, created_at)
+		VALUES ($id, $telegram_chat_id, $payload, $status, $attempts, $next_attempt_at, $created_at);
+	`
+
+	params := []table.ParameterOption{
+		table.ValueParam("$id", types.TextValue(msg.ID)),
+		table.ValueParam("$telegram_chat_id", types.Int64Value(msg.TelegramChatID)),
+		table.ValueParam("$payload", types.TextValue(msg.Payload)),
+		table.ValueParam("$status", types.TextValue(string(msg.Status))),
+		table.ValueParam("$attempts", types.Int32Value(int32(msg.Attempts))),
+		table.ValueParam("$next_attempt_at", types.DatetimeValue(uint32(msg.NextAttemptAt.Unix()))),
+		table.ValueParam("$created_at", types.DatetimeValue(uint32(msg.CreatedAt.Unix()))),
+	}
+
+	return Exec(ctx, sql, params...)
+}
+
+// ClaimDueOutboxMessages retrieves up to limit pending messages due at now, oldest first,
+// and moves their next attempt to leaseUntil within one transaction, so overlapping
+// drains skip them. A message neither marked sent nor attempted by then, e.g. after a
+// crash, comes due again.
+func ClaimDueOutboxMessages(ctx context.Context, now time.Time, limit int, leaseUntil time.Time) ([]models.OutboxMessage, error) {
+	selectSQL := TablePathPrefix("") + `
+		DECLARE $now AS Datetime;
+		DECLARE $limit AS Uint64;
+
+		SELECT id, telegram_chat_id, payload, status, attempts, next_attempt_at, last_error, created_at
+		FROM outbox VIEW idx_status_next_attempt
+		WHERE status = "pending" AND next_attempt_at <= $now
+		ORDER BY next_attempt_at
+		LIMIT $limit;
+	`
+	updateSQL := TablePathPrefix("") + `
+		DECLARE $claimed AS List<Utf8>;
+		DECLARE $lease_until AS Datetime;
+
+		UPDATE outbox SET next_attempt_at = $lease_until WHERE id IN $claimed AND status = "pending";
+	`
+
+	var msgs []models.OutboxMessage
+	err := DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		msgs = nil
+		res, err := tx.Execute(ctx, selectSQL, table.NewQueryParameters(
+			table.ValueParam("$now", types.DatetimeValue(uint32(now.Unix()))),
+			table.ValueParam("$limit", types.Uint64Value(uint64(limit))),
+		))
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err = res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+
+		var claimed []types.Value
+		for res.NextRow() {
+			msg, err := scanOutboxMessage(res)
+			if err != nil {
+				return err
+			}
+			msgs = append(msgs, msg)
+			claimed = append(claimed, types.TextValue(msg.ID))
+		}
+		if len(claimed) == 0 {
+			return nil
+		}
+
+		_, err = tx.Execute(ctx, updateSQL, table.NewQueryParameters(
+			table.ValueParam("$claimed", types.ListValue(claimed...)),
+			table.ValueParam("$lease_until", types.DatetimeValue(uint32(leaseUntil.Unix()))),
+		))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due outbox messages: %w", err)
+	}
+	return msgs, nil
+}
+
+// scanOutboxMessage reads a row selected with the columns of ClaimDueOutboxMessages
+func scanOutboxMessage(res result.Result) (models.OutboxMessage, error) {
+	var msg models.OutboxMessage
+	var lastError *string
+	err := res.Scan(&msg.ID, &msg.TelegramChatID, &msg.Payload, &msg.Status, &msg.Attempts,
+		&msg.NextAttemptAt, &lastError, &msg.CreatedAt)
+	if err != nil {
+		return msg, fmt.Errorf("failed to scan outbox message: %w", err)
+	}
+	if lastError != nil {
+		msg.LastError = *lastError
+	}
+	return msg, nil
+}
+
+// MarkOutboxMessageSent marks a message as delivered
+func MarkOutboxMessageSent(ctx context.Context, id string) error {
+	sql := TablePathPrefix("") + `
+		DECLARE $id AS Utf8;
+
+		UPDATE outbox SET status = "sent" WHERE id = $id;
+	`
+
+	params := []table.ParameterOption{
+		table.ValueParam("$id", types.TextValue(id)),
+	}
+
+	return Exec(ctx, sql, params...)
+}
+
+// UpdateOutboxMessageAttempt records a failed attempt and the resulting status and next attempt time
+func UpdateOutboxMessageAttempt(ctx context.Context, id string, status models.OutboxStatus, attempts int, nextAttemptAt time.Time, lastError string) error {
+	sql := TablePathPrefix("") + `
+		DECLARE $id AS Utf8;
+		DECLARE $status AS Utf8;
+		DECLARE $attempts AS Int32;
+		DECLARE $next_attempt_at AS Datetime;
+		DECLARE $last_error AS Optional<Utf8>;
+
+		UPDATE outbox
+		SET status = $status, attempts = $attempts, next_attempt_at = $next_attempt_at, last_error = $last_error
+		WHERE id = $id;
+	`
+
+	params := []table.ParameterOption{
+		table.ValueParam("$id", types.TextValue(id)),
+		table.ValueParam("$status", types.TextValue(string(status))),
+		table.ValueParam("$attempts", types.Int32Value(int32(attempts))),
+		table.ValueParam("$next_attempt_at", types.DatetimeValue(uint32(nextAttemptAt.Unix()))),
+		table.ValueParam("$last_error", optionalText(&lastError)),
+	}
+
+	return Exec(ctx, sql, params...)
+}
+
+// GetOutboxDeadLetters retrieves messages given up on, most recent failure first
+func GetOutboxDeadLetters(ctx context.Context, limit int) ([]models.OutboxMessage, error) {
+	sql := TablePathPrefix("") + `
+		DECLARE $limit AS Uint64;
+
+		SELECT id, telegram_chat_id, payload, status, attempts, next_attempt_at, last_error, created_at
+		FROM outbox VIEW idx_status_next_attempt
+		WHERE status = "dead_letter" OR status = "failed"
+		ORDER BY next_attempt_at DESC
+		LIMIT $limit;
+	`
+
+	params := []table.ParameterOption{
+		table.ValueParam("$limit", types.Uint64Value(uint64(limit))),
+	}
+
+	res, err := Query(ctx, sql, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox dead letters: %w", err)
+	}
+	defer res.Close()
+
+	var msgs []models.OutboxMessage
+	for res.NextRow() {
+		msg, err := scanOutboxMessage(res)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+
+	return msgs, nil
+}
+
+// RedriveOutboxMessage makes a dead letter pending again with a fresh attempt budget;
+// messages in any other status are left alone
+func RedriveOutboxMessage(ctx context.Context, id string) error {
+	sql := TablePathPrefix("") + `
+		DECLARE $id AS Utf8;
+		DECLARE $now AS Datetime;
+
+		UPDATE outbox
+		SET status = "pending", attempts = 0, next_attempt_at = $now
+		WHERE id = $id AND (status = "dead_letter" OR status = "failed");
+	`
+
+	params := []table.ParameterOption{
+		table.ValueParam("$id", types.TextValue(id)),
+		table.ValueParam("$now", types.DatetimeValue(uint32(clock.From(ctx).Now().Unix()))),
+	}
+
+	return Exec(ctx, sql, params...)
+}
+
+// OutboxStore exposes the outbox functions as a telegram.OutboxStore
+type OutboxStore struct{}
+
+// Enqueue implements telegram.OutboxStore
+func (OutboxStore) Enqueue(ctx context.Context, msg *models.OutboxMessage) error {
+	return EnqueueOutboxMessage(ctx, msg)
+}
+
+// Claim implements telegram.OutboxStore
+func (OutboxStore) Claim(ctx context.Context, now time.Time, limit int, leaseUntil time.Time) ([]models.OutboxMessage, error) {
+	return ClaimDueOutboxMessages(ctx, now, limit, leaseUntil)
+}
+
+// MarkSent implements telegram.OutboxStore
+func (OutboxStore) MarkSent(ctx context.Context, id string) error {
+	return MarkOutboxMessageSent(ctx, id)
+}
+
+// MarkAttempt implements telegram.OutboxStore
+func (OutboxStore) MarkAttempt(ctx context.Context, id string, status models.OutboxStatus, attempts int, nextAttemptAt time.Time, lastError string) error {
+	return UpdateOutboxMessageAttempt(ctx, id, status, attempts, nextAttemptAt, lastError)
+}
+
+// DeadLetters implements telegram.OutboxStore
+func (OutboxStore) DeadLetters(ctx context.Context, limit int) ([]models.OutboxMessage, error) {
+	return GetOutboxDeadLetters(ctx, limit)
+}
+
+// Redrive implements telegram.OutboxStore
+func (OutboxStore) Redrive(ctx context.Context, id string) error {
+	return RedriveOutboxMessage(ctx, id)
+}
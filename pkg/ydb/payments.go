@@ -0,0 +1,90 @@
+package ydb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// The payments table stores successful Telegram payments:
+//
+//	CREATE TABLE payments (
+//		id Utf8 NOT NULL,
+//		telegram_chat_id Int64 NOT NULL,
+//		payload Utf8 NOT NULL,
+//		currency Utf8 NOT NULL,
+//		total_amount Int64 NOT NULL,
+//		provider_charge_id Utf8 NOT NULL,
+//		created_at Datetime NOT NULL,
+//		PRIMARY KEY (id),
+//		INDEX idx_telegram_chat_id GLOBAL ON (telegram_chat_id)
+//	);
+
+// CreatePayment stores a payment; the Telegram charge ID makes repeated updates idempotent
+func CreatePayment(ctx context.Context, payment *models.Payment) error {
+	sql := TablePathPrefix("") + `
+		DECLARE $id AS Utf8;
+		DECLARE $telegram_chat_id AS Int64;
+		DECLARE $payload AS Utf8;
+		DECLARE $currency AS Utf8;
+		DECLARE $total_amount AS Int64;
+		DECLARE $provider_charge_id AS Utf8;
+		DECLARE $created_at AS Datetime;
+
+		UPSERT INTO payments (id, telegram_chat_id, payload, currency, total_amount, provider_charge_id, created_at)
+		VALUES ($id, $telegram_chat_id, $payload, $currency, $total_amount, $provider_charge_id, $created_at);
+	`
+
+	params := []table.ParameterOption{
+		table.ValueParam("$id", types.TextValue(payment.ID)),
+		table.ValueParam("$telegram_chat_id", types.Int64Value(payment.TelegramChatID)),
+		table.ValueParam("$payload", types.TextValue(payment.Payload)),
+		table.ValueParam("$currency", types.TextValue(payment.Currency)),
+		table.ValueParam("$total_amount", types.Int64Value(int64(payment.TotalAmount))),
+		table.ValueParam("$provider_charge_id", types.TextValue(payment.ProviderChargeID)),
+		table.ValueParam("$created_at", types.DatetimeValue(uint32(payment.CreatedAt.Unix()))),
+	}
+
+	return Exec(ctx, sql, params...)
+}
+
+// GetPaymentsByUser retrieves all payments made by a user, newest first
+func GetPaymentsByUser(ctx context.Context, chatID int64) ([]models.Payment, error) {
+	sql := TablePathPrefix("") + `
+		DECLARE $telegram_chat_id AS Int64;
+
+		SELECT id, telegram_chat_id, payload, currency, total_amount, provider_charge_id, created_at
+		FROM payments VIEW idx_telegram_chat_id
+		WHERE telegram_chat_id = $telegram_chat_id
+		ORDER BY created_at DESC;
+	`
+
+	params := []table.ParameterOption{
+		table.ValueParam("$telegram_chat_id", types.Int64Value(chatID)),
+	}
+
+	res, err := Query(ctx, sql, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payments: %w", err)
+	}
+	defer res.Close()
+
+	var payments []models.Payment
+	for res.NextRow() {
+		var payment models.Payment
+		var totalAmount int64
+		err = res.Scan(&payment.ID, &payment.TelegramChatID, &payment.Payload, &payment.Currency,
+			&totalAmount, &payment.ProviderChargeID, &payment.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan payment: %w", err)
+		}
+		payment.TotalAmount = int(totalAmount)
+		payments = append(payments, payment)
+	}
+
+	return payments, nil
+}
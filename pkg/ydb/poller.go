@@ -0,0 +1,43 @@
+package ydb
+
+import (
+	"context"
+	"time"
+
+	"github.com/arseniisemenow/bbc-common/pkg/clock"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
+)
+
+// PollerStore exposes the subscription and notification functions as a poller.Store
+type PollerStore struct{}
+
+// DueSubscriptions implements poller.Store with the active subscriptions whose next check is due
+func (PollerStore) DueSubscriptions(ctx context.Context) ([]models.SearchSubscription, error) {
+	return GetDueSubscriptions(ctx, clock.From(ctx).Now())
+}
+
+// MarkChecked implements poller.Store
+func (PollerStore) MarkChecked(ctx context.Context, sub models.SearchSubscription, checkedAt, nextCheckAt time.Time, emptyChecks int) error {
+	return UpdateSubscriptionSchedule(ctx, sub.ID, checkedAt, nextCheckAt, emptyChecks)
+}
+
+// Notified implements poller.Store
+func (PollerStore) Notified(ctx context.Context, chatID int64, subID, tripID string) (bool, error) {
+	notif, err := GetNotificationByTrip(ctx, chatID, subID, tripID)
+	return notif != nil, err
+}
+
+// NotifiedTrips implements poller.BatchStore
+func (PollerStore) NotifiedTrips(ctx context.Context, chatID int64, subID string, tripIDs []string) (map[string]bool, error) {
+	return GetNotifiedTrips(ctx, chatID, subID, tripIDs)
+}
+
+// SentNotifications implements poller.ReconcileStore
+func (PollerStore) SentNotifications(ctx context.Context) ([]models.Notification, error) {
+	return GetSentNotifications(ctx, models.Today(clock.From(ctx).Now(), time.UTC))
+}
+
+// ActiveSubscriptions implements poller.ReconcileStore
+func (PollerStore) ActiveSubscriptions(ctx context.Context) ([]models.SearchSubscription, error) {
+	return GetActiveSubscriptions(ctx)
+}
@@ -0,0 +1,74 @@
+package ydb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+// The rate_limits table counts requests per key in fixed windows, shared by all
+// function instances; enable YDB TTL on expires_at to drop old windows:
+//
+//	CREATE TABLE rate_limits (
+//		key Utf8 NOT NULL,
+//		window_start Datetime NOT NULL,
+//		count Int32,
+//		expires_at Datetime,
+//		PRIMARY KEY (key, window_start)
+//	);
+
+// IncrementRateLimit counts one request for key in the window starting at windowStart
+// and returns the number of requests counted in that window so far. The increment is not
+// retried when its commit may have applied, so a request is never counted twice.
+func IncrementRateLimit(ctx context.Context, key string, windowStart time.Time, window time.Duration) (int, error) {
+	sql := TablePathPrefix("") + `
+		DECLARE $key AS Utf8;
+		DECLARE $window_start AS Datetime;
+		DECLARE $expires_at AS Datetime;
+
+		$current = (SELECT count FROM rate_limits WHERE key = $key AND window_start = $window_start);
+		$next = COALESCE($current, 0) + 1;
+
+		SELECT $next AS count;
+
+		UPSERT INTO rate_limits (key, window_start, count, expires_at)
+		VALUES ($key, $window_start, $next, $expires_at);
+	`
+
+	params := table.NewQueryParameters(
+		table.ValueParam("$key", types.TextValue(key)),
+		table.ValueParam("$window_start", types.DatetimeValue(uint32(windowStart.Unix()))),
+		table.ValueParam("$expires_at", types.DatetimeValue(uint32(windowStart.Add(2*window).Unix()))),
+	)
+
+	var count int
+	err := doTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
+		res, err := tx.Execute(ctx, sql, params)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		if err = res.NextResultSetErr(ctx); err != nil {
+			return err
+		}
+		if !res.NextRow() {
+			return fmt.Errorf("no count returned")
+		}
+		return res.Scan(&count)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment rate limit %s: %w", key, err)
+	}
+	return count, nil
+}
+
+// RateLimitStore exposes the rate_limits table as a ratelimit.Counter
+type RateLimitStore struct{}
+
+// Increment calls IncrementRateLimit
+func (RateLimitStore) Increment(ctx context.Context, key string, windowStart time.Time, window time.Duration) (int, error) {
+	return IncrementRateLimit(ctx, key, windowStart, window)
+}
@@ -2,15 +2,19 @@ package ydb
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/result"
 	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
 
-	"github.com/arseniisemenow/bbc-common/pkg/models"
 	"github.com/flymedllva/ydb-go-qb/yscan"
+
+	"github.com/arseniisemenow/bbc-common/pkg/clock"
+	"github.com/arseniisemenow/bbc-common/pkg/idgen"
+	"github.com/arseniisemenow/bbc-common/pkg/models"
 )
 
 // optionalDatetime creates an optional Datetime value from a uint32 pointer
@@ -29,6 +33,25 @@ func optionalText(s *string) types.Value {
 	return types.OptionalValue(types.TextValue(*s))
 }
 
+// scanUser reads a row selected with the columns of GetUserByTelegramChatID
+func scanUser(res result.Result) (models.User, error) {
+	var user models.User
+	var lastAuthSuccess, lastAuthFailure *uint32
+	err := res.Scan(&user.TelegramChatID, &user.Status, &user.CreatedAt, &lastAuthSuccess, &lastAuthFailure)
+	if err != nil {
+		return user, fmt.Errorf("failed to scan user: %w", err)
+	}
+	if lastAuthSuccess != nil {
+		t := time.Unix(int64(*lastAuthSuccess), 0)
+		user.LastAuthSuccessAt = &t
+	}
+	if lastAuthFailure != nil {
+		t := time.Unix(int64(*lastAuthFailure), 0)
+		user.LastAuthFailureAt = &t
+	}
+	return user, nil
+}
+
 // GetUserByTelegramChatID retrieves a user by their Telegram chat ID
 func GetUserByTelegramChatID(ctx context.Context, telegramChatID int64) (*models.User, error) {
 	sql := TablePathPrefix("") + `
@@ -49,35 +72,28 @@ func GetUserByTelegramChatID(ctx context.Context, telegramChatID int64) (*models
 	}
 	defer res.Close()
 
-	log.Printf("[YDB] GetUserByTelegramChatID: Query returned, checking rows...")
+	logger(ctx).Debug("GetUserByTelegramChatID: query returned, checking rows")
 
-	var user models.User
 	if res.NextRow() {
-		log.Printf("[YDB] GetUserByTelegramChatID: Found row for telegram_chat_id %d", telegramChatID)
+		logger(ctx).Debug("GetUserByTelegramChatID: found row", "telegram_chat_id", telegramChatID)
 
-		var lastAuthSuccess, lastAuthFailure *uint32
-		err = res.Scan(&user.TelegramChatID, &user.Status, &user.CreatedAt, &lastAuthSuccess, &lastAuthFailure)
+		user, err := scanUser(res)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan user: %w", err)
-		}
-		if lastAuthSuccess != nil {
-			t := time.Unix(int64(*lastAuthSuccess), 0)
-			user.LastAuthSuccessAt = &t
-		}
-		if lastAuthFailure != nil {
-			t := time.Unix(int64(*lastAuthFailure), 0)
-			user.LastAuthFailureAt = &t
+			return nil, err
 		}
-
 		return &user, nil
 	}
 
-	log.Printf("[YDB] GetUserByTelegramChatID: No rows found for telegram_chat_id %d", telegramChatID)
+	logger(ctx).Debug("GetUserByTelegramChatID: no rows found", "telegram_chat_id", telegramChatID)
 	return nil, ErrUserNotFound
 }
 
-// UpsertUser inserts or updates a user
+// UpsertUser inserts or updates a user; an invalid user is rejected with a *models.ValidationError
 func UpsertUser(ctx context.Context, user *models.User) error {
+	if err := user.Validate(); err != nil {
+		return err
+	}
+
 	sql := TablePathPrefix("") + `
 		DECLARE $telegram_chat_id AS Int64;
 		DECLARE $status AS Utf8;
@@ -107,12 +123,17 @@ func UpsertUser(ctx context.Context, user *models.User) error {
 		table.ValueParam("$last_auth_failure_at", optionalDatetime(lastAuthFailure)),
 	}
 
-	log.Printf("[YDB] UpsertUser: Attempting to upsert user with telegram_chat_id %d", user.TelegramChatID)
+	logger(ctx).Debug("UpsertUser: upserting user", "telegram_chat_id", user.TelegramChatID)
 	return Exec(ctx, sql, params...)
 }
 
-// UpdateUserStatus updates a user's status
+// UpdateUserStatus updates a user's status; an unknown status is rejected with a
+// *models.FieldError
 func UpdateUserStatus(ctx context.Context, chatID int64, status models.UserStatus) error {
+	if _, err := models.ParseUserStatus(string(status)); err != nil {
+		return fmt.Errorf("failed to update status of user %d: %w", chatID, err)
+	}
+
 	sql := TablePathPrefix("") + `
 		DECLARE $telegram_chat_id AS Int64;
 		DECLARE $status AS Utf8;
@@ -131,14 +152,14 @@ func UpdateUserStatus(ctx context.Context, chatID int64, status models.UserStatu
 }
 
 // GetActiveUsers retrieves all active users
-func GetActiveUsers(ctx context.Context) ([]models.User, error) {
+func GetActiveUsers(ctx context.Context, opts ...ReadOption) ([]models.User, error) {
 	sql := TablePathPrefix("") + `
 		SELECT telegram_chat_id, status, created_at, last_auth_success_at, last_auth_failure_at
 		FROM users
 		WHERE status = "active";
 	`
 
-	res, err := Query(ctx, sql)
+	res, err := read(ctx, sql, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query active users: %w", err)
 	}
@@ -146,19 +167,9 @@ func GetActiveUsers(ctx context.Context) ([]models.User, error) {
 
 	var users []models.User
 	for res.NextRow() {
-		var user models.User
-		var lastAuthSuccess, lastAuthFailure *uint32
-		err = res.Scan(&user.TelegramChatID, &user.Status, &user.CreatedAt, &lastAuthSuccess, &lastAuthFailure)
+		user, err := scanUser(res)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan user: %w", err)
-		}
-		if lastAuthSuccess != nil {
-			t := time.Unix(int64(*lastAuthSuccess), 0)
-			user.LastAuthSuccessAt = &t
-		}
-		if lastAuthFailure != nil {
-			t := time.Unix(int64(*lastAuthFailure), 0)
-			user.LastAuthFailureAt = &t
+			return nil, err
 		}
 		users = append(users, user)
 	}
@@ -168,12 +179,13 @@ func GetActiveUsers(ctx context.Context) ([]models.User, error) {
 
 // GetUserTokens retrieves tokens for a user
 func GetUserTokens(ctx context.Context, chatID int64) (*models.UserTokens, error) {
-	log.Printf("[YDB] GetUserTokens: searching for chatID=%d", chatID)
+	logger(ctx).Debug("GetUserTokens: searching", "telegram_chat_id", chatID)
 
 	sql := TablePathPrefix("") + `
 		DECLARE $telegram_chat_id AS Int64;
 
-		SELECT telegram_chat_id, access_token, refresh_token, user_id, datadome, app_token, created_at, updated_at
+		SELECT telegram_chat_id, access_token, refresh_token, user_id, datadome, app_token, created_at, updated_at,
+			user_agent, client_version, visitor_id, locale, currency
 		FROM user_tokens
 		WHERE telegram_chat_id = $telegram_chat_id;
 	`
@@ -189,7 +201,7 @@ func GetUserTokens(ctx context.Context, chatID int64) (*models.UserTokens, error
 	defer res.Close()
 
 	if res.NextRow() {
-		log.Printf("[YDB] GetUserTokens: found row for chatID=%d", chatID)
+		logger(ctx).Debug("GetUserTokens: found row", "telegram_chat_id", chatID)
 		var tokens models.UserTokens
 		err = yscan.ScanRow(&tokens, res)
 		if err != nil {
@@ -198,13 +210,83 @@ func GetUserTokens(ctx context.Context, chatID int64) (*models.UserTokens, error
 		return &tokens, nil
 	}
 
-	log.Printf("[YDB] GetUserTokens: no row found for chatID=%d, returning ErrTokensNotFound", chatID)
+	logger(ctx).Debug("GetUserTokens: no row found", "telegram_chat_id", chatID)
 	return nil, ErrTokensNotFound
 }
 
-// StoreUserTokens stores or updates user tokens
+// GetUserTokensBatch retrieves the tokens of many users at once, keyed by chat ID;
+// chats without tokens are missing from the map
+func GetUserTokensBatch(ctx context.Context, chatIDs []int64) (map[int64]models.UserTokens, error) {
+	q := ChunkedQuery[int64, models.UserTokens]{
+		SQL: TablePathPrefix("") + `
+			DECLARE $keys AS List<Int64>;
+
+			SELECT telegram_chat_id, access_token, refresh_token, user_id, datadome, app_token, created_at, updated_at,
+				user_agent, client_version, visitor_id, locale, currency
+			FROM user_tokens
+			WHERE telegram_chat_id IN $keys;
+		`,
+		Key: func(chatID int64) types.Value { return types.Int64Value(chatID) },
+		Scan: func(res result.Result) (models.UserTokens, error) {
+			var tokens models.UserTokens
+			if err := yscan.ScanRow(&tokens, res); err != nil {
+				return tokens, fmt.Errorf("failed to scan user tokens: %w", err)
+			}
+			return tokens, nil
+		},
+	}
+
+	rows, err := QueryChunked(ctx, q, chatIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user tokens: %w", err)
+	}
+	tokens := make(map[int64]models.UserTokens, len(rows))
+	for _, t := range rows {
+		tokens[t.TelegramChatID] = t
+	}
+	return tokens, nil
+}
+
+// GetUserLanguage returns the language the user chose, the locale stored with their
+// tokens such as "fr_FR", or "" when none is stored; pass it to i18n.Normalize
+func GetUserLanguage(ctx context.Context, chatID int64) (string, error) {
+	sql := TablePathPrefix("") + `
+		DECLARE $telegram_chat_id AS Int64;
+
+		SELECT locale
+		FROM user_tokens
+		WHERE telegram_chat_id = $telegram_chat_id;
+	`
+
+	res, err := Query(ctx, sql, table.ValueParam("$telegram_chat_id", types.Int64Value(chatID)))
+	if err != nil {
+		return "", fmt.Errorf("failed to query user language: %w", err)
+	}
+	defer res.Close()
+
+	var locale *string
+	if res.NextRow() {
+		if err := res.Scan(&locale); err != nil {
+			return "", fmt.Errorf("failed to scan user language: %w", err)
+		}
+	}
+	if err := res.Err(); err != nil {
+		return "", err
+	}
+	if locale == nil {
+		return "", nil
+	}
+	return *locale, nil
+}
+
+// StoreUserTokens stores or updates user tokens together with their header profile,
+// kept in the optional Utf8 columns user_agent, client_version, visitor_id, locale and currency;
+// invalid tokens are rejected with a *models.ValidationError
 func StoreUserTokens(ctx context.Context, tokens *models.UserTokens) error {
-	log.Printf("[YDB] StoreUserTokens: storing tokens for chatID=%d, userID=%s", tokens.TelegramChatID, tokens.UserID)
+	if err := tokens.Validate(); err != nil {
+		return err
+	}
+	logger(ctx).Debug("StoreUserTokens: storing tokens", "telegram_chat_id", tokens.TelegramChatID, "user_id", tokens.UserID)
 
 	sql := TablePathPrefix("") + `
 		DECLARE $telegram_chat_id AS Int64;
@@ -215,9 +297,16 @@ func StoreUserTokens(ctx context.Context, tokens *models.UserTokens) error {
 		DECLARE $app_token AS Optional<Utf8>;
 		DECLARE $created_at AS Datetime;
 		DECLARE $updated_at AS Datetime;
-
-		UPSERT INTO user_tokens (telegram_chat_id, access_token, refresh_token, user_id, datadome, app_token, created_at, updated_at)
-		VALUES ($telegram_chat_id, $access_token, $refresh_token, $user_id, $datadome, $app_token, $created_at, $updated_at);
+		DECLARE $user_agent AS Optional<Utf8>;
+		DECLARE $client_version AS Optional<Utf8>;
+		DECLARE $visitor_id AS Optional<Utf8>;
+		DECLARE $locale AS Optional<Utf8>;
+		DECLARE $currency AS Optional<Utf8>;
+
+		UPSERT INTO user_tokens (telegram_chat_id, access_token, refresh_token, user_id, datadome, app_token, created_at, updated_at,
+			user_agent, client_version, visitor_id, locale, currency)
+		VALUES ($telegram_chat_id, $access_token, $refresh_token, $user_id, $datadome, $app_token, $created_at, $updated_at,
+			$user_agent, $client_version, $visitor_id, $locale, $currency);
 	`
 
 	var datadome, appToken *string
@@ -237,6 +326,11 @@ func StoreUserTokens(ctx context.Context, tokens *models.UserTokens) error {
 		table.ValueParam("$app_token", optionalText(appToken)),
 		table.ValueParam("$created_at", types.DatetimeValue(uint32(tokens.CreatedAt.Unix()))),
 		table.ValueParam("$updated_at", types.DatetimeValue(uint32(tokens.UpdatedAt.Unix()))),
+		table.ValueParam("$user_agent", optionalText(nonEmpty(tokens.UserAgent))),
+		table.ValueParam("$client_version", optionalText(nonEmpty(tokens.ClientVersion))),
+		table.ValueParam("$visitor_id", optionalText(nonEmpty(tokens.VisitorID))),
+		table.ValueParam("$locale", optionalText(nonEmpty(tokens.Locale))),
+		table.ValueParam("$currency", optionalText(nonEmpty(tokens.Currency))),
 	}
 
 	return Exec(ctx, sql, params...)
@@ -258,7 +352,57 @@ func DeleteUserTokens(ctx context.Context, chatID int64) error {
 	return Exec(ctx, sql, params...)
 }
 
-// CreateSearchSubscription creates a new search subscription
+// encodeFilters stores subscription filters as JSON in the optional filters column; no filters store NULL
+func encodeFilters(filters models.TripFilters) (*string, error) {
+	if filters.IsZero() {
+		return nil, nil
+	}
+	data, err := json.Marshal(filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode subscription filters: %w", err)
+	}
+	encoded := string(data)
+	return &encoded, nil
+}
+
+func decodeFilters(raw *string, filters *models.TripFilters) error {
+	if raw == nil || *raw == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(*raw), filters)
+}
+
+// scanSubscription reads a row selected with the columns of GetSearchSubscriptionsByUser
+func scanSubscription(res result.Result) (models.SearchSubscription, error) {
+	var sub models.SearchSubscription
+	var lastChecked, nextCheck *uint32
+	var filters *string
+	var emptyChecks *int32
+	err := res.Scan(&sub.ID, &sub.TelegramChatID, &sub.FromPlaceID, &sub.FromPlaceName,
+		&sub.ToPlaceID, &sub.ToPlaceName, &sub.DepartureDate, &sub.RequestedSeats,
+		&sub.IsActive, &sub.CreatedAt, &lastChecked, &filters, &nextCheck, &emptyChecks)
+	if err != nil {
+		return sub, fmt.Errorf("failed to scan subscription: %w", err)
+	}
+	if lastChecked != nil {
+		t := time.Unix(int64(*lastChecked), 0)
+		sub.LastCheckedAt = &t
+	}
+	if nextCheck != nil {
+		t := time.Unix(int64(*nextCheck), 0)
+		sub.NextCheckAt = &t
+	}
+	if emptyChecks != nil {
+		sub.EmptyChecks = int(*emptyChecks)
+	}
+	if err = decodeFilters(filters, &sub.Filters); err != nil {
+		return sub, fmt.Errorf("failed to decode filters of subscription %s: %w", sub.ID, err)
+	}
+	return sub, nil
+}
+
+// CreateSearchSubscription creates a new search subscription, assigning a new ID if it has none;
+// an invalid subscription is rejected with a *models.ValidationError
 func CreateSearchSubscription(ctx context.Context, sub *models.SearchSubscription) error {
 	sql := TablePathPrefix("") + `
 		DECLARE $id AS Utf8;
@@ -271,11 +415,23 @@ func CreateSearchSubscription(ctx context.Context, sub *models.SearchSubscriptio
 		DECLARE $requested_seats AS Int32;
 		DECLARE $is_active AS Bool;
 		DECLARE $created_at AS Datetime;
+		DECLARE $filters AS Optional<Utf8>;
 
-		INSERT INTO search_subscriptions (id, telegram_chat_id, from_place_id, from_place_name, to_place_id, to_place_name, departure_date, requested_seats, is_active, created_at)
-		VALUES ($id, $telegram_chat_id, $from_place_id, $from_place_name, $to_place_id, $to_place_name, $departure_date, $requested_seats, $is_active, $created_at);
+		INSERT INTO search_subscriptions (id, telegram_chat_id, from_place_id, from_place_name, to_place_id, to_place_name, departure_date, requested_seats, is_active, created_at, filters)
+		VALUES ($id, $telegram_chat_id, $from_place_id, $from_place_name, $to_place_id, $to_place_name, $departure_date, $requested_seats, $is_active, $created_at, $filters);
 	`
 
+	if err := sub.Validate(); err != nil {
+		return err
+	}
+	if sub.ID == "" {
+		sub.ID = idgen.New()
+	}
+	filters, err := encodeFilters(sub.Filters)
+	if err != nil {
+		return err
+	}
+
 	params := []table.ParameterOption{
 		table.ValueParam("$id", types.TextValue(sub.ID)),
 		table.ValueParam("$telegram_chat_id", types.Int64Value(sub.TelegramChatID)),
@@ -283,21 +439,23 @@ func CreateSearchSubscription(ctx context.Context, sub *models.SearchSubscriptio
 		table.ValueParam("$from_place_name", types.TextValue(sub.FromPlaceName)),
 		table.ValueParam("$to_place_id", types.TextValue(sub.ToPlaceID)),
 		table.ValueParam("$to_place_name", types.TextValue(sub.ToPlaceName)),
-		table.ValueParam("$departure_date", types.TextValue(sub.DepartureDate)),
+		table.ValueParam("$departure_date", types.TextValue(sub.DepartureDate.String())),
 		table.ValueParam("$requested_seats", types.Int32Value(int32(sub.RequestedSeats))),
 		table.ValueParam("$is_active", types.BoolValue(sub.IsActive)),
 		table.ValueParam("$created_at", types.DatetimeValue(uint32(sub.CreatedAt.Unix()))),
+		table.ValueParam("$filters", optionalText(filters)),
 	}
 
 	return Exec(ctx, sql, params...)
 }
 
 // GetSearchSubscriptionsByUser retrieves all subscriptions for a user
-func GetSearchSubscriptionsByUser(ctx context.Context, chatID int64) ([]models.SearchSubscription, error) {
+func GetSearchSubscriptionsByUser(ctx context.Context, chatID int64, opts ...ReadOption) ([]models.SearchSubscription, error) {
 	sql := TablePathPrefix("") + `
 		DECLARE $telegram_chat_id AS Int64;
 
-		SELECT id, telegram_chat_id, from_place_id, from_place_name, to_place_id, to_place_name, departure_date, requested_seats, is_active, created_at, last_checked_at
+		SELECT id, telegram_chat_id, from_place_id, from_place_name, to_place_id, to_place_name, departure_date, requested_seats, is_active, created_at, last_checked_at, filters,
+			next_check_at, empty_checks
 		FROM search_subscriptions
 		WHERE telegram_chat_id = $telegram_chat_id;
 	`
@@ -306,7 +464,7 @@ func GetSearchSubscriptionsByUser(ctx context.Context, chatID int64) ([]models.S
 		table.ValueParam("$telegram_chat_id", types.Int64Value(chatID)),
 	}
 
-	res, err := Query(ctx, sql, params...)
+	res, err := read(ctx, sql, opts, params...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query subscriptions: %w", err)
 	}
@@ -314,17 +472,9 @@ func GetSearchSubscriptionsByUser(ctx context.Context, chatID int64) ([]models.S
 
 	var subs []models.SearchSubscription
 	for res.NextRow() {
-		var sub models.SearchSubscription
-		var lastChecked *uint32
-		err = res.Scan(&sub.ID, &sub.TelegramChatID, &sub.FromPlaceID, &sub.FromPlaceName,
-			&sub.ToPlaceID, &sub.ToPlaceName, &sub.DepartureDate, &sub.RequestedSeats,
-			&sub.IsActive, &sub.CreatedAt, &lastChecked)
+		sub, err := scanSubscription(res)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan subscription: %w", err)
-		}
-		if lastChecked != nil {
-			t := time.Unix(int64(*lastChecked), 0)
-			sub.LastCheckedAt = &t
+			return nil, err
 		}
 		subs = append(subs, sub)
 	}
@@ -333,14 +483,15 @@ func GetSearchSubscriptionsByUser(ctx context.Context, chatID int64) ([]models.S
 }
 
 // GetActiveSubscriptions retrieves all active subscriptions
-func GetActiveSubscriptions(ctx context.Context) ([]models.SearchSubscription, error) {
+func GetActiveSubscriptions(ctx context.Context, opts ...ReadOption) ([]models.SearchSubscription, error) {
 	sql := TablePathPrefix("") + `
-		SELECT id, telegram_chat_id, from_place_id, from_place_name, to_place_id, to_place_name, departure_date, requested_seats, is_active, created_at, last_checked_at
+		SELECT id, telegram_chat_id, from_place_id, from_place_name, to_place_id, to_place_name, departure_date, requested_seats, is_active, created_at, last_checked_at, filters,
+			next_check_at, empty_checks
 		FROM search_subscriptions
 		WHERE is_active = true;
 	`
 
-	res, err := Query(ctx, sql)
+	res, err := read(ctx, sql, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query active subscriptions: %w", err)
 	}
@@ -348,17 +499,9 @@ func GetActiveSubscriptions(ctx context.Context) ([]models.SearchSubscription, e
 
 	var subs []models.SearchSubscription
 	for res.NextRow() {
-		var sub models.SearchSubscription
-		var lastChecked *uint32
-		err = res.Scan(&sub.ID, &sub.TelegramChatID, &sub.FromPlaceID, &sub.FromPlaceName,
-			&sub.ToPlaceID, &sub.ToPlaceName, &sub.DepartureDate, &sub.RequestedSeats,
-			&sub.IsActive, &sub.CreatedAt, &lastChecked)
+		sub, err := scanSubscription(res)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan subscription: %w", err)
-		}
-		if lastChecked != nil {
-			t := time.Unix(int64(*lastChecked), 0)
-			sub.LastCheckedAt = &t
+			return nil, err
 		}
 		subs = append(subs, sub)
 	}
@@ -377,7 +520,67 @@ func UpdateSubscriptionLastChecked(ctx context.Context, subID string) error {
 
 	params := []table.ParameterOption{
 		table.ValueParam("$id", types.TextValue(subID)),
-		table.ValueParam("$last_checked_at", types.DatetimeValue(uint32(time.Now().Unix()))),
+		table.ValueParam("$last_checked_at", types.DatetimeValue(uint32(clock.From(ctx).Now().Unix()))),
+	}
+
+	return Exec(ctx, sql, params...)
+}
+
+// GetDueSubscriptions retrieves active subscriptions for future dates whose next check is
+// due at now; subscriptions never scheduled are always due
+func GetDueSubscriptions(ctx context.Context, now time.Time, opts ...ReadOption) ([]models.SearchSubscription, error) {
+	sql := TablePathPrefix("") + `
+		DECLARE $now AS Datetime;
+		DECLARE $today AS Utf8;
+
+		SELECT id, telegram_chat_id, from_place_id, from_place_name, to_place_id, to_place_name, departure_date, requested_seats, is_active, created_at, last_checked_at, filters,
+			next_check_at, empty_checks
+		FROM search_subscriptions
+		WHERE is_active = true AND departure_date >= $today AND (next_check_at IS NULL OR next_check_at <= $now);
+	`
+
+	params := []table.ParameterOption{
+		table.ValueParam("$now", types.DatetimeValue(uint32(now.Unix()))),
+		table.ValueParam("$today", types.TextValue(models.Today(now, time.UTC).String())),
+	}
+
+	res, err := read(ctx, sql, opts, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due subscriptions: %w", err)
+	}
+	defer res.Close()
+
+	var subs []models.SearchSubscription
+	for res.NextRow() {
+		sub, err := scanSubscription(res)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+
+	return subs, nil
+}
+
+// UpdateSubscriptionSchedule records a check together with the time of the next one and
+// the number of consecutive checks without matches
+func UpdateSubscriptionSchedule(ctx context.Context, subID string, checkedAt, nextCheckAt time.Time, emptyChecks int) error {
+	sql := TablePathPrefix("") + `
+		DECLARE $id AS Utf8;
+		DECLARE $last_checked_at AS Datetime;
+		DECLARE $next_check_at AS Datetime;
+		DECLARE $empty_checks AS Int32;
+
+		UPDATE search_subscriptions
+		SET last_checked_at = $last_checked_at, next_check_at = $next_check_at, empty_checks = $empty_checks
+		WHERE id = $id;
+	`
+
+	params := []table.ParameterOption{
+		table.ValueParam("$id", types.TextValue(subID)),
+		table.ValueParam("$last_checked_at", types.DatetimeValue(uint32(checkedAt.Unix()))),
+		table.ValueParam("$next_check_at", types.DatetimeValue(uint32(nextCheckAt.Unix()))),
+		table.ValueParam("$empty_checks", types.Int32Value(int32(emptyChecks))),
 	}
 
 	return Exec(ctx, sql, params...)
@@ -415,7 +618,8 @@ func SetSubscriptionActive(ctx context.Context, subID string, active bool) error
 	return Exec(ctx, sql, params...)
 }
 
-// CreateNotification creates a new notification
+// CreateNotification creates a new notification with its snapshot, assigning a new ID
+// if it has none; an invalid notification is rejected with a *models.ValidationError
 func CreateNotification(ctx context.Context, notif *models.Notification) error {
 	sql := TablePathPrefix("") + `
 		DECLARE $id AS Utf8;
@@ -425,19 +629,31 @@ func CreateNotification(ctx context.Context, notif *models.Notification) error {
 		DECLARE $telegram_message_id AS Int32;
 		DECLARE $status AS Utf8;
 		DECLARE $created_at AS Datetime;
+		DECLARE $snapshot AS Optional<Utf8>;
 
-		INSERT INTO notifications (id, telegram_chat_id, subscription_id, trip_id, telegram_message_id, status, created_at)
-		VALUES ($id, $telegram_chat_id, $subscription_id, $trip_id, $telegram_message_id, $status, $created_at);
+		INSERT INTO notifications (id, telegram_chat_id, subscription_id, trip_id, telegram_message_id, status, created_at, snapshot)
+		VALUES ($id, $telegram_chat_id, $subscription_id, $trip_id, $telegram_message_id, $status, $created_at, $snapshot);
 	`
 
+	if err := notif.Validate(); err != nil {
+		return err
+	}
+	snapshot, err := encodeSnapshot(notif.Snapshot)
+	if err != nil {
+		return err
+	}
+	if notif.ID == "" {
+		notif.ID = idgen.New()
+	}
 	params := []table.ParameterOption{
 		table.ValueParam("$id", types.TextValue(notif.ID)),
 		table.ValueParam("$telegram_chat_id", types.Int64Value(notif.TelegramChatID)),
 		table.ValueParam("$subscription_id", types.TextValue(notif.SubscriptionID)),
 		table.ValueParam("$trip_id", types.TextValue(notif.TripID)),
 		table.ValueParam("$telegram_message_id", types.Int32Value(int32(notif.TelegramMessageID))),
-		table.ValueParam("$status", types.TextValue(notif.Status)),
+		table.ValueParam("$status", types.TextValue(string(notif.Status))),
 		table.ValueParam("$created_at", types.DatetimeValue(uint32(notif.CreatedAt.Unix()))),
+		table.ValueParam("$snapshot", optionalText(snapshot)),
 	}
 
 	return Exec(ctx, sql, params...)
@@ -450,7 +666,7 @@ func GetNotificationByTrip(ctx context.Context, chatID int64, subID, tripID stri
 		DECLARE $subscription_id AS Utf8;
 		DECLARE $trip_id AS Utf8;
 
-		SELECT id, telegram_chat_id, subscription_id, trip_id, telegram_message_id, status, created_at
+		SELECT id, telegram_chat_id, subscription_id, trip_id, telegram_message_id, status, created_at, snapshot
 		FROM notifications
 		WHERE telegram_chat_id = $telegram_chat_id AND subscription_id = $subscription_id AND trip_id = $trip_id;
 	`
@@ -468,14 +684,10 @@ func GetNotificationByTrip(ctx context.Context, chatID int64, subID, tripID stri
 	defer res.Close()
 
 	if res.NextRow() {
-		var notif models.Notification
-		var createdAt uint32
-		err = res.Scan(&notif.ID, &notif.TelegramChatID, &notif.SubscriptionID,
-			&notif.TripID, &notif.TelegramMessageID, &notif.Status, &createdAt)
+		notif, err := scanNotification(res)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan notification: %w", err)
+			return nil, err
 		}
-		notif.CreatedAt = time.Unix(int64(createdAt), 0)
 		return &notif, nil
 	}
 
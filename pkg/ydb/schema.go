@@ -0,0 +1,389 @@
+package ydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/sugar"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/options"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+// Column is a column of a table. YDB creates columns as Optional unless they are NOT
+// NULL, and Optional columns do not scan into plain values, so NotNull is set on keys
+// and on every column the queries scan without a pointer.
+type Column struct {
+	Name    string
+	Type    types.Type
+	NotNull bool
+}
+
+// definition returns the column as written in CREATE TABLE and ADD COLUMN
+func (c Column) definition() string {
+	if c.NotNull {
+		return c.Name + " " + c.Type.Yql() + " NOT NULL"
+	}
+	return c.Name + " " + c.Type.Yql()
+}
+
+// Index is a global secondary index
+type Index struct {
+	Name    string
+	Columns []string
+}
+
+// Table is the schema of a table as the queries of this package expect it
+type Table struct {
+	Name       string
+	Columns    []Column
+	PrimaryKey []string
+	Indexes    []Index
+}
+
+// CreateSQL returns the CREATE TABLE statement of the table
+func (t Table) CreateSQL() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE TABLE `%s` (\n", t.Name)
+	for _, c := range t.Columns {
+		fmt.Fprintf(&b, "\t%s,\n", c.definition())
+	}
+	for _, idx := range t.Indexes {
+		fmt.Fprintf(&b, "\tINDEX %s GLOBAL ON (%s),\n", idx.Name, strings.Join(idx.Columns, ", "))
+	}
+	fmt.Fprintf(&b, "\tPRIMARY KEY (%s)\n);", strings.Join(t.PrimaryKey, ", "))
+	return b.String()
+}
+
+// Schema lists the tables of the bot. Tables documented next to their queries, e.g.
+// outbox, are repeated here; keep both in sync when adding columns, and bump
+// CurrentSchema's version.
+var Schema = []Table{
+	{
+		Name: "users",
+		Columns: []Column{
+			{"telegram_chat_id", types.TypeInt64, true},
+			{"status", types.TypeText, true},
+			{"created_at", types.TypeDatetime, true},
+			{"last_auth_success_at", types.TypeDatetime, false},
+			{"last_auth_failure_at", types.TypeDatetime, false},
+		},
+		PrimaryKey: []string{"telegram_chat_id"},
+	},
+	{
+		Name: "user_tokens",
+		Columns: []Column{
+			{"telegram_chat_id", types.TypeInt64, true},
+			{"access_token", types.TypeText, false},
+			{"refresh_token", types.TypeText, false},
+			{"user_id", types.TypeText, false},
+			{"datadome", types.TypeText, false},
+			{"app_token", types.TypeText, false},
+			{"created_at", types.TypeDatetime, false},
+			{"updated_at", types.TypeDatetime, false},
+			{"user_agent", types.TypeText, false},
+			{"client_version", types.TypeText, false},
+			{"visitor_id", types.TypeText, false},
+			{"locale", types.TypeText, false},
+			{"currency", types.TypeText, false},
+		},
+		PrimaryKey: []string{"telegram_chat_id"},
+	},
+	{
+		Name: "search_subscriptions",
+		Columns: []Column{
+			{"id", types.TypeText, true},
+			{"telegram_chat_id", types.TypeInt64, true},
+			{"from_place_id", types.TypeText, true},
+			{"from_place_name", types.TypeText, true},
+			{"to_place_id", types.TypeText, true},
+			{"to_place_name", types.TypeText, true},
+			{"departure_date", types.TypeText, true},
+			{"requested_seats", types.TypeInt32, true},
+			{"is_active", types.TypeBool, true},
+			{"created_at", types.TypeDatetime, true},
+			{"last_checked_at", types.TypeDatetime, false},
+			{"filters", types.TypeText, false},
+			{"next_check_at", types.TypeDatetime, false},
+			{"empty_checks", types.TypeInt32, false},
+		},
+		PrimaryKey: []string{"id"},
+	},
+	{
+		Name: "notifications",
+		Columns: []Column{
+			{"id", types.TypeText, true},
+			{"telegram_chat_id", types.TypeInt64, true},
+			{"subscription_id", types.TypeText, true},
+			{"trip_id", types.TypeText, true},
+			{"telegram_message_id", types.TypeInt32, true},
+			{"status", types.TypeText, true},
+			{"created_at", types.TypeDatetime, true},
+			{"snapshot", types.TypeText, false},
+		},
+		PrimaryKey: []string{"id"},
+	},
+	{
+		Name: "booking_attempts",
+		Columns: []Column{
+			{"id", types.TypeText, true},
+			{"telegram_chat_id", types.TypeInt64, true},
+			{"trip_id", types.TypeText, true},
+			{"seats", types.TypeInt32, true},
+			{"outcome", types.TypeText, true},
+			{"booking_id", types.TypeText, false},
+			{"error", types.TypeText, false},
+			{"created_at", types.TypeDatetime, true},
+		},
+		PrimaryKey: []string{"id"},
+		Indexes:    []Index{{"idx_telegram_chat_id", []string{"telegram_chat_id"}}},
+	},
+	{
+		Name: "payments",
+		Columns: []Column{
+			{"id", types.TypeText, true},
+			{"telegram_chat_id", types.TypeInt64, true},
+			{"payload", types.TypeText, true},
+			{"currency", types.TypeText, true},
+			{"total_amount", types.TypeInt64, true},
+			{"provider_charge_id", types.TypeText, true},
+			{"created_at", types.TypeDatetime, true},
+		},
+		PrimaryKey: []string{"id"},
+		Indexes:    []Index{{"idx_telegram_chat_id", []string{"telegram_chat_id"}}},
+	},
+	{
+		Name: "outbox",
+		Columns: []Column{
+			{"id", types.TypeText, true},
+			{"telegram_chat_id", types.TypeInt64, true},
+			{"payload", types.TypeText, true},
+			{"status", types.TypeText, true},
+			{"attempts", types.TypeInt32, true},
+			{"next_attempt_at", types.TypeDatetime, true},
+			{"last_error", types.TypeText, false},
+			{"created_at", types.TypeDatetime, true},
+		},
+		PrimaryKey: []string{"id"},
+		Indexes:    []Index{{"idx_status_next_attempt", []string{"status", "next_attempt_at"}}},
+	},
+	{
+		Name: "digest_entries",
+		Columns: []Column{
+			{"id", types.TypeText, true},
+			{"telegram_chat_id", types.TypeInt64, true},
+			{"notification_id", types.TypeText, true},
+			{"payload", types.TypeText, true},
+			{"deliver_at", types.TypeDatetime, true},
+			{"created_at", types.TypeDatetime, true},
+		},
+		PrimaryKey: []string{"id"},
+		Indexes:    []Index{{"idx_deliver_at", []string{"deliver_at"}}},
+	},
+	{
+		Name: "events",
+		Columns: []Column{
+			{"id", types.TypeText, true},
+			{"type", types.TypeText, true},
+			{"telegram_chat_id", types.TypeInt64, true},
+			{"properties", types.TypeText, false},
+			{"created_at", types.TypeDatetime, true},
+		},
+		PrimaryKey: []string{"id"},
+		Indexes: []Index{
+			{"idx_type_created_at", []string{"type", "created_at"}},
+			{"idx_telegram_chat_id", []string{"telegram_chat_id"}},
+		},
+	},
+	{
+		Name: "kv_store",
+		Columns: []Column{
+			{"namespace", types.TypeText, true},
+			{"key", types.TypeText, true},
+			{"value", types.TypeText, true},
+			{"expires_at", types.TypeDatetime, false},
+		},
+		PrimaryKey: []string{"namespace", "key"},
+	},
+	{
+		Name: "rate_limits",
+		Columns: []Column{
+			{"key", types.TypeText, true},
+			{"window_start", types.TypeDatetime, true},
+			{"count", types.TypeInt32, false},
+			{"expires_at", types.TypeDatetime, false},
+		},
+		PrimaryKey: []string{"key", "window_start"},
+	},
+}
+
+// ErrSchemaIncompatible is returned by CheckSchemaCompatibility when the deployed schema
+// lacks what the queries need
+var ErrSchemaIncompatible = errors.New("database schema is incompatible")
+
+// SchemaVersion is a revision of Schema; bump Version whenever a table, column or index
+// is added, so a failed check names the revision the binary needs
+type SchemaVersion struct {
+	Version int
+	Tables  []Table
+}
+
+// CurrentSchema is the schema the queries of this build expect
+var CurrentSchema = SchemaVersion{Version: 1, Tables: Schema}
+
+// CheckSchemaCompatibility checks that every table, column and index of expected is
+// deployed with the expected column types, nullability and primary key, and lists all
+// differences in an ErrSchemaIncompatible error. Columns and indexes the binary does not
+// know are ignored, so blue and green deployments both pass while the schema only grows.
+// Call it at startup to fail fast instead of failing scans later.
+func CheckSchemaCompatibility(ctx context.Context, expected SchemaVersion) error {
+	driver, err := GetConnection(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get YDB connection: %w", err)
+	}
+
+	var problems []string
+	for _, t := range expected.Tables {
+		desc, exists, err := describeTable(ctx, driver, t.Name)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			problems = append(problems, fmt.Sprintf("table %s is missing", t.Name))
+			continue
+		}
+		problems = append(problems, schemaProblems(t, desc)...)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: version %d needs: %s", ErrSchemaIncompatible, expected.Version, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// schemaProblems describes how the deployed desc differs from t
+func schemaProblems(t Table, desc options.Description) []string {
+	var problems []string
+	if !slices.Equal(desc.PrimaryKey, t.PrimaryKey) {
+		problems = append(problems, fmt.Sprintf("%s has primary key (%s), want (%s)",
+			t.Name, strings.Join(desc.PrimaryKey, ", "), strings.Join(t.PrimaryKey, ", ")))
+	}
+	for _, c := range t.Columns {
+		i := slices.IndexFunc(desc.Columns, func(dc options.Column) bool { return dc.Name == c.Name })
+		if i < 0 {
+			problems = append(problems, fmt.Sprintf("column %s.%s is missing", t.Name, c.Name))
+			continue
+		}
+		optional, got := types.IsOptional(desc.Columns[i].Type)
+		if !optional {
+			got = desc.Columns[i].Type
+		}
+		if !types.Equal(got, c.Type) {
+			problems = append(problems, fmt.Sprintf("column %s.%s is %s, want %s", t.Name, c.Name, got.Yql(), c.Type.Yql()))
+		}
+		if optional == c.NotNull {
+			problems = append(problems, fmt.Sprintf("column %s.%s is %s, want %s",
+				t.Name, c.Name, nullability(!optional), nullability(c.NotNull)))
+		}
+	}
+	for _, idx := range t.Indexes {
+		i := slices.IndexFunc(desc.Indexes, func(di options.IndexDescription) bool { return di.Name == idx.Name })
+		if i < 0 {
+			problems = append(problems, fmt.Sprintf("index %s.%s is missing", t.Name, idx.Name))
+			continue
+		}
+		if got := desc.Indexes[i].IndexColumns; !slices.Equal(got, idx.Columns) {
+			problems = append(problems, fmt.Sprintf("index %s.%s is on (%s), want (%s)",
+				t.Name, idx.Name, strings.Join(got, ", "), strings.Join(idx.Columns, ", ")))
+		}
+	}
+	return problems
+}
+
+// nullability names the optionality of a column for schema problems
+func nullability(notNull bool) string {
+	if notNull {
+		return "NOT NULL"
+	}
+	return "nullable"
+}
+
+// Migrate brings the database up to Schema: it creates missing tables and adds missing
+// columns and indexes, and returns the statements. Nothing is dropped or changed, so
+// it is safe while older code is running. With dryRun the statements are only returned.
+func Migrate(ctx context.Context, dryRun bool) ([]string, error) {
+	driver, err := GetConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get YDB connection: %w", err)
+	}
+
+	var stmts []string
+	for _, t := range Schema {
+		desc, exists, err := describeTable(ctx, driver, t.Name)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			stmts = append(stmts, t.CreateSQL())
+			continue
+		}
+		stmts = append(stmts, alterStatements(t, desc)...)
+	}
+	if dryRun {
+		return stmts, nil
+	}
+
+	for i, stmt := range stmts {
+		err := driver.Table().Do(ctx, func(ctx context.Context, s table.Session) error {
+			return s.ExecuteSchemeQuery(ctx, TablePathPrefix("")+"\n"+stmt)
+		}, table.WithIdempotent())
+		if err != nil {
+			return stmts[:i], fmt.Errorf("failed to migrate schema: %q: %w", stmt, err)
+		}
+		logger(ctx).Info("Applied schema change", "statement", stmt)
+	}
+	return stmts, nil
+}
+
+// alterStatements returns the statements adding what t has and the deployed desc lacks
+func alterStatements(t Table, desc options.Description) []string {
+	var stmts []string
+	for _, c := range t.Columns {
+		if !slices.ContainsFunc(desc.Columns, func(dc options.Column) bool { return dc.Name == c.Name }) {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN %s;", t.Name, c.definition()))
+		}
+	}
+	for _, idx := range t.Indexes {
+		if !slices.ContainsFunc(desc.Indexes, func(di options.IndexDescription) bool { return di.Name == idx.Name }) {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE `%s` ADD INDEX %s GLOBAL ON (%s);",
+				t.Name, idx.Name, strings.Join(idx.Columns, ", ")))
+		}
+	}
+	return stmts
+}
+
+// describeTable returns the deployed schema of a table, and false if it does not exist
+func describeTable(ctx context.Context, driver *ydb.Driver, name string) (options.Description, bool, error) {
+	var desc options.Description
+	tablePath := path.Join(driver.Name(), name)
+	exists, err := sugar.IsTableExists(ctx, driver.Scheme(), tablePath)
+	if err != nil {
+		return desc, false, fmt.Errorf("failed to check table %s: %w", name, err)
+	}
+	if !exists {
+		return desc, false, nil
+	}
+	err = driver.Table().Do(ctx, func(ctx context.Context, s table.Session) error {
+		var err error
+		desc, err = s.DescribeTable(ctx, tablePath)
+		return err
+	}, table.WithIdempotent())
+	if err != nil {
+		return desc, false, fmt.Errorf("failed to describe table %s: %w", name, err)
+	}
+	return desc, true, nil
+}
@@ -3,9 +3,9 @@ package ydb
 import (
 	"context"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/ydb-platform/ydb-go-sdk/v3"
 	"github.com/ydb-platform/ydb-go-sdk/v3/table"
@@ -13,6 +13,10 @@ import (
 	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
 
 	yc "github.com/ydb-platform/ydb-go-yc-metadata"
+
+	"github.com/arseniisemenow/bbc-common/pkg/config"
+	"github.com/arseniisemenow/bbc-common/pkg/logging"
+	"github.com/arseniisemenow/bbc-common/pkg/retry"
 )
 
 var (
@@ -20,74 +24,108 @@ var (
 	once sync.Once
 )
 
+// openRetry retries opening the connection, which a cold start may attempt before the
+// metadata service or the network is ready; a failure is final for the process
+var openRetry = retry.New(
+	retry.MaxAttempts(3),
+	retry.Backoff(200*time.Millisecond, 2*time.Second),
+	retry.Jitter(),
+	retry.OnRetry(func(ctx context.Context, attempt int, err error, wait time.Duration) {
+		logger(ctx).Warn("Failed to open connection, retrying", "attempt", attempt, "wait", wait, "error", err)
+	}),
+)
+
 // GetConnection returns a YDB connection, creating it if needed
 func GetConnection(ctx context.Context) (*ydb.Driver, error) {
 	var initErr error
 	once.Do(func() {
-		endpoint := os.Getenv("YDB_ENDPOINT")
-		database := os.Getenv("YDB_DATABASE")
+		cfg := config.LoadYDB()
 
-		log.Printf("[YDB] Initializing connection: endpoint=%s database=%s", endpoint, database)
+		logger(ctx).Info("Initializing connection", "endpoint", cfg.Endpoint, "database", cfg.Database)
 
-		if endpoint == "" {
-			initErr = fmt.Errorf("YDB_ENDPOINT environment variable not set")
-			return
-		}
-		if database == "" {
-			initErr = fmt.Errorf("YDB_DATABASE environment variable not set")
+		if initErr = cfg.Validate(); initErr != nil {
 			return
 		}
 
-		connectionString := endpoint + "/?database=" + database
-		log.Printf("[YDB] Connection string: %s", connectionString)
+		connectionString := cfg.ConnectionString()
+		logger(ctx).Debug("Connection string", "connection_string", connectionString)
 
-		db, initErr = ydb.Open(ctx, connectionString,
-			yc.WithCredentials(), // Use instance metadata service for authentication
-			yc.WithInternalCA(),  // Append Yandex Cloud certificates
-		)
+		initErr = openRetry.Do(ctx, func(ctx context.Context) error {
+			var err error
+			db, err = ydb.Open(ctx, connectionString,
+				yc.WithCredentials(), // Use instance metadata service for authentication
+				yc.WithInternalCA(),  // Append Yandex Cloud certificates
+			)
+			return err
+		})
 
 		if initErr != nil {
-			log.Printf("[YDB] Failed to open connection: %v", initErr)
+			logger(ctx).Error("Failed to open connection", "error", initErr)
 		} else {
-			log.Printf("[YDB] Successfully opened connection")
+			logger(ctx).Info("Opened connection")
 		}
 	})
 
 	if db == nil && initErr == nil {
-		log.Printf("[YDB] WARNING: db is nil but initErr is also nil")
+		logger(ctx).Warn("Connection is nil without an error")
 	}
 
 	return db, initErr
 }
 
+// Close closes the connection opened by GetConnection, for the shutdown of long-running
+// deployments; the connection is not reopened afterwards
+func Close(ctx context.Context) error {
+	if db == nil {
+		return nil
+	}
+	logger(ctx).Info("Closing connection")
+	return db.Close(ctx)
+}
+
+// Ping runs a trivial query to check that the database is reachable, e.g. as a
+// readiness check
+func Ping(ctx context.Context) error {
+	res, err := Query(ctx, "SELECT 1;")
+	if err != nil {
+		return err
+	}
+	return res.Close()
+}
+
 // Query executes a query and returns the result set
 func Query(ctx context.Context, sql string, params ...table.ParameterOption) (result.Result, error) {
+	return query(ctx, table.DefaultTxControl(), sql, params...)
+}
+
+// query executes a query in a transaction of tx and returns the result set
+func query(ctx context.Context, tx *table.TransactionControl, sql string, params ...table.ParameterOption) (result.Result, error) {
 	driver, err := GetConnection(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get YDB connection: %w", err)
 	}
 
-	log.Printf("[YDB] Querying SQL (first 100 chars): %s", truncateString(sql, 100))
+	logger(ctx).Debug("Querying", "sql", truncateString(sql, 100))
 	var res result.Result
 	err = driver.Table().Do(ctx, func(ctx context.Context, s table.Session) error {
-		_, r, err := s.Execute(ctx, table.DefaultTxControl(), sql, table.NewQueryParameters(params...))
+		_, r, err := s.Execute(ctx, tx, sql, table.NewQueryParameters(params...))
 		if err != nil {
-			log.Printf("[YDB] Execute failed: %v", err)
+			logger(ctx).Warn("Execute failed", "error", err)
 			return err
 		}
 		if err := r.NextResultSetErr(ctx); err != nil {
-			log.Printf("[YDB] NextResultSetErr failed: %v", err)
+			logger(ctx).Warn("NextResultSetErr failed", "error", err)
 			r.Close()
 			return err
 		}
 		res = r
-		log.Printf("[YDB] Execute succeeded, got result set")
+		logger(ctx).Debug("Execute succeeded, got result set")
 		return nil
 	}, table.WithIdempotent())
 
 	if err != nil {
-		log.Printf("[YDB] Do failed: %v", err)
-		return nil, fmt.Errorf("query execution failed: %w", err)
+		logger(ctx).Error("Query failed", "error", err)
+		return nil, fmt.Errorf("query execution failed: %w", logging.RedactError(err))
 	}
 
 	return res, nil
@@ -100,31 +138,37 @@ func Exec(ctx context.Context, sql string, params ...table.ParameterOption) erro
 		return fmt.Errorf("failed to get YDB connection: %w", err)
 	}
 
-	log.Printf("[YDB] Executing SQL (first 100 chars): %s", truncateString(sql, 100))
+	logger(ctx).Debug("Executing", "sql", truncateString(sql, 100))
 	err = driver.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
 		res, err := tx.Execute(ctx, sql, table.NewQueryParameters(params...))
 		if err != nil {
-			log.Printf("[YDB] Execute failed: %v", err)
+			logger(ctx).Warn("Execute failed", "error", err)
 			return err
 		}
 		if err = res.Err(); err != nil {
-			log.Printf("[YDB] Result error: %v", err)
+			logger(ctx).Warn("Result error", "error", err)
 			return err
 		}
 		if err = res.Close(); err != nil {
-			log.Printf("[YDB] Close failed: %v", err)
+			logger(ctx).Warn("Close failed", "error", err)
 			return err
 		}
-		log.Printf("[YDB] Execute succeeded, DoTx will commit on callback return")
+		logger(ctx).Debug("Execute succeeded, committing")
 		return nil
 	}, table.WithIdempotent())
 
 	if err != nil {
-		log.Printf("[YDB] DoTx failed: %v", err)
+		logger(ctx).Error("Transaction failed", "error", err)
 	} else {
-		log.Printf("[YDB] DoTx succeeded - transaction should be committed")
+		logger(ctx).Debug("Transaction committed")
 	}
-	return err
+	// failed statements may quote parameters such as tokens
+	return logging.RedactError(err)
+}
+
+// logger returns the package's logger for ctx
+func logger(ctx context.Context) *slog.Logger {
+	return logging.Component(ctx, "ydb")
 }
 
 func truncateString(s string, maxLen int) string {
@@ -134,8 +178,15 @@ func truncateString(s string, maxLen int) string {
 	return s[:maxLen] + "..."
 }
 
-// DoTx executes a function within a transaction
+// DoTx executes a function within a transaction, retrying it on any transient error
 func DoTx(ctx context.Context, fn func(ctx context.Context, tx table.TransactionActor) error) error {
+	return doTx(ctx, fn, table.WithIdempotent())
+}
+
+// doTx executes a function within a transaction with opts; without table.WithIdempotent
+// it is retried only on errors after which the transaction surely did not commit, for
+// writes that must not apply twice
+func doTx(ctx context.Context, fn func(ctx context.Context, tx table.TransactionActor) error, opts ...table.Option) error {
 	driver, err := GetConnection(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get YDB connection: %w", err)
@@ -143,7 +194,7 @@ func DoTx(ctx context.Context, fn func(ctx context.Context, tx table.Transaction
 
 	return driver.Table().DoTx(ctx, func(ctx context.Context, tx table.TransactionActor) error {
 		return fn(ctx, tx)
-	}, table.WithIdempotent())
+	}, opts...)
 }
 
 // NewParameter creates a new query parameter
@@ -154,7 +205,7 @@ func NewParameter(name string, value any) table.ParameterOption {
 // TablePathPrefix returns the PRAGMA TablePathPrefix directive
 func TablePathPrefix(path string) string {
 	if path == "" {
-		database := os.Getenv("YDB_DATABASE")
+		database := config.LoadYDB().Database
 		if database == "" {
 			return ""
 		}